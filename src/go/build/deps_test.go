@@ -58,18 +58,20 @@ var pkgDeps = map[string][]string{
 
 	// L1 adds simple functions and strings processing,
 	// but not Unicode tables.
-	"math":          {"internal/cpu", "unsafe"},
-	"math/bits":     {},
-	"math/cmplx":    {"math"},
-	"math/rand":     {"L0", "math"},
-	"strconv":       {"L0", "unicode/utf8", "math"},
-	"unicode/utf16": {},
-	"unicode/utf8":  {},
+	"math":            {"internal/cpu", "unsafe"},
+	"math/bits":       {},
+	"math/big/ctword": {},
+	"math/cmplx":      {"math"},
+	"math/rand":       {"L0", "math"},
+	"strconv":         {"L0", "unicode/utf8", "math"},
+	"unicode/utf16":   {},
+	"unicode/utf8":    {},
 
 	"L1": {
 		"L0",
 		"math",
 		"math/bits",
+		"math/big/ctword",
 		"math/cmplx",
 		"math/rand",
 		"sort",
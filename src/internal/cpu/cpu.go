@@ -14,6 +14,7 @@ var X86 x86
 // The struct is padded to avoid false sharing.
 type x86 struct {
 	_            [CacheLineSize]byte
+	HasADX       bool
 	HasAES       bool
 	HasAVX       bool
 	HasAVX2      bool
@@ -0,0 +1,118 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the arithmetic-geometric mean (AGM) algorithms
+// for π, log, and exp at very high precision. They take O(log(p))
+// multiplications and square roots of p-bit numbers, while the series
+// take O(√p) or more multiplications; at large precisions, where Mul uses
+// fftMul, this makes the AGM faster.
+
+package big
+
+// π and logarithms of at least agmThreshold bits are computed with the
+// AGM, and exponentials of at least expAGMThreshold bits with Newton's
+// method from such logarithms; smaller ones with series.
+var (
+	agmThreshold    uint = 10000   // measured with BenchmarkFloatLogAGM
+	expAGMThreshold uint = 1 << 16 // measured with BenchmarkFloatLogAGM
+)
+
+// agm returns the arithmetic-geometric mean of a and b, for a >= b > 0 in
+// fixed point. Both means truncate, which keeps a >= b; the iteration
+// stops once they differ by at most 1 unit.
+func agm(a, b *Int) *Int {
+	a = new(Int).Set(a)
+	b = new(Int).Set(b)
+	var d, t Int
+	for d.Sub(a, b).Cmp(intOne) > 0 {
+		t.Mul(a, b)
+		a.Add(a, b).Rsh(a, 1)
+		b.Sqrt(&t)
+	}
+	return a
+}
+
+// piAGM returns π × 2**prec, less by less than 2, computed with the
+// Gauss-Legendre algorithm with 64 guard bits. Starting from a = 1, b =
+// √½, t = ¼, each step takes a' = (a + b)/2, b' = √(a × b), and subtracts
+// 2**k × (a - a')**2 from t in step k; the number of correct digits of
+// π ≈ (a + b)**2/(4t) doubles with each step.
+//
+// Each step truncates a, b, and t by less than 1 unit, which adds up to
+// less than 2**(k+1) units of t after the k steps, where 2**k is about
+// twice the precision; t > 1/5, so π is off by less than 2**(k+5) < 2**48
+// units.
+func piAGM(prec uint) *Int {
+	q := prec + 64
+	one := new(Int).Lsh(intOne, q)
+	a := new(Int).Set(one)
+	b := new(Int).Lsh(one, q-1)
+	b.Sqrt(b) // √½
+	t := new(Int).Rsh(one, 2)
+	var a1, d Int
+	for k := uint(0); d.Sub(a, b).Cmp(intOne) > 0; k++ {
+		a1.Add(a, b).Rsh(&a1, 1)
+		b.Mul(a, b).Sqrt(b)
+		d.Sub(a, &a1)
+		d.Mul(&d, &d).Rsh(&d, q-k)
+		t.Sub(t, &d)
+		a.Set(&a1)
+	}
+	z := new(Int).Add(a, b)
+	z.Mul(z, z).Rsh(z, 2)
+	z.Quo(z, t)
+	z.Sub(z, new(Int).Lsh(intOne, 48))
+	return z.Rsh(z, 64)
+}
+
+// logFixed returns log(g × 2**-q) × 2**q, off by less than 2 units, for
+// 1/2 <= g × 2**-q <= 2. With s = g × 2**(m-q) for some m > q/2, log(s) =
+// π/(2 AGM(1, 4/s)), off by less than 4(log(s) + 1)/s**2 (Brent), and the
+// result is log(s) - m × ln(2).
+//
+// Since AGM(1, 4/s) changes by 1/log(s) of any relative error of 4/s,
+// which is about 2**-m in fixed point, the AGM is computed with m + 64
+// bits beyond q; its truncation errors then change the result by much
+// less than 1 unit.
+func logFixed(g *Int, q uint) *Int {
+	m := q/2 + 64
+	p := q + m + 64
+	a := new(Int).Lsh(intOne, p)
+	b := new(Int).Lsh(intOne, p+q+2-m)
+	b.Quo(b, g) // 4/s
+	z := piCache.get(p)
+	z.Lsh(z, p-1).Quo(z, agm(a, b))
+	l := ln2Cache.get(p)
+	z.Sub(z, l.Mul(l, NewInt(int64(m))))
+	return z.Rsh(z, p-q)
+}
+
+// expNewton returns e**r × 2**q and a bound on its error in units, for
+// r × 2**-q with |r| <= ln(2)/2, exact, and q >= expAGMThreshold. It
+// computes y ≈ e**r at half the precision, recursively, and then takes the
+// Newton step y' = y × (1 + δ) with δ = r - log(y), computed with logFixed.
+//
+// As e**r = y × e**δ and y < 3/2, y' is off by less than 2δ**2 plus 3
+// units for the error of δ, which is less than 2 units, and 1 unit for
+// the truncation; the bound uses |δ| < 1.
+func expNewton(r *Int, q uint) (y, err *Int) {
+	p := q/2 + 32
+	rp := new(Int).Rsh(r, q-p)
+	if p < expAGMThreshold {
+		y, _ = expSeries(rp, p)
+	} else {
+		y, _ = expNewton(rp, p)
+	}
+	y.Lsh(y, q-p)
+
+	d := logFixed(y, q)
+	d.Sub(r, d)
+	t := new(Int).Mul(y, d)
+	y.Add(y, t.Rsh(t, q))
+
+	// err = 2 × (|δ| + 2 units)**2 + 5 units
+	d.Abs(d).Add(d, NewInt(2))
+	err = d.Mul(d, d).Rsh(d, q-1)
+	return y, err.Add(err, NewInt(5))
+}
@@ -0,0 +1,148 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// withAGM runs f with the AGM algorithms used from precision th on, and
+// with empty constant caches, so that they are computed anew.
+func withAGM(th uint, f func()) {
+	defer func(th, eth uint) { agmThreshold, expAGMThreshold = th, eth }(agmThreshold, expAGMThreshold)
+	agmThreshold, expAGMThreshold = th, th
+	for _, c := range []*constCache{&piCache, &eCache, &ln2Cache} {
+		c.Lock()
+		c.prec, c.val = 0, nil
+		c.Unlock()
+	}
+	f()
+}
+
+func TestPiAGM(t *testing.T) {
+	for _, prec := range []uint{100, 1000, 10000} {
+		var want *Int
+		withAGM(1<<30, func() { want = piFixed(prec) })
+		got := piAGM(prec)
+		if d := new(Int).Sub(got, want); d.CmpAbs(NewInt(2)) >= 0 {
+			t.Errorf("piAGM(%d) - piFixed(%d) = %s", prec, prec, d)
+		}
+	}
+}
+
+func TestLogFixed(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, q := range []uint{100, 1000, 10000} {
+		for i := 0; i < 10; i++ {
+			// 1/2 <= x <= 2
+			x := new(Float).SetPrec(q).SetFloat64(0.5 + 1.5*r.Float64())
+			if i == 0 {
+				x.SetFloat64(0.5)
+			}
+			want := new(Float).SetPrec(q + 64).Log(x)
+			got := new(Float).SetMantExp(new(Float).SetInt(logFixed(fixedFloat(x, q), q)), -int(q))
+			d := new(Float).Sub(got, want)
+			if d.Sign() != 0 && d.MantExp(nil) > -int(q)+1 {
+				t.Errorf("logFixed(%g, %d) off by %g", x, q, d)
+			}
+		}
+	}
+}
+
+func TestAGMBracket(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for _, w := range []uint{300, 1000, 5000} {
+		for i := 0; i < 20; i++ {
+			x := new(Float).SetPrec(w).SetFloat64(r.NormFloat64() * 1000)
+			if i < 5 {
+				x.SetMantExp(x, -r.Intn(int(w)))
+			}
+			ax := new(Float).Abs(x)
+			f, e, tz := logReduce(ax)
+			eref := new(Float).SetPrec(w + 300).Exp(x)
+			lref := new(Float).SetPrec(w + tz + 300).Log(ax)
+			withAGM(256, func() {
+				lo, hi, exp := expBracket(x, w)
+				checkBracket(t, "expBracket", x, w, eref, lo, hi, exp)
+				lo, hi, exp = logBracket(f, e, tz, w)
+				checkBracket(t, "logBracket", ax, w, lref, lo, hi, exp)
+			})
+		}
+	}
+}
+
+func TestFloatExpLogAGM(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, prec := range []uint{200, 1000, 3000} {
+		var xs []*Float
+		for i := 0; i < 10; i++ {
+			x := new(Float).SetPrec(prec).SetFloat64(r.NormFloat64() * 10)
+			x.Mul(x, Pi(prec))
+			xs = append(xs, x)
+		}
+		xs = append(xs, new(Float).SetPrec(prec).SetMantExp(NewFloat(1), -100))
+		for _, x := range xs {
+			for _, mode := range []RoundingMode{ToNearestEven, ToNearestAway, ToZero, AwayFromZero, ToNegativeInf, ToPositiveInf} {
+				var y [2]*Float
+				var ly [2]*Float
+				ax := new(Float).Abs(x)
+				for j, th := range []uint{1 << 30, 256} {
+					withAGM(th, func() {
+						y[j] = new(Float).SetPrec(prec).SetMode(mode).Exp(x)
+						ly[j] = new(Float).SetPrec(prec).SetMode(mode).Log(ax)
+					})
+				}
+				if y[0].Cmp(y[1]) != 0 || y[0].Acc() != y[1].Acc() {
+					t.Errorf("prec %d, %s: Exp(%g) = %g (%s) with the AGM; want %g (%s)", prec, mode, x, y[1], y[1].Acc(), y[0], y[0].Acc())
+				}
+				if ly[0].Cmp(ly[1]) != 0 || ly[0].Acc() != ly[1].Acc() {
+					t.Errorf("prec %d, %s: Log(%g) = %g (%s) with the AGM; want %g (%s)", prec, mode, ax, ly[1], ly[1].Acc(), ly[0], ly[0].Acc())
+				}
+			}
+		}
+	}
+}
+
+func TestConstantsAGM(t *testing.T) {
+	for _, prec := range []uint{100, 1000, 5000} {
+		var want *Float
+		withAGM(1<<30, func() { want = Pi(prec) })
+		withAGM(256, func() {
+			if z := Pi(prec); z.Cmp(want) != 0 {
+				t.Errorf("Pi(%d) = %s with the AGM; want %s", prec, z.Text('p', 0), want.Text('p', 0))
+			}
+		})
+	}
+}
+
+// BenchmarkFloatLogAGM compares the series and the AGM for Log and Exp;
+// agmThreshold and expAGMThreshold are about where they break even.
+func BenchmarkFloatLogAGM(b *testing.B) {
+	x := new(Float).SetPrec(1 << 20).SetFloat64(1.5)
+	for _, prec := range []uint{5e3, 1e4, 2e4, 5e4, 1e5, 2e5} {
+		for _, th := range []uint{1 << 30, 1000} {
+			alg := "series"
+			if th <= prec {
+				alg = "AGM"
+			}
+			withAGM(th, func() {
+				Pi(prec + 1000)
+				Ln2(prec + 1000)
+				b.Run(fmt.Sprintf("Log/%d/%s", prec, alg), func(b *testing.B) {
+					for i := 0; i < b.N; i++ {
+						new(Float).SetPrec(prec).Log(x)
+					}
+				})
+				b.Run(fmt.Sprintf("Exp/%d/%s", prec, alg), func(b *testing.B) {
+					for i := 0; i < b.N; i++ {
+						new(Float).SetPrec(prec).Exp(x)
+					}
+				})
+			})
+		}
+	}
+}
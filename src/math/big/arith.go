@@ -70,9 +70,8 @@ func mulWW_g(x, y Word) (z1, z0 Word) {
 // z1<<_W + z0 = x*y + c
 func mulAddWWW_g(x, y, c Word) (z1, z0 Word) {
 	z1, zz0 := mulWW_g(x, y)
-	if z0 = zz0 + c; z0 < zz0 {
-		z1++
-	}
+	z0 = zz0 + c
+	z1 += (zz0&c | (zz0|c)&^z0) >> (_W - 1) // carry, without a branch
 	return
 }
 
@@ -235,26 +234,240 @@ func shrVU_g(z, x []Word, s uint) (c Word) {
 
 func mulAddVWW_g(z, x []Word, y, r Word) (c Word) {
 	c = r
-	for i := range z {
-		c, z[i] = mulAddWWW_g(x[i], y, c)
+	x = x[:len(z)]
+	for i, xi := range x {
+		c, z[i] = mulAddWWW_g(xi, y, c)
 	}
 	return
 }
 
-// TODO(gri) Remove use of addWW_g here and then we can remove addWW_g and subWW_g.
 func addMulVVW_g(z, x []Word, y Word) (c Word) {
+	x = x[:len(z)]
+	for i, xi := range x {
+		z1, z0 := mulAddWWW_g(xi, y, z[i])
+		zi := z0 + c
+		z[i] = zi
+		c = z1 + (z0&c|(z0|c)&^zi)>>(_W-1) // cannot overflow
+	}
+	return
+}
+
+// The logical kernels set z[i] to x[i] op y[i] for 0 <= i < len(z).
+// x and y must be at least as long as z.
+
+func andVV_g(z, x, y []Word) {
 	for i := range z {
-		z1, z0 := mulAddWWW_g(x[i], y, z[i])
-		c, z[i] = addWW_g(z0, c, 0)
-		c += z1
+		z[i] = x[i] & y[i]
+	}
+}
+
+func andNotVV_g(z, x, y []Word) {
+	for i := range z {
+		z[i] = x[i] &^ y[i]
+	}
+}
+
+func orVV_g(z, x, y []Word) {
+	for i := range z {
+		z[i] = x[i] | y[i]
+	}
+}
+
+func xorVV_g(z, x, y []Word) {
+	for i := range z {
+		z[i] = x[i] ^ y[i]
+	}
+}
+
+// popV_g returns the number of one bits in x.
+func popV_g(x []Word) (n int) {
+	for _, xi := range x {
+		n += bits.OnesCount(uint(xi))
+	}
+	return
+}
+
+// popVV_g returns the number of bits in which x and y differ; y must be
+// at least as long as x.
+func popVV_g(x, y []Word) (n int) {
+	for i, xi := range x {
+		n += bits.OnesCount(uint(xi ^ y[i]))
+	}
+	return
+}
+
+// cmpVV_g compares x and y, which have the same length, from the most
+// significant word down, and returns -1, 0, or +1 like nat.cmp.
+func cmpVV_g(x, y []Word) (r int) {
+	for i := len(x) - 1; i >= 0; i-- {
+		if x[i] != y[i] {
+			if x[i] < y[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// ccmpVV_g compares x and y, which have the same length, in constant
+// time: lt is 1 if x < y, gt is 1 if x > y, and both are 0 otherwise.
+// lt is the borrow of x - y, and x != y if any word of the difference
+// is not 0.
+func ccmpVV_g(x, y []Word) (lt, gt Word) {
+	var d Word
+	for i, xi := range x {
+		yi := y[i]
+		zi := xi - yi - lt
+		d |= zi
+		// see "Hacker's Delight", section 2-12 (overflow detection)
+		lt = (yi&^xi | (yi|^xi)&zi) >> (_W - 1)
 	}
+	gt = (ctIsZero(d) ^ 1) &^ lt
 	return
 }
 
+// ceqVV_g returns 1 if x and y, which have the same length, are equal
+// and 0 otherwise, in constant time.
+func ceqVV_g(x, y []Word) (eq Word) {
+	var d Word
+	for i, xi := range x {
+		d |= xi ^ y[i]
+	}
+	return ctIsZero(d)
+}
+
+// divWVW_g divides by the reciprocal of y, which replaces the software
+// division of each word by multiplications.
 func divWVW_g(z []Word, xn Word, x []Word, y Word) (r Word) {
+	if len(z) > 1 {
+		return divWVWRec(z, xn, x, y)
+	}
 	r = xn
 	for i := len(z) - 1; i >= 0; i-- {
 		z[i], r = divWW_g(r, x[i], y)
 	}
 	return
 }
+
+// divWVWRec is like divWVW, but multiplies by the reciprocal of y instead
+// of dividing each word by y. The divisor is normalized once, and the
+// words of x are shifted along as they are divided.
+func divWVWRec(z []Word, xn Word, x []Word, y Word) (r Word) {
+	s := nlz(y)
+	y <<= s
+	m := reciprocalWord(y)
+	// Shifts by _W yield 0 if s == 0.
+	r = xn<<s | x[len(x)-1]>>(_W-s)
+	for i := len(z) - 1; i > 0; i-- {
+		z[i], r = divWWRec(r, x[i]<<s|x[i-1]>>(_W-s), y, m)
+	}
+	z[0], r = divWWRec(r, x[0]<<s, y, m)
+	return r >> s
+}
+
+// reciprocalWord returns the reciprocal floor((B**2 - 1)/d) - B of the
+// normalized divisor d, for B = 2**_W.
+func reciprocalWord(d Word) Word {
+	m, _ := divWW_g(^d, _M, d)
+	return m
+}
+
+// reciprocal3by2 returns the reciprocal floor((B**3 - 1)/d) - B of the
+// normalized two-word divisor d = d1<<_W + d0, for B = 2**_W. See Möller
+// and Granlund, Algorithm 6.
+func reciprocal3by2(d1, d0 Word) Word {
+	v := reciprocalWord(d1)
+	p := d1*v + d0
+	if p < d0 {
+		v--
+		if p >= d1 {
+			v--
+			p -= d1
+		}
+		p -= d1
+	}
+	t1, t0 := mulWW(v, d0)
+	if p += t1; p < t1 {
+		v--
+		if p > d1 || p == d1 && t0 >= d0 {
+			v--
+		}
+	}
+	return v
+}
+
+// div3by2 returns the quotient q of u = u2<<(2*_W) + u1<<_W + u0 and the
+// normalized two-word divisor d = d1<<_W + d0, for u2<<_W + u1 < d, and
+// the remainder r1<<_W + r0, given the reciprocal v = reciprocal3by2(d1, d0).
+// The quotient is exact, unlike the estimate from the two most significant
+// words of u and d1 alone. See Möller and Granlund, Algorithm 5.
+func div3by2(u2, u1, u0, d1, d0, v Word) (q, r1, r0 Word) {
+	// (q1, q0) = v*u2 + (u2, u1)
+	q1, q0 := mulWW(v, u2)
+	if q0 += u1; q0 < u1 {
+		q1++
+	}
+	q1 += u2
+	// (r1, r0) = (u1 - q1*d1, u0) - q1*d0 - d, modulo B**2
+	r1 = u1 - q1*d1
+	t1, t0 := mulWW(q1, d0)
+	r1, r0 = sub2(r1, u0, t1, t0)
+	r1, r0 = sub2(r1, r0, d1, d0)
+	q1++
+	if r1 >= q0 {
+		q1--
+		r1, r0 = add2(r1, r0, d1, d0)
+	}
+	if r1 > d1 || r1 == d1 && r0 >= d0 {
+		q1++
+		r1, r0 = sub2(r1, r0, d1, d0)
+	}
+	return q1, r1, r0
+}
+
+// add2 returns (x1, x0) + (y1, y0) modulo B**2.
+func add2(x1, x0, y1, y0 Word) (z1, z0 Word) {
+	z0 = x0 + y0
+	z1 = x1 + y1
+	if z0 < y0 {
+		z1++
+	}
+	return
+}
+
+// sub2 returns (x1, x0) - (y1, y0) modulo B**2.
+func sub2(x1, x0, y1, y0 Word) (z1, z0 Word) {
+	z0 = x0 - y0
+	z1 = x1 - y1
+	if x0 < y0 {
+		z1--
+	}
+	return
+}
+
+// divWWRec returns q = (x1<<_W + x0 - r)/d and r, for x1 < d, the
+// normalized divisor d, and its reciprocal m = reciprocalWord(d), using
+// multiplications instead of a division. See Niels Möller and Torbjörn
+// Granlund, "Improved division by invariant integers", IEEE Transactions
+// on Computers 60(2), 2011, Algorithm 4.
+func divWWRec(x1, x0, d, m Word) (q, r Word) {
+	// (q1, q0) = m*x1 + (x1, x0)
+	q1, q0 := mulWW(m, x1)
+	q0 += x0
+	if q0 < x0 {
+		q1++
+	}
+	q1 += x1 + 1
+	// The candidate quotient q1 is at most one too large or too small.
+	r = x0 - q1*d
+	if r > q0 {
+		q1--
+		r += d
+	}
+	if r >= d {
+		q1++
+		r -= d
+	}
+	return q1, r
+}
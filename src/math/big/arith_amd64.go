@@ -0,0 +1,18 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// +build !math_big_pure_go
+
+package big
+
+import "internal/cpu"
+
+// support_avx2 selects the AVX2 paths of the logical kernels,
+// support_adx the MULX/ADCX/ADOX path of addMulVVW, and support_popcnt
+// the POPCNT paths of popV and popVV, in arith_amd64.s.
+var (
+	support_avx2   = cpu.X86.HasAVX2
+	support_adx    = cpu.X86.HasADX && cpu.X86.HasBMI2
+	support_popcnt = cpu.X86.HasPOPCNT
+)
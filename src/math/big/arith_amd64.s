@@ -33,6 +33,13 @@ TEXT ·divWW(SB),NOSPLIT,$0
 //
 // CAUTION: Note that MOVQ $0, Rx is translated to XORQ Rx, Rx which clears the carry bit!
 
+// The assembler does not know ADCX and ADOX yet.
+#define ADCXQ_CX_AX BYTE $0x66; BYTE $0x48; BYTE $0x0f; BYTE $0x38; BYTE $0xf6; BYTE $0xc1
+#define ADCXQ_DI_AX BYTE $0x66; BYTE $0x48; BYTE $0x0f; BYTE $0x38; BYTE $0xf6; BYTE $0xc7
+#define ADCXQ_R13_CX BYTE $0x66; BYTE $0x49; BYTE $0x0f; BYTE $0x38; BYTE $0xf6; BYTE $0xcd
+#define ADOXQ_R14_AX BYTE $0xf3; BYTE $0x49; BYTE $0x0f; BYTE $0x38; BYTE $0xf6; BYTE $0xc6
+#define ADOXQ_R13_CX BYTE $0xf3; BYTE $0x49; BYTE $0x0f; BYTE $0x38; BYTE $0xf6; BYTE $0xcd
+
 // func addVV(z, x, y []Word) (c Word)
 TEXT ·addVV(SB),NOSPLIT,$0
 	MOVQ z_len+8(FP), DI
@@ -386,6 +393,12 @@ TEXT ·addMulVVW(SB),NOSPLIT,$0
 	MOVQ z_len+8(FP), R11
 	MOVQ $0, BX		// i = 0
 	MOVQ $0, CX		// c = 0
+	CMPQ R11, $8
+	JB noadx
+	CMPB ·support_adx(SB), $1
+	JEQ adx
+
+noadx:
 	MOVQ R11, R12
 	ANDQ $-2, R12
 	CMPQ R11, $2
@@ -431,6 +444,71 @@ E6:	CMPQ BX, R11		// i < n
 	MOVQ CX, c+56(FP)
 	RET
 
+adx:
+	// 8 words at a time, with two carry chains: ADCX adds the high half
+	// of the previous product to the low half of the next one, and ADOX
+	// adds z[i]. MULX leaves the flags alone, and XORQ clears both
+	// chains at the start of each iteration.
+	MOVQ R9, DX		// MULX multiplies by DX
+	MOVQ R11, R12
+	ANDQ $-8, R12		// the words the loop handles
+
+U6:	XORQ R13, R13		// R13 = 0, CF = OF = 0
+	MULXQ (R8)(BX*8), AX, DI
+	MOVQ (R10)(BX*8), R14
+	ADCXQ_CX_AX
+	ADOXQ_R14_AX
+	MOVQ AX, (R10)(BX*8)
+
+	MULXQ (8)(R8)(BX*8), AX, CX
+	MOVQ (8)(R10)(BX*8), R14
+	ADCXQ_DI_AX
+	ADOXQ_R14_AX
+	MOVQ AX, (8)(R10)(BX*8)
+
+	MULXQ (16)(R8)(BX*8), AX, DI
+	MOVQ (16)(R10)(BX*8), R14
+	ADCXQ_CX_AX
+	ADOXQ_R14_AX
+	MOVQ AX, (16)(R10)(BX*8)
+
+	MULXQ (24)(R8)(BX*8), AX, CX
+	MOVQ (24)(R10)(BX*8), R14
+	ADCXQ_DI_AX
+	ADOXQ_R14_AX
+	MOVQ AX, (24)(R10)(BX*8)
+
+	MULXQ (32)(R8)(BX*8), AX, DI
+	MOVQ (32)(R10)(BX*8), R14
+	ADCXQ_CX_AX
+	ADOXQ_R14_AX
+	MOVQ AX, (32)(R10)(BX*8)
+
+	MULXQ (40)(R8)(BX*8), AX, CX
+	MOVQ (40)(R10)(BX*8), R14
+	ADCXQ_DI_AX
+	ADOXQ_R14_AX
+	MOVQ AX, (40)(R10)(BX*8)
+
+	MULXQ (48)(R8)(BX*8), AX, DI
+	MOVQ (48)(R10)(BX*8), R14
+	ADCXQ_CX_AX
+	ADOXQ_R14_AX
+	MOVQ AX, (48)(R10)(BX*8)
+
+	MULXQ (56)(R8)(BX*8), AX, CX
+	MOVQ (56)(R10)(BX*8), R14
+	ADCXQ_DI_AX
+	ADOXQ_R14_AX
+	MOVQ AX, (56)(R10)(BX*8)
+	ADCXQ_R13_CX		// c = last high half + both carries,
+	ADOXQ_R13_CX		// which cannot overflow
+
+	ADDQ $8, BX		// i += 8
+	CMPQ BX, R12
+	JL U6
+	JMP E6		// the remaining words one at a time
+
 
 // func divWVW(z []Word, xn Word, x []Word, y Word) (r Word)
 TEXT ·divWVW(SB),NOSPLIT,$0
@@ -439,6 +517,8 @@ TEXT ·divWVW(SB),NOSPLIT,$0
 	MOVQ x+32(FP), R8
 	MOVQ y+56(FP), R9
 	MOVQ z_len+8(FP), BX	// i = z
+	CMPQ BX, $16
+	JGE rec7		// i >= 16
 	JMP E7
 
 L7:	MOVQ (R8)(BX*8), AX
@@ -450,3 +530,562 @@ E7:	SUBQ $1, BX		// i--
 
 	MOVQ DX, r+64(FP)
 	RET
+
+	// Divide by the reciprocal of the normalized divisor instead; see
+	// divWVWRec. Unlike the words of x, r and the quotient words are
+	// those of the shifted dividend.
+rec7:	MOVQ DX, R12		// r = xn
+	BSRQ R9, CX
+	XORQ $63, CX		// s = nlz(y)
+	SHLQ CX, R9		// d = y<<s
+	MOVQ R9, DX
+	NOTQ DX
+	MOVQ $-1, AX
+	DIVQ R9
+	MOVQ AX, R11		// m = reciprocalWord(d)
+	SUBQ $1, BX		// i = n-1
+	MOVQ (R8)(BX*8), DI	// w = x[n-1]
+	MOVQ DI, AX
+	SHLQ CX, R12:AX		// r = xn<<s | w>>ŝ
+
+loop7:	MOVQ DI, R13		// u = w
+	XORQ DI, DI
+	TESTQ BX, BX
+	JEQ 2(PC)
+	MOVQ -8(R8)(BX*8), DI	// w = x[i-1]
+	SHLQ CX, R13:DI		// u = u<<s | w>>ŝ
+
+	// (q1, q0) = m*r + (r, u) + (1, 0)
+	MOVQ R11, AX
+	MULQ R12
+	ADDQ R13, AX
+	ADCQ R12, DX
+	ADDQ $1, DX
+
+	// r = u - q1*d, with one correction each way
+	MOVQ DX, R14
+	IMULQ R9, R14
+	MOVQ R13, R12
+	SUBQ R14, R12
+	LEAQ -1(DX), R14
+	LEAQ (R12)(R9*1), SI
+	CMPQ R12, AX
+	CMOVQHI R14, DX		// q1--
+	CMOVQHI SI, R12		// r += d
+	CMPQ R12, R9
+	JAE fix7		// r >= d
+
+next7:	MOVQ DX, (R10)(BX*8)	// z[i] = q1
+	SUBQ $1, BX		// i--
+	JGE loop7			// i >= 0
+
+	SHRQ CX, R12
+	MOVQ R12, r+64(FP)
+	RET
+
+fix7:	ADDQ $1, DX		// q1++
+	SUBQ R9, R12		// r -= d
+	JMP next7
+
+// The logical kernels process 16 and then 4 words at a time in the YMM
+// registers if AVX2 is available, and the remaining words one at a time.
+// x &^ y is computed as x & (y ^ -1) with an all-ones register, Y15.
+
+// func andVV(z, x, y []Word)
+TEXT ·andVV(SB),NOSPLIT,$0
+	MOVQ z_len+8(FP), DI
+	MOVQ x+24(FP), R8
+	MOVQ y+48(FP), R9
+	MOVQ z+0(FP), R10
+	MOVQ $0, SI		// i = 0
+	CMPB ·support_avx2(SB), $1
+	JNE V1			// no AVX2
+
+	SUBQ $16, DI		// n -= 16
+	JL V16			// if n < 0 goto V16
+
+U16:	// n >= 0
+	VMOVDQU 0(R8)(SI*8), Y0
+	VMOVDQU 32(R8)(SI*8), Y1
+	VMOVDQU 64(R8)(SI*8), Y2
+	VMOVDQU 96(R8)(SI*8), Y3
+	VPAND 0(R9)(SI*8), Y0, Y0
+	VPAND 32(R9)(SI*8), Y1, Y1
+	VPAND 64(R9)(SI*8), Y2, Y2
+	VPAND 96(R9)(SI*8), Y3, Y3
+	VMOVDQU Y0, 0(R10)(SI*8)
+	VMOVDQU Y1, 32(R10)(SI*8)
+	VMOVDQU Y2, 64(R10)(SI*8)
+	VMOVDQU Y3, 96(R10)(SI*8)
+	ADDQ $16, SI		// i += 16
+	SUBQ $16, DI		// n -= 16
+	JGE U16			// if n >= 0 goto U16
+
+V16:	ADDQ $12, DI		// n += 16, n -= 4
+	JL E4			// if n < 0 goto E4
+
+U4:	// n >= 0
+	VMOVDQU 0(R8)(SI*8), Y0
+	VPAND 0(R9)(SI*8), Y0, Y0
+	VMOVDQU Y0, 0(R10)(SI*8)
+	ADDQ $4, SI		// i += 4
+	SUBQ $4, DI		// n -= 4
+	JGE U4			// if n >= 0 goto U4
+
+E4:	VZEROUPPER
+	ADDQ $4, DI		// n += 4
+
+V1:	CMPQ DI, $0
+	JLE E1			// if n <= 0 goto E1
+
+L1:	// n > 0
+	MOVQ 0(R8)(SI*8), R11
+	ANDQ 0(R9)(SI*8), R11
+	MOVQ R11, 0(R10)(SI*8)	// z[i] = x[i] & y[i]
+	ADDQ $1, SI		// i++
+	SUBQ $1, DI		// n--
+	JG L1			// if n > 0 goto L1
+
+E1:	RET
+
+// func andNotVV(z, x, y []Word)
+TEXT ·andNotVV(SB),NOSPLIT,$0
+	MOVQ z_len+8(FP), DI
+	MOVQ x+24(FP), R8
+	MOVQ y+48(FP), R9
+	MOVQ z+0(FP), R10
+	MOVQ $0, SI		// i = 0
+	CMPB ·support_avx2(SB), $1
+	JNE V1			// no AVX2
+	VPCMPEQB Y15, Y15, Y15
+
+	SUBQ $16, DI		// n -= 16
+	JL V16			// if n < 0 goto V16
+
+U16:	// n >= 0
+	VMOVDQU 0(R9)(SI*8), Y0
+	VMOVDQU 32(R9)(SI*8), Y1
+	VMOVDQU 64(R9)(SI*8), Y2
+	VMOVDQU 96(R9)(SI*8), Y3
+	VPXOR Y15, Y0, Y0
+	VPXOR Y15, Y1, Y1
+	VPXOR Y15, Y2, Y2
+	VPXOR Y15, Y3, Y3
+	VPAND 0(R8)(SI*8), Y0, Y0
+	VPAND 32(R8)(SI*8), Y1, Y1
+	VPAND 64(R8)(SI*8), Y2, Y2
+	VPAND 96(R8)(SI*8), Y3, Y3
+	VMOVDQU Y0, 0(R10)(SI*8)
+	VMOVDQU Y1, 32(R10)(SI*8)
+	VMOVDQU Y2, 64(R10)(SI*8)
+	VMOVDQU Y3, 96(R10)(SI*8)
+	ADDQ $16, SI		// i += 16
+	SUBQ $16, DI		// n -= 16
+	JGE U16			// if n >= 0 goto U16
+
+V16:	ADDQ $12, DI		// n += 16, n -= 4
+	JL E4			// if n < 0 goto E4
+
+U4:	// n >= 0
+	VMOVDQU 0(R9)(SI*8), Y0
+	VPXOR Y15, Y0, Y0
+	VPAND 0(R8)(SI*8), Y0, Y0
+	VMOVDQU Y0, 0(R10)(SI*8)
+	ADDQ $4, SI		// i += 4
+	SUBQ $4, DI		// n -= 4
+	JGE U4			// if n >= 0 goto U4
+
+E4:	VZEROUPPER
+	ADDQ $4, DI		// n += 4
+
+V1:	CMPQ DI, $0
+	JLE E1			// if n <= 0 goto E1
+
+L1:	// n > 0
+	MOVQ 0(R9)(SI*8), R11
+	NOTQ R11
+	ANDQ 0(R8)(SI*8), R11
+	MOVQ R11, 0(R10)(SI*8)	// z[i] = x[i] &^ y[i]
+	ADDQ $1, SI		// i++
+	SUBQ $1, DI		// n--
+	JG L1			// if n > 0 goto L1
+
+E1:	RET
+
+// func orVV(z, x, y []Word)
+TEXT ·orVV(SB),NOSPLIT,$0
+	MOVQ z_len+8(FP), DI
+	MOVQ x+24(FP), R8
+	MOVQ y+48(FP), R9
+	MOVQ z+0(FP), R10
+	MOVQ $0, SI		// i = 0
+	CMPB ·support_avx2(SB), $1
+	JNE V1			// no AVX2
+
+	SUBQ $16, DI		// n -= 16
+	JL V16			// if n < 0 goto V16
+
+U16:	// n >= 0
+	VMOVDQU 0(R8)(SI*8), Y0
+	VMOVDQU 32(R8)(SI*8), Y1
+	VMOVDQU 64(R8)(SI*8), Y2
+	VMOVDQU 96(R8)(SI*8), Y3
+	VPOR 0(R9)(SI*8), Y0, Y0
+	VPOR 32(R9)(SI*8), Y1, Y1
+	VPOR 64(R9)(SI*8), Y2, Y2
+	VPOR 96(R9)(SI*8), Y3, Y3
+	VMOVDQU Y0, 0(R10)(SI*8)
+	VMOVDQU Y1, 32(R10)(SI*8)
+	VMOVDQU Y2, 64(R10)(SI*8)
+	VMOVDQU Y3, 96(R10)(SI*8)
+	ADDQ $16, SI		// i += 16
+	SUBQ $16, DI		// n -= 16
+	JGE U16			// if n >= 0 goto U16
+
+V16:	ADDQ $12, DI		// n += 16, n -= 4
+	JL E4			// if n < 0 goto E4
+
+U4:	// n >= 0
+	VMOVDQU 0(R8)(SI*8), Y0
+	VPOR 0(R9)(SI*8), Y0, Y0
+	VMOVDQU Y0, 0(R10)(SI*8)
+	ADDQ $4, SI		// i += 4
+	SUBQ $4, DI		// n -= 4
+	JGE U4			// if n >= 0 goto U4
+
+E4:	VZEROUPPER
+	ADDQ $4, DI		// n += 4
+
+V1:	CMPQ DI, $0
+	JLE E1			// if n <= 0 goto E1
+
+L1:	// n > 0
+	MOVQ 0(R8)(SI*8), R11
+	ORQ 0(R9)(SI*8), R11
+	MOVQ R11, 0(R10)(SI*8)	// z[i] = x[i] | y[i]
+	ADDQ $1, SI		// i++
+	SUBQ $1, DI		// n--
+	JG L1			// if n > 0 goto L1
+
+E1:	RET
+
+// func xorVV(z, x, y []Word)
+TEXT ·xorVV(SB),NOSPLIT,$0
+	MOVQ z_len+8(FP), DI
+	MOVQ x+24(FP), R8
+	MOVQ y+48(FP), R9
+	MOVQ z+0(FP), R10
+	MOVQ $0, SI		// i = 0
+	CMPB ·support_avx2(SB), $1
+	JNE V1			// no AVX2
+
+	SUBQ $16, DI		// n -= 16
+	JL V16			// if n < 0 goto V16
+
+U16:	// n >= 0
+	VMOVDQU 0(R8)(SI*8), Y0
+	VMOVDQU 32(R8)(SI*8), Y1
+	VMOVDQU 64(R8)(SI*8), Y2
+	VMOVDQU 96(R8)(SI*8), Y3
+	VPXOR 0(R9)(SI*8), Y0, Y0
+	VPXOR 32(R9)(SI*8), Y1, Y1
+	VPXOR 64(R9)(SI*8), Y2, Y2
+	VPXOR 96(R9)(SI*8), Y3, Y3
+	VMOVDQU Y0, 0(R10)(SI*8)
+	VMOVDQU Y1, 32(R10)(SI*8)
+	VMOVDQU Y2, 64(R10)(SI*8)
+	VMOVDQU Y3, 96(R10)(SI*8)
+	ADDQ $16, SI		// i += 16
+	SUBQ $16, DI		// n -= 16
+	JGE U16			// if n >= 0 goto U16
+
+V16:	ADDQ $12, DI		// n += 16, n -= 4
+	JL E4			// if n < 0 goto E4
+
+U4:	// n >= 0
+	VMOVDQU 0(R8)(SI*8), Y0
+	VPXOR 0(R9)(SI*8), Y0, Y0
+	VMOVDQU Y0, 0(R10)(SI*8)
+	ADDQ $4, SI		// i += 4
+	SUBQ $4, DI		// n -= 4
+	JGE U4			// if n >= 0 goto U4
+
+E4:	VZEROUPPER
+	ADDQ $4, DI		// n += 4
+
+V1:	CMPQ DI, $0
+	JLE E1			// if n <= 0 goto E1
+
+L1:	// n > 0
+	MOVQ 0(R8)(SI*8), R11
+	XORQ 0(R9)(SI*8), R11
+	MOVQ R11, 0(R10)(SI*8)	// z[i] = x[i] ^ y[i]
+	ADDQ $1, SI		// i++
+	SUBQ $1, DI		// n--
+	JG L1			// if n > 0 goto L1
+
+E1:	RET
+
+// func popV(x []Word) (n int)
+TEXT ·popV(SB),NOSPLIT,$0
+	CMPB ·support_popcnt(SB), $1
+	JEQ P0
+	JMP ·popV_g(SB)		// no POPCNT
+
+P0:	MOVQ x_len+8(FP), DI
+	MOVQ x+0(FP), R8
+	MOVQ $0, SI		// i = 0
+	// The four counts are independent, so that the POPCNTs can overlap.
+	XORQ AX, AX
+	XORQ BX, BX
+	XORQ CX, CX
+	XORQ DX, DX
+
+	SUBQ $4, DI		// n -= 4
+	JL V4			// if n < 0 goto V4
+
+U4:	// n >= 0
+	POPCNTQ 0(R8)(SI*8), R10
+	POPCNTQ 8(R8)(SI*8), R11
+	POPCNTQ 16(R8)(SI*8), R12
+	POPCNTQ 24(R8)(SI*8), R13
+	ADDQ R10, AX
+	ADDQ R11, BX
+	ADDQ R12, CX
+	ADDQ R13, DX
+	ADDQ $4, SI		// i += 4
+	SUBQ $4, DI		// n -= 4
+	JGE U4			// if n >= 0 goto U4
+
+V4:	ADDQ $4, DI		// n += 4
+	JLE E4			// if n <= 0 goto E4
+
+L4:	// n > 0
+	POPCNTQ 0(R8)(SI*8), R10
+	ADDQ R10, AX
+	ADDQ $1, SI		// i++
+	SUBQ $1, DI		// n--
+	JG L4			// if n > 0 goto L4
+
+E4:	ADDQ BX, AX
+	ADDQ CX, AX
+	ADDQ DX, AX
+	MOVQ AX, n+24(FP)
+	RET
+
+// func popVV(x, y []Word) (n int)
+TEXT ·popVV(SB),NOSPLIT,$0
+	CMPB ·support_popcnt(SB), $1
+	JEQ P0
+	JMP ·popVV_g(SB)	// no POPCNT
+
+P0:	MOVQ x_len+8(FP), DI
+	MOVQ x+0(FP), R8
+	MOVQ y+24(FP), R9
+	MOVQ $0, SI		// i = 0
+	XORQ AX, AX
+	XORQ BX, BX
+	XORQ CX, CX
+	XORQ DX, DX
+
+	SUBQ $4, DI		// n -= 4
+	JL V4			// if n < 0 goto V4
+
+U4:	// n >= 0
+	MOVQ 0(R8)(SI*8), R10
+	MOVQ 8(R8)(SI*8), R11
+	MOVQ 16(R8)(SI*8), R12
+	MOVQ 24(R8)(SI*8), R13
+	XORQ 0(R9)(SI*8), R10
+	XORQ 8(R9)(SI*8), R11
+	XORQ 16(R9)(SI*8), R12
+	XORQ 24(R9)(SI*8), R13
+	POPCNTQ R10, R10
+	POPCNTQ R11, R11
+	POPCNTQ R12, R12
+	POPCNTQ R13, R13
+	ADDQ R10, AX
+	ADDQ R11, BX
+	ADDQ R12, CX
+	ADDQ R13, DX
+	ADDQ $4, SI		// i += 4
+	SUBQ $4, DI		// n -= 4
+	JGE U4			// if n >= 0 goto U4
+
+V4:	ADDQ $4, DI		// n += 4
+	JLE E4			// if n <= 0 goto E4
+
+L4:	// n > 0
+	MOVQ 0(R8)(SI*8), R10
+	XORQ 0(R9)(SI*8), R10
+	POPCNTQ R10, R10
+	ADDQ R10, AX
+	ADDQ $1, SI		// i++
+	SUBQ $1, DI		// n--
+	JG L4			// if n > 0 goto L4
+
+E4:	ADDQ BX, AX
+	ADDQ CX, AX
+	ADDQ DX, AX
+	MOVQ AX, n+48(FP)
+	RET
+
+// func cmpVV(x, y []Word) (r int)
+TEXT ·cmpVV(SB),NOSPLIT,$0
+	MOVQ x_len+8(FP), SI	// i = n
+	MOVQ x+0(FP), R8
+	MOVQ y+24(FP), R9
+	CMPB ·support_avx2(SB), $1
+	JNE L1			// no AVX2
+
+	SUBQ $4, SI		// i -= 4
+	JL V4			// if i < 0 goto V4
+
+U4:	// i >= 0, compare x[i:i+4] and y[i:i+4]
+	VMOVDQU 0(R8)(SI*8), Y0
+	VPXOR 0(R9)(SI*8), Y0, Y0
+	VPTEST Y0, Y0
+	JNZ V4			// the words differ
+	SUBQ $4, SI		// i -= 4
+	JGE U4			// if i >= 0 goto U4
+
+V4:	VZEROUPPER
+	ADDQ $4, SI		// i += 4
+
+L1:	// compare the words below i one by one
+	SUBQ $1, SI		// i--
+	JL E1			// if i < 0 goto E1
+	MOVQ 0(R8)(SI*8), R10
+	CMPQ R10, 0(R9)(SI*8)
+	JEQ L1			// if x[i] == y[i] goto L1
+
+	MOVQ $1, AX
+	MOVQ $-1, R11
+	CMOVQCS R11, AX		// r = -1 if x[i] < y[i]
+	MOVQ AX, r+48(FP)
+	RET
+
+E1:	MOVQ $0, r+48(FP)	// x == y
+	RET
+
+// func ccmpVV(x, y []Word) (lt, gt Word)
+// (the borrow chain of subVV, without storing the difference words,
+// which are ORed together instead)
+TEXT ·ccmpVV(SB),NOSPLIT,$0
+	MOVQ x_len+8(FP), DI
+	MOVQ x+0(FP), R8
+	MOVQ y+24(FP), R9
+
+	MOVQ $0, CX		// c = 0
+	MOVQ $0, SI		// i = 0
+	MOVQ $0, AX		// d = 0
+
+	SUBQ $8, DI		// n -= 8
+	JL V2			// if n < 0 goto V2
+
+U2:	// n >= 0
+	ADDQ CX, CX		// restore CF
+	MOVQ 0(R8)(SI*8), R10
+	MOVQ 8(R8)(SI*8), R11
+	MOVQ 16(R8)(SI*8), R12
+	MOVQ 24(R8)(SI*8), R13
+	SBBQ 0(R9)(SI*8), R10
+	SBBQ 8(R9)(SI*8), R11
+	SBBQ 16(R9)(SI*8), R12
+	SBBQ 24(R9)(SI*8), R13
+	MOVQ 32(R8)(SI*8), R14
+	MOVQ 40(R8)(SI*8), R15
+	MOVQ 48(R8)(SI*8), BX
+	MOVQ 56(R8)(SI*8), DX
+	SBBQ 32(R9)(SI*8), R14
+	SBBQ 40(R9)(SI*8), R15
+	SBBQ 48(R9)(SI*8), BX
+	SBBQ 56(R9)(SI*8), DX
+	SBBQ CX, CX		// save CF
+	ORQ R10, R11
+	ORQ R12, R13
+	ORQ R14, R15
+	ORQ BX, DX
+	ORQ R11, R13
+	ORQ R15, DX
+	ORQ R13, AX
+	ORQ DX, AX		// d |= x[i:i+8] - y[i:i+8]
+
+	ADDQ $8, SI		// i += 8
+	SUBQ $8, DI		// n -= 8
+	JGE U2			// if n >= 0 goto U2
+
+V2:	ADDQ $8, DI		// n += 8
+	JLE E2			// if n <= 0 goto E2
+
+L2:	// n > 0
+	ADDQ CX, CX		// restore CF
+	MOVQ 0(R8)(SI*8), R10
+	SBBQ 0(R9)(SI*8), R10
+	SBBQ CX, CX		// save CF
+	ORQ R10, AX		// d |= x[i] - y[i]
+
+	ADDQ $1, SI		// i++
+	SUBQ $1, DI		// n--
+	JG L2			// if n > 0 goto L2
+
+E2:	NEGQ CX			// lt = c
+	NEGQ AX			// CF = d != 0
+	SBBQ AX, AX
+	NEGQ AX			// AX = 1 if d != 0
+	MOVQ CX, DX
+	XORQ $1, DX
+	ANDQ DX, AX		// gt = (d != 0) &^ lt
+	MOVQ CX, lt+48(FP)
+	MOVQ AX, gt+56(FP)
+	RET
+
+// func ceqVV(x, y []Word) (eq Word)
+TEXT ·ceqVV(SB),NOSPLIT,$0
+	MOVQ x_len+8(FP), DI
+	MOVQ x+0(FP), R8
+	MOVQ y+24(FP), R9
+	MOVQ $0, SI		// i = 0
+	MOVQ $0, AX		// d = 0
+	CMPB ·support_avx2(SB), $1
+	JNE V1			// no AVX2
+	VPXOR Y2, Y2, Y2
+	VPXOR Y3, Y3, Y3
+
+	SUBQ $8, DI		// n -= 8
+	JL V8			// if n < 0 goto V8
+
+U8:	// n >= 0
+	VMOVDQU 0(R8)(SI*8), Y0
+	VMOVDQU 32(R8)(SI*8), Y1
+	VPXOR 0(R9)(SI*8), Y0, Y0
+	VPXOR 32(R9)(SI*8), Y1, Y1
+	VPOR Y0, Y2, Y2
+	VPOR Y1, Y3, Y3		// d |= x[i:i+8] ^ y[i:i+8]
+	ADDQ $8, SI		// i += 8
+	SUBQ $8, DI		// n -= 8
+	JGE U8			// if n >= 0 goto U8
+
+V8:	VPOR Y3, Y2, Y2
+	VPTEST Y2, Y2
+	MOVQ $1, R10
+	CMOVQNE R10, AX		// d = 1 if the vector words differ
+	VZEROUPPER
+	ADDQ $8, DI		// n += 8
+
+V1:	CMPQ DI, $0
+	JLE E1			// if n <= 0 goto E1
+
+L1:	// n > 0
+	MOVQ 0(R8)(SI*8), R10
+	XORQ 0(R9)(SI*8), R10
+	ORQ R10, AX		// d |= x[i] ^ y[i]
+	ADDQ $1, SI		// i++
+	SUBQ $1, DI		// n--
+	JG L1			// if n > 0 goto L1
+
+E1:	NEGQ AX			// CF = d != 0
+	SBBQ AX, AX
+	INCQ AX			// eq = 1 if d == 0
+	MOVQ AX, eq+48(FP)
+	RET
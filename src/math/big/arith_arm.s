@@ -292,3 +292,30 @@ TEXT ·mulWW(SB),NOSPLIT,$0
 	MOVW	R4, z1+8(FP)
 	MOVW	R3, z0+12(FP)
 	RET
+
+TEXT ·andVV(SB),NOSPLIT,$0
+	B ·andVV_g(SB)
+
+TEXT ·andNotVV(SB),NOSPLIT,$0
+	B ·andNotVV_g(SB)
+
+TEXT ·orVV(SB),NOSPLIT,$0
+	B ·orVV_g(SB)
+
+TEXT ·xorVV(SB),NOSPLIT,$0
+	B ·xorVV_g(SB)
+
+TEXT ·popV(SB),NOSPLIT,$0
+	B ·popV_g(SB)
+
+TEXT ·popVV(SB),NOSPLIT,$0
+	B ·popVV_g(SB)
+
+TEXT ·cmpVV(SB),NOSPLIT,$0
+	B ·cmpVV_g(SB)
+
+TEXT ·ccmpVV(SB),NOSPLIT,$0
+	B ·ccmpVV_g(SB)
+
+TEXT ·ceqVV(SB),NOSPLIT,$0
+	B ·ceqVV_g(SB)
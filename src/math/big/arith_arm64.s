@@ -10,7 +10,9 @@
 // arithmetic operations on vectors implemented in arith.go.
 
 // TODO: Consider re-implementing using Advanced SIMD
-// once the assembler supports those instructions.
+// once the assembler supports those instructions;
+// until then the logical kernels use LDP and STP to move
+// two words per instruction.
 
 // func mulWW(x, y Word) (z1, z0 Word)
 TEXT ·mulWW(SB),NOSPLIT,$0
@@ -141,6 +143,30 @@ TEXT ·mulAddVWW(SB),NOSPLIT,$0
 	MOVD	x+24(FP), R2
 	MOVD	y+48(FP), R3
 	MOVD	r+56(FP), R4
+	// 4 words at a time: all products first, then a single carry chain
+	// adds the low halves to the high halves of the previous words.
+loop4:
+	CMP	$4, R0
+	BLT	loop
+	LDP.P	16(R2), (R5, R6)
+	LDP.P	16(R2), (R7, R8)
+	MUL	R5, R3, R9
+	UMULH	R5, R3, R5
+	MUL	R6, R3, R10
+	UMULH	R6, R3, R6
+	MUL	R7, R3, R11
+	UMULH	R7, R3, R7
+	MUL	R8, R3, R12
+	UMULH	R8, R3, R8
+	ADDS	R4, R9
+	ADCS	R5, R10
+	ADCS	R6, R11
+	ADCS	R7, R12
+	ADC	$0, R8, R4
+	STP.P	(R9, R10), 16(R1)
+	STP.P	(R11, R12), 16(R1)
+	SUB	$4, R0
+	B	loop4
 loop:
 	CBZ	R0, done
 	MOVD.P	8(R2), R5
@@ -159,9 +185,379 @@ done:
 
 // func addMulVVW(z, x []Word, y Word) (c Word)
 TEXT ·addMulVVW(SB),NOSPLIT,$0
-	B ·addMulVVW_g(SB)
+	MOVD	z+0(FP), R1
+	MOVD	z_len+8(FP), R0
+	MOVD	x+24(FP), R2
+	MOVD	y+48(FP), R3
+	MOVD	$0, R4
+	MOVD	R1, R24 // z is read through R1 and written through R24
+	// 4 words at a time: all products first, then one carry chain adds
+	// the low halves to z, and another adds c and the high halves of the
+	// previous words; both end in the top high half, which cannot overflow.
+loop4:
+	CMP	$4, R0
+	BLT	loop
+	LDP.P	16(R2), (R5, R6)
+	LDP.P	16(R2), (R7, R8)
+	LDP.P	16(R1), (R9, R10)
+	LDP.P	16(R1), (R11, R12)
+	MUL	R5, R3, R13
+	UMULH	R5, R3, R20
+	MUL	R6, R3, R14
+	UMULH	R6, R3, R21
+	MUL	R7, R3, R15
+	UMULH	R7, R3, R22
+	MUL	R8, R3, R19
+	UMULH	R8, R3, R23
+	ADDS	R13, R9
+	ADCS	R14, R10
+	ADCS	R15, R11
+	ADCS	R19, R12
+	ADC	$0, R23
+	ADDS	R4, R9
+	ADCS	R20, R10
+	ADCS	R21, R11
+	ADCS	R22, R12
+	ADC	$0, R23, R4
+	STP.P	(R9, R10), 16(R24)
+	STP.P	(R11, R12), 16(R24)
+	SUB	$4, R0
+	B	loop4
+loop:
+	CBZ	R0, done
+	MOVD.P	8(R2), R5
+	MOVD	(R1), R9
+	UMULH	R5, R3, R7
+	MUL	R5, R3, R6
+	ADDS	R4, R6
+	ADC	$0, R7
+	ADDS	R9, R6
+	ADC	$0, R7
+	MOVD.P	R6, 8(R1)
+	MOVD	R7, R4
+	SUB	$1, R0
+	B	loop
+done:
+	MOVD	R4, c+56(FP)
+	RET
 
 
 // func divWVW(z []Word, xn Word, x []Word, y Word) (r Word)
 TEXT ·divWVW(SB),NOSPLIT,$0
 	B ·divWVW_g(SB)
+
+// func andVV(z, x, y []Word)
+TEXT ·andVV(SB),NOSPLIT,$0
+	MOVD	z+0(FP), R3
+	MOVD	z_len+8(FP), R0
+	MOVD	x+24(FP), R1
+	MOVD	y+48(FP), R2
+loop4:
+	CMP	$4, R0
+	BLT	loop
+	LDP.P	16(R1), (R4, R5)
+	LDP.P	16(R1), (R6, R7)
+	LDP.P	16(R2), (R8, R9)
+	LDP.P	16(R2), (R10, R11)
+	AND	R8, R4
+	AND	R9, R5
+	AND	R10, R6
+	AND	R11, R7
+	STP.P	(R4, R5), 16(R3)
+	STP.P	(R6, R7), 16(R3)
+	SUB	$4, R0
+	B	loop4
+loop:
+	CBZ	R0, done
+	MOVD.P	8(R1), R4
+	MOVD.P	8(R2), R8
+	AND	R8, R4
+	MOVD.P	R4, 8(R3)
+	SUB	$1, R0
+	B	loop
+done:
+	RET
+
+
+// func andNotVV(z, x, y []Word)
+TEXT ·andNotVV(SB),NOSPLIT,$0
+	MOVD	z+0(FP), R3
+	MOVD	z_len+8(FP), R0
+	MOVD	x+24(FP), R1
+	MOVD	y+48(FP), R2
+loop4:
+	CMP	$4, R0
+	BLT	loop
+	LDP.P	16(R1), (R4, R5)
+	LDP.P	16(R1), (R6, R7)
+	LDP.P	16(R2), (R8, R9)
+	LDP.P	16(R2), (R10, R11)
+	BIC	R8, R4
+	BIC	R9, R5
+	BIC	R10, R6
+	BIC	R11, R7
+	STP.P	(R4, R5), 16(R3)
+	STP.P	(R6, R7), 16(R3)
+	SUB	$4, R0
+	B	loop4
+loop:
+	CBZ	R0, done
+	MOVD.P	8(R1), R4
+	MOVD.P	8(R2), R8
+	BIC	R8, R4
+	MOVD.P	R4, 8(R3)
+	SUB	$1, R0
+	B	loop
+done:
+	RET
+
+
+// func orVV(z, x, y []Word)
+TEXT ·orVV(SB),NOSPLIT,$0
+	MOVD	z+0(FP), R3
+	MOVD	z_len+8(FP), R0
+	MOVD	x+24(FP), R1
+	MOVD	y+48(FP), R2
+loop4:
+	CMP	$4, R0
+	BLT	loop
+	LDP.P	16(R1), (R4, R5)
+	LDP.P	16(R1), (R6, R7)
+	LDP.P	16(R2), (R8, R9)
+	LDP.P	16(R2), (R10, R11)
+	ORR	R8, R4
+	ORR	R9, R5
+	ORR	R10, R6
+	ORR	R11, R7
+	STP.P	(R4, R5), 16(R3)
+	STP.P	(R6, R7), 16(R3)
+	SUB	$4, R0
+	B	loop4
+loop:
+	CBZ	R0, done
+	MOVD.P	8(R1), R4
+	MOVD.P	8(R2), R8
+	ORR	R8, R4
+	MOVD.P	R4, 8(R3)
+	SUB	$1, R0
+	B	loop
+done:
+	RET
+
+
+// func xorVV(z, x, y []Word)
+TEXT ·xorVV(SB),NOSPLIT,$0
+	MOVD	z+0(FP), R3
+	MOVD	z_len+8(FP), R0
+	MOVD	x+24(FP), R1
+	MOVD	y+48(FP), R2
+loop4:
+	CMP	$4, R0
+	BLT	loop
+	LDP.P	16(R1), (R4, R5)
+	LDP.P	16(R1), (R6, R7)
+	LDP.P	16(R2), (R8, R9)
+	LDP.P	16(R2), (R10, R11)
+	EOR	R8, R4
+	EOR	R9, R5
+	EOR	R10, R6
+	EOR	R11, R7
+	STP.P	(R4, R5), 16(R3)
+	STP.P	(R6, R7), 16(R3)
+	SUB	$4, R0
+	B	loop4
+loop:
+	CBZ	R0, done
+	MOVD.P	8(R1), R4
+	MOVD.P	8(R2), R8
+	EOR	R8, R4
+	MOVD.P	R4, 8(R3)
+	SUB	$1, R0
+	B	loop
+done:
+	RET
+
+
+// The vector instructions of popV and popVV are encoded as WORDs. CNT
+// counts the one bits of each byte, and UADDLV adds up the bytes.
+
+// func popV(x []Word) (n int)
+TEXT ·popV(SB),NOSPLIT,$0
+	MOVD	x+0(FP), R1
+	MOVD	x_len+8(FP), R0
+	MOVD	$0, R4
+loop4:
+	CMP	$4, R0
+	BLT	loop
+	WORD	$0x4cdfa020	// VLD1.P 32(R1), [V0.B16, V1.B16]
+	WORD	$0x4e205800	// VCNT V0.B16, V0.B16
+	WORD	$0x4e205821	// VCNT V1.B16, V1.B16
+	WORD	$0x4e218400	// VADD V1.B16, V0.B16, V0.B16
+	WORD	$0x6e303800	// VUADDLV V0.B16, V0
+	FMOVD	F0, R5
+	ADD	R5, R4
+	SUB	$4, R0
+	B	loop4
+loop:
+	CBZ	R0, done
+	FMOVD.P	8(R1), F0
+	WORD	$0x0e205800	// VCNT V0.B8, V0.B8
+	WORD	$0x2e303800	// VUADDLV V0.B8, V0
+	FMOVD	F0, R5
+	ADD	R5, R4
+	SUB	$1, R0
+	B	loop
+done:
+	MOVD	R4, n+24(FP)
+	RET
+
+
+// func popVV(x, y []Word) (n int)
+TEXT ·popVV(SB),NOSPLIT,$0
+	MOVD	x+0(FP), R1
+	MOVD	x_len+8(FP), R0
+	MOVD	y+24(FP), R2
+	MOVD	$0, R4
+loop4:
+	CMP	$4, R0
+	BLT	loop
+	WORD	$0x4cdfa020	// VLD1.P 32(R1), [V0.B16, V1.B16]
+	WORD	$0x4cdfa042	// VLD1.P 32(R2), [V2.B16, V3.B16]
+	WORD	$0x6e221c00	// VEOR V2.B16, V0.B16, V0.B16
+	WORD	$0x6e231c21	// VEOR V3.B16, V1.B16, V1.B16
+	WORD	$0x4e205800	// VCNT V0.B16, V0.B16
+	WORD	$0x4e205821	// VCNT V1.B16, V1.B16
+	WORD	$0x4e218400	// VADD V1.B16, V0.B16, V0.B16
+	WORD	$0x6e303800	// VUADDLV V0.B16, V0
+	FMOVD	F0, R5
+	ADD	R5, R4
+	SUB	$4, R0
+	B	loop4
+loop:
+	CBZ	R0, done
+	MOVD.P	8(R1), R5
+	MOVD.P	8(R2), R6
+	EOR	R6, R5
+	FMOVD	R5, F0
+	WORD	$0x0e205800	// VCNT V0.B8, V0.B8
+	WORD	$0x2e303800	// VUADDLV V0.B8, V0
+	FMOVD	F0, R5
+	ADD	R5, R4
+	SUB	$1, R0
+	B	loop
+done:
+	MOVD	R4, n+48(FP)
+	RET
+
+
+// func cmpVV(x, y []Word) (r int)
+TEXT ·cmpVV(SB),NOSPLIT,$0
+	MOVD	x+0(FP), R1
+	MOVD	x_len+8(FP), R0
+	MOVD	y+24(FP), R2
+	LSL	$3, R0, R3
+	ADD	R3, R1 // compare from the top down
+	ADD	R3, R2
+loop2:
+	CMP	$2, R0
+	BLT	loop
+	LDP.W	-16(R1), (R4, R5)
+	LDP.W	-16(R2), (R6, R7)
+	CMP	R7, R5
+	BNE	diff
+	CMP	R6, R4
+	BNE	diff
+	SUB	$2, R0
+	B	loop2
+loop:
+	CBZ	R0, equal
+	MOVD.W	-8(R1), R4
+	MOVD.W	-8(R2), R6
+	CMP	R6, R4
+	BNE	diff
+	SUB	$1, R0
+	B	loop
+equal:
+	MOVD	ZR, r+48(FP)
+	RET
+diff:
+	MOVD	$1, R3
+	MOVD	$-1, R4
+	CSEL	LO, R4, R3, R3 // r = -1 if the word of x is less
+	MOVD	R3, r+48(FP)
+	RET
+
+
+// func ccmpVV(x, y []Word) (lt, gt Word)
+TEXT ·ccmpVV(SB),NOSPLIT,$0
+	MOVD	x+0(FP), R1
+	MOVD	x_len+8(FP), R0
+	MOVD	y+24(FP), R2
+	LSR	$1, R0, R3
+	AND	$1, R0
+	MOVD	ZR, R8 // OR of the difference words
+	CMP	R0, R0 // set carry flag
+loop2:
+	CBZ	R3, loop // careful not to touch the carry flag
+	LDP.P	16(R1), (R4, R5)
+	LDP.P	16(R2), (R6, R7)
+	SBCS	R6, R4
+	SBCS	R7, R5
+	ORR	R4, R8
+	ORR	R5, R8
+	SUB	$1, R3
+	B	loop2
+loop:
+	CBZ	R0, done
+	MOVD.P	8(R1), R4
+	MOVD.P	8(R2), R6
+	SBCS	R6, R4
+	ORR	R4, R8
+	SUB	$1, R0
+	B	loop
+done:
+	CSET	LO, R4 // lt: extract carry flag
+	CMP	$0, R8
+	CSET	NE, R5
+	BIC	R4, R5 // gt = (d != 0) &^ lt
+	MOVD	R4, lt+48(FP)
+	MOVD	R5, gt+56(FP)
+	RET
+
+
+// func ceqVV(x, y []Word) (eq Word)
+TEXT ·ceqVV(SB),NOSPLIT,$0
+	MOVD	x+0(FP), R1
+	MOVD	x_len+8(FP), R0
+	MOVD	y+24(FP), R2
+	MOVD	ZR, R8 // OR of the XORs of the words
+loop4:
+	CMP	$4, R0
+	BLT	loop
+	LDP.P	16(R1), (R4, R5)
+	LDP.P	16(R1), (R6, R7)
+	LDP.P	16(R2), (R9, R10)
+	LDP.P	16(R2), (R11, R12)
+	EOR	R9, R4
+	EOR	R10, R5
+	EOR	R11, R6
+	EOR	R12, R7
+	ORR	R4, R8
+	ORR	R5, R8
+	ORR	R6, R8
+	ORR	R7, R8
+	SUB	$4, R0
+	B	loop4
+loop:
+	CBZ	R0, done
+	MOVD.P	8(R1), R4
+	MOVD.P	8(R2), R9
+	EOR	R9, R4
+	ORR	R4, R8
+	SUB	$1, R0
+	B	loop
+done:
+	CMP	$0, R8
+	CSET	EQ, R0
+	MOVD	R0, eq+48(FP)
+	RET
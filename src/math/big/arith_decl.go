@@ -6,6 +6,13 @@
 
 package big
 
+import "runtime"
+
+// divWWNative reports whether divWW is a hardware division instruction
+// on GOARCH; on the other architectures it calls divWW_g.
+var divWWNative = runtime.GOARCH == "386" || runtime.GOARCH == "amd64" ||
+	runtime.GOARCH == "ppc64" || runtime.GOARCH == "ppc64le" || runtime.GOARCH == "s390x"
+
 // implemented in arith_$GOARCH.s
 func mulWW(x, y Word) (z1, z0 Word)
 func divWW(x1, x0, y Word) (q, r Word)
@@ -18,3 +25,12 @@ func shrVU(z, x []Word, s uint) (c Word)
 func mulAddVWW(z, x []Word, y, r Word) (c Word)
 func addMulVVW(z, x []Word, y Word) (c Word)
 func divWVW(z []Word, xn Word, x []Word, y Word) (r Word)
+func andVV(z, x, y []Word)
+func andNotVV(z, x, y []Word)
+func orVV(z, x, y []Word)
+func xorVV(z, x, y []Word)
+func popV(x []Word) (n int)
+func popVV(x, y []Word) (n int)
+func cmpVV(x, y []Word) (r int)
+func ccmpVV(x, y []Word) (lt, gt Word)
+func ceqVV(x, y []Word) (eq Word)
@@ -6,6 +6,9 @@
 
 package big
 
+// divWWNative reports whether divWW is a hardware division instruction.
+const divWWNative = false
+
 func mulWW(x, y Word) (z1, z0 Word) {
 	return mulWW_g(x, y)
 }
@@ -49,3 +52,39 @@ func addMulVVW(z, x []Word, y Word) (c Word) {
 func divWVW(z []Word, xn Word, x []Word, y Word) (r Word) {
 	return divWVW_g(z, xn, x, y)
 }
+
+func andVV(z, x, y []Word) {
+	andVV_g(z, x, y)
+}
+
+func andNotVV(z, x, y []Word) {
+	andNotVV_g(z, x, y)
+}
+
+func orVV(z, x, y []Word) {
+	orVV_g(z, x, y)
+}
+
+func xorVV(z, x, y []Word) {
+	xorVV_g(z, x, y)
+}
+
+func popV(x []Word) (n int) {
+	return popV_g(x)
+}
+
+func popVV(x, y []Word) (n int) {
+	return popVV_g(x, y)
+}
+
+func cmpVV(x, y []Word) (r int) {
+	return cmpVV_g(x, y)
+}
+
+func ccmpVV(x, y []Word) (lt, gt Word) {
+	return ccmpVV_g(x, y)
+}
+
+func ceqVV(x, y []Word) (eq Word) {
+	return ceqVV_g(x, y)
+}
@@ -41,3 +41,30 @@ TEXT ·addMulVVW(SB),NOSPLIT,$0
 
 TEXT ·divWVW(SB),NOSPLIT,$0
 	JMP	·divWVW_g(SB)
+
+TEXT ·andVV(SB),NOSPLIT,$0
+	JMP	·andVV_g(SB)
+
+TEXT ·andNotVV(SB),NOSPLIT,$0
+	JMP	·andNotVV_g(SB)
+
+TEXT ·orVV(SB),NOSPLIT,$0
+	JMP	·orVV_g(SB)
+
+TEXT ·xorVV(SB),NOSPLIT,$0
+	JMP	·xorVV_g(SB)
+
+TEXT ·popV(SB),NOSPLIT,$0
+	JMP	·popV_g(SB)
+
+TEXT ·popVV(SB),NOSPLIT,$0
+	JMP	·popVV_g(SB)
+
+TEXT ·cmpVV(SB),NOSPLIT,$0
+	JMP	·cmpVV_g(SB)
+
+TEXT ·ccmpVV(SB),NOSPLIT,$0
+	JMP	·ccmpVV_g(SB)
+
+TEXT ·ceqVV(SB),NOSPLIT,$0
+	JMP	·ceqVV_g(SB)
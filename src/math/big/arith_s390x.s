@@ -1237,3 +1237,30 @@ E7:	SUB	$1, R7		// i--
 
 	MOVD	R10, r+64(FP)
 	RET
+
+TEXT ·andVV(SB),NOSPLIT,$0
+	BR	·andVV_g(SB)
+
+TEXT ·andNotVV(SB),NOSPLIT,$0
+	BR	·andNotVV_g(SB)
+
+TEXT ·orVV(SB),NOSPLIT,$0
+	BR	·orVV_g(SB)
+
+TEXT ·xorVV(SB),NOSPLIT,$0
+	BR	·xorVV_g(SB)
+
+TEXT ·popV(SB),NOSPLIT,$0
+	BR	·popV_g(SB)
+
+TEXT ·popVV(SB),NOSPLIT,$0
+	BR	·popVV_g(SB)
+
+TEXT ·cmpVV(SB),NOSPLIT,$0
+	BR	·cmpVV_g(SB)
+
+TEXT ·ccmpVV(SB),NOSPLIT,$0
+	BR	·ccmpVV_g(SB)
+
+TEXT ·ceqVV(SB),NOSPLIT,$0
+	BR	·ceqVV_g(SB)
@@ -108,6 +108,92 @@ func TestFunVV(t *testing.T) {
 	}
 }
 
+type funLogicVV func(z, x, y []Word)
+
+var logicVV = []struct {
+	name string
+	f, g funLogicVV
+}{
+	{"andVV", andVV, andVV_g},
+	{"andNotVV", andNotVV, andNotVV_g},
+	{"orVV", orVV, orVV_g},
+	{"xorVV", xorVV, xorVV_g},
+}
+
+func TestLogicVV(t *testing.T) {
+	for _, k := range logicVV {
+		for n := 0; n <= 70; n++ {
+			x, y := rndV(n), rndV(n)
+			want := make([]Word, n)
+			k.g(want, x, y)
+			z := make([]Word, n)
+			k.f(z, x, y)
+			if nat(z).cmp(want) != 0 {
+				t.Errorf("%s(n = %d): got %v; want %v", k.name, n, z, want)
+			}
+			// in place
+			z = append(z[:0], x...)
+			k.f(z, z, y)
+			if nat(z).cmp(want) != 0 {
+				t.Errorf("%s(z, z, y) (n = %d): got %v; want %v", k.name, n, z, want)
+			}
+			z = append(z[:0], y...)
+			k.f(z, x, z)
+			if nat(z).cmp(want) != 0 {
+				t.Errorf("%s(z, x, z) (n = %d): got %v; want %v", k.name, n, z, want)
+			}
+		}
+	}
+}
+
+func TestPopVV(t *testing.T) {
+	for n := 0; n <= 70; n++ {
+		x, y := rndV(n), rndV(n)
+		if n > 2 {
+			x[1], y[2] = ^Word(0), 0 // all bits set or clear
+		}
+		if got, want := popV(x), popV_g(x); got != want {
+			t.Errorf("popV(n = %d): got %d; want %d", n, got, want)
+		}
+		if got, want := popVV(x, y), popVV_g(x, y); got != want {
+			t.Errorf("popVV(n = %d): got %d; want %d", n, got, want)
+		}
+		if got := popVV(x, x); got != 0 {
+			t.Errorf("popVV(x, x) (n = %d): got %d; want 0", n, got)
+		}
+	}
+}
+
+func TestCmpVV(t *testing.T) {
+	for n := 0; n <= 70; n++ {
+		x := rndV(n)
+		for i := -1; i < n; i++ {
+			// y differs from x in word i and below, if any
+			y := append([]Word(nil), x...)
+			if i >= 0 {
+				y[i] = rndW()
+				copy(y[:i], rndV(i))
+			}
+			for _, y := range [][]Word{y, x} {
+				want := cmpVV_g(x, y)
+				if got := cmpVV(x, y); got != want {
+					t.Errorf("cmpVV(n = %d, i = %d): got %d; want %d", n, i, got, want)
+				}
+				lt, gt := ccmpVV(x, y)
+				if wlt, wgt := ccmpVV_g(x, y); lt != wlt || gt != wgt {
+					t.Errorf("ccmpVV(n = %d, i = %d): got %d, %d; want %d, %d", n, i, lt, gt, wlt, wgt)
+				}
+				if int(gt)-int(lt) != want {
+					t.Errorf("ccmpVV(n = %d, i = %d): got %d, %d; want %d", n, i, lt, gt, want)
+				}
+				if got, want := ceqVV(x, y), boolWord(want == 0); got != want {
+					t.Errorf("ceqVV(n = %d, i = %d): got %d; want %d", n, i, got, want)
+				}
+			}
+		}
+	}
+}
+
 // Always the same seed for reproducible results.
 var rnd = rand.New(rand.NewSource(0))
 
@@ -238,6 +324,75 @@ func TestFunVW(t *testing.T) {
 	}
 }
 
+func BenchmarkLogicVV(b *testing.B) {
+	for _, k := range logicVV {
+		for _, n := range benchSizes {
+			if isRaceBuilder && n > 1e3 {
+				continue
+			}
+			x := rndV(n)
+			y := rndV(n)
+			z := make([]Word, n)
+			b.Run(fmt.Sprintf("%s/%d", k.name, n), func(b *testing.B) {
+				b.SetBytes(int64(n * _W))
+				for i := 0; i < b.N; i++ {
+					k.f(z, x, y)
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkPopVV(b *testing.B) {
+	for _, n := range benchSizes {
+		if isRaceBuilder && n > 1e3 {
+			continue
+		}
+		x := rndV(n)
+		y := rndV(n)
+		b.Run(fmt.Sprintf("popV/%d", n), func(b *testing.B) {
+			b.SetBytes(int64(n * _W))
+			for i := 0; i < b.N; i++ {
+				popV(x)
+			}
+		})
+		b.Run(fmt.Sprintf("popVV/%d", n), func(b *testing.B) {
+			b.SetBytes(int64(n * _W))
+			for i := 0; i < b.N; i++ {
+				popVV(x, y)
+			}
+		})
+	}
+}
+
+func BenchmarkCmpVV(b *testing.B) {
+	for _, n := range benchSizes {
+		if isRaceBuilder && n > 1e3 {
+			continue
+		}
+		x := rndV(n)
+		y := append([]Word(nil), x...) // equal, so that all words are compared
+		b.Run(fmt.Sprintf("cmpVV/%d", n), func(b *testing.B) {
+			b.SetBytes(int64(n * _W))
+			for i := 0; i < b.N; i++ {
+				cmpVV(x, y)
+			}
+		})
+		b.Run(fmt.Sprintf("ccmpVV/%d", n), func(b *testing.B) {
+			b.SetBytes(int64(n * _W))
+			for i := 0; i < b.N; i++ {
+				ccmpVV(x, y)
+			}
+		})
+		b.Run(fmt.Sprintf("ceqVV/%d", n), func(b *testing.B) {
+			b.SetBytes(int64(n * _W))
+			for i := 0; i < b.N; i++ {
+				ceqVV(x, y)
+			}
+		})
+	}
+}
+
 func BenchmarkAddVW(b *testing.B) {
 	for _, n := range benchSizes {
 		if isRaceBuilder && n > 1e3 {
@@ -379,6 +534,60 @@ func TestMulAddWWW(t *testing.T) {
 	}
 }
 
+func TestAddMulVVW(t *testing.T) {
+	for n := 0; n <= 40; n++ {
+		for _, y := range []Word{0, 1, _M, rndW()} {
+			for _, x := range [][]Word{rndV(n), make([]Word, n), allOnes(n)} {
+				for _, z0 := range [][]Word{rndV(n), allOnes(n)} {
+					want := append([]Word(nil), z0...)
+					wantC := addMulVVW_g(want, x, y)
+					z := append([]Word(nil), z0...)
+					c := addMulVVW(z, x, y)
+					if c != wantC || nat(z).cmp(want) != 0 {
+						t.Errorf("addMulVVW(%#x, %#x, %#x) = %#x, %#x; want %#x, %#x", z0, x, y, z, c, want, wantC)
+					}
+				}
+			}
+		}
+	}
+}
+
+func allOnes(n int) []Word {
+	v := make([]Word, n)
+	for i := range v {
+		v[i] = _M
+	}
+	return v
+}
+
+func TestDivWVWRec(t *testing.T) {
+	ys := []Word{1, 2, 3, 7, _M, _M - 1, 1 << (_W - 1), 1<<(_W-1) + 1, 1<<_W2 - 1, 1 << _W2}
+	for i := 0; i < 100; i++ {
+		ys = append(ys, rndW(), rndW()>>uint(i%_W))
+	}
+	for _, y := range ys {
+		if y == 0 {
+			continue
+		}
+		for _, n := range []int{1, 2, 5} {
+			x := rndV(n)
+			x[n-1] |= _M // exercise the carries of the shifts
+			for _, xn := range []Word{0, y - 1, rndW() % y} {
+				z := make([]Word, n)
+				r := divWVWRec(z, xn, x, y)
+				wz := make([]Word, n)
+				wr := xn
+				for i := n - 1; i >= 0; i-- {
+					wz[i], wr = divWW_g(wr, x[i], y)
+				}
+				if r != wr || nat(z).cmp(wz) != 0 {
+					t.Errorf("divWVWRec(%#x, %#x, %#x) = %#x, %#x; want %#x, %#x", xn, x, y, z, r, wz, wr)
+				}
+			}
+		}
+	}
+}
+
 func BenchmarkAddMulVVW(b *testing.B) {
 	for _, n := range benchSizes {
 		if isRaceBuilder && n > 1e3 {
@@ -395,3 +604,26 @@ func BenchmarkAddMulVVW(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkDivWVW(b *testing.B) {
+	for _, n := range benchSizes {
+		if isRaceBuilder && n > 1e3 {
+			continue
+		}
+		x := rndV(n)
+		y := rndW()
+		z := make([]Word, n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.SetBytes(int64(n * _W))
+			for i := 0; i < b.N; i++ {
+				divWVW(z, 0, x, y)
+			}
+		})
+		b.Run(fmt.Sprintf("%dRec", n), func(b *testing.B) {
+			b.SetBytes(int64(n * _W))
+			for i := 0; i < b.N; i++ {
+				divWVWRec(z, 0, x, y)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Barrett reduction by a fixed modulus.
+
+package big
+
+// A Reducer reduces values modulo a fixed, non-zero modulus m using
+// Barrett reduction. It precomputes the Barrett constant for m once, so
+// that each reduction takes two multiplications instead of the long
+// division performed by Mod, which is faster when many values are
+// reduced by the same modulus.
+//
+// Unlike Modulus, a Reducer runs in variable time and must only be used
+// with public values. A Reducer is immutable and safe for concurrent use
+// by multiple goroutines. The zero value is not a valid Reducer; use
+// NewReducer.
+type Reducer struct {
+	m  nat // |m|, normalized
+	mu nat // floor(2**(2*_W*len(m)) / m)
+}
+
+// NewReducer returns a new Reducer for the modulus |m|. The value of m
+// is copied, so m may be changed afterwards without affecting the result.
+// If m == 0, NewReducer panics.
+func NewReducer(m *Int) *Reducer {
+	if len(m.abs) == 0 {
+		panic("division by zero")
+	}
+	abs := nat(nil).set(m.abs)
+	b2k := nat(nil).shl(natOne, uint(2*_W*len(abs)))
+	mu, _ := nat(nil).div(nil, b2k, abs)
+	return &Reducer{m: abs, mu: mu}
+}
+
+// Int returns the modulus |m|. If a non-nil *Int argument z is provided,
+// Int stores the result in z instead of allocating a new Int.
+func (r *Reducer) Int(z *Int) *Int {
+	if z == nil {
+		z = new(Int)
+	}
+	z.abs = z.abs.set(r.m)
+	z.neg = false
+	return z
+}
+
+// Reduce sets z to the Euclidean modulus x mod |m| and returns z,
+// like z.Mod(x, m). The result is in the range [0, |m|).
+func (r *Reducer) Reduce(z, x *Int) *Int {
+	k := len(r.m)
+	xa := x.abs
+	abs := z.abs
+	if alias(abs, xa) {
+		abs = nil // z is an alias for x - cannot reuse
+	}
+
+	// Reduce the 2k most significant words of x, and then shift in k
+	// more words at a time: the partial remainder is < m, so the result
+	// is < b**(2k) as required by barrett.
+	i := max(0, len(xa)-2*k)
+	abs = abs.make(2 * k)
+	abs = abs[:copy(abs, xa[i:])]
+	for {
+		if abs.cmp(r.m) >= 0 {
+			abs = abs.barrett(abs, r.m, r.mu)
+		}
+		if i == 0 {
+			break
+		}
+		c := min(k, i)
+		i -= c
+		abs = abs[:len(abs)+c]
+		copy(abs[c:], abs[:len(abs)-c])
+		copy(abs, xa[i:i+c])
+		abs = abs.norm()
+	}
+
+	if x.neg && len(abs) > 0 {
+		abs = abs.sub(r.m, abs)
+	}
+	z.abs = abs
+	z.neg = false
+	return z
+}
+
+// barrett sets z to x mod m and returns z, for x < b**(2k), where
+// b = 2**_W and k = len(m), and the Barrett constant mu = floor(b**(2k)/m).
+// z may alias x. See Handbook of Applied Cryptography, Algorithm 14.42;
+// both products are truncated as described in Note 14.44.
+func (z nat) barrett(x, m, mu nat) nat {
+	k := len(m)
+	if len(x) < k {
+		return z.set(x) // x < b**(k-1) <= m
+	}
+
+	// q = floor(floor(x / b**(k-1)) * mu / b**(k+1)) is at most 2 less
+	// than the quotient floor(x / m). Omitting the partial products
+	// below word k-1 makes it at most one less still.
+	q1 := x[k-1:]
+	tp := getNat(len(q1) + len(mu))
+	t := *tp
+	t.clear()
+	for i, d := range q1 {
+		if j := max(0, k-1-i); j < len(mu) {
+			t[i+len(mu)] = addMulVVW(t[i+j:i+len(mu)], mu[j:], d)
+		}
+	}
+	q := t[min(k+1, len(t)):].norm()
+
+	// q*m mod b**(k+1), omitting the partial products above word k
+	up := getNat(k + 1)
+	u := *up
+	u.clear()
+	for i, d := range q {
+		if i > k {
+			break
+		}
+		l := min(k, k+1-i)
+		if c := addMulVVW(u[i:i+l], m[:l], d); i+l <= k {
+			addVW(u[i+l:], u[i+l:], c)
+		}
+	}
+
+	// x - q*m < 4*m, computed modulo b**(k+1)
+	n := min(len(x), k+1)
+	z = z.make(k + 1)
+	copy(z, x[:n])
+	z[n:].clear()
+	subVV(z, z, u) // a borrow wraps around modulo b**(k+1)
+	z = z.norm()
+	for z.cmp(m) >= 0 {
+		z = z.sub(z, m)
+	}
+
+	putNat(tp)
+	putNat(up)
+	return z
+}
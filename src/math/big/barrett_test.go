@@ -0,0 +1,87 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestReducer(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, bits := range []int{1, 2, 31, 64, 65, 200, 1000, 3000} {
+		for i := 0; i < 10; i++ {
+			m := new(Int).Rand(r, new(Int).Lsh(intOne, uint(bits)))
+			m.SetBit(m, bits-1, 1)
+			if i == 1 {
+				m.Neg(m)
+			}
+			red := NewReducer(m)
+			for _, xbits := range []int{0, 1, bits - 1, bits, 2 * bits, 5*bits + 17} {
+				x := new(Int)
+				if xbits > 0 {
+					x.Rand(r, new(Int).Lsh(intOne, uint(xbits)))
+				}
+				if r.Intn(3) == 0 {
+					x.Neg(x)
+				}
+				want := new(Int).Mod(x, m)
+				if got := red.Reduce(new(Int), x); got.Cmp(want) != 0 {
+					t.Errorf("Reduce(%s) mod %s = %s; want %s", x, m, got, want)
+				}
+				// aliased operands
+				if got := red.Reduce(x, x); got.Cmp(want) != 0 {
+					t.Errorf("aliased Reduce mod %s = %s; want %s", m, got, want)
+				}
+			}
+		}
+	}
+}
+
+func TestReducerEdgeCases(t *testing.T) {
+	// values of the form m*q + r with extreme quotients and remainders
+	m := new(Int).Sub(new(Int).Lsh(intOne, 4*_W), intOne)
+	red := NewReducer(m)
+	if got := red.Int(nil); got.Cmp(m) != 0 {
+		t.Errorf("Int() = %s; want %s", got, m)
+	}
+	for _, q := range []*Int{intOne, m, new(Int).Mul(m, m)} {
+		for _, r := range []int64{0, 1, -1} {
+			x := new(Int).Mul(m, q)
+			x.Add(x, NewInt(r))
+			want := new(Int).Mod(x, m)
+			if got := red.Reduce(new(Int), x); got.Cmp(want) != 0 {
+				t.Errorf("Reduce(%s) = %s; want %s", x, got, want)
+			}
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewReducer(0) did not panic")
+		}
+	}()
+	NewReducer(new(Int))
+}
+
+func BenchmarkReducer(b *testing.B) {
+	for _, bits := range []int{256, 2048} {
+		m := new(Int).Sub(new(Int).Lsh(intOne, uint(bits)), NewInt(189))
+		x := new(Int).Sub(new(Int).Lsh(intOne, uint(2*bits)), intOne)
+		red := NewReducer(m)
+		z := new(Int)
+		b.Run(fmt.Sprintf("%d/Reduce", bits), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				red.Reduce(z, x)
+			}
+		})
+		b.Run(fmt.Sprintf("%d/Mod", bits), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				z.Mod(x, m)
+			}
+		})
+	}
+}
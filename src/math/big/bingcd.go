@@ -0,0 +1,283 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements a binary extended GCD algorithm, which replaces
+// the divisions of the Euclidean algorithm by subtractions and shifts.
+
+package big
+
+// Operands of at least two and at most bingcdThreshold words, whose
+// lengths differ by at most one word, use the binary GCD algorithm
+// instead of Lehmer's.
+var bingcdThreshold = 32 // measured with BenchmarkBinaryGCD
+
+// useBinaryGCD reports whether GCD uses the binary algorithm for a and b.
+func useBinaryGCD(a, b nat) bool {
+	m, n := len(a), len(b)
+	if m > n {
+		m, n = n, m
+	}
+	return m >= 2 && n <= bingcdThreshold && n-m <= 1
+}
+
+// bingcdK is the number of binary GCD steps computed at a time from
+// double-word approximations of the operands. The steps are recorded in
+// a matrix of signed words, where the magnitudes of the entries of each
+// row add up to at most 2**bingcdK.
+const bingcdK = _W - 2
+
+// bingcd is like lehmerGCD, but uses the binary GCD algorithm: see
+// Pornin, "Optimized Binary GCD for Modular Inversion", 2020. The
+// cofactors x and y match those of lehmerGCD.
+func (z *Int) bingcd(x, y, a, b *Int) *Int {
+	// gcd(a, b) = 2**k * gcd(A, B), where one of A and B is odd.
+	k := a.abs.trailingZeroBits()
+	if kb := b.abs.trailingZeroBits(); kb < k {
+		k = kb
+	}
+	Ap, Bp := getNat(0), getNat(0)
+	A := (*Ap).shr(a.abs, k)
+	B := (*Bp).shr(b.abs, k)
+	swapped := B[0]&1 == 0
+	if swapped {
+		A, B = B, A
+	}
+
+	extended := x != nil || y != nil
+	G, X := getInt(), getInt()
+	G.abs, X.abs = bingcdOdd(G.abs, X.abs, A, B, extended)
+	G.abs = G.abs.shl(G.abs, k)
+	*Ap, *Bp = A, B
+	putNat(Ap)
+	putNat(Bp)
+
+	if extended {
+		// u*A = gcd(A, B) mod B, so u is a cofactor of a, or of b if
+		// a and b have been swapped.
+		X.abs = X.abs.norm()
+		if swapped {
+			// X = (g - b*u)/a
+			X.Mul(b, X)
+			X.Sub(G, X)
+			X.Quo(X, a)
+		}
+		setCofactors(x, y, a, b, G, X)
+	}
+	z.abs = z.abs.set(G.abs) // don't pool storage the caller may own
+	z.neg = G.neg
+	putInt(G)
+	putInt(X)
+	return z
+}
+
+// setCofactors sets x and y, if not nil, to the cofactors of a, b > 0
+// with a*x + b*y = g = gcd(a, b), given the cofactor X of a modulo b/g.
+// Of all the cofactors X + j*b/g, the Euclidean algorithm yields the one
+// with |x| <= b/(2*g), which is also the range ModInverse relies upon.
+func setCofactors(x, y, a, b, g, X *Int) {
+	Y, t := getInt(), getInt()
+	t.Quo(b, g)
+	X.Mod(X, t)
+	if X.Cmp(Y.Rsh(t, 1)) > 0 {
+		X.Sub(X, t)
+	}
+	if y != nil {
+		// Y = (g - a*X)/b
+		Y.Mul(a, X)
+		Y.Sub(g, Y)
+		Y.Quo(Y, b)
+		y.abs = y.abs.set(Y.abs)
+		y.neg = Y.neg
+	}
+	if x != nil {
+		x.abs = x.abs.set(X.abs)
+		x.neg = X.neg
+	}
+	putInt(Y)
+	putInt(t)
+}
+
+// bingcdOdd sets g = gcd(a, m), for a > 0 and odd m, and returns g and
+// u. If extended is true, it also sets u with u*a = g mod m and
+// 0 <= u <= m; the result u is not normalized. The temporaries are
+// taken from the pool of getNat. The updates do not depend on the values of a and
+// m other than through the matrix of each batch of steps, so they carry
+// over to a constant-time inversion with a fixed number of batches.
+func bingcdOdd(g, u, a, m nat, extended bool) (nat, nat) {
+	n := max(len(a), len(m))
+	Ap, Bp := getNat(n), getNat(n)
+	A, B := *Ap, *Bp
+	A[copy(A, a):].clear()
+	B[copy(B, m):].clear()
+
+	// A = U0*a and B = U1*a modulo m.
+	// The buffers of U0 and U1 are swapped with the scratches z0 and z1
+	// in the updates, so they all have the same length.
+	var U0p, U1p *nat
+	var U0, U1 nat
+	var k0 Word
+	if extended {
+		U0p, U1p = getNat(n+1), getNat(n+1)
+		U0, U1 = *U0p, *U1p
+		U0.clear()
+		U1.clear()
+		U0[0] = 1
+		k0 = montgomeryK0(m[0])
+	}
+	z0p, z1p, tp := getNat(n+1), getNat(n+1), getNat(n+1)
+	z0, z1, t := *z0p, *z1p, *tp
+
+	const mask = 1<<bingcdK - 1
+	for l := n; ; {
+		for l > 1 && A[l-1] == 0 && B[l-1] == 0 {
+			l--
+		}
+		if len(A[:l].norm()) == 0 {
+			break
+		}
+
+		// The low bingcdK bits of A and B determine the parities in the
+		// steps, and their top bits approximate the comparisons. While
+		// A and B fit into the approximations, these are exact.
+		var ah, al, bh, bl Word
+		if bits := uint(l*_W) - nlz(A[l-1]|B[l-1]); bits <= 2*bingcdK+2 {
+			al, bl = A[0], B[0]
+			if l > 1 {
+				ah, bh = A[1], B[1]
+			}
+		} else {
+			s := bits - (bingcdK + 2)
+			at, bt := bitsAt(A[:l], s), bitsAt(B[:l], s)
+			ah, al = at>>(_W-bingcdK), at<<bingcdK|A[0]&mask
+			bh, bl = bt>>(_W-bingcdK), bt<<bingcdK|B[0]&mask
+		}
+		f0, g0, f1, g1 := Word(1), Word(0), Word(0), Word(1)
+		for i := 0; i < bingcdK; i++ {
+			if al&1 != 0 {
+				if ah < bh || ah == bh && al < bl {
+					ah, al, bh, bl = bh, bl, ah, al
+					f0, f1 = f1, f0
+					g0, g1 = g1, g0
+				}
+				c := Word(0)
+				if al < bl {
+					c = 1
+				}
+				al -= bl
+				ah -= bh + c
+				f0 -= f1
+				g0 -= g1
+			}
+			al = al>>1 | ah<<(_W-1)
+			ah >>= 1
+			f1 <<= 1
+			g1 <<= 1
+		}
+
+		// (A, B) = (f0*A + g0*B, f1*A + g1*B) / 2**bingcdK, where
+		// negative results are negated along with their coefficients.
+		if bingcdLin(z0, t, A[:l], B[:l], f0, g0) {
+			f0, g0 = -f0, -g0
+		}
+		if bingcdLin(z1, t, A[:l], B[:l], f1, g1) {
+			f1, g1 = -f1, -g1
+		}
+		bingcdShr(A[:l], z0)
+		bingcdShr(B[:l], z1)
+
+		if extended {
+			x, y := U0[:len(m)], U1[:len(m)]
+			bingcdLinMod(z0, t, x, y, m, f0, g0, k0)
+			bingcdLinMod(z1, t, x, y, m, f1, g1, k0)
+			U0, z0 = z0, U0
+			U1, z1 = z1, U1
+		}
+	}
+	g = g.set(B.norm())
+	if extended {
+		u = u.set(U1[:len(m)])
+		*U0p, *U1p = U0, U1
+		putNat(U0p)
+		putNat(U1p)
+	}
+	*z0p, *z1p = z0, z1
+	putNat(Ap)
+	putNat(Bp)
+	putNat(z0p)
+	putNat(z1p)
+	putNat(tp)
+	return g, u
+}
+
+// bitsAt returns the word of the bits of x starting at bit s.
+func bitsAt(x nat, s uint) Word {
+	i, r := s/_W, s%_W
+	w := x[i] >> r
+	if r > 0 && int(i)+1 < len(x) {
+		w |= x[i+1] << (_W - r)
+	}
+	return w
+}
+
+// bingcdShr sets z to x >> bingcdK, for len(x) > len(z) and a result
+// that fits into z.
+func bingcdShr(z, x nat) {
+	n := len(z)
+	shrVU(z, x[:n], bingcdK)
+	z[n-1] |= x[n] << (_W - bingcdK)
+}
+
+// bingcdLin sets z to |f*x + g*y| and reports whether f*x + g*y < 0,
+// for words f and g interpreted as signed integers with |f|, |g| < 2**(_W-1).
+// x and y must have the same length n, z and t of at least n+1 words
+// must not overlap x or y, and only the first n+1 words of z are set.
+func bingcdLin(z, t, x, y nat, f, g Word) (neg bool) {
+	n := len(x)
+	fneg, gneg := f>>(_W-1) != 0, g>>(_W-1) != 0
+	if fneg {
+		f = -f
+	}
+	if gneg {
+		g = -g
+	}
+	z, t = z[:n+1], t[:n+1]
+	z[n] = mulAddVWW(z[:n], x, f, 0)
+	if fneg == gneg {
+		z[n] += addMulVVW(z[:n], y, g)
+		return fneg
+	}
+	t[n] = mulAddVWW(t[:n], y, g, 0)
+	if subVV(z, z, t) != 0 {
+		// |f*x| < |g*y|
+		for i := range z {
+			z[i] = ^z[i]
+		}
+		addVW(z, z, 1)
+		return gneg
+	}
+	return fneg
+}
+
+// bingcdLinMod sets z to (f*x + g*y) / 2**bingcdK mod m, with a result
+// in the range [0, m], for odd m, x, y <= m of the same length as m, and
+// |f| + |g| <= 2**bingcdK. k0 is montgomeryK0(m[0]), and the operands and
+// the scratch t are as for bingcdLin.
+func bingcdLinMod(z, t, x, y, m nat, f, g, k0 Word) {
+	n := len(m)
+	z, t = z[:n+1], t[:n+1]
+	neg := bingcdLin(z, t, x, y, f, g)
+
+	// Adding q*m makes the sum divisible by 2**bingcdK, so that the
+	// quotient is |f*x + g*y| / 2**bingcdK mod m. It is < 2*m, because
+	// |f*x + g*y| <= 2**bingcdK * m and q < 2**bingcdK.
+	q := z[0] * k0 & (1<<bingcdK - 1)
+	z[n] += addMulVVW(z[:n], m, q)
+	shrVU(z, z, bingcdK)
+	if c := subVV(t[:n], z[:n], m); z[n] >= c {
+		copy(z, t[:n])
+	}
+	if neg {
+		subVV(z[:n], m, z[:n])
+	}
+}
@@ -0,0 +1,77 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestBinaryGCDExt(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for _, size := range []uint{1, 7, _W - 1, _W, _W + 1, 2 * _W, 200, 1000} {
+		for i := 0; i < 40; i++ {
+			a := randInt(r, size)
+			b := randInt(r, size-uint(i)*size/80)
+			switch i % 5 {
+			case 0:
+				// a large common factor
+				g := randInt(r, size/2+1)
+				a.Mul(a, g)
+				b.Mul(b, g)
+			case 1:
+				// common and uncommon powers of two
+				a.Lsh(a, uint(i))
+				b.Lsh(b, uint(i/2))
+			case 2:
+				b.Lsh(b, uint(i))
+			}
+			if i == 39 {
+				b.Set(a)
+			}
+			if a.Sign() == 0 || b.Sign() == 0 {
+				continue
+			}
+			d, x, y := euclidGCD(a, b)
+			D, X, Y := new(Int), new(Int), new(Int)
+			D.bingcd(X, Y, a, b)
+			if D.Cmp(d) != 0 || X.Cmp(x) != 0 || Y.Cmp(y) != 0 {
+				t.Errorf("bingcd(%s, %s) = %s, %s, %s; want %s, %s, %s", a, b, D, X, Y, d, x, y)
+			}
+			if D.bingcd(nil, nil, a, b).Cmp(d) != 0 {
+				t.Errorf("bingcd(%s, %s) = %s; want %s", a, b, D, d)
+			}
+		}
+	}
+}
+
+func BenchmarkBinaryGCD(b *testing.B) {
+	r := rand.New(rand.NewSource(4))
+	for _, words := range []uint{1, 2, 4, 8, 16, 32, 64} {
+		x := randInt(r, words*_W)
+		y := randInt(r, words*_W)
+		y.SetBit(y, 0, 1)
+		for _, ext := range []bool{false, true} {
+			var u *Int
+			name := "%s/%d"
+			if ext {
+				u = new(Int)
+				name = "%s/%dWithX"
+			}
+			z := new(Int)
+			b.Run(fmt.Sprintf(name, "binary", words), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					z.bingcd(u, nil, x, y)
+				}
+			})
+			b.Run(fmt.Sprintf(name, "lehmer", words), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					z.lehmerGCD(u, nil, x, y)
+				}
+			})
+		}
+	}
+}
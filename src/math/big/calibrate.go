@@ -0,0 +1,113 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the measurement of the multiplication
+// thresholds on the running machine.
+
+package big
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Calibrate measures the speed of the multiplication algorithms on the
+// running machine and sets the operand lengths at which multiplication
+// (and thus squaring, division and exponentiation) switches from basic
+// to Karatsuba multiplication, and from Karatsuba to Schönhage-Strassen
+// multiplication. The default thresholds were measured on a typical
+// x86-64 machine; other machines may be noticeably faster with different
+// values.
+//
+// Calibrate takes a fraction of a second. The measurements are only as
+// good as the machine is idle while they run, so Calibrate is best called
+// once during program initialization. Calibrate must not be called
+// concurrently with any other operation of this package.
+func Calibrate() {
+	r := rand.New(rand.NewSource(1))
+	x := make(nat, 32000)
+	y := make(nat, 32000)
+	for i := range x {
+		x[i] = Word(r.Int63())<<1 ^ Word(r.Int63())
+		y[i] = Word(r.Int63())<<1 ^ Word(r.Int63())
+	}
+
+	karatsubaThreshold = calibrateKaratsuba(x, y)
+	fftThreshold = calibrateFFT(x, y)
+}
+
+// calibrateKaratsuba returns the shortest even length n between 8 and
+// 256 words for which one level of Karatsuba multiplication of n-word
+// operands is faster than basic multiplication, both for n and n+8.
+// Operands of length n only use basic multiplication below that level,
+// so this is the length at which splitting starts to pay off.
+func calibrateKaratsuba(x, y nat) int {
+	const maxThreshold = 256
+	defer func(th int) { karatsubaThreshold = th }(karatsubaThreshold)
+	z := make(nat, 6*(maxThreshold+8))
+	faster := func(n int) bool {
+		x, y := x[:n], y[:n]
+		tb := measure(func() { basicMul(z, x, y) })
+		karatsubaThreshold = n
+		tk := measure(func() { karatsuba(z, x, y) })
+		return tk < tb
+	}
+	for n := 8; n < maxThreshold; n += 4 {
+		if faster(n) && faster(n+8) {
+			return n
+		}
+	}
+	return maxThreshold
+}
+
+// calibrateFFT returns the shortest length n, out of successive powers
+// of two times 1000 words, for which fftMul of n-word operands is faster
+// than Karatsuba multiplication, both for n and 2n. The cost of fftMul
+// grows in steps, so the threshold can only be approximate.
+func calibrateFFT(x, y nat) int {
+	const maxThreshold = 32000
+	defer func(th int) { fftThreshold = th }(fftThreshold)
+	fftThreshold = 1 << 30 // mul uses Karatsuba multiplication only
+	var z nat
+	faster := func(n int) bool {
+		x, y := x[:n], y[:n]
+		tk := measure(func() { z = z.mul(x, y) })
+		tf := measure(func() { z = z.fftMul(x, y) })
+		return tf < tk
+	}
+	for n := 1000; n < maxThreshold; n *= 2 {
+		if faster(n) && faster(2*n) {
+			return n
+		}
+	}
+	return maxThreshold
+}
+
+// measure returns the shortest of several measurements of the time f
+// takes. Each measurement repeats f for at least 100µs.
+func measure(f func()) time.Duration {
+	const minTime = 100 * time.Microsecond
+	reps := 1
+	for {
+		start := time.Now()
+		for i := 0; i < reps; i++ {
+			f()
+		}
+		if time.Since(start) >= minTime {
+			break
+		}
+		reps *= 2
+	}
+	best := time.Duration(-1)
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		for j := 0; j < reps; j++ {
+			f()
+		}
+		if d := time.Since(start) / time.Duration(reps); best < 0 || d < best {
+			best = d
+		}
+	}
+	return best
+}
@@ -86,3 +86,28 @@ func TestCalibrate(t *testing.T) {
 		computeThresholds()
 	}
 }
+
+func TestCalibrateThresholds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping calibration in short mode")
+	}
+	defer func(k, f int) { karatsubaThreshold, fftThreshold = k, f }(karatsubaThreshold, fftThreshold)
+	Calibrate()
+	if karatsubaThreshold < 8 || karatsubaThreshold > 256 {
+		t.Errorf("karatsubaThreshold = %d; want a value in [8, 256]", karatsubaThreshold)
+	}
+	if fftThreshold < 1000 || fftThreshold > 32000 {
+		t.Errorf("fftThreshold = %d; want a value in [1000, 32000]", fftThreshold)
+	}
+	t.Logf("karatsubaThreshold = %d, fftThreshold = %d", karatsubaThreshold, fftThreshold)
+
+	// multiplication remains correct with the measured thresholds
+	x := rndNat(5000)
+	y := rndNat(3000)
+	want := new(Int).Mul(new(Int).SetBits(x), new(Int).SetBits(y))
+	karatsubaThreshold, fftThreshold = 1<<30, 1<<30
+	got := new(Int).Mul(new(Int).SetBits(x), new(Int).SetBits(y))
+	if got.Cmp(want) != 0 {
+		t.Errorf("mul with calibrated thresholds differs from basic multiplication")
+	}
+}
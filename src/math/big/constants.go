@@ -0,0 +1,162 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the mathematical constants π, e, and ln(2) at
+// arbitrary precision.
+
+package big
+
+import (
+	"math"
+	"sync"
+)
+
+// Pi returns a new Float with the value of π rounded to nearest even at
+// precision prec. Pi panics if prec is 0.
+func Pi(prec uint) *Float {
+	return piCache.float(prec)
+}
+
+// E returns a new Float with the value of e, the base of natural
+// logarithms, rounded to nearest even at precision prec. E panics if
+// prec is 0.
+func E(prec uint) *Float {
+	return eCache.float(prec)
+}
+
+// Ln2 returns a new Float with the value of ln(2), the natural logarithm
+// of 2, rounded to nearest even at precision prec. Ln2 panics if prec
+// is 0.
+func Ln2(prec uint) *Float {
+	return ln2Cache.float(prec)
+}
+
+// A constCache holds the most precise fixed-point value of a constant y
+// computed so far, and computes a more precise one when it is asked for.
+// Pi, E, Ln2, and the transcendental functions share these caches.
+type constCache struct {
+	sync.Mutex
+	prec    uint
+	val     nat                  // y × 2**prec, less by less than 2
+	compute func(prec uint) *Int // returns a val for prec
+}
+
+var (
+	piCache  = constCache{compute: piFixed}
+	eCache   = constCache{compute: eFixed}
+	ln2Cache = constCache{compute: ln2Fixed}
+)
+
+// get returns y × 2**prec, less by less than 2, as a new Int.
+func (c *constCache) get(prec uint) *Int {
+	c.Lock()
+	if c.prec < prec {
+		c.val = c.compute(prec).abs
+		c.prec = prec
+	}
+	z := &Int{abs: nat(nil).shr(c.val, c.prec-prec)}
+	c.Unlock()
+	return z
+}
+
+// float returns y rounded to nearest even at precision prec.
+func (c *constCache) float(prec uint) *Float {
+	if prec == 0 {
+		panic("math/big: constant of precision 0")
+	}
+	z := new(Float).SetPrec(prec)
+	return z.setBracketed(func(w uint) (lo, hi *Int, exp int64) {
+		lo = c.get(w)
+		hi = &Int{abs: nat(nil).add(lo.abs, natTwo)}
+		return lo, hi, -int64(w)
+	})
+}
+
+// piFixed returns π × 2**prec, less by less than 2, computed with Machin's
+// formula π = 16 atan(1/5) - 4 atan(1/239) with 8 guard bits, or with
+// piAGM for prec >= agmThreshold.
+func piFixed(prec uint) *Int {
+	if prec >= agmThreshold {
+		return piAGM(prec)
+	}
+	p := prec + 8
+	z := arctanFixed(5, -1, p)
+	y := arctanFixed(239, -1, p)
+	z.Lsh(z, 4).Sub(z, y.Lsh(y, 2))
+	// z is off by less than 16 × 2 + 4 × 2 units; make it less
+	z.Sub(z, NewInt(40))
+	return z.Rsh(z, 8)
+}
+
+// eFixed returns e × 2**prec, less by less than 2, computed from the
+// series e = 1 + 1/1! + 1/2! + ... up to the first term 1/n! with n! >
+// 2**(prec+2), which makes the omitted tail less than 1/2 unit.
+func eFixed(prec uint) *Int {
+	n, bits := 1, 0.0
+	for bits <= float64(prec+2) {
+		n++
+		bits += math.Log2(float64(n))
+	}
+	t, q := invFactSplit(0, n)
+	t.Add(t, q).Lsh(t, prec)
+	return t.Quo(t, q)
+}
+
+// ln2Fixed returns ln(2) × 2**prec, less by less than 2, computed as
+// ln(2) = 2 atanh(1/3) with 4 guard bits.
+func ln2Fixed(prec uint) *Int {
+	p := prec + 4
+	z := arctanFixed(3, 1, p)
+	z.Lsh(z, 1)
+	// z is off by less than 2 × 2 units; make it less
+	z.Sub(z, NewInt(4))
+	return z.Rsh(z, 4)
+}
+
+// arctanFixed returns atan(1/m) for s = -1, or atanh(1/m) for s = 1, times
+// 2**prec and off by less than 2 units, for m >= 2. It sums the terms of
+// the series s**k/((2k + 1) × m**(2k+1)) while m**(2k) < 2**prec, so that
+// the omitted tail is less than 1 unit, as is the truncation of the sum.
+func arctanFixed(m, s int64, prec uint) *Int {
+	n := int(float64(prec)/(2*math.Log2(float64(m)))) + 1
+	t, b, q := arctanSplit(m, s, 0, n)
+	t.Lsh(t, prec)
+	return t.Quo(t, b.Mul(b, q))
+}
+
+// arctanSplit sums the terms a <= k < c of the series of arctanFixed by
+// binary splitting. With q_0 = m and q_k = s × m**2 for k > 0, the series
+// is Σ 1/((2k + 1) × q_0 × ... × q_k); arctanSplit returns t, b, and q with
+// b = Π (2k + 1) and q = q_a × ... × q_(c-1) over the terms, and t/(b × q)
+// = Σ 1/((2k + 1) × q_a × ... × q_k).
+func arctanSplit(m, s int64, a, c int) (t, b, q *Int) {
+	if c-a == 1 {
+		q = NewInt(m)
+		if a > 0 {
+			q.SetInt64(s * m * m)
+		}
+		return NewInt(1), NewInt(int64(2*a + 1)), q
+	}
+	mid := (a + c) / 2
+	t1, b1, q1 := arctanSplit(m, s, a, mid)
+	t2, b2, q2 := arctanSplit(m, s, mid, c)
+	// t1/(b1 × q1) + t2/(b2 × q2 × q1)
+	t1.Mul(t1, b2).Mul(t1, q2)
+	t2.Mul(t2, b1)
+	return t1.Add(t1, t2), b1.Mul(b1, b2), q1.Mul(q1, q2)
+}
+
+// invFactSplit returns t and q with t/q = Σ a!/k! for a < k <= c and q =
+// c!/a!, by binary splitting.
+func invFactSplit(a, c int) (t, q *Int) {
+	if c-a == 1 {
+		return NewInt(1), NewInt(int64(c))
+	}
+	mid := (a + c) / 2
+	t1, q1 := invFactSplit(a, mid)
+	t2, q2 := invFactSplit(mid, c)
+	// t1/q1 + t2/(q2 × q1)
+	t1.Mul(t1, q2)
+	return t1.Add(t1, t2), q1.Mul(q1, q2)
+}
@@ -0,0 +1,108 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const piDigits = "3.14159265358979323846264338327950288419716939937510582097494459230781640628620899862803482534211706798214808651"
+
+var constants = []struct {
+	name   string
+	f      func(prec uint) *Float
+	x      float64
+	digits string
+}{
+	{"Pi", Pi, math.Pi, piDigits},
+	{"E", E, math.E, eDigits},
+	{"Ln2", Ln2, math.Ln2, ln2Digits},
+}
+
+func TestConstants(t *testing.T) {
+	for _, c := range constants {
+		if got, acc := c.f(53).Float64(); got != c.x || acc != Exact {
+			t.Errorf("%s(53) = %g (%s); want %g (Exact)", c.name, got, acc, c.x)
+		}
+
+		// 100 digits need about 333 bits; the last digits are rounded
+		got := c.f(350).Text('f', 100)
+		if !strings.HasPrefix(c.digits, got[:len(got)-4]) {
+			t.Errorf("%s(350) = %s; want %s", c.name, got, c.digits)
+		}
+	}
+}
+
+func TestConstantsRounding(t *testing.T) {
+	for _, c := range constants {
+		for prec := uint(1); prec <= 300; prec++ {
+			z := c.f(prec)
+			if z.Prec() != prec || z.Mode() != ToNearestEven || z.Acc() == Exact {
+				t.Errorf("%s(%d) = %s, %s, %s", c.name, prec, z.Mode(), z.Acc(), z.Text('p', 0))
+				continue
+			}
+			want := new(Float).SetPrec(prec).Set(c.f(prec + 100))
+			if z.Cmp(want) != 0 || z.Acc() != want.Acc() {
+				t.Errorf("%s(%d) = %s (%s); want %s (%s)", c.name, prec, z.Text('p', 0), z.Acc(), want.Text('p', 0), want.Acc())
+			}
+		}
+	}
+
+	// E is the value of Exp(1), which is correctly rounded too
+	for prec := uint(1); prec <= 300; prec++ {
+		want := new(Float).SetPrec(prec).Exp(NewFloat(1))
+		if z := E(prec); z.Cmp(want) != 0 {
+			t.Errorf("E(%d) = %s; want %s", prec, z.Text('p', 0), want.Text('p', 0))
+		}
+	}
+}
+
+func TestConstantsConcurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(prec uint) {
+			defer wg.Done()
+			for _, c := range constants {
+				z := c.f(prec)
+				want := new(Float).SetPrec(prec).Set(c.f(2 * prec))
+				if z.Cmp(want) != 0 {
+					t.Errorf("%s(%d) = %s; want %s", c.name, prec, z.Text('p', 0), want.Text('p', 0))
+				}
+			}
+		}(uint(1000 + 500*i))
+	}
+	wg.Wait()
+}
+
+func TestConstantsPanic(t *testing.T) {
+	for _, c := range constants {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s(0) did not panic", c.name)
+				}
+			}()
+			c.f(0)
+		}()
+	}
+}
+
+func BenchmarkPi(b *testing.B) {
+	for _, prec := range []uint{1e2, 1e3, 1e4, 1e5} {
+		b.Run(fmt.Sprint(prec), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				piCache.Lock()
+				piCache.prec, piCache.val = 0, nil
+				piCache.Unlock()
+				Pi(prec)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements CRT, which reconstructs an integer from its
+// residues by the Chinese Remainder Theorem.
+
+package big
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errNotCoprime = errors.New("math/big: CRT moduli are not pairwise coprime")
+
+// CRT returns the unique x in the range [0, m) with x ≡ residues[i]
+// (mod moduli[i]) for every i, and the product m of the moduli; for no
+// residues, x = 0 and m = 1. The moduli must be > 0 and pairwise
+// coprime, and there must be as many residues as moduli; otherwise CRT
+// returns nil, nil, and an error. The residues may lie outside the
+// range of their moduli.
+//
+// CRT uses Garner's algorithm, which adds the residues one at a time in
+// mixed radix, taking one modular inverse per modulus, and computes the
+// intermediate values in a single Scratch. Like GCD, CRT runs in
+// variable time and panics if a residue or modulus is marked as
+// constant-time.
+func CRT(residues, moduli []*Int) (x, m *Int, err error) {
+	if len(residues) != len(moduli) {
+		return nil, nil, fmt.Errorf("math/big: CRT of %d residues and %d moduli", len(residues), len(moduli))
+	}
+	for i, n := range moduli {
+		if residues[i].zcap|n.zcap != 0 {
+			panic("math/big: CRT of value marked as constant-time")
+		}
+		if n.Sign() <= 0 {
+			return nil, nil, fmt.Errorf("math/big: CRT modulus %s <= 0", n)
+		}
+	}
+
+	var s Scratch
+	x, m = new(Int), NewInt(1)
+	var c, t Int
+	for i, n := range moduli {
+		// x is the solution modulo m for the first i residues. It is
+		// extended to x + m*t, with t = (r - x) * m**-1 mod n, which is
+		// the solution modulo m*n, and in the range [0, m*n).
+		c.ModScratch(m, n, &s)
+		if _, ok := c.ModInverseChecked(&c, n); !ok {
+			return nil, nil, errNotCoprime
+		}
+		t.Sub(residues[i], x)
+		t.ModScratch(&t, n, &s)
+		t.MulScratch(&t, &c, &s)
+		t.ModScratch(&t, n, &s)
+		x.Add(x, t.MulScratch(&t, m, &s))
+		m.MulScratch(m, n, &s)
+	}
+	return x, m, nil
+}
@@ -0,0 +1,99 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// crtModuli returns n random, pairwise coprime moduli of up to maxBits bits.
+func crtModuli(r *rand.Rand, n, maxBits int) []*Int {
+	var ms []*Int
+	m := NewInt(1)
+	for len(ms) < n {
+		x := new(Int).Rand(r, new(Int).Lsh(intOne, uint(r.Intn(maxBits)+1)))
+		x.Add(x, intOne)
+		if new(Int).GCD(nil, nil, x, m).Cmp(intOne) == 0 {
+			ms = append(ms, x)
+			m.Mul(m, x)
+		}
+	}
+	return ms
+}
+
+func TestCRT(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, n := range []int{0, 1, 2, 3, 10, 50} {
+		for _, bits := range []int{4, 64, 300} {
+			ms := crtModuli(r, n, bits)
+			want := NewInt(1)
+			for _, m := range ms {
+				want.Mul(want, m)
+			}
+			rs := make([]*Int, n)
+			for i, m := range ms {
+				rs[i] = new(Int).Rand(r, new(Int).Lsh(m, 2))
+				if r.Intn(2) == 0 {
+					rs[i].Neg(rs[i])
+				}
+			}
+			x, m, err := CRT(rs, ms)
+			if err != nil {
+				t.Fatalf("CRT of %d %d-bit moduli: %v", n, bits, err)
+			}
+			if m.Cmp(want) != 0 {
+				t.Errorf("CRT of %d %d-bit moduli: m = %s; want %s", n, bits, m, want)
+			}
+			if x.Sign() < 0 || x.Cmp(m) >= 0 {
+				t.Errorf("CRT of %d %d-bit moduli: x = %s out of range [0, %s)", n, bits, x, m)
+			}
+			for i, mi := range ms {
+				d := new(Int).Sub(x, rs[i])
+				if d.Mod(d, mi).Sign() != 0 {
+					t.Errorf("CRT of %d %d-bit moduli: x = %s ≢ %s (mod %s)", n, bits, x, rs[i], mi)
+				}
+			}
+		}
+	}
+}
+
+func TestCRTErrors(t *testing.T) {
+	for _, test := range []struct {
+		rs, ms []int64
+	}{
+		{[]int64{1}, nil},
+		{[]int64{1, 2}, []int64{5}},
+		{[]int64{1}, []int64{0}},
+		{[]int64{1, 2}, []int64{3, -5}},
+		{[]int64{1, 2}, []int64{6, 15}},
+		{[]int64{1, 2, 3}, []int64{7, 11, 14}},
+		{[]int64{0, 0}, []int64{4, 4}},
+	} {
+		rs, ms := make([]*Int, len(test.rs)), make([]*Int, len(test.ms))
+		for i, r := range test.rs {
+			rs[i] = NewInt(r)
+		}
+		for i, m := range test.ms {
+			ms[i] = NewInt(m)
+		}
+		if x, m, err := CRT(rs, ms); x != nil || m != nil || err == nil {
+			t.Errorf("CRT(%v, %v) = %v, %v, %v; want an error", test.rs, test.ms, x, m, err)
+		}
+	}
+}
+
+func BenchmarkCRT(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	ms := crtModuli(r, 64, 64)
+	rs := make([]*Int, len(ms))
+	for i, m := range ms {
+		rs[i] = new(Int).Rand(r, m)
+	}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		CRT(rs, ms)
+	}
+}
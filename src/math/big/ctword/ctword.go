@@ -0,0 +1,173 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package ctword implements constant-time operations on machine words
+// and on multi-word numbers stored as little-endian []uint slices, as
+// used by the constant-time arithmetic of package math/big.
+//
+// None of the functions branch on, or index memory by, the values of
+// their operands, so their running time and memory access pattern depend
+// only on the lengths of the slices. Conditions are passed as words v
+// with the value 0 or 1; the behavior for other values is undefined.
+// Slice operands must all have the same length; the functions panic
+// otherwise, since a length mismatch is a programming error that must
+// not depend on secret values.
+package ctword
+
+const uintSize = 32 << (^uint(0) >> 32 & 1) // 32 or 64
+
+// IsZero returns 1 if x == 0 and 0 otherwise.
+func IsZero(x uint) uint {
+	return 1 ^ (x|-x)>>(uintSize-1)
+}
+
+// Eq returns 1 if x == y and 0 otherwise.
+func Eq(x, y uint) uint {
+	return IsZero(x ^ y)
+}
+
+// Less returns 1 if x < y and 0 otherwise.
+func Less(x, y uint) uint {
+	// see "Hacker's Delight", section 2-12 (unsigned comparison)
+	return (^x&y | ^(x^y)&(x-y)) >> (uintSize - 1)
+}
+
+// Select returns x if v == 1 and y if v == 0.
+func Select(v, x, y uint) uint {
+	return y ^ -v&(x^y)
+}
+
+func checkLen(n int, s ...[]uint) {
+	for _, x := range s {
+		if len(x) != n {
+			panic("ctword: mismatched slice lengths")
+		}
+	}
+}
+
+// Zero sets all words of z to 0.
+func Zero(z []uint) {
+	for i := range z {
+		z[i] = 0
+	}
+}
+
+// SelectSlice sets z to x if v == 1 and to y if v == 0.
+// z may alias x or y.
+func SelectSlice(z, x, y []uint, v uint) {
+	checkLen(len(z), x, y)
+	mask := -v
+	for i := range z {
+		z[i] = y[i] ^ mask&(x[i]^y[i])
+	}
+}
+
+// Copy sets z to x if v == 1 and leaves z unchanged if v == 0.
+func Copy(z, x []uint, v uint) {
+	SelectSlice(z, x, z, v)
+}
+
+// Swap swaps the contents of x and y if v == 1 and leaves them
+// unchanged if v == 0.
+func Swap(x, y []uint, v uint) {
+	checkLen(len(x), y)
+	mask := -v
+	for i := range x {
+		t := mask & (x[i] ^ y[i])
+		x[i] ^= t
+		y[i] ^= t
+	}
+}
+
+// Neg sets z to -z mod 2**(len(z)*bits) if v == 1, where bits is the
+// size of a uint, and leaves z unchanged if v == 0.
+func Neg(z []uint, v uint) {
+	mask := -v
+	c := v
+	for i := range z {
+		zi := z[i] ^ mask + c
+		// the addition of c carries only if it wraps around to 0
+		c &= IsZero(zi)
+		z[i] = zi
+	}
+}
+
+// Equal returns 1 if x and y hold the same number and 0 otherwise.
+func Equal(x, y []uint) uint {
+	checkLen(len(x), y)
+	var acc uint
+	for i := range x {
+		acc |= x[i] ^ y[i]
+	}
+	return IsZero(acc)
+}
+
+// Add sets z to x + y and returns the carry, which is 0 or 1.
+// z may alias x or y.
+func Add(z, x, y []uint) (c uint) {
+	checkLen(len(z), x, y)
+	for i := range z {
+		xi, yi := x[i], y[i]
+		zi := xi + yi + c
+		// carry out of the most significant bit of xi + yi + c;
+		// see "Hacker's Delight", section 2-13
+		c = (xi&yi | (xi|yi)&^zi) >> (uintSize - 1)
+		z[i] = zi
+	}
+	return
+}
+
+// Sub sets z to x - y and returns the borrow, which is 0 or 1.
+// z may alias x or y.
+func Sub(z, x, y []uint) (b uint) {
+	checkLen(len(z), x, y)
+	for i := range z {
+		xi, yi := x[i], y[i]
+		zi := xi - yi - b
+		b = (^xi&yi | ^(xi^yi)&zi) >> (uintSize - 1)
+		z[i] = zi
+	}
+	return
+}
+
+// CondAdd sets z to z + x if v == 1 and leaves z unchanged if v == 0.
+// It returns the carry, which is 0 if v == 0.
+func CondAdd(z, x []uint, v uint) (c uint) {
+	checkLen(len(z), x)
+	mask := -v
+	for i := range z {
+		zi, xi := z[i], x[i]&mask
+		s := zi + xi + c
+		c = (zi&xi | (zi|xi)&^s) >> (uintSize - 1)
+		z[i] = s
+	}
+	return
+}
+
+// CondSub sets z to z - x if v == 1 and leaves z unchanged if v == 0.
+// It returns the borrow, which is 0 if v == 0.
+func CondSub(z, x []uint, v uint) (b uint) {
+	checkLen(len(z), x)
+	mask := -v
+	for i := range z {
+		zi, xi := z[i], x[i]&mask
+		d := zi - xi - b
+		b = (^zi&xi | ^(zi^xi)&d) >> (uintSize - 1)
+		z[i] = d
+	}
+	return
+}
+
+// LessSlice returns 1 if the number x is less than the number y
+// and 0 otherwise.
+func LessSlice(x, y []uint) uint {
+	checkLen(len(x), y)
+	var b uint
+	for i := range x {
+		xi, yi := x[i], y[i]
+		d := xi - yi - b
+		b = (^xi&yi | ^(xi^yi)&d) >> (uintSize - 1)
+	}
+	return b
+}
@@ -0,0 +1,207 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ctword
+
+import (
+	"math/rand"
+	"testing"
+	"testing/quick"
+)
+
+var words = []uint{0, 1, 2, 3, 1<<(uintSize-1) - 1, 1 << (uintSize - 1), ^uint(0) - 1, ^uint(0)}
+
+func b2u(b bool) uint {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestWordOps(t *testing.T) {
+	for _, x := range words {
+		if got, want := IsZero(x), b2u(x == 0); got != want {
+			t.Errorf("IsZero(%#x) = %d; want %d", x, got, want)
+		}
+		for _, y := range words {
+			if got, want := Eq(x, y), b2u(x == y); got != want {
+				t.Errorf("Eq(%#x, %#x) = %d; want %d", x, y, got, want)
+			}
+			if got, want := Less(x, y), b2u(x < y); got != want {
+				t.Errorf("Less(%#x, %#x) = %d; want %d", x, y, got, want)
+			}
+			if got := Select(1, x, y); got != x {
+				t.Errorf("Select(1, %#x, %#x) = %#x", x, y, got)
+			}
+			if got := Select(0, x, y); got != y {
+				t.Errorf("Select(0, %#x, %#x) = %#x", x, y, got)
+			}
+		}
+	}
+}
+
+// randSlice returns a random n-word number, with a bias
+// towards the edge cases in words.
+func randSlice(r *rand.Rand, n int) []uint {
+	x := make([]uint, n)
+	for i := range x {
+		x[i] = words[r.Intn(len(words))]
+		if r.Intn(2) == 0 {
+			x[i] = uint(r.Uint64())
+		}
+	}
+	return x
+}
+
+// refAdd and refSub are branching reference implementations of Add and Sub.
+func refAdd(z, x, y []uint) (c uint) {
+	for i := range z {
+		s := x[i] + y[i]
+		c1 := b2u(s < x[i])
+		s += c
+		c = c1 | b2u(s < c)
+		z[i] = s
+	}
+	return
+}
+
+func refSub(z, x, y []uint) (b uint) {
+	for i := range z {
+		d := x[i] - y[i]
+		b1 := b2u(x[i] < y[i])
+		b2 := b2u(d < b)
+		z[i] = d - b
+		b = b1 | b2
+	}
+	return
+}
+
+func equal(x, y []uint) bool {
+	for i := range x {
+		if x[i] != y[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSliceOps(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		n := r.Intn(5)
+		x, y := randSlice(r, n), randSlice(r, n)
+		if r.Intn(4) == 0 {
+			copy(y, x)
+		}
+		z, want := make([]uint, n), make([]uint, n)
+
+		c, wc := Add(z, x, y), refAdd(want, x, y)
+		if c != wc || !equal(z, want) {
+			t.Errorf("Add(%#x, %#x) = %#x, %d; want %#x, %d", x, y, z, c, want, wc)
+		}
+		b, wb := Sub(z, x, y), refSub(want, x, y)
+		if b != wb || !equal(z, want) {
+			t.Errorf("Sub(%#x, %#x) = %#x, %d; want %#x, %d", x, y, z, b, want, wb)
+		}
+		if got := LessSlice(x, y); got != wb {
+			t.Errorf("LessSlice(%#x, %#x) = %d; want %d", x, y, got, wb)
+		}
+		if got, want := Equal(x, y), b2u(equal(x, y)); got != want {
+			t.Errorf("Equal(%#x, %#x) = %d; want %d", x, y, got, want)
+		}
+
+		for v := uint(0); v <= 1; v++ {
+			copy(z, x)
+			c := CondAdd(z, y, v)
+			copy(want, x)
+			wc := uint(0)
+			if v == 1 {
+				wc = refAdd(want, x, y)
+			}
+			if c != wc || !equal(z, want) {
+				t.Errorf("CondAdd(%#x, %#x, %d) = %#x, %d; want %#x, %d", x, y, v, z, c, want, wc)
+			}
+			copy(z, x)
+			b := CondSub(z, y, v)
+			copy(want, x)
+			wb := uint(0)
+			if v == 1 {
+				wb = refSub(want, x, y)
+			}
+			if b != wb || !equal(z, want) {
+				t.Errorf("CondSub(%#x, %#x, %d) = %#x, %d; want %#x, %d", x, y, v, z, b, want, wb)
+			}
+
+			sel, other := y, x
+			if v == 1 {
+				sel, other = x, y
+			}
+			SelectSlice(z, x, y, v)
+			if !equal(z, sel) {
+				t.Errorf("SelectSlice(%#x, %#x, %d) = %#x", x, y, v, z)
+			}
+			copy(z, y)
+			Copy(z, x, v)
+			if !equal(z, sel) {
+				t.Errorf("Copy(%#x, %#x, %d) = %#x", y, x, v, z)
+			}
+			x2, y2 := append([]uint(nil), x...), append([]uint(nil), y...)
+			Swap(x2, y2, v)
+			if !equal(y2, sel) || !equal(x2, other) {
+				t.Errorf("Swap(%#x, %#x, %d) = %#x, %#x", x, y, v, x2, y2)
+			}
+		}
+		Zero(z)
+		if !equal(z, make([]uint, n)) {
+			t.Errorf("Zero: got %#x", z)
+		}
+	}
+}
+
+func TestAddSubQuick(t *testing.T) {
+	// (x + y) - y == x, with matching carry and borrow
+	f := func(x, y []uint) bool {
+		n := len(x)
+		if len(y) < n {
+			n = len(y)
+		}
+		x, y = x[:n], y[:n]
+		z := make([]uint, n)
+		c := Add(z, x, y)
+		b := Sub(z, z, y)
+		return c == b && equal(z, x)
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestNeg(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 100; i++ {
+		n := 1 + r.Intn(5)
+		x := randSlice(r, n)
+		z := append([]uint(nil), x...)
+		Neg(z, 0)
+		if !equal(z, x) {
+			t.Errorf("Neg(%#x, 0) = %#x", x, z)
+		}
+		Neg(z, 1)
+		// x + -x == 0
+		sum := make([]uint, n)
+		Add(sum, x, z)
+		if !equal(sum, make([]uint, n)) {
+			t.Errorf("Neg(%#x, 1) = %#x", x, z)
+		}
+	}
+}
+
+func TestMismatchedLengths(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Add with mismatched lengths did not panic")
+		}
+	}()
+	Add(make([]uint, 2), make([]uint, 2), make([]uint, 1))
+}
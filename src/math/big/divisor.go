@@ -0,0 +1,104 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Divisor, a fixed divisor prepared for repeated
+// long division.
+
+package big
+
+// A Divisor represents a fixed, non-zero divisor d prepared for dividing
+// many values by it. It keeps d normalized for long division, which
+// QuoRem otherwise shifts into a temporary on every call. On
+// architectures without a hardware instruction for dividing a double
+// word, it also keeps the reciprocal of the two most significant words
+// of d, with which each quotient word is computed exactly by
+// multiplications instead of a software division and the estimate
+// corrections of Knuth's Algorithm D.
+//
+// Unlike Modulus and Reducer, a Divisor also yields the quotient, and d
+// may be even or negative. Divisor runs in variable time unless an
+// operand is marked with SetConstantTime; then it computes the result as
+// QuoRem does. A Divisor is immutable and safe for concurrent use by
+// multiple goroutines. The zero value is not a valid Divisor; use
+// NewDivisor.
+type Divisor struct {
+	v   nat  // |d|, normalized
+	neg bool // d < 0
+
+	vn    nat  // v << shift, if len(v) > 1
+	shift uint // nlz(v[len(v)-1])
+	inv   Word // reciprocal3by2(vn[len(vn)-1], vn[len(vn)-2]), if rec
+	rec   bool // len(v) > 1 && !divWWNative
+}
+
+// NewDivisor returns a new Divisor for the value of d. The value of d is
+// copied, so d may be changed afterwards without affecting the result.
+// If d == 0, NewDivisor panics.
+func NewDivisor(d *Int) *Divisor {
+	if len(d.abs) == 0 {
+		panic("division by zero")
+	}
+	v := nat(nil).set(d.abs)
+	div := &Divisor{v: v, neg: d.neg}
+	if n := len(v); n > 1 {
+		div.shift = nlz(v[n-1])
+		div.vn = nat(nil).make(n)
+		shlVU(div.vn, v, div.shift)
+		if !divWWNative {
+			div.inv = reciprocal3by2(div.vn[n-1], div.vn[n-2])
+			div.rec = true
+		}
+	}
+	return div
+}
+
+// Int returns the divisor d. If a non-nil *Int argument z is provided,
+// Int stores the result in z instead of allocating a new Int.
+func (d *Divisor) Int(z *Int) *Int {
+	if z == nil {
+		z = new(Int)
+	}
+	z.abs = z.abs.set(d.v)
+	z.neg = d.neg
+	return z
+}
+
+// QuoRem sets q to the quotient x/d and r to the remainder x%d and
+// returns the pair (q, r), like q.QuoRem(x, d, r): the quotient is
+// truncated to zero, and r = x - d*q. q and r must be distinct; either
+// may be x.
+func (d *Divisor) QuoRem(q, r, x *Int) (*Int, *Int) {
+	if q.zcap|r.zcap|x.zcap != 0 || varTimeDisabled() {
+		y := Int{abs: d.v, neg: d.neg}
+		return q.QuoRem(x, &y, r)
+	}
+	q.abs, r.abs = d.div(q.abs, r.abs, x.abs)
+	q.neg, r.neg = len(q.abs) > 0 && x.neg != d.neg, len(r.abs) > 0 && x.neg // 0 has no sign
+	return q, r
+}
+
+// div returns q = u/|d| and r = u%|d|, like z.div(z2, u, d.v).
+func (d *Divisor) div(z, z2, u nat) (q, r nat) {
+	if u.cmp(d.v) < 0 {
+		q = z[:0]
+		r = z2.set(u)
+		return
+	}
+
+	if len(d.v) == 1 {
+		var r2 Word
+		q, r2 = z.divW(u, d.v[0])
+		r = z2.setWord(r2)
+		return
+	}
+
+	if alias(z, u) {
+		z = nil // z is an alias for u - cannot reuse
+	}
+	if alias(z2, u) {
+		z2 = nil // z2 is an alias for u - cannot reuse
+	}
+	q = z.make(len(u) - len(d.v) + 1)
+	return q.divNormalized(z2.make(len(u)+1), u, d.vn, d.shift, d.inv, d.rec)
+}
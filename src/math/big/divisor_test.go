@@ -0,0 +1,151 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestDiv3by2(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	word := func() Word {
+		switch r.Intn(4) {
+		case 0:
+			return _M - Word(r.Intn(3))
+		case 1:
+			return Word(r.Intn(3))
+		}
+		return Word(r.Uint64())
+	}
+	for i := 0; i < 10000; i++ {
+		d1, d0 := word()|1<<(_W-1), word()
+		if i == 0 {
+			d1, d0 = _M, _M
+		}
+		d := nat{d0, d1}
+		v := reciprocal3by2(d1, d0)
+		// v = floor((B**3 - 1)/d) - B
+		b3 := nat(nil).sub(nat(nil).shl(natOne, 3*_W), natOne)
+		want, _ := nat(nil).div(nil, b3, d)
+		want = want.sub(want, nat(nil).shl(natOne, _W))
+		if got := nat(nil).setWord(v); got.cmp(want) != 0 {
+			t.Fatalf("reciprocal3by2(%#x, %#x) = %#x; want %s", d1, d0, v, want.utoa(16))
+		}
+
+		u2, u1, u0 := word(), word(), word()
+		if u2 > d1 || u2 == d1 && u1 >= d0 {
+			u2--
+			if u2 >= d1 {
+				u2 = d1 - 1
+			}
+		}
+		u := nat{u0, u1, u2}.norm()
+		q, rem := nat(nil).div(nil, u, d)
+		q1, r1, r0 := div3by2(u2, u1, u0, d1, d0, v)
+		if nat(nil).setWord(q1).cmp(q) != 0 || (nat{r0, r1}).norm().cmp(rem) != 0 {
+			t.Fatalf("div3by2(%#x, %#x, %#x, %#x, %#x) = %#x, %#x, %#x; want %s, %s", u2, u1, u0, d1, d0, q1, r1, r0, q.utoa(16), rem.utoa(16))
+		}
+	}
+}
+
+func TestDivisor(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	var divisors []*Int
+	for _, s := range []string{"1", "-1", "3", "0xffffffffffffffff", "0x10000000000000000", "0x8000000000000000ffffffffffffffff",
+		"-0xffffffffffffffffffffffffffffffffffffffffffffffff", "0x800000000000000000000000000000000000000000000001"} {
+		d, _ := new(Int).SetString(s, 0)
+		divisors = append(divisors, d)
+	}
+	for _, bits := range []int{31, 64, 65, 128, 200, 1000, 3000} {
+		for i := 0; i < 5; i++ {
+			d := new(Int).Rand(r, new(Int).Lsh(intOne, uint(bits)))
+			d.SetBit(d, bits-1, 1)
+			if i&1 != 0 {
+				d.Neg(d)
+			}
+			divisors = append(divisors, d)
+		}
+	}
+	for _, d := range divisors {
+		div := NewDivisor(d)
+		if got := div.Int(nil); got.Cmp(d) != 0 {
+			t.Errorf("NewDivisor(%s).Int() = %s", d, got)
+		}
+		// the quotient words are computed by div3by2 only where divWW
+		// is not a hardware division, so test both ways
+		divs := []*Divisor{div}
+		if n := len(div.vn); n > 1 {
+			rec := *div
+			rec.inv, rec.rec = reciprocal3by2(div.vn[n-1], div.vn[n-2]), true
+			divs = append(divs, &rec)
+		}
+		bits := d.BitLen()
+		for _, xbits := range []int{0, 1, bits - 1, bits, bits + 1, 2 * bits, 5*bits + 17} {
+			x := new(Int)
+			if xbits > 0 {
+				x.Rand(r, new(Int).Lsh(intOne, uint(xbits)))
+			}
+			if r.Intn(3) == 0 {
+				x.Neg(x)
+			}
+			// dividends with runs of ones, for which the quotient
+			// estimates of Algorithm D are most often too large
+			ones := new(Int).Sub(new(Int).Lsh(intOne, uint(xbits)), intOne)
+			for _, x := range []*Int{x, ones, new(Int).Mul(d, ones)} {
+				for _, div := range divs {
+					wantQ, wantR := new(Int).QuoRem(x, d, new(Int))
+					q, rem := div.QuoRem(new(Int), new(Int), x)
+					if q.Cmp(wantQ) != 0 || rem.Cmp(wantR) != 0 || !isNormalized(q) || !isNormalized(rem) {
+						t.Errorf("QuoRem(%s, %s) = %s, %s; want %s, %s", x, d, q, rem, wantQ, wantR)
+					}
+					// aliased operands
+					q.Set(x)
+					if div.QuoRem(q, rem, q); q.Cmp(wantQ) != 0 || rem.Cmp(wantR) != 0 {
+						t.Errorf("QuoRem(q = %s, %s) = %s, %s; want %s, %s", x, d, q, rem, wantQ, wantR)
+					}
+					rem.Set(x)
+					if div.QuoRem(q, rem, rem); q.Cmp(wantQ) != 0 || rem.Cmp(wantR) != 0 {
+						t.Errorf("QuoRem(r = %s, %s) = %s, %s; want %s, %s", x, d, q, rem, wantQ, wantR)
+					}
+					// marked operands
+					xm := new(Int).Set(x).SetConstantTime(x.BitLen() + 1)
+					if q, rem := div.QuoRem(new(Int), new(Int), xm); q.Cmp(wantQ) != 0 || rem.Cmp(wantR) != 0 {
+						t.Errorf("marked QuoRem(%s, %s) = %s, %s; want %s, %s", x, d, q, rem, wantQ, wantR)
+					}
+				}
+			}
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("NewDivisor(0) did not panic")
+		}
+	}()
+	NewDivisor(new(Int))
+}
+
+func BenchmarkDivisor(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	for _, bits := range []int{128, 1024, 4096} {
+		d := new(Int).Rand(r, new(Int).Lsh(intOne, uint(bits)))
+		d.SetBit(d, bits-1, 1)
+		x := new(Int).Rand(r, new(Int).Lsh(intOne, uint(2*bits)))
+		div := NewDivisor(d)
+		q, rem := new(Int), new(Int)
+		b.Run(fmt.Sprintf("Divisor/%d", bits), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				div.QuoRem(q, rem, x)
+			}
+		})
+		b.Run(fmt.Sprintf("QuoRem/%d", bits), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				q.QuoRem(x, d, rem)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements division by divisors of the special forms 2**k,
+// 2**k - c, and 2**k + c for a single word c, which are reduced by
+// shifting and folding instead of long division.
+
+package big
+
+// specialDivisor reports whether v has one of the forms 2**k - c
+// (minus == true) or 2**k + c (minus == false) for a single word c,
+// and returns k and c if so. Only divisors of at least three words are
+// recognized, so that each fold of the dividend removes at least a word.
+// Divisors of other forms are rejected after inspecting a few words.
+func specialDivisor(v nat) (k uint, c Word, minus, ok bool) {
+	n := len(v)
+	if n < 3 {
+		return 0, 0, false, false
+	}
+	top := v[n-1]
+	if top&(top-1) == 0 && v[n-2] == 0 {
+		// 2**k + c: a single bit in the top word, zeros below it
+		for _, w := range v[1 : n-2] {
+			if w != 0 {
+				return 0, 0, false, false
+			}
+		}
+		return uint(n*_W) - nlz(top) - 1, v[0], false, true
+	}
+	if top&(top+1) == 0 && v[n-2] == _M && v[0] != 0 {
+		// 2**k - c: all bits of the top word up to its high bit are
+		// set, and all bits of the words below it except the lowest
+		for _, w := range v[1 : n-2] {
+			if w != _M {
+				return 0, 0, false, false
+			}
+		}
+		return uint(n*_W) - nlz(top), -v[0], true, true
+	}
+	return 0, 0, false, false
+}
+
+// divSpecial is like div for a divisor v of one of the forms recognized
+// by specialDivisor, and a dividend u with len(u) <= 2*len(v) unless v is
+// a power of two; ok reports whether it computed q and r.
+func (z nat) divSpecial(z2, u, v nat) (q, r nat, ok bool) {
+	k, c, minus, ok := specialDivisor(v)
+	if !ok || c != 0 && len(u) > 2*len(v) {
+		return nil, nil, false
+	}
+	// As in divLarge, the storage of u and v cannot be reused; q and r
+	// are computed side by side, so they need separate storage, too.
+	if alias(z, u) || alias(z, v) || alias(z, z2) {
+		z = nil
+	}
+	if alias(z2, u) || alias(z2, v) {
+		z2 = nil
+	}
+	switch {
+	case c == 0:
+		q, r = z.shr(u, k), z2.trunc(u, k)
+	case minus:
+		q, r = z.divMinus(z2, u, v, k, c)
+	default:
+		q, r = z.divPlus(z2, u, v, k, c)
+	}
+	return q, r, true
+}
+
+// trunc returns z = x mod 2**k, using z as storage.
+func (z nat) trunc(x nat, k uint) nat {
+	n := int((k + _W - 1) / _W)
+	if len(x) < n {
+		return z.set(x)
+	}
+	z = z.make(n)
+	copy(z, x)
+	if s := k % _W; s != 0 {
+		z[n-1] &= 1<<s - 1
+	}
+	return z.norm()
+}
+
+// divMinus returns q = u/v and r = u%v for v = 2**k - c, using z and z2
+// as storage for q and r. With x = hi*2**k + lo, x = hi*v + hi*c + lo,
+// so each fold adds hi to the quotient and replaces x by the shorter
+// hi*c + lo, until x < 2**k.
+func (z nat) divMinus(z2, u, v nat, k uint, c Word) (q, r nat) {
+	hip, tp := getNat(0), getNat(0)
+	hi, t := *hip, *tp
+	q = z[:0]
+	x := z2.set(u)
+	for x.bitLen() > int(k) {
+		hi = hi.shr(x, k)
+		q = q.add(q, hi)
+		t = t.mulAddWW(hi, c, 0)
+		x = x.trunc(x, k)
+		x = x.add(x, t)
+	}
+	// 2**k - c <= x < 2**k at most once
+	if x.cmp(v) >= 0 {
+		x = x.sub(x, v)
+		q = q.add(q, natOne)
+	}
+	*hip, *tp = hi, t
+	putNat(hip)
+	putNat(tp)
+	return q, x
+}
+
+// divPlus returns q = x/v and r = x%v for v = 2**k + c, using z and z2
+// as storage for q and r. With x = hi*2**k + lo, x = hi*v - (hi*c - lo):
+// if hi*c <= lo, the remainder is lo - hi*c; otherwise d = hi*c - lo is
+// shorter than x, and x = (hi - d/v)*v - d%v follows from the division
+// of d. x must not alias z or z2.
+func (z nat) divPlus(z2, x, v nat, k uint, c Word) (q, r nat) {
+	if x.cmp(v) < 0 {
+		return z[:0], z2.set(x)
+	}
+	q = z.shr(x, k)    // hi
+	r = z2.trunc(x, k) // lo
+	tp := getNat(0)
+	t := tp.mulAddWW(q, c, 0)
+	if t.cmp(r) <= 0 {
+		r = r.sub(r, t) // lo - hi*c < 2**k < v
+	} else {
+		t = t.sub(t, r)
+		qdp := getNat(0)
+		qd, rd := qdp.divPlus(r, t, v, k, c)
+		q = q.sub(q, qd)
+		if len(rd) == 0 {
+			r = rd
+		} else {
+			q = q.sub(q, natOne)
+			r = rd.sub(v, rd)
+		}
+		*qdp = qd
+		putNat(qdp)
+	}
+	*tp = t
+	putNat(tp)
+	return q, r
+}
@@ -0,0 +1,120 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// specialForm returns 2**k - c if minus is set, and 2**k + c otherwise.
+func specialForm(k uint, c Word, minus bool) nat {
+	v := nat(nil).shl(natOne, k)
+	if minus {
+		return v.sub(v, nat(nil).setWord(c))
+	}
+	return v.add(v, nat(nil).setWord(c))
+}
+
+func TestSpecialDivisor(t *testing.T) {
+	for _, test := range []struct {
+		k     uint
+		c     Word
+		minus bool
+		ok    bool
+	}{
+		{2 * _W, 0, false, true},
+		{2*_W + 5, 1, false, true},
+		{3*_W - 1, _M, false, true},
+		{2 * _W, 1, true, false}, // fewer than three words
+		{2*_W + 1, 1, true, true},
+		{3 * _W, 19, true, true},
+		{5*_W - 3, _M, true, true},
+		{4*_W + 7, 1 << (_W - 1), true, true},
+	} {
+		v := specialForm(test.k, test.c, test.minus)
+		k, c, minus, ok := specialDivisor(v)
+		if ok != test.ok || ok && (k != test.k || c != test.c || minus != test.minus) {
+			t.Errorf("specialDivisor(%s) = %d, %#x, %v, %v; want %d, %#x, %v, %v",
+				v.utoa(16), k, c, minus, ok, test.k, test.c, test.minus, test.ok)
+		}
+	}
+
+	// divisors of other forms
+	for _, s := range []string{
+		"0x10000000000000000000000000000000000000000000000000000000000000001",
+		"0x1000000000000000000000000000000010000000000000000",
+		"0xfffffffffffffffffffffffffffffffeffffffffffffffff",
+		"0xffffffffffffffffffffffffffffffffffffffffffffffff00000000",
+		"0xfedcba9876543210fedcba9876543210fedcba9876543210",
+	} {
+		v := natFromString(s)
+		if k, c, minus, ok := specialDivisor(v); ok && specialForm(k, c, minus).cmp(v) != 0 {
+			t.Errorf("specialDivisor(%s) = %d, %#x, %v, true; want false", s, k, c, minus)
+		}
+	}
+}
+
+func TestDivSpecial(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for n := 3; n <= 8; n++ {
+		for _, c := range []Word{0, 1, 19, _M, Word(r.Uint64())} {
+			for _, minus := range []bool{false, true} {
+				if minus && c == 0 {
+					continue
+				}
+				k := uint((n-1)*_W + 1 + r.Intn(_W-1))
+				v := specialForm(k, c, minus)
+				for i := 0; i < 20; i++ {
+					var u nat
+					switch i {
+					case 0:
+						u = nat(nil).set(v)
+					case 1:
+						u = nat(nil).sub(nat(nil).shl(natOne, uint(2*n*_W)), natOne)
+					case 2:
+						u = nat(nil).mul(v, natFromString("0x123456789abcdef"))
+					default:
+						limit := nat(nil).shl(natOne, k)
+						x := nat(nil).random(r, limit, int(k)+1)
+						u = u.mul(x, nat(nil).random(r, limit, int(k)+1))
+						u = u.add(u, nat(nil).setWord(Word(i)))
+					}
+					q, rem, ok := nat(nil).divSpecial(nil, u, v)
+					if !ok {
+						t.Errorf("divSpecial(%s, %s): ok = false", u.utoa(16), v.utoa(16))
+						continue
+					}
+					wantQ, wantR := nat(nil).divLarge(nil, u, v)
+					if q.cmp(wantQ) != 0 || rem.cmp(wantR) != 0 {
+						t.Errorf("divSpecial(%s, %s) = %s, %s; want %s, %s",
+							u.utoa(16), v.utoa(16), q.utoa(16), rem.utoa(16), wantQ.utoa(16), wantR.utoa(16))
+					}
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkDivSpecial(b *testing.B) {
+	r := rand.New(rand.NewSource(0))
+	for _, bits := range []uint{255, 521, 2048} {
+		v := specialForm(bits, 19, true)
+		u := nat(nil).random(r, v, int(bits))
+		u = u.mul(u, u)
+		var q, rr nat
+		b.Run(fmt.Sprintf("%d/divSpecial", bits), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				q, rr, _ = q.divSpecial(rr, u, v)
+			}
+		})
+		b.Run(fmt.Sprintf("%d/divLarge", bits), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				q, rr = q.divLarge(rr, u, v)
+			}
+		})
+	}
+}
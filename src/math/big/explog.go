@@ -0,0 +1,340 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Float.Exp and Float.Log.
+
+package big
+
+import "math"
+
+// Exp sets z to the rounded value of e**x, and returns z.
+// If z's precision is 0, it is changed to x's precision before the
+// operation. The result is the exact value rounded according to z's
+// precision and rounding mode, and its accuracy is reported as for Add;
+// it is Exact only for x = ±0. A result outside the exponent range of
+// Float overflows to +Inf or underflows to +0.
+//
+// Exp(±0) = 1, Exp(+Inf) = +Inf, and Exp(-Inf) = +0.
+func (z *Float) Exp(x *Float) *Float {
+	if debugFloat {
+		x.validate()
+	}
+
+	if z.prec == 0 {
+		z.prec = x.prec
+	}
+
+	// z may be x; the result is never negative
+	neg := x.neg
+	switch x.form {
+	case zero:
+		return z.SetInt64(1)
+	case inf:
+		z.acc = Exact
+		z.neg = false
+		if neg {
+			z.form = zero
+		} else {
+			z.form = inf
+		}
+		return z
+	}
+
+	if x.exp > 31 {
+		// |x| >= 2**31 > MaxExp × ln(2)
+		z.neg = false
+		if neg {
+			z.setExpAndRound(MinExp-1, 0) // underflow
+		} else {
+			z.setExpAndRound(MaxExp+1, 0) // overflow
+		}
+		return z
+	}
+
+	if int64(x.exp) < -int64(z.prec)-1 {
+		// |x| < 2**-(z.prec+2)
+		return z.setExpTiny(neg, false)
+	}
+
+	return z.setBracketed(func(w uint) (lo, hi *Int, exp int64) {
+		return expBracket(x, w)
+	})
+}
+
+// Log sets z to the rounded natural logarithm of x, and returns z.
+// If z's precision is 0, it is changed to x's precision before the
+// operation. The result is the exact value rounded according to z's
+// precision and rounding mode, and its accuracy is reported as for Add;
+// it is Exact only for x = 1.
+//
+// Log(±0) = -Inf, Log(1) = +0, and Log(+Inf) = +Inf. Log panics with
+// ErrNaN if x < 0; the value of z is undefined in that case.
+func (z *Float) Log(x *Float) *Float {
+	if debugFloat {
+		x.validate()
+	}
+
+	if z.prec == 0 {
+		z.prec = x.prec
+	}
+
+	if x.neg && x.form != zero {
+		// value of z is undefined but make sure it's valid
+		z.acc = Exact
+		z.form = zero
+		z.neg = false
+		panic(ErrNaN{"logarithm of negative operand"})
+	}
+
+	switch x.form {
+	case zero:
+		z.acc = Exact
+		z.form = inf
+		z.neg = true
+		return z
+	case inf:
+		z.acc = Exact
+		z.form = inf
+		z.neg = false
+		return z
+	}
+
+	f, e, t := logReduce(x)
+	if f == nil {
+		// x == 1
+		z.acc = Exact
+		z.form = zero
+		z.neg = false
+		return z
+	}
+
+	return z.setBracketed(func(w uint) (lo, hi *Int, exp int64) {
+		return logBracket(f, e, t, w)
+	})
+}
+
+var floatOne = NewFloat(1)
+
+// logReduce returns f, e, and t for logBracket for log(x), with x > 0
+// finite: x = f × 2**e with √½ <= f < √2, so that |log(f)| < ln(2)/2.
+// For e == 0, log(x) = log(f) is about f - 1, with t leading zero bits
+// after the binary point, which the working precision must cover too.
+// If x == 1, f is nil.
+func logReduce(x *Float) (f *Float, e int64, t uint) {
+	f = new(Float)
+	e = int64(x.MantExp(f))
+	if v, _ := f.Float64(); v < math.Sqrt2/2 {
+		f.SetMantExp(f, 1)
+		e--
+	}
+	if e == 0 {
+		d := new(Float).SetPrec(uint(f.prec)+1).Sub(f, floatOne)
+		if d.form == zero {
+			return nil, 0, 0
+		}
+		t = uint(-d.exp)
+	}
+	return f, e, t
+}
+
+// setExpTiny sets z to the rounded value of ±e**y, for the sign neg and a y
+// with |y| < 2**-(z.prec+2) and sign yneg, and returns z. e**y lies between
+// 1 and 1 ± 2**-(z.prec+1), where no value of precision z.prec and no
+// midpoint between two such values other than 1 exists, so it rounds like
+// 1 ± 2**-(z.prec+2).
+func (z *Float) setExpTiny(yneg, neg bool) *Float {
+	prec := int64(z.prec)
+	m := new(Int).Lsh(intOne, uint(prec)+2)
+	if yneg {
+		m.Sub(m, intOne)
+	} else {
+		m.Add(m, intOne)
+	}
+	m.neg = neg
+	return z.setScaled(m, -prec-2)
+}
+
+// setScaled sets z to the value of m × 2**exp, rounded according to z's
+// precision and rounding mode, and returns z.
+func (z *Float) setScaled(m *Int, exp int64) *Float {
+	z.neg = m.neg
+	if len(m.abs) == 0 {
+		z.acc = Exact
+		z.form = zero
+		return z
+	}
+	z.mant = z.mant.set(m.abs)
+	s := fnorm(z.mant)
+	z.setExpAndRound(exp+int64(len(z.mant))*_W-s, 0)
+	return z
+}
+
+// setBracketed sets z to the correctly rounded value of a real number y,
+// which must not be representable as a Float, and returns z. bracket(w)
+// returns integers lo < hi with lo × 2**exp < y < hi × 2**exp, and hi - lo
+// less than about 2**-w × |y|. setBracketed calls it with w growing from
+// z's precision plus 32 guard bits until lo and hi round to the same
+// value in the same direction (Ziv's strategy). Because y is not
+// representable, this must happen eventually.
+func (z *Float) setBracketed(bracket func(w uint) (lo, hi *Int, exp int64)) *Float {
+	lo := Float{prec: z.prec, mode: z.mode}
+	hi := Float{prec: z.prec, mode: z.mode}
+	for guard := uint(32); ; guard *= 2 {
+		l, h, exp := bracket(uint(z.prec) + guard)
+		lo.setScaled(l, exp)
+		hi.setScaled(h, exp)
+		if lo.acc == hi.acc && lo.Cmp(&hi) == 0 {
+			break
+		}
+	}
+	z.Set(&lo)
+	z.acc = lo.acc
+	return z
+}
+
+// expBracket returns lo, hi, and exp for setBracketed for y = e**x, with
+// 0 < |x| < 2**31. It reduces x to r = x - k × ln(2) with |r| <=
+// ln(2)/2, sums the Taylor series of e**(r/2**s), and squares the sum s
+// times, all in fixed point with q = w + s fractional bits. For w >=
+// expAGMThreshold, it computes e**r with expNewton instead, with s = 0.
+//
+// The fixed-point value of r/2**s is off by less than 2 units in the last
+// place, and each of the n terms of the series by less than 3, as is the
+// omitted tail. The relative error of the sum doubles with each squaring,
+// which adds less than 2 units itself, for a total of less than
+// (8n + 32) × 2**s units.
+func expBracket(x *Float, w uint) (lo, hi *Int, exp int64) {
+	agm := w >= expAGMThreshold
+	s := uint(math.Sqrt(float64(w)))
+	if agm {
+		s = 0
+	}
+	q := w + s
+
+	// k = round(x / ln(2)); 32 more bits keep the error of k × ln(2) small
+	xq := fixedFloat(x, q+32)
+	l := ln2Cache.get(q + 32)
+	k := new(Int).Rsh(l, 1)
+	k.Add(k, xq).Div(k, l)
+	y := new(Int).Mul(k, l)
+	y.Sub(xq, y).Rsh(y, 32+s) // r/2**s
+
+	var sum, err *Int
+	if agm {
+		// the error of r changes e**r by less than 3 units
+		sum, err = expNewton(y, q)
+		err.Add(err, NewInt(3))
+	} else {
+		var n int64
+		sum, n = expSeries(y, q)
+
+		// e**r = (e**y)**(2**s)
+		for i := uint(0); i < s; i++ {
+			sum.Mul(sum, sum).Rsh(sum, q)
+		}
+		err = new(Int).Lsh(NewInt(8*n+32), s)
+	}
+
+	lo = new(Int).Sub(sum, err)
+	hi = sum.Add(sum, err)
+	return lo, hi, k.Int64() - int64(q)
+}
+
+// expSeries returns the sum of the Taylor series of e**y in fixed point
+// with q fractional bits, and the number n of its terms.
+func expSeries(y *Int, q uint) (sum *Int, n int64) {
+	// e**y = 1 + y + y**2/2! + y**3/3! + ...
+	sum = new(Int).Lsh(intOne, q)
+	sum.Add(sum, y)
+	term := new(Int).Set(y)
+	n = 1
+	for len(term.abs) > 0 {
+		n++
+		term.Mul(term, y).Rsh(term, q)
+		term.QuoInt64(term, n) // 0 once |term| < n; Rsh rounds towards -Inf
+		sum.Add(sum, term)
+	}
+	return sum, n
+}
+
+// logBracket returns lo, hi, and exp for setBracketed for y = log(f) + e ×
+// ln(2), with √½ <= f < √2, where |log(f)| >= 2**-t if e == 0. It takes s
+// square roots of f and sums the series log(g) = 2 atanh(u) = 2(u + u**3/3
+// + u**5/5 + ...) with u = (g - 1)/(g + 1) for the result g, which is
+// close to 1; then log(f) = 2**s × log(g). The computations are in fixed
+// point with q = w + t + s fractional bits.
+//
+// For w >= agmThreshold and t < √w, it computes log(f) with logFixed
+// instead, with s = 0; for more leading zero bits, the series converges
+// faster.
+//
+// The fixed-point values of g and u are off by less than 3 units in the last
+// place, each of the n terms of the series and the omitted tail by less
+// than 4, and e × ln(2) by less than 2, for a total of less than (8n + 16)
+// × 2**s + 4 units. With logFixed, g is off by less than 1 unit, which
+// changes log(g) by less than 2, and log(g) by less than 2 more.
+func logBracket(f *Float, e int64, t, w uint) (lo, hi *Int, exp int64) {
+	if w >= agmThreshold && t < uint(math.Sqrt(float64(w))) {
+		q := w + t
+		sum := logFixed(fixedFloat(f, q), q)
+		return addLn2Bracket(sum, e, q, NewInt(8))
+	}
+
+	// each root halves log(g) and u, as does each of the t leading zero bits
+	s := uint(math.Sqrt(float64(w))) / 4
+	if s > t {
+		s -= t
+	} else {
+		s = 0
+	}
+	q := w + t + s
+
+	one := new(Int).Lsh(intOne, q)
+	g := fixedFloat(f, q)
+	for i := uint(0); i < s; i++ {
+		g.Lsh(g, q).Sqrt(g)
+	}
+
+	// atanh is odd; sum the series for |u|, where the shifts truncate
+	u := new(Int).Sub(g, one)
+	u.Lsh(u, q).Quo(u, g.Add(g, one))
+	neg := u.neg
+	u.neg = false
+	u2 := new(Int).Mul(u, u)
+	u2.Rsh(u2, q)
+	sum := new(Int).Set(u)
+	pow := u // u**(2k+1)
+	var term Int
+	n := int64(1)
+	for len(pow.abs) > 0 {
+		pow.Mul(pow, u2).Rsh(pow, q)
+		term.QuoInt64(pow, 2*n+1)
+		sum.Add(sum, &term)
+		n++
+	}
+	sum.Lsh(sum, s+1)
+	sum.neg = neg && len(sum.abs) > 0
+
+	err := new(Int).Lsh(NewInt(8*n+16), s)
+	return addLn2Bracket(sum, e, q, err.Add(err, NewInt(4)))
+}
+
+// addLn2Bracket returns lo, hi, and exp for setBracketed for sum + e ×
+// ln(2) ± err, with sum and err in fixed point with q fractional bits.
+func addLn2Bracket(sum *Int, e int64, q uint, err *Int) (lo, hi *Int, exp int64) {
+	if e != 0 {
+		l := ln2Cache.get(q + 32)
+		l.Mul(l, NewInt(e)).Rsh(l, 32)
+		sum.Add(sum, l)
+	}
+	lo = new(Int).Sub(sum, err)
+	hi = sum.Add(sum, err)
+	return lo, hi, -int64(q)
+}
+
+// fixedFloat returns x × 2**q truncated to an integer.
+func fixedFloat(x *Float, q uint) *Int {
+	z, _ := new(Float).SetMantExp(x, int(q)).Int(nil)
+	return z
+}
@@ -0,0 +1,267 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+const (
+	eDigits    = "2.71828182845904523536028747135266249775724709369995957496696762772407663035354759457138217852516642742746"
+	ln2Digits  = "0.693147180559945309417232121458176568075500134360255254120680009493393621969694715605863326996418687542001"
+	ln10Digits = "2.302585092994045684017991454684364207601101488628772976033327900967572609677352480235997205089598298341967"
+)
+
+func TestFloatExpLogConstants(t *testing.T) {
+	for _, test := range []struct {
+		f    func(z, x *Float) *Float
+		x    int64
+		want string
+	}{
+		{(*Float).Exp, 1, eDigits},
+		{(*Float).Log, 2, ln2Digits},
+		{(*Float).Log, 10, ln10Digits},
+	} {
+		// 100 digits need about 333 bits; the last digits are rounded
+		z := test.f(new(Float).SetPrec(350), NewFloat(float64(test.x)))
+		got := z.Text('f', 100)
+		if !strings.HasPrefix(test.want, got[:len(got)-4]) {
+			t.Errorf("f(%d) = %s; want %s", test.x, got, test.want)
+		}
+	}
+}
+
+// TestFloatExpLog64 checks Exp and Log at precision 53 against the package
+// math, which is not always correctly rounded but within an ulp.
+func TestFloatExpLog64(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 2000; i++ {
+		x := r.NormFloat64() * 100
+		if i%2 == 0 {
+			x = math.Ldexp(r.Float64(), r.Intn(40)-20)
+		}
+		checkULP := func(name string, got *Float, want float64) {
+			g, _ := got.Float64()
+			if d := math.Abs(g - want); d > math.Abs(want)*0x1p-52 {
+				t.Errorf("%s(%g) = %g; want %g", name, x, g, want)
+			}
+		}
+		checkULP("Exp", new(Float).SetPrec(53).Exp(NewFloat(x)), math.Exp(x))
+		if x > 0 {
+			checkULP("Log", new(Float).SetPrec(53).Log(NewFloat(x)), math.Log(x))
+		}
+	}
+}
+
+// TestFloatExpLogRounding checks that Exp and Log round correctly in all
+// modes, against the results at 100 more bits rounded to nearest, which
+// lie close enough to the exact value for the random arguments.
+func TestFloatExpLogRounding(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, prec := range []uint{1, 2, 10, 24, 53, 64, 100, 500, 2000} {
+		for i := 0; i < 20; i++ {
+			x := new(Float).SetPrec(uint(r.Intn(200) + 1))
+			x.SetInt(new(Int).Rand(r, new(Int).Lsh(intOne, x.Prec())))
+			x.SetMantExp(x, r.Intn(40)-20-int(x.Prec()))
+			if x.Sign() == 0 {
+				continue
+			}
+			for _, test := range []struct {
+				name string
+				f    func(z, x *Float) *Float
+				x    *Float
+			}{
+				{"Exp", (*Float).Exp, x},
+				{"Exp", (*Float).Exp, new(Float).Neg(x)},
+				{"Log", (*Float).Log, x},
+			} {
+				if test.name == "Log" && x.Cmp(floatOne) == 0 {
+					continue
+				}
+				ref := test.f(new(Float).SetPrec(prec+100), test.x)
+				for _, mode := range []RoundingMode{ToNearestEven, ToNearestAway, ToZero, AwayFromZero, ToNegativeInf, ToPositiveInf} {
+					want := new(Float).SetPrec(prec).SetMode(mode).Set(ref)
+					got := test.f(new(Float).SetPrec(prec).SetMode(mode), test.x)
+					if got.Cmp(want) != 0 || got.Acc() != want.Acc() {
+						t.Errorf("%s(%s) at precision %d in mode %s = %s (%s); want %s (%s)",
+							test.name, test.x.Text('p', 0), prec, mode, got.Text('p', 0), got.Acc(), want.Text('p', 0), want.Acc())
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestExpLogBracket checks the error bounds of expBracket and logBracket
+// against results at a much higher precision.
+func TestExpLogBracket(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	for _, w := range []uint{33, 64, 100, 1000, 5000} {
+		for i := 0; i < 20; i++ {
+			x := new(Float).SetPrec(w).SetFloat64(r.NormFloat64() * 1000)
+			if i < 5 {
+				x.SetMantExp(x, -r.Intn(int(w)))
+			}
+			ref := new(Float).SetPrec(w + 300).Exp(x)
+			lo, hi, exp := expBracket(x, w)
+			checkBracket(t, "expBracket", x, w, ref, lo, hi, exp)
+
+			x.Abs(x)
+			f, e, tz := logReduce(x)
+			ref = new(Float).SetPrec(w + tz + 300).Log(x)
+			lo, hi, exp = logBracket(f, e, tz, w)
+			checkBracket(t, "logBracket", x, w, ref, lo, hi, exp)
+		}
+	}
+}
+
+func checkBracket(t *testing.T, name string, x *Float, w uint, ref *Float, lo, hi *Int, exp int64) {
+	l := new(Float).SetInt(lo)
+	l.SetMantExp(l, int(exp))
+	h := new(Float).SetInt(hi)
+	h.SetMantExp(h, int(exp))
+	if l.Cmp(ref) >= 0 || h.Cmp(ref) <= 0 {
+		t.Errorf("%s(%s, %d): %s is not between %s and %s", name, x.Text('g', 20), w, ref.Text('g', 30), l.Text('g', 30), h.Text('g', 30))
+	}
+	// hi - lo < 2**-(w-20) × |y|
+	d := new(Float).Sub(h, l)
+	if d.MantExp(nil)-ref.MantExp(nil) > -int(w)+20 {
+		t.Errorf("%s(%s, %d): bracket [%s, %s] is too wide", name, x.Text('g', 20), w, l.Text('g', 30), h.Text('g', 30))
+	}
+}
+
+func TestFloatExpLogIdentities(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for _, prec := range []uint{64, 300, 3000} {
+		for i := 0; i < 10; i++ {
+			x := new(Float).SetPrec(prec).SetFloat64(r.NormFloat64() * 50)
+
+			// Log(Exp(x)) = x, within an ulp of the intermediate result
+			y := new(Float).SetPrec(prec).Exp(x)
+			y.Log(y)
+			if d := new(Float).Sub(x, y); d.Sign() != 0 && d.MantExp(nil)-x.MantExp(nil) > -int(prec)+8 {
+				t.Errorf("precision %d: Log(Exp(%s)) = %s", prec, x.Text('g', 20), y.Text('g', 20))
+			}
+
+			// Exp(x + y) = Exp(x) × Exp(y)
+			yy := new(Float).SetPrec(prec).SetFloat64(r.NormFloat64() * 50)
+			sum := new(Float).SetPrec(2*prec+64).Add(x, yy)
+			p := new(Float).SetPrec(prec).Exp(sum)
+			q := new(Float).SetPrec(prec + 32).Exp(x)
+			q.Mul(q, new(Float).SetPrec(prec+32).Exp(yy))
+			q.SetPrec(prec)
+			if d := new(Float).Sub(p, q); d.Sign() != 0 && d.MantExp(nil)-p.MantExp(nil) > -int(prec)+1 {
+				t.Errorf("precision %d: Exp(%s + %s) = %s; want %s", prec, x.Text('g', 20), yy.Text('g', 20), p.Text('g', 20), q.Text('g', 20))
+			}
+		}
+	}
+}
+
+func TestFloatExpLogSpecial(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		f       func(z, x *Float) *Float
+		x, want string
+		acc     Accuracy
+	}{
+		{"Exp", (*Float).Exp, "0", "1", Exact},
+		{"Exp", (*Float).Exp, "-0", "1", Exact},
+		{"Exp", (*Float).Exp, "+Inf", "+Inf", Exact},
+		{"Exp", (*Float).Exp, "-Inf", "0", Exact},
+		{"Exp", (*Float).Exp, "1e10", "+Inf", Above},
+		{"Exp", (*Float).Exp, "-1e10", "0", Below},
+		{"Exp", (*Float).Exp, "1.5e9", "+Inf", Above},
+		{"Exp", (*Float).Exp, "-1.5e9", "0", Below},
+		{"Exp", (*Float).Exp, "1e-30", "1", Below},
+		{"Exp", (*Float).Exp, "-1e-30", "1", Above},
+		{"Exp", (*Float).Exp, "1e-1000", "1", Below},
+		{"Exp", (*Float).Exp, "1000", "1.970071114e+434", Below},
+		{"Exp", (*Float).Exp, "-1000", "5.075958897e-435", Below},
+		{"Log", (*Float).Log, "0", "-Inf", Exact},
+		{"Log", (*Float).Log, "-0", "-Inf", Exact},
+		{"Log", (*Float).Log, "1", "0", Exact},
+		{"Log", (*Float).Log, "+Inf", "+Inf", Exact},
+		{"Log", (*Float).Log, "0.5", "-0.6931471806", Below},
+		{"Log", (*Float).Log, "1e-1000", "-2302.585093", Above},
+		{"Log", (*Float).Log, "1e1000", "2302.585093", Below},
+		{"Log", (*Float).Log, "0x1p1000000000", "693147180.5", Below},
+	} {
+		x := makeFloat(test.x)
+		z := new(Float).SetPrec(32)
+		test.f(z, x)
+		if got := z.Text('g', 10); got != test.want || z.Acc() != test.acc {
+			t.Errorf("%s(%s) = %s (%s); want %s (%s)", test.name, test.x, got, z.Acc(), test.want, test.acc)
+		}
+		// z may be x
+		x.SetPrec(32)
+		test.f(x, x)
+		if got := x.Text('g', 10); got != test.want {
+			t.Errorf("aliased %s(%s) = %s; want %s", test.name, test.x, got, test.want)
+		}
+	}
+
+	// x close to 1
+	for _, n := range []uint{100, 1000, 100000} {
+		d := new(Float).SetMantExp(NewFloat(1), -int(n))
+		x := new(Float).SetPrec(n+1).Add(floatOne, d)
+		// log(1 + d) = d - d**2/2 + d**3/3 - ..., where d**3/3 is negligible
+		z := new(Float).SetPrec(53).SetMode(ToZero).Log(x)
+		want := new(Float).SetPrec(53).SetMode(ToZero).Sub(d, new(Float).SetMantExp(d, -int(n)-1))
+		if z.Cmp(want) != 0 || z.Acc() != Below {
+			t.Errorf("Log(1 + 2**-%d) = %s (%s); want %s (Below)", n, z.Text('p', 0), z.Acc(), want.Text('p', 0))
+		}
+	}
+
+	// e**1e9 = 2**(1e9 / ln(2)), with 1e9 / ln(2) = 1442695040.9
+	if z := new(Float).SetPrec(32).Exp(NewFloat(1e9)); z.MantExp(nil) != 1442695041 {
+		t.Errorf("Exp(1e9) = %s; want a value of exponent 1442695041", z.Text('p', 0))
+	}
+
+	if z := new(Float).Exp(NewFloat(2)); z.Prec() != 53 {
+		t.Errorf("Exp(2) with precision 0: got precision %d; want 53", z.Prec())
+	}
+	if z := new(Float).Log(NewFloat(2)); z.Prec() != 53 {
+		t.Errorf("Log(2) with precision 0: got precision %d; want 53", z.Prec())
+	}
+
+	for _, x := range []string{"-1", "-Inf", "-1e-1000"} {
+		func() {
+			defer func() {
+				if _, ok := recover().(ErrNaN); !ok {
+					t.Errorf("Log(%s) did not panic with ErrNaN", x)
+				}
+			}()
+			new(Float).Log(makeFloat(x))
+		}()
+	}
+}
+
+func BenchmarkFloatExp(b *testing.B) {
+	for _, prec := range []uint{64, 256, 1000, 10000, 100000} {
+		x := new(Float).SetPrec(prec).SetFloat64(1.2345)
+		z := new(Float).SetPrec(prec)
+		b.Run(fmt.Sprintf("%v", prec), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				z.Exp(x)
+			}
+		})
+	}
+}
+
+func BenchmarkFloatLog(b *testing.B) {
+	for _, prec := range []uint{64, 256, 1000, 10000, 100000} {
+		x := new(Float).SetPrec(prec).SetFloat64(12.345)
+		z := new(Float).SetPrec(prec)
+		b.Run(fmt.Sprintf("%v", prec), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				z.Log(x)
+			}
+		})
+	}
+}
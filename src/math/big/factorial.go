@@ -0,0 +1,233 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the computation of factorials with the prime
+// swing algorithm, and the products of ranges of integers used by it,
+// and of binomial and multinomial coefficients from the factorization
+// of their numerators and denominators.
+
+package big
+
+import "math/bits"
+
+// Products of ranges 1...n with n >= factorialThreshold are computed
+// with the prime swing algorithm, which is limited to n <= maxFactorial.
+var factorialThreshold uint64 = 512 // measured with BenchmarkFactorial
+
+const maxFactorial = 1<<32 - 1
+
+// Multinomial coefficients n!/(k[0]!···k[m-1]!) are computed from their
+// prime factorization if n <= maxFactorial and the sum r of all parts
+// but the largest is at least multinomialThreshold and n/multinomialRatio;
+// otherwise as the quotient of the product of the range (n-r, n] by the
+// factorials of the smaller parts.
+var (
+	multinomialThreshold uint64 = 500 // measured with BenchmarkMultinomial
+	multinomialRatio     uint64 = 32
+)
+
+// useFactoredMultinomial reports whether the multinomial coefficient of
+// n, of which all parts but the largest sum to r, is computed by
+// multinomial.
+func useFactoredMultinomial(n, r uint64) bool {
+	return n <= maxFactorial && r >= multinomialThreshold && r >= n/multinomialRatio
+}
+
+// mulRangeWords returns the product of all the unsigned integers in the
+// range [a, b], for 0 < a <= b, accumulating as many factors as fit into
+// a single Word before multiplying them into z.
+func (z nat) mulRangeWords(a, b uint64) nat {
+	z = z.setWord(1)
+	w := Word(1)
+	for i := a; ; i++ {
+		if uint64(Word(i)) != i {
+			// i does not fit into a Word (32-bit platforms)
+			z = z.mulAddWW(z, w, 0)
+			z = z.mul(z, nat(nil).setUint64(i))
+			w = 1
+		} else if hi, lo := mulWW(w, Word(i)); hi != 0 {
+			z = z.mulAddWW(z, w, 0)
+			w = Word(i)
+		} else {
+			w = lo
+		}
+		if i == b {
+			break // i++ may overflow
+		}
+	}
+	return z.mulAddWW(z, w, 0).norm()
+}
+
+// rangeParallel reports whether the two halves of the product of the
+// range [a, b] are large enough to be computed concurrently.
+func rangeParallel(a, b uint64) bool {
+	return b-a >= uint64(2*parallelMulWords*_W/bits.Len64(b))
+}
+
+// product returns the product of the Words in f.
+func (z nat) product(f []Word) nat {
+	if len(f) <= 16 {
+		z = z.setWord(1)
+		w := Word(1)
+		for _, d := range f {
+			if hi, lo := mulWW(w, d); hi != 0 {
+				z = z.mulAddWW(z, w, 0)
+				w = d
+			} else {
+				w = lo
+			}
+		}
+		return z.mulAddWW(z, w, 0).norm()
+	}
+	m := len(f) / 2
+	var x, y nat
+	if len(f) >= 2*parallelMulWords {
+		parallelDo(func() { x = nat(nil).product(f[:m]) }, func() { y = nat(nil).product(f[m:]) })
+	} else {
+		x = nat(nil).product(f[:m])
+		y = nat(nil).product(f[m:])
+	}
+	return z.mul(x, y)
+}
+
+// factorial sets z = n! and returns z, for n <= maxFactorial, by the
+// prime swing algorithm: with the swing number
+//
+//	swing(n) = n! / (n/2)!**2
+//
+// the factorial is n! = (n/2)!**2 * swing(n), and swing(n) is the product
+// of the powers p**e of the primes p <= n, where e is the number of odd
+// quotients n/p, n/p**2, ... (rounded down). The powers of two are
+// collected in a final shift; only the odd parts of the factorials and
+// swing numbers are multiplied. See Peter Luschny, "Fast Factorial
+// Functions", http://www.luschny.de/math/factorial/FastFactorialFunctions.htm.
+func (z nat) factorial(n uint64) nat {
+	// the number of factors 2 in n! is n - popcount(n)
+	pow2 := uint(n) - uint(bits.OnesCount64(n))
+	sieve := oddSieve(n)
+	var f []Word
+	z = z.oddFactorial(n, sieve, &f)
+	return z.shl(z, pow2)
+}
+
+// oddFactorial sets z to the odd part of n! and returns z. sieve is the
+// result of oddSieve(m) for some m >= n, and f is reused as scratch space
+// for the factors of the swing numbers.
+func (z nat) oddFactorial(n uint64, sieve []Word, f *[]Word) nat {
+	if n < 3 {
+		return z.setWord(1)
+	}
+	x := nat(nil).oddFactorial(n/2, sieve, f)
+	*f = oddSwingFactors((*f)[:0], n, sieve)
+	var s nat
+	if len(x) >= parallelMulWords {
+		parallelDo(func() { x = x.mul(x, x) }, func() { s = s.product(*f) })
+	} else {
+		x = x.mul(x, x)
+		s = s.product(*f)
+	}
+	return z.mul(x, s)
+}
+
+// oddSwingFactors appends the prime powers whose product is the odd
+// part of swing(n) to f, and returns the extended slice.
+func oddSwingFactors(f []Word, n uint64, sieve []Word) []Word {
+	for p := uint64(3); p <= n; p += 2 {
+		if sieve[p/2/_W]&(1<<(p/2%_W)) != 0 {
+			continue // p is not prime
+		}
+		// p**e <= n, so it fits into a Word since n <= maxFactorial
+		pe := Word(1)
+		for q := n / p; q > 0; q /= p {
+			if q&1 != 0 {
+				pe *= Word(p)
+			}
+		}
+		if pe > 1 {
+			f = append(f, pe)
+		}
+	}
+	return f
+}
+
+// oddSieve returns the sieve of Eratosthenes of the odd numbers up to n:
+// the bit for the odd number i is bit i/2 % _W of word i/2 / _W, and it
+// is set if i is not prime.
+func oddSieve(n uint64) []Word {
+	sieve := make([]Word, n/2/_W+1)
+	sieve[0] = 1 // 1 is not prime
+	for p := uint64(3); p*p <= n; p += 2 {
+		if sieve[p/2/_W]&(1<<(p/2%_W)) != 0 {
+			continue
+		}
+		for i := p * p; i <= n; i += 2 * p {
+			sieve[i/2/_W] |= 1 << (i / 2 % _W)
+		}
+	}
+	return sieve
+}
+
+// doubleFactorial sets z = n!! = n*(n-2)*(n-4)*... and returns z. The
+// double factorial of an even n = 2*m is 2**m * m!, and that of an odd
+// n = 2*m + 1 is n! / (2**m * m!), the product of the range [m+1, n]
+// divided by 2**m.
+func (z nat) doubleFactorial(n uint64) nat {
+	m := n / 2
+	if n&1 == 0 {
+		z = z.mulRange(1, m)
+		return z.shl(z, uint(m))
+	}
+	z = z.mulRange(m+1, n)
+	return z.shr(z, uint(m))
+}
+
+// multinomial sets z to the multinomial coefficient n!/(k[0]!···k[m-1]!)
+// and returns z, for parts k summing to n <= maxFactorial. By Legendre's
+// formula, the exponent of a prime p in n! is the sum of the quotients
+// n/p, n/p**2, ... (rounded down), so the exponent of p in the quotient
+// follows without dividing any large numbers. The factors 2 are
+// collected in a final shift.
+func (z nat) multinomial(n uint64, k []uint64) nat {
+	// the number of factors 2 in n! is n - popcount(n)
+	pow2 := n - uint64(bits.OnesCount64(n))
+	for _, ki := range k {
+		pow2 -= ki - uint64(bits.OnesCount64(ki))
+	}
+	sieve := oddSieve(n)
+	var f []Word
+	for p := uint64(3); p <= n; p += 2 {
+		if sieve[p/2/_W]&(1<<(p/2%_W)) != 0 {
+			continue // p is not prime
+		}
+		e := legendre(n, p)
+		for _, ki := range k {
+			if ki >= p {
+				e -= legendre(ki, p)
+			}
+		}
+		// collect p**e in as few Words as possible
+		for e > 0 {
+			w := Word(p)
+			for e--; e > 0; e-- {
+				hi, lo := mulWW(w, Word(p))
+				if hi != 0 {
+					break
+				}
+				w = lo
+			}
+			f = append(f, w)
+		}
+	}
+	z = z.product(f)
+	return z.shl(z, uint(pow2))
+}
+
+// legendre returns the exponent of the prime p in n!.
+func legendre(n, p uint64) (e uint64) {
+	for n >= p {
+		n /= p
+		e += n
+	}
+	return e
+}
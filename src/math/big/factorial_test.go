@@ -0,0 +1,126 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// slowMulRange computes the product of [a, b] for a > 0 one factor
+// at a time.
+func slowMulRange(a, b uint64) nat {
+	z := nat(nil).setWord(1)
+	for i := a; i <= b; i++ {
+		z = z.mul(z, nat(nil).setUint64(i))
+		if i == b {
+			break // i++ may overflow
+		}
+	}
+	return z
+}
+
+func TestOddSieve(t *testing.T) {
+	const n = 3001
+	sieve := oddSieve(n)
+	for i := uint64(1); i <= n; i += 2 {
+		composite := sieve[i/2/_W]&(1<<(i/2%_W)) != 0
+		if prime := new(Int).SetUint64(i).ProbablyPrime(0); composite == prime {
+			t.Errorf("sieve marks %d as composite = %v", i, composite)
+		}
+	}
+}
+
+func TestFactorial(t *testing.T) {
+	for _, n := range []uint64{0, 1, 2, 3, 4, 5, 10, 31, 32, 33, 100, 255, 256, 257, 1000, 2047} {
+		want := slowMulRange(1, n)
+		if got := nat(nil).factorial(n); got.cmp(want) != 0 {
+			t.Errorf("factorial(%d) = %s; want %s", n, got.utoa(10), want.utoa(10))
+		}
+	}
+}
+
+func TestMulRangeWords(t *testing.T) {
+	for _, r := range []struct{ a, b uint64 }{
+		{1, 1},
+		{1, 40},
+		{1<<16 - 3, 1<<16 + 3},
+		{1<<32 - 3, 1<<32 + 3},
+		{1<<64 - 3, 1<<64 - 1},
+	} {
+		want := slowMulRange(r.a, r.b)
+		if got := nat(nil).mulRangeWords(r.a, r.b); got.cmp(want) != 0 {
+			t.Errorf("mulRangeWords(%d, %d) = %s; want %s", r.a, r.b, got.utoa(10), want.utoa(10))
+		}
+	}
+}
+
+func TestMulRangeLarge(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	defer func(th uint64, w int) { factorialThreshold, parallelMulWords = th, w }(factorialThreshold, parallelMulWords)
+	for _, r := range []struct{ a, b uint64 }{
+		{1, 3000},
+		{2, 3000},
+		{1000, 4000},
+	} {
+		factorialThreshold = 1 << 62 // no prime swing
+		parallelMulWords = 1 << 30   // sequential
+		want := nat(nil).mulRange(r.a, r.b)
+		if r.a <= 2 {
+			if slow := slowMulRange(r.a, r.b); want.cmp(slow) != 0 {
+				t.Fatalf("mulRange(%d, %d) differs from the product of the factors", r.a, r.b)
+			}
+		}
+		factorialThreshold = 256
+		parallelMulWords = 16
+		if got := nat(nil).mulRange(r.a, r.b); got.cmp(want) != 0 {
+			t.Errorf("parallel mulRange(%d, %d) differs from sequential mulRange", r.a, r.b)
+		}
+	}
+}
+
+func BenchmarkFactorial(b *testing.B) {
+	for _, n := range []uint64{100, 1000, 1e4, 1e5} {
+		b.Run(fmt.Sprintf("%d/swing", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				nat(nil).factorial(n)
+			}
+		})
+		b.Run(fmt.Sprintf("%d/range", n), func(b *testing.B) {
+			defer func(th uint64) { factorialThreshold = th }(factorialThreshold)
+			factorialThreshold = 1 << 62
+			for i := 0; i < b.N; i++ {
+				nat(nil).mulRange(1, n)
+			}
+		})
+	}
+}
+
+func BenchmarkMultinomial(b *testing.B) {
+	defer func(th, ratio uint64) { multinomialThreshold, multinomialRatio = th, ratio }(multinomialThreshold, multinomialRatio)
+	for _, n := range []int64{100, 1000, 1e4, 1e5} {
+		for _, d := range []int64{2, 8, 32, 128} {
+			k := n / d
+			if k == 0 {
+				continue
+			}
+			b.Run(fmt.Sprintf("%d/%d/factors", n, k), func(b *testing.B) {
+				multinomialThreshold, multinomialRatio = 0, 1<<62
+				var z Int
+				for i := 0; i < b.N; i++ {
+					z.Binomial(n, k)
+				}
+			})
+			b.Run(fmt.Sprintf("%d/%d/range", n, k), func(b *testing.B) {
+				multinomialThreshold = 1 << 62
+				var z Int
+				for i := 0; i < b.N; i++ {
+					z.Binomial(n, k)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Schönhage-Strassen multiplication of very long
+// nats, using fast Fourier transforms over the integers modulo a Fermat
+// number 2**(n*_W) + 1.
+
+package big
+
+// Operands that are at least fftThreshold words long are multiplied
+// using fftMul; shorter operands use Karatsuba multiplication.
+var fftThreshold = 8000 // measured with BenchmarkFFTMul
+
+// The longer operand of an unbalanced fftMul is split into blocks of
+// fftBlockWords words, or of the length of the shorter operand if that
+// is larger, so that the transforms of the blocks fit into the caches.
+var fftBlockWords = 1 << 16 // measured with BenchmarkFFTMulUnbalanced
+
+// A fermat is an integer modulo 2**(n*_W) + 1, stored in n+1 words,
+// where n = len(z)-1. A fermat is normalized if it is at most 2**(n*_W),
+// that is, if z[n] is 0, or 1 and all other words are 0.
+type fermat nat
+
+// norm normalizes z.
+func (z fermat) norm() {
+	n := len(z) - 1
+	// z = lo + hi*2**(n*_W) ≡ lo - hi
+	hi := z[n]
+	z[n] = 0
+	if subVW(z[:n], z[:n], hi) != 0 {
+		// lo - hi < 0: add 2**(n*_W) + 1
+		z[n] = addVW(z[:n], z[:n], 1)
+	}
+}
+
+// reduce sets z to t mod 2**(n*_W) + 1, for t with 2n+2 words
+// and t < 2**((2n+1)*_W), and normalizes z.
+func (z fermat) reduce(t nat) {
+	n := len(z) - 1
+	// t = lo + hi*2**(n*_W) + top*2**(2n*_W) ≡ lo - hi + top
+	b := subVV(z[:n], t[:n], t[n:2*n])
+	// a borrow adds 2**(n*_W) ≡ -1, which is compensated by adding b
+	z[n] = addVW(z[:n], z[:n], b+t[2*n])
+	z.norm()
+}
+
+// neg sets z to -z for normalized z, and normalizes z.
+func (z fermat) neg() {
+	n := len(z) - 1
+	// 2**(n*_W) + 1 - z, with z = lo + z[n]*2**(n*_W)
+	top := z[n]
+	for i := range z[:n] {
+		z[i] = ^z[i] // 2**(n*_W) - 1 - lo
+	}
+	z[n] = addVW(z[:n], z[:n], 2) - top
+	z.norm()
+}
+
+// shift sets z to x * 2**s for normalized x, using t as scratch space of
+// at least 2n+2 words, and normalizes z. z must not alias x or t.
+func (z fermat) shift(x fermat, s int, t nat) {
+	n := len(z) - 1
+	s %= 2 * n * _W
+	neg := s >= n*_W // 2**(n*_W) ≡ -1
+	if neg {
+		s -= n * _W
+	}
+	q, r := s/_W, uint(s%_W)
+	t = t[:2*n+2]
+	t.clear()
+	t[q+n+1] = shlVU(t[q:q+n+1], nat(x), r)
+	z.reduce(t)
+	if neg {
+		z.neg()
+	}
+}
+
+// add sets z to x+y for normalized x and y, and normalizes z.
+func (z fermat) add(x, y fermat) {
+	addVV(z, x, y)
+	z.norm()
+}
+
+// sub sets z to x-y for normalized x and y, and normalizes z.
+func (z fermat) sub(x, y fermat) {
+	// x - y + 2**(n*_W) + 1 is positive and fits n+1 words, so
+	// it can be computed modulo 2**((n+1)*_W), ignoring carries.
+	n := len(z) - 1
+	subVV(z, x, y)
+	addVW(z, z, 1)
+	z[n]++
+	z.norm()
+}
+
+// mul sets z to x*y for normalized x and y, using t as scratch space
+// of at least 2n+2 words, and normalizes z. z may alias x or y.
+func (z fermat) mul(x, y fermat, t nat) {
+	n := len(z) - 1
+	p := t.mul(nat(x).norm(), nat(y).norm())
+	q := t[:2*n+2] // x*y < 2**((2n+1)*_W)
+	copy(q, p)     // in case mul did not use t
+	q[len(p):].clear()
+	z.reduce(q)
+}
+
+// fftSize returns the parameters k, m and n for multiplying operands
+// of nx and ny words with fftMul: the operands are split into pieces of
+// m words, which are the coefficients of polynomials of 2**k coefficients
+// over the integers modulo 2**(n*_W) + 1. The polynomial sizes are chosen
+// to minimize an estimate of the cost of the transforms and of the
+// pointwise multiplications.
+func fftSize(nx, ny int) (k uint, m, n int) {
+	best := -1.0
+	for kk := uint(2); kk < 24; kk++ {
+		K := 1 << kk
+		// px + py - 1 <= K pieces leave no wraparound
+		mm := (nx + ny + K - 1) / K
+		for (nx+mm-1)/mm+(ny+mm-1)/mm-1 > K {
+			mm++
+		}
+		// The product coefficients are less than K * 2**(2*mm*_W), and
+		// 2**(2*n*_W/K) must be a K-th root of unity.
+		nn := 2*mm + 1
+		if step := K / (2 * _W); step > 1 {
+			nn = (nn + step - 1) / step * step
+		}
+		cost := float64(K) * (mulCost(nn+1) + 3*float64(kk)*float64(nn))
+		if best < 0 || cost < best {
+			best, k, m, n = cost, kk, mm, nn
+		}
+	}
+	return
+}
+
+// mulCost estimates the cost of multiplying two nats of n words.
+func mulCost(n int) float64 {
+	c := float64(n) * float64(n)
+	for n >= karatsubaThreshold {
+		// each Karatsuba step replaces one multiplication by three
+		// of half the size
+		c *= 0.75
+		n >>= 1
+	}
+	return c
+}
+
+// fftPoly returns the polynomial of 2**k coefficients of n+1 words
+// whose coefficients are the pieces of m words of x.
+func fftPoly(x nat, k uint, m, n int) []fermat {
+	p := make([]fermat, 1<<k)
+	buf := make(nat, (n+1)<<k)
+	for i := range p {
+		p[i] = fermat(buf[i*(n+1) : (i+1)*(n+1)])
+	}
+	fftLoad(p, x, m)
+	return p
+}
+
+// fftLoad sets the coefficients of the polynomial p to the pieces of
+// m words of x.
+func fftLoad(p []fermat, x nat, m int) {
+	for i := range p {
+		lo := i * m
+		if lo >= len(x) {
+			nat(p[i]).clear()
+			continue
+		}
+		nat(p[i][copy(p[i], x[lo:min(lo+m, len(x))]):]).clear()
+	}
+}
+
+// fourier sets dst to the discrete Fourier transform of the 2**k values
+// src[0], src[stride], src[2*stride], ..., with the root of unity 2**w,
+// using t as scratch space. The values must be normalized, and dst must
+// not overlap src.
+func fourier(dst, src []fermat, k uint, stride, w int, t nat) {
+	if k == 0 {
+		copy(dst[0], src[0])
+		return
+	}
+	n := len(dst[0]) - 1
+	half := 1 << (k - 1)
+	w2 := 2 * w % (2 * n * _W)
+	if half*(n+1) >= parallelMulWords && acquireWorker() {
+		// the halves are independent; transform one concurrently
+		done := make(chan bool)
+		go func() {
+			fourier(dst[:half], src, k-1, 2*stride, w2, make(nat, 3*n+3))
+			releaseWorker()
+			done <- true
+		}()
+		fourier(dst[half:], src[stride:], k-1, 2*stride, w2, t)
+		<-done
+	} else {
+		fourier(dst[:half], src, k-1, 2*stride, w2, t)
+		fourier(dst[half:], src[stride:], k-1, 2*stride, w2, t)
+	}
+	u := fermat(t[2*n+2 : 3*n+3])
+	for i := 0; i < half; i++ {
+		a, b := dst[i], dst[half+i]
+		u.shift(b, i*w, t) // b * 2**(i*w)
+		b.sub(a, u)
+		a.add(a, u)
+	}
+}
+
+// fftMul sets z to x*y and returns z, using Schönhage-Strassen
+// multiplication: the product of the polynomials given by the pieces
+// of x and y is computed as the inverse transform of the products of
+// their Fourier transforms, and its coefficients are added at their
+// offsets. len(x) and len(y) must be > 0; z must not alias x or y.
+func (z nat) fftMul(x, y nat) nat {
+	if len(x) < len(y) {
+		x, y = y, x
+	}
+	if len(x) >= 2*len(y) {
+		return z.fftMulBlocks(x, y)
+	}
+	k, m, n := fftSize(len(x), len(y))
+	K := 1 << k
+	w := 2 * n * _W / K // 2**w is a primitive K-th root of unity
+	t := make(nat, 3*n+3)
+
+	xf := fftPoly(nil, k, m, n)
+	fourier(xf, fftPoly(x, k, m, n), k, 1, w, t)
+	yf := fftPoly(nil, k, m, n)
+	fourier(yf, fftPoly(y, k, m, n), k, 1, w, t)
+	fftPointwise(xf, yf)
+
+	// The inverse transform uses the root 2**-w and divides by K.
+	c := yf
+	fourier(c, xf, k, 1, 2*n*_W-w, t)
+	z = z.make(len(x) + len(y))
+	z.clear()
+	fftAccumulate(z, c, k, m, t)
+	return z.norm()
+}
+
+// fftMulBlocks is like fftMul for len(x) >= 2*len(y). It splits x into
+// blocks of fftBlockWords words, or len(y) if that is more, whose
+// products with y are added to z one after the other. The working set of
+// the transforms thus does not grow with x, and y is transformed only
+// once.
+func (z nat) fftMulBlocks(x, y nat) nat {
+	b := min(max(len(y), fftBlockWords), len(x))
+	k, m, n := fftSize(b, len(y))
+	K := 1 << k
+	w := 2 * n * _W / K // 2**w is a primitive K-th root of unity
+	t := make(nat, 3*n+3)
+
+	yf := fftPoly(nil, k, m, n)
+	fourier(yf, fftPoly(y, k, m, n), k, 1, w, t)
+	xp := fftPoly(nil, k, m, n)
+	xf := fftPoly(nil, k, m, n)
+
+	z = z.make(len(x) + len(y))
+	z.clear()
+	for lo := 0; lo < len(x); lo += b {
+		fftLoad(xp, x[lo:min(lo+b, len(x))], m)
+		fourier(xf, xp, k, 1, w, t)
+		fftPointwise(xf, yf)
+		// The product of the block with y is less than 2**(_W*(2*b)),
+		// and the sum of the products so far less than x*y, so that the
+		// additions of the coefficients stay within z.
+		fourier(xp, xf, k, 1, 2*n*_W-w, t)
+		fftAccumulate(z[lo:], xp, k, m, t)
+	}
+	return z.norm()
+}
+
+// fftPointwise sets each coefficient of x to its product with the
+// coefficient of y, which is left unchanged.
+func fftPointwise(x, y []fermat) {
+	n := len(x[0]) - 1
+	grain := max(1, parallelMulWords/(n+1))
+	parallelFor(0, len(x), grain, func(lo, hi int) {
+		t := make(nat, 3*n+3)
+		for i := lo; i < hi; i++ {
+			x[i].mul(x[i], y[i], t)
+		}
+	})
+}
+
+// fftAccumulate adds the coefficients of the inverse transform c of
+// 2**k values, divided by 2**k, to z at their offsets of m words, using
+// t as scratch space.
+func fftAccumulate(z nat, c []fermat, k uint, m int, t nat) {
+	n := len(c[0]) - 1
+	u := fermat(t[2*n+2 : 3*n+3])
+	for i := range c {
+		u.shift(c[i], 2*n*_W-int(k), t)
+		if lo := i * m; lo < len(z) {
+			v := nat(u).norm()
+			addAt(z, v[:min(len(v), len(z)-lo)], lo)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// fermatInt returns the value of x as an Int, and the modulus of x.
+func fermatInt(x fermat) (v, m *Int) {
+	n := len(x) - 1
+	m = new(Int).Lsh(intOne, uint(n*_W))
+	m.Add(m, intOne)
+	v = new(Int).SetBits(append([]Word(nil), x...))
+	return v, m
+}
+
+// randFermat returns a random normalized fermat of n+1 words.
+func randFermat(r *rand.Rand, n int) fermat {
+	z := make(fermat, n+1)
+	switch r.Intn(8) {
+	case 0:
+		z[n] = 1 // 2**(n*_W) ≡ -1
+	case 1:
+		// 0
+	default:
+		for i := range z[:n] {
+			z[i] = Word(r.Int63())<<1 ^ Word(r.Int63())
+		}
+	}
+	return z
+}
+
+func TestFermat(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, n := range []int{1, 2, 3, 8} {
+		tmp := make(nat, 3*n+3)
+		for i := 0; i < 200; i++ {
+			x, y := randFermat(r, n), randFermat(r, n)
+			xv, m := fermatInt(x)
+			yv, _ := fermatInt(y)
+			check := func(op string, got fermat, want *Int) {
+				want.Mod(want, m)
+				if v, _ := fermatInt(got); v.Cmp(want) != 0 || v.Cmp(m) >= 0 {
+					t.Errorf("%s(%s, %s) mod %s = %s; want %s", op, xv, yv, m, v, want)
+				}
+			}
+
+			z := make(fermat, n+1)
+			z.add(x, y)
+			check("add", z, new(Int).Add(xv, yv))
+			z.sub(x, y)
+			check("sub", z, new(Int).Sub(xv, yv))
+			z.mul(x, y, tmp)
+			check("mul", z, new(Int).Mul(xv, yv))
+			copy(z, x)
+			z.neg()
+			check("neg", z, new(Int).Neg(xv))
+			s := r.Intn(2 * n * _W)
+			z.shift(x, s, tmp)
+			check(fmt.Sprintf("shift %d", s), z, new(Int).Lsh(xv, uint(s)))
+		}
+	}
+}
+
+func TestFFTMul(t *testing.T) {
+	for _, test := range []struct{ nx, ny int }{
+		{1, 1},
+		{2, 1},
+		{10, 10},
+		{100, 3},
+		{300, 300},
+		{1000, 999},
+		{2000, 1500},
+		{5000, 100},
+		{5000, 2500},
+		{7001, 1000},
+		{999, 9000},
+	} {
+		x := rndNat(test.nx)
+		y := rndNat(test.ny)
+		want := nat(nil).mul(x, y)
+		if got := nat(nil).fftMul(x, y); got.cmp(want) != 0 {
+			t.Errorf("fftMul of %d and %d words differs from mul", test.nx, test.ny)
+		}
+	}
+
+	// all ones, for the largest coefficients
+	x := nat(nil).sub(nat(nil).shl(natOne, 3000*_W), natOne)
+	want := nat(nil).mul(x, x)
+	if got := nat(nil).fftMul(x, x); got.cmp(want) != 0 {
+		t.Errorf("fftMul of all ones differs from mul")
+	}
+}
+
+func TestMulFFTThreshold(t *testing.T) {
+	defer func(th int) { fftThreshold = th }(fftThreshold)
+	x := rndNat(3000)
+	y := rndNat(2500)
+	want := nat(nil).mul(x, y)
+	fftThreshold = 1000
+	if got := nat(nil).mul(x, y); got.cmp(want) != 0 {
+		t.Errorf("mul above fftThreshold differs from Karatsuba multiplication")
+	}
+}
+
+func TestFFTMulBlocks(t *testing.T) {
+	defer func(bw int) { fftBlockWords = bw }(fftBlockWords)
+	fftBlockWords = 300
+	for _, test := range []struct{ nx, ny int }{
+		{600, 1},
+		{1000, 100},
+		{3000, 200},
+		{7001, 1000},
+		{999, 9000},
+	} {
+		x := rndNat(test.nx)
+		y := rndNat(test.ny)
+		want := nat(nil).mul(x, y)
+		if got := nat(nil).fftMul(x, y); got.cmp(want) != 0 {
+			t.Errorf("fftMul of %d and %d words in blocks differs from mul", test.nx, test.ny)
+		}
+	}
+}
+
+func BenchmarkFFTMul(b *testing.B) {
+	for _, n := range []int{1e3, 5e3, 1e4, 5e4, 1e5} {
+		x := rndNat(n)
+		y := rndNat(n)
+		b.Run(fmt.Sprintf("%d/karatsuba", n), func(b *testing.B) {
+			defer func(th int) { fftThreshold = th }(fftThreshold)
+			fftThreshold = 1 << 30
+			for i := 0; i < b.N; i++ {
+				nat(nil).mul(x, y)
+			}
+		})
+		b.Run(fmt.Sprintf("%d/fft", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				nat(nil).fftMul(x, y)
+			}
+		})
+	}
+}
+
+func BenchmarkFFTMulUnbalanced(b *testing.B) {
+	y := rndNat(1e4)
+	for _, n := range []int{1e5, 1e6} {
+		x := rndNat(n)
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				nat(nil).fftMul(x, y)
+			}
+		})
+	}
+}
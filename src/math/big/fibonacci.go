@@ -0,0 +1,74 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Fibonacci and Lucas numbers by fast doubling.
+
+package big
+
+import "math/bits"
+
+// Fibonacci sets z to the n'th Fibonacci number F(n) and returns z,
+// with F(0) = 0, F(1) = 1, and F(n) = F(n-1) + F(n-2).
+func (z *Int) Fibonacci(n uint64) *Int {
+	z.abs, _ = fibonacci(n)
+	z.neg = false
+	return z
+}
+
+// Lucas sets z to the n'th Lucas number L(n) and returns z,
+// with L(0) = 2, L(1) = 1, and L(n) = L(n-1) + L(n-2).
+func (z *Int) Lucas(n uint64) *Int {
+	// L(n) = F(n+1) + F(n-1) = F(n) + 2*F(n-1)
+	f, f1 := fibonacci(n)
+	f1 = f1.shl(f1, 1)
+	z.abs = f.add(f, f1)
+	z.neg = false
+	return z
+}
+
+// fibonacci returns F(n) and F(n-1), with F(-1) = 1. It doubles the index
+// for each bit of n, from the most significant one down, with the two
+// squares of
+//
+//	F(2k+1) = 4*F(k)**2 - F(k-1)**2 + 2*(-1)**k
+//	F(2k-1) = F(k)**2 + F(k-1)**2
+//	F(2k)   = F(2k+1) - F(2k-1)
+//
+// and moves to the index 2k+1 if the bit is set. See Richard P. Brent
+// and Paul Zimmermann, Modern Computer Arithmetic, Exercise 4.27, and
+// the Fibonacci functions of the GNU MP library.
+func fibonacci(n uint64) (f, f1 nat) {
+	if n == 0 {
+		return nil, nat(nil).setWord(1)
+	}
+	f = nat(nil).setWord(1) // F(k) for k = 1
+	f1 = nil                // F(k-1)
+	var a, b nat
+	odd := true // k is odd
+	for i := bits.Len64(n) - 2; i >= 0; i-- {
+		if len(f) >= parallelMulWords {
+			parallelDo(func() { a = a.mul(f, f) }, func() { b = b.mul(f1, f1) })
+		} else {
+			a = a.mul(f, f)
+			b = b.mul(f1, f1)
+		}
+		// f = F(2k+1), f1 = F(2k-1)
+		f = f.shl(a, 2)
+		f = f.sub(f, b)
+		if odd {
+			f = f.sub(f, natTwo)
+		} else {
+			f = f.add(f, natTwo)
+		}
+		f1 = f1.add(a, b)
+		if n>>uint(i)&1 != 0 {
+			f1 = f1.sub(f, f1) // k = 2k+1
+			odd = true
+		} else {
+			f = f.sub(f, f1) // k = 2k
+			odd = false
+		}
+	}
+	return f, f1
+}
@@ -0,0 +1,53 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestFibonacciLucas(t *testing.T) {
+	defer func(n int) { parallelMulWords = n }(parallelMulWords)
+	for _, parallelWords := range []int{1 << 30, 16} {
+		parallelMulWords = parallelWords
+		f, f1 := NewInt(0), NewInt(1) // F(n), F(n-1)
+		for n := uint64(0); n <= 3000; n++ {
+			if got := new(Int).Fibonacci(n); got.Cmp(f) != 0 {
+				t.Fatalf("Fibonacci(%d) = %s; want %s", n, got, f)
+			}
+			l := new(Int).Lsh(f1, 1)
+			l.Add(l, f)
+			if got := new(Int).Lucas(n); got.Cmp(l) != 0 {
+				t.Fatalf("Lucas(%d) = %s; want %s", n, got, l)
+			}
+			f, f1 = f1.Add(f, f1), f
+		}
+	}
+
+	// F(2n) = F(n)*L(n), and L(n)**2 - 5*F(n)**2 = 4*(-1)**n
+	for _, n := range []uint64{1e4 + 1, 1e5, 1e6} {
+		f, l := new(Int).Fibonacci(n), new(Int).Lucas(n)
+		if got, want := new(Int).Fibonacci(2*n), new(Int).Mul(f, l); got.Cmp(want) != 0 {
+			t.Errorf("Fibonacci(%d) != Fibonacci(%d) * Lucas(%d)", 2*n, n, n)
+		}
+		d := new(Int).Mul(l, l)
+		d.Sub(d, new(Int).Mul(new(Int).Mul(f, f), NewInt(5)))
+		if want := int64(4 - 8*int64(n&1)); d.Cmp(NewInt(want)) != 0 {
+			t.Errorf("Lucas(%d)**2 - 5*Fibonacci(%d)**2 = %s; want %d", n, n, d, want)
+		}
+	}
+}
+
+func BenchmarkFibonacci(b *testing.B) {
+	for _, n := range []uint64{1e3, 1e5, 1e7} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			var z Int
+			for i := 0; i < b.N; i++ {
+				z.Fibonacci(n)
+			}
+		})
+	}
+}
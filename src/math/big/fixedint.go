@@ -0,0 +1,133 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements FixedInt, a fixed-width unsigned integer
+// with constant-time arithmetic.
+
+package big
+
+// A FixedInt is an unsigned integer of a fixed width in bits, declared
+// when it is created. Arithmetic on a FixedInt is computed modulo 2**width
+// in constant time: the running time and memory access pattern of each
+// operation depend only on the widths of its operands, not on their values.
+//
+// Unlike operations on Int values marked with SetConstantTime, which widen
+// their results as needed to hold any value, FixedInt operations truncate
+// the result to the width of the receiver and report whether any bits were lost
+// (or, for subtraction, whether the result would have been negative) as a
+// mask that is all ones in that case and zero otherwise, so that it can be
+// combined with other such masks without branching.
+//
+// The zero value for a FixedInt has width 0 and always holds the value 0.
+type FixedInt struct {
+	bits int // width in bits
+	abs  nat // exactly fixedWords(bits) words, not normalized
+}
+
+// fixedWords returns the number of words of a FixedInt of the given width.
+func fixedWords(bits int) int {
+	return (bits + _W - 1) / _W
+}
+
+// NewFixedInt allocates and returns a new FixedInt of the given
+// width in bits, set to 0. If bits < 0, NewFixedInt panics.
+func NewFixedInt(bits int) *FixedInt {
+	if bits < 0 {
+		panic("math/big: negative FixedInt width")
+	}
+	return &FixedInt{bits: bits, abs: make(nat, fixedWords(bits))}
+}
+
+// Bits returns the width of x in bits.
+func (x *FixedInt) Bits() int {
+	return x.bits
+}
+
+// set sets z to the value of x truncated to the width of z, and returns
+// a mask that is all ones if any of the truncated bits were set and zero
+// otherwise. x may have any length, and z.abs may alias x.
+func (z *FixedInt) set(x nat) (carry Word) {
+	n := fixedWords(z.bits)
+	for i := n; i < len(x); i++ {
+		carry |= x[i]
+	}
+	var top Word // bits of the most significant word beyond the width
+	if r := uint(z.bits % _W); r != 0 {
+		top = ^Word(0) << r
+	}
+	if n > 0 && n <= len(x) {
+		carry |= x[n-1] & top
+	}
+	if z.abs == nil || len(z.abs) != n {
+		z.abs = make(nat, n)
+	}
+	if len(x) >= n {
+		copy(z.abs, x[:n])
+	} else {
+		copy(z.abs, x)
+		z.abs[len(x):].clear()
+	}
+	if n > 0 {
+		z.abs[n-1] &^= top
+	}
+	return -(ctIsZero(carry) ^ 1)
+}
+
+// SetInt sets z to x mod 2**z.Bits(), using the two's complement
+// representation for negative x, and returns a mask that is all ones if
+// x is not in the range [0, 2**z.Bits()) and zero otherwise. The running
+// time depends only on the width of z and on the constant-time width of x
+// (see SetConstantTime); the sign of x is not concealed.
+func (z *FixedInt) SetInt(x *Int) (carry Word) {
+	n := max(fixedWords(z.bits), x.ctWords())
+	a := nat(nil).cpad(x.abs, n)
+	neg := boolWord(x.neg)
+	a.cneg(neg)
+	// a negative value is out of range even if its truncation is 0
+	over := -(neg & x.abs.cnonzero())
+	return z.set(a) | over
+}
+
+// Set sets z to x truncated to the width of z, and returns a mask
+// that is all ones if any bits were lost and zero otherwise.
+func (z *FixedInt) Set(x *FixedInt) (carry Word) {
+	return z.set(x.abs)
+}
+
+// Int returns the value of x as an Int, marked as constant-time with the
+// width of x (see SetConstantTime). If a non-nil *Int argument z is
+// provided, Int stores the result in z instead of allocating a new Int.
+func (x *FixedInt) Int(z *Int) *Int {
+	if z == nil {
+		z = new(Int)
+	}
+	return z.setCT(nat(nil).set(x.abs), 0, len(x.abs))
+}
+
+// Add sets z to x+y mod 2**z.Bits(), and returns a mask that is all ones
+// if the sum does not fit in the width of z and zero otherwise.
+func (z *FixedInt) Add(x, y *FixedInt) (carry Word) {
+	n := max(len(x.abs), len(y.abs))
+	return z.set(nat(nil).cadd(x.abs, y.abs, n+1))
+}
+
+// Sub sets z to x-y mod 2**z.Bits(), and returns a mask that is all ones
+// if x < y or if the difference does not fit in the width of z, and zero
+// otherwise.
+func (z *FixedInt) Sub(x, y *FixedInt) (borrow Word) {
+	n := max(fixedWords(z.bits), max(len(x.abs), len(y.abs)))
+	d, b := nat(nil).csub(x.abs, y.abs)
+	// sign-extend the difference to the width of z
+	ext := d.cpad(d, n)
+	for i := len(d); i < n; i++ {
+		ext[i] = -b
+	}
+	return z.set(ext) | -b
+}
+
+// Mul sets z to x*y mod 2**z.Bits(), and returns a mask that is all ones
+// if the product does not fit in the width of z and zero otherwise.
+func (z *FixedInt) Mul(x, y *FixedInt) (carry Word) {
+	return z.set(nat(nil).cmul(x.abs, y.abs, len(x.abs)+len(y.abs)))
+}
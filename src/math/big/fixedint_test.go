@@ -0,0 +1,121 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"math/rand"
+	"testing"
+)
+
+// fixedRef returns x mod 2**bits and whether x is in [0, 2**bits).
+func fixedRef(x *Int, bits int) (*Int, bool) {
+	lim := new(Int).Lsh(intOne, uint(bits))
+	r := new(Int).Mod(x, lim)
+	return r, x.Sign() >= 0 && x.Cmp(lim) < 0
+}
+
+func randFixed(r *rand.Rand, bits int) (*FixedInt, *Int) {
+	x := new(Int).Rand(r, new(Int).Lsh(intOne, uint(bits)))
+	if r.Intn(4) == 0 {
+		x.Sub(new(Int).Lsh(intOne, uint(bits)), intOne) // all ones
+	}
+	z := NewFixedInt(bits)
+	if c := z.SetInt(x); c != 0 {
+		panic("value does not fit")
+	}
+	return z, x
+}
+
+func checkFixed(t *testing.T, op string, z *FixedInt, carry Word, want *Int) {
+	w, ok := fixedRef(want, z.Bits())
+	if got := z.Int(nil); got.Cmp(w) != 0 {
+		t.Errorf("%s = %s; want %s", op, got, w)
+	}
+	if wantCarry := -boolWord(!ok); carry != wantCarry {
+		t.Errorf("%s: carry = %#x; want %#x", op, carry, wantCarry)
+	}
+	if len(z.abs) != fixedWords(z.Bits()) {
+		t.Errorf("%s: %d words; want %d", op, len(z.abs), fixedWords(z.Bits()))
+	}
+}
+
+var fixedWidths = []int{0, 1, 7, _W - 1, _W, _W + 1, 2 * _W, 3*_W + 5}
+
+func TestFixedIntArith(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		xb := fixedWidths[r.Intn(len(fixedWidths))]
+		yb := fixedWidths[r.Intn(len(fixedWidths))]
+		zb := fixedWidths[r.Intn(len(fixedWidths))]
+		x, xi := randFixed(r, xb)
+		y, yi := randFixed(r, yb)
+		z := NewFixedInt(zb)
+
+		c := z.Add(x, y)
+		checkFixed(t, "Add", z, c, new(Int).Add(xi, yi))
+		c = z.Sub(x, y)
+		checkFixed(t, "Sub", z, c, new(Int).Sub(xi, yi))
+		c = z.Mul(x, y)
+		checkFixed(t, "Mul", z, c, new(Int).Mul(xi, yi))
+		c = z.Set(x)
+		checkFixed(t, "Set", z, c, xi)
+	}
+}
+
+func TestFixedIntAlias(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	x, xi := randFixed(r, 2*_W+3)
+	y, yi := randFixed(r, _W)
+	want := new(Int).Add(xi, yi)
+	c := x.Add(x, y)
+	checkFixed(t, "Add (aliased)", x, c, want)
+	want.Mul(want, want)
+	c = x.Mul(x, x)
+	checkFixed(t, "Mul (aliased)", x, c, want)
+	c = x.Sub(x, x)
+	checkFixed(t, "Sub (aliased)", x, c, new(Int))
+}
+
+func TestFixedIntSetInt(t *testing.T) {
+	for _, test := range []struct {
+		x    string
+		bits int
+	}{
+		{"0", 0},
+		{"1", 0},
+		{"0", 8},
+		{"255", 8},
+		{"256", 8},
+		{"-1", 8},
+		{"-256", 8},
+		{"0x123456789abcdef0123456789abcdef", 64},
+		{"0x123456789abcdef0123456789abcdef", 125},
+		{"-0x123456789abcdef0123456789abcdef", 200},
+	} {
+		x, _ := new(Int).SetString(test.x, 0)
+		z := NewFixedInt(test.bits)
+		c := z.SetInt(x)
+		checkFixed(t, "SetInt("+test.x+")", z, c, x)
+	}
+
+	// the constant-time width of x is used
+	x := new(Int).SetConstantTime(3 * _W)
+	z := NewFixedInt(_W)
+	if c := z.SetInt(x); c != 0 {
+		t.Errorf("SetInt(0 with width %d): carry = %#x", 3*_W, c)
+	}
+	if got := z.Int(nil); got.zcap != 1 {
+		t.Errorf("Int: width %d; want 1", got.zcap)
+	}
+}
+
+func TestFixedIntZero(t *testing.T) {
+	var x FixedInt
+	y := NewFixedInt(8)
+	y.SetInt(NewInt(200))
+	if c := x.Add(y, y); c != ^Word(0) || x.Int(nil).Sign() != 0 {
+		t.Errorf("zero FixedInt Add = %s, %#x; want 0, all ones", x.Int(nil), c)
+	}
+}
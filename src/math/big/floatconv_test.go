@@ -119,6 +119,27 @@ func TestFloatSetFloat64String(t *testing.T) {
 		{"-0X0.00008p16", -0.5},
 		{"0x0.0000000000001p-1022", math.SmallestNonzeroFloat64},
 		{"0x1.fffffffffffffp1023", math.MaxFloat64},
+
+		// octal mantissa
+		{"0o17", 15},
+		{"-0O1.4", -1.5},
+		{"0o.4p1", 1},
+		{"0o8", nan},
+
+		// separators
+		{"1_000.000_5", 1000.0005},
+		{"0x_ff_ff", 65535},
+		{"0b_1.1p1_0", 1536},
+		{"1e1_0", 1e10},
+		{"0_1.5", 1.5},
+		{"_1", nan},
+		{"1_", nan},
+		{"1__0", nan},
+		{"1_.5", nan},
+		{"1._5", nan},
+		{"1e_1", nan},
+		{"1e1_", nan},
+		{"0x_", nan},
 	} {
 		var x Float
 		x.SetPrec(53)
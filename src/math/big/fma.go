@@ -0,0 +1,114 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Float.FMA.
+
+package big
+
+// FMA sets z to the rounded value of x × y + w and returns z. The exact
+// product x × y is not rounded; only the sum is, according to z's
+// precision and rounding mode, and its accuracy is reported as for Add.
+// If z's precision is 0, it is changed to the largest of x's, y's, or w's
+// precision before the operation. An exact sum of 0 is +0, as for Add.
+//
+// FMA panics with ErrNaN if x × y is the product of a zero and an
+// infinity, or an infinity and w an infinity of the opposite sign; the
+// value of z is undefined in that case.
+func (z *Float) FMA(x, y, w *Float) *Float {
+	if debugFloat {
+		x.validate()
+		y.validate()
+		w.validate()
+	}
+
+	if z.prec == 0 {
+		z.prec = umax32(umax32(x.prec, y.prec), w.prec)
+	}
+
+	if x.form == finite && y.form == finite && w.form == finite {
+		// x × y + w = pm × 2**pe + wm × 2**we (common case)
+		pm, pe := x.oddExp()
+		ym, ye := y.oddExp()
+		pm.Mul(pm, ym)
+		wm, we := w.oddExp()
+		m, exp := addScaled(pm, pe+ye, wm, we, int64(z.prec))
+		return z.setScaled(m, exp)
+	}
+
+	pneg := x.neg != y.neg
+	switch {
+	case x.form == zero && y.form == inf || x.form == inf && y.form == zero:
+		// value of z is undefined but make sure it's valid
+		z.acc = Exact
+		z.form = zero
+		z.neg = false
+		panic(ErrNaN{"multiplication of zero with infinity"})
+
+	case x.form == inf || y.form == inf:
+		if w.form == inf && w.neg != pneg {
+			// value of z is undefined but make sure it's valid
+			z.acc = Exact
+			z.form = zero
+			z.neg = false
+			panic(ErrNaN{"addition of infinities with opposite signs"})
+		}
+		// ±Inf + w
+		z.acc = Exact
+		z.form = inf
+		z.neg = pneg
+		return z
+
+	case x.form == zero || y.form == zero:
+		if w.form == zero {
+			// ±0 + ±0
+			z.acc = Exact
+			z.form = zero
+			z.neg = pneg && w.neg // -0 + -0 == -0
+			return z
+		}
+		// ±0 + w
+		return z.Set(w)
+
+	case w.form == inf:
+		// x × y + ±Inf
+		return z.Set(w)
+	}
+
+	// x × y + ±0
+	return z.Mul(x, y)
+}
+
+// addScaled returns m and exp such that m × 2**exp, rounded to any
+// precision up to prec, rounds like a × 2**ae + b × 2**be, for odd a and b.
+// m × 2**exp is the exact sum unless one term is too small to matter but
+// for its sign; that term is then replaced by a smaller one of the same
+// sign, which keeps the shifts that align the terms short.
+func addScaled(a *Int, ae int64, b *Int, be int64, prec int64) (m *Int, exp int64) {
+	// |a| × 2**ae < 2**at and |b| × 2**be < 2**bt, with at >= bt
+	at := ae + int64(a.BitLen())
+	bt := be + int64(b.BitLen())
+	if at < bt {
+		a, ae, b, be = b, be, a, ae
+		at, bt = bt, at
+	}
+
+	// Neither a × 2**ae nor any value of precision prec, or midpoint
+	// between two such values, lies strictly between a × 2**ae and a ×
+	// 2**ae ± 2**l; the sum lies there if bt <= l.
+	l := at - prec - 2
+	if ae < l {
+		l = ae
+	}
+	l -= 2
+	if bt <= l {
+		b = NewInt(int64(b.Sign()))
+		be = l - 1
+	}
+
+	if ae < be {
+		a, ae, b, be = b, be, a, ae
+	}
+	m = new(Int).Lsh(a, uint(ae-be))
+	return m.Add(m, b), be
+}
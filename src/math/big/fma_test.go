@@ -0,0 +1,157 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+// randFloat returns a random Float with a mantissa of up to prec bits and
+// an exponent within ±exp.
+func randFloat(r *rand.Rand, prec uint, exp int) *Float {
+	m := new(Int).Rand(r, new(Int).Lsh(intOne, prec))
+	if r.Intn(2) == 0 {
+		m.Neg(m)
+	}
+	x := new(Float).SetInt(m)
+	return x.SetMantExp(x, r.Intn(2*exp+1)-exp)
+}
+
+// fmaRat returns x × y + w computed exactly with Rats.
+func fmaRat(x, y, w *Float) *Rat {
+	rx, _ := x.Rat(nil)
+	ry, _ := y.Rat(nil)
+	rw, _ := w.Rat(nil)
+	rx.Mul(rx, ry)
+	return rx.Add(rx, rw)
+}
+
+func TestFloatFMA(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		// operands of mixed precisions, whose terms overlap, or not
+		exp := 10
+		switch i % 4 {
+		case 2:
+			exp = 300
+		case 3:
+			exp = 1e4
+		}
+		x := randFloat(r, uint(r.Intn(200)+1), exp)
+		y := randFloat(r, uint(r.Intn(200)+1), exp)
+		w := randFloat(r, uint(r.Intn(200)+1), 2*exp)
+		if i%10 == 0 {
+			// cancel the leading bits of x × y
+			w.SetPrec(uint(r.Intn(200)+1)).Mul(x, y).Neg(w)
+		}
+		want := fmaRat(x, y, w)
+		for _, mode := range []RoundingMode{ToNearestEven, ToNearestAway, ToZero, AwayFromZero, ToNegativeInf, ToPositiveInf} {
+			prec := uint(r.Intn(100) + 1)
+			z := new(Float).SetPrec(prec).SetMode(mode).FMA(x, y, w)
+			wz := new(Float).SetPrec(prec).SetMode(mode).SetRat(want)
+			if z.Cmp(wz) != 0 || z.Acc() != wz.Acc() {
+				t.Errorf("%d: prec %d, %s: FMA(%s, %s, %s) = %s (%s); want %s (%s)", i, prec, mode, x.Text('p', 0), y.Text('p', 0), w.Text('p', 0), z.Text('p', 0), z.Acc(), wz.Text('p', 0), wz.Acc())
+			}
+		}
+	}
+}
+
+func TestFloatFMATwoProduct(t *testing.T) {
+	// x × y = p + e exactly, with p = x × y rounded and e = FMA(x, y, -p)
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		x := new(Float).SetFloat64(r.NormFloat64())
+		y := new(Float).SetFloat64(r.NormFloat64())
+		p := new(Float).Mul(x, y)
+		e := new(Float).FMA(x, y, new(Float).Neg(p))
+		if e.Acc() != Exact {
+			t.Errorf("FMA(%s, %s, -%s) = %s is not exact", x.Text('p', 0), y.Text('p', 0), p.Text('p', 0), e.Text('p', 0))
+		}
+		exact := new(Float).SetPrec(106).Mul(x, y)
+		if got := new(Float).SetPrec(106).Add(p, e); got.Cmp(exact) != 0 {
+			t.Errorf("%s + %s = %s; want %s", p.Text('p', 0), e.Text('p', 0), got.Text('p', 0), exact.Text('p', 0))
+		}
+	}
+}
+
+func TestFloatFMASpecial(t *testing.T) {
+	for _, test := range []struct {
+		x, y, w, want string
+		acc           Accuracy
+	}{
+		{"0", "1", "0", "0", Exact},
+		{"-0", "1", "0", "0", Exact},
+		{"-0", "1", "-0", "-0", Exact},
+		{"-0", "-1", "-0", "0", Exact},
+		{"0", "1", "-3", "-3", Exact},
+		{"0", "1", "0x1.000000001p0", "1", Below},
+		{"2", "3", "-6", "0", Exact},
+		{"-2", "3", "6", "0", Exact},
+		{"2", "3", "0", "6", Exact},
+		{"2", "3", "-0", "6", Exact},
+		{"+Inf", "-2", "1", "-Inf", Exact},
+		{"+Inf", "2", "+Inf", "+Inf", Exact},
+		{"2", "3", "-Inf", "-Inf", Exact},
+		{"0", "3", "+Inf", "+Inf", Exact},
+		// single rounding
+		{"0x1.00000001p0", "0x1.00000001p0", "-1", "0x1p-31", Below},
+		{"0x1.00000001p0", "0x1.00000001p0", "-0x1.000000008p0", "0x1.8p-32", Below},
+		// a tiny w only decides the rounding
+		{"0x1.00000002p0", "1", "0x1p-1000000", "0x1.00000002p0", Below},
+		{"0x1.00000002p0", "1", "-0x1p-1000000", "0x1.00000002p0", Above},
+		{"0x1p1000000", "1", "-0x1p-1000000", "0x1p1000000", Above},
+		// x × y out of range, but not x × y + w
+		{"0x1p1073741824", "0x1.8p1073741822", "-0x1.ff8p2147483645", "0x1.008p2147483645", Exact},
+		{"0x1p-1073741824", "0x1p-1073741850", "0x1p-10", "0x1p-10", Below},
+		{"0x1p1073741824", "0x1p1073741824", "-1", "+Inf", Above},
+	} {
+		x, y, w := makeFloat(test.x), makeFloat(test.y), makeFloat(test.w)
+		z := new(Float).SetPrec(32).FMA(x, y, w)
+		if got := z.Text('p', 0); got != makeFloat(test.want).Text('p', 0) || z.Acc() != test.acc {
+			t.Errorf("FMA(%s, %s, %s) = %s (%s); want %s (%s)", test.x, test.y, test.w, got, z.Acc(), test.want, test.acc)
+		}
+	}
+
+	// z may be x, y, or w
+	x, y, w := NewFloat(1.5), NewFloat(-2.25), NewFloat(0.125)
+	want := new(Float).FMA(x, y, w)
+	for i := 0; i < 3; i++ {
+		ops := []*Float{new(Float).Copy(x), new(Float).Copy(y), new(Float).Copy(w)}
+		if got := ops[i].FMA(ops[0], ops[1], ops[2]); got.Cmp(want) != 0 {
+			t.Errorf("FMA aliased with operand %d = %s; want %s", i, got.Text('p', 0), want.Text('p', 0))
+		}
+	}
+
+	if z := new(Float).FMA(new(Float).SetPrec(20), new(Float).SetPrec(30), new(Float).SetPrec(70)); z.Prec() != 70 {
+		t.Errorf("FMA with precision 0: got precision %d; want 70", z.Prec())
+	}
+
+	for _, test := range [][3]string{{"0", "+Inf", "1"}, {"-Inf", "0", "1"}, {"+Inf", "1", "-Inf"}, {"-Inf", "-1", "-Inf"}} {
+		func() {
+			defer func() {
+				if _, ok := recover().(ErrNaN); !ok {
+					t.Errorf("FMA(%s, %s, %s) did not panic with ErrNaN", test[0], test[1], test[2])
+				}
+			}()
+			new(Float).FMA(makeFloat(test[0]), makeFloat(test[1]), makeFloat(test[2]))
+		}()
+	}
+}
+
+func BenchmarkFloatFMA(b *testing.B) {
+	for _, prec := range []uint{64, 256, 1000, 10000} {
+		x := new(Float).SetPrec(prec).SetFloat64(1.2345)
+		y := new(Float).SetPrec(prec).SetFloat64(6.789)
+		w := new(Float).SetPrec(prec).SetFloat64(-8.1)
+		z := new(Float).SetPrec(prec)
+		b.Run(fmt.Sprintf("%v", prec), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				z.FMA(x, y, w)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the canonical byte form of an Int, from which
+// Hash derives a hash of its value.
+
+package big
+
+import (
+	"encoding/binary"
+	"hash"
+	"sync"
+)
+
+// AppendCanonical appends the canonical byte form of x to buf and returns
+// the extended buffer. The canonical form is a sign byte, 0 for x >= 0
+// and 1 for x < 0, followed by the length of |x| in bytes as a uvarint
+// (see encoding/binary) and the big-endian bytes of |x| without leading
+// zeros. Equal values have the same canonical form, regardless of their
+// internal representation or mark (see SetConstantTime), and the form
+// of a sequence of values is unambiguous. If buf has enough spare
+// capacity, AppendCanonical doesn't allocate.
+func (x *Int) AppendCanonical(buf []byte) []byte {
+	sign := byte(0)
+	if x.neg && len(x.abs) > 0 {
+		sign = 1
+	}
+	var hdr [1 + binary.MaxVarintLen64]byte
+	hdr[0] = sign
+	n := 1 + binary.PutUvarint(hdr[1:], uint64((x.abs.bitLen()+7)/8))
+	return x.AppendBytes(append(buf, hdr[:n]...))
+}
+
+// Hash resets h, writes the canonical byte form of x to it (see
+// AppendCanonical), and returns h.Sum64(). Equal values have equal
+// hashes, so that Hash can index map keys or hash-consing tables of
+// Ints. For such tables filled with untrusted values, h should be a
+// hash with a random key. Hash takes the canonical form from a pooled
+// buffer, so that it doesn't allocate unless h does.
+func (x *Int) Hash(h hash.Hash64) uint64 {
+	bp := getHashBuf()
+	*bp = x.AppendCanonical((*bp)[:0])
+	h.Reset()
+	h.Write(*bp)
+	if x.zcap != 0 {
+		for i := range *bp {
+			(*bp)[i] = 0 // the pool must not keep marked values
+		}
+	}
+	hashBufPool.Put(bp)
+	return h.Sum64()
+}
+
+// getHashBuf returns a buffer for the canonical form of Hash from
+// hashBufPool.
+func getHashBuf() *[]byte {
+	if v := hashBufPool.Get(); v != nil {
+		return v.(*[]byte)
+	}
+	return new([]byte)
+}
+
+var hashBufPool sync.Pool
@@ -0,0 +1,73 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"bytes"
+	"encoding/hex"
+	"hash/fnv"
+	"testing"
+)
+
+func TestAppendCanonical(t *testing.T) {
+	for _, test := range []struct {
+		x    string
+		want string
+	}{
+		{"0", "0000"},
+		{"1", "000101"},
+		{"-1", "010101"},
+		{"255", "0001ff"},
+		{"-256", "01020100"},
+		{"0x123456789abcdef0123456789abcdef", "0010" + "0123456789abcdef0123456789abcdef"},
+		{"-0x" + string(bytes.Repeat([]byte("ab"), 200)), "01c801" + string(bytes.Repeat([]byte("ab"), 200))},
+	} {
+		x, _ := new(Int).SetString(test.x, 0)
+		if got := hex.EncodeToString(x.AppendCanonical(nil)); got != test.want {
+			t.Errorf("AppendCanonical(%s) = %s; want %s", test.x, got, test.want)
+		}
+		prefix := []byte("prefix")
+		if got := x.AppendCanonical(prefix); !bytes.HasPrefix(got, prefix) || hex.EncodeToString(got[len(prefix):]) != test.want {
+			t.Errorf("AppendCanonical(%s) with prefix = %x", test.x, got)
+		}
+		// the canonical form ignores the representation of x
+		y := new(Int).Set(x).SetConstantTime(x.BitLen() + 100)
+		if got := hex.EncodeToString(y.AppendCanonical(nil)); got != test.want {
+			t.Errorf("marked AppendCanonical(%s) = %s; want %s", test.x, got, test.want)
+		}
+	}
+
+	negZero := &Int{neg: true}
+	if got := negZero.AppendCanonical(nil); !bytes.Equal(got, []byte{0, 0}) {
+		t.Errorf("AppendCanonical(-0) = %x; want 0000", got)
+	}
+}
+
+func TestHash(t *testing.T) {
+	h := fnv.New64a()
+	seen := make(map[uint64]*Int)
+	for i := int64(-1000); i <= 1000; i++ {
+		x := NewInt(i << 20)
+		h2 := fnv.New64a()
+		h2.Write(x.AppendCanonical(nil))
+		sum := x.Hash(h)
+		if sum != h2.Sum64() {
+			t.Errorf("Hash(%s) = %#x; want %#x", x, sum, h2.Sum64())
+		}
+		if y := seen[sum]; y != nil {
+			t.Errorf("Hash(%s) = Hash(%s) = %#x", x, y, sum)
+		}
+		seen[sum] = x
+		if got := new(Int).Set(x).SetConstantTime(64).Hash(h); got != sum {
+			t.Errorf("marked Hash(%s) = %#x; want %#x", x, got, sum)
+		}
+	}
+
+	x := new(Int).Lsh(NewInt(-12345), 1000)
+	x.Hash(h)
+	if allocs := testing.AllocsPerRun(100, func() { x.Hash(h) }); allocs != 0 {
+		t.Errorf("Hash: got %v allocations; want 0", allocs)
+	}
+}
@@ -0,0 +1,209 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements a subquadratic GCD algorithm for very large
+// operands, based on a recursive half-GCD reduction.
+
+package big
+
+// Operands that are at least hgcdThreshold words long are reduced with
+// the half-GCD algorithm before the remaining GCD is computed with
+// Lehmer's algorithm.
+var hgcdThreshold = 1000 // measured with BenchmarkHalfGCD
+
+// hgcd reduces operands that exceed the limit by at least hgcdRecWords
+// words by recursion; smaller reductions use Lehmer's algorithm.
+var hgcdRecWords = 30
+
+// A gcdMatrix is a matrix M = [[m00, m01], [m10, m11]] of non-negative
+// integers with determinant 1. It records the reduction of a pair (a, b)
+// to a pair (x, y), with (a, b) = M (x, y), by a sequence of subtractions
+// of multiples of x from y and vice versa.
+type gcdMatrix struct {
+	m00, m01, m10, m11 Int
+}
+
+// newGcdMatrix returns the identity matrix.
+func newGcdMatrix() *gcdMatrix {
+	m := new(gcdMatrix)
+	m.m00.SetInt64(1)
+	m.m11.SetInt64(1)
+	return m
+}
+
+// isIdentity reports whether m is the identity matrix.
+func (m *gcdMatrix) isIdentity() bool {
+	return len(m.m01.abs) == 0 && len(m.m10.abs) == 0
+}
+
+// mul sets m to m*n.
+func (m *gcdMatrix) mul(n *gcdMatrix) {
+	var t0, t1, u Int
+	t0.Mul(&m.m00, &n.m00)
+	t0.Add(&t0, u.Mul(&m.m01, &n.m10))
+	t1.Mul(&m.m00, &n.m01)
+	m.m01.Mul(&m.m01, &n.m11)
+	m.m01.Add(&m.m01, &t1)
+	m.m00.Set(&t0)
+
+	t0.Mul(&m.m10, &n.m00)
+	t0.Add(&t0, u.Mul(&m.m11, &n.m10))
+	t1.Mul(&m.m10, &n.m01)
+	m.m11.Mul(&m.m11, &n.m11)
+	m.m11.Add(&m.m11, &t1)
+	m.m10.Set(&t0)
+}
+
+// applyInverse sets (x, y) to M**-1 (x, y). Since det M = 1, that is
+// (m11*x - m01*y, m00*y - m10*x).
+func (m *gcdMatrix) applyInverse(x, y *Int) {
+	var t, u Int
+	t.Mul(&m.m11, x)
+	t.Sub(&t, u.Mul(&m.m01, y))
+	y.Mul(&m.m00, y)
+	y.Sub(y, u.Mul(&m.m10, x))
+	x.Set(&t)
+}
+
+// step reduces the larger of x and y by the largest multiple of the
+// smaller one for which it remains >= 2**s, and records the step in m.
+// It reports whether x or y changed. Both x and y must be > 0.
+func (m *gcdMatrix) step(x, y *Int, s uint) bool {
+	big, small := x, y
+	if x.Cmp(y) < 0 {
+		big, small = y, x
+	}
+	var q, t Int
+	t.Lsh(intOne, s)
+	t.Sub(big, &t)
+	if t.Cmp(small) < 0 {
+		return false // q == 0
+	}
+	q.Quo(&t, small)
+	big.Sub(big, t.Mul(&q, small))
+	if big == x {
+		// (a, b) = M (x + q*y, y)
+		m.m01.Add(&m.m01, t.Mul(&q, &m.m00))
+		m.m11.Add(&m.m11, t.Mul(&q, &m.m10))
+	} else {
+		// (a, b) = M (x, y + q*x)
+		m.m00.Add(&m.m00, t.Mul(&q, &m.m01))
+		m.m10.Add(&m.m10, t.Mul(&q, &m.m11))
+	}
+	return true
+}
+
+// lehmerStep reduces x and y by as many steps of the Euclidean algorithm
+// as lehmerSimulate determines from their leading words, and records the
+// steps in m. It reports whether x and y changed, which they do only if
+// both remain >= 2**s.
+func (m *gcdMatrix) lehmerStep(x, y *Int, s uint) bool {
+	A, B := x, y
+	if x.Cmp(y) < 0 {
+		A, B = y, x
+	}
+	if len(B.abs) < 2 {
+		return false
+	}
+	u0, u1, v0, v1, even := lehmerSimulate(A, B)
+	if v0 == 0 {
+		return false
+	}
+	A1 := new(Int).Set(A)
+	B1 := new(Int).Set(B)
+	var q, r, t, u Int
+	lehmerUpdate(A1, B1, &q, &r, &t, &u, u0, u1, v0, v1, even)
+	if B1.BitLen() <= int(s) {
+		return false // B1 < A1 is below the limit
+	}
+
+	// (A, B) = [[v1, v0], [u1, u0]] (A1, B1), where the matrix has the
+	// determinant 1 if even and -1 otherwise. In the latter case, A1 and
+	// B1 are swapped to keep the determinant of m at 1.
+	w := [4]Word{v1, v0, u1, u0}
+	if even {
+		*A, *B = *A1, *B1
+	} else {
+		*A, *B = *B1, *A1
+		w = [4]Word{v0, v1, u0, u1}
+	}
+	if A == y {
+		// (x, y) = [[0, 1], [1, 0]] (A, B)
+		w = [4]Word{w[3], w[2], w[1], w[0]}
+	}
+	var n gcdMatrix
+	n.m00.abs = n.m00.abs.setWord(w[0])
+	n.m01.abs = n.m01.abs.setWord(w[1])
+	n.m10.abs = n.m10.abs.setWord(w[2])
+	n.m11.abs = n.m11.abs.setWord(w[3])
+	m.mul(&n)
+	return true
+}
+
+// hgcd reduces x, y >= 2**s in place by the steps of the Euclidean
+// algorithm, for as long as both remain >= 2**s, and returns the matrix
+// M with (x, y) = M (x', y') for the results x', y'. The entries of M
+// are at most max(x, y) / min(x', y').
+//
+// The reduction of the top halves of x and y is computed recursively
+// and then applied to x and y: for the top parts xh = x>>s and yh = y>>s
+// of k bits, reducing xh and yh as long as both remain >= 2**(k/2+1)
+// yields a matrix with entries < 2**(k-k/2-1). Since the low parts of
+// x and y are < 2**s, the same matrix reduces x and y to values that
+// differ from the reduced top parts times 2**s by less than half, so
+// they remain positive and >= 2**s.
+func hgcd(x, y *Int, s uint) *gcdMatrix {
+	m := newGcdMatrix()
+	for {
+		k := uint(max(x.BitLen(), y.BitLen())) - s
+		s1 := k/2 + 1
+		if k >= uint(hgcdRecWords*_W) && uint(min(x.BitLen(), y.BitLen())) > s+s1 {
+			xh := new(Int).Rsh(x, s)
+			yh := new(Int).Rsh(y, s)
+			if n := hgcd(xh, yh, s1); !n.isIdentity() {
+				n.applyInverse(x, y)
+				m.mul(n)
+			}
+		} else if m.lehmerStep(x, y, s) {
+			continue
+		}
+		if !m.step(x, y, s) {
+			return m
+		}
+	}
+}
+
+// halfGCD is like lehmerGCD, but reduces a and b with hgcd first,
+// which takes subquadratic time for large operands.
+func (z *Int) halfGCD(x, y, a, b *Int) *Int {
+	A := new(Int).Set(a)
+	B := new(Int).Set(b)
+	m := newGcdMatrix()
+	for min(len(A.abs), len(B.abs)) >= hgcdThreshold {
+		n := hgcd(A, B, uint(max(A.BitLen(), B.BitLen())/2))
+		m.mul(n)
+		// Make progress even if hgcd stops early, because the
+		// difference of A and B is small.
+		if !m.step(A, B, 0) {
+			break // A == B
+		}
+	}
+
+	var u, v *Int
+	if x != nil || y != nil {
+		u, v = new(Int), new(Int)
+	}
+	g := new(Int).lehmerGCD(u, v, A, B)
+
+	if x != nil || y != nil {
+		// g = u*A + v*B with (A, B) = M**-1 (a, b), so that
+		// g = (u*m11 - v*m10)*a + (v*m00 - u*m01)*b.
+		var X, t Int
+		X.Mul(u, &m.m11)
+		X.Sub(&X, t.Mul(v, &m.m10))
+		setCofactors(x, y, a, b, g, &X)
+	}
+	z.abs, z.neg = g.abs, g.neg
+	return z
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+func TestHGCD(t *testing.T) {
+	defer func(rec int) { hgcdRecWords = rec }(hgcdRecWords)
+	hgcdRecWords = 2
+	r := rand.New(rand.NewSource(0))
+	for _, size := range []uint{300, 1000, 5000} {
+		for i := 0; i < 10; i++ {
+			x := randInt(r, size)
+			y := randInt(r, size-uint(i)*size/20)
+			a, b := new(Int).Set(x), new(Int).Set(y)
+			s := size / 2
+			m := hgcd(x, y, s)
+			// (a, b) = M (x, y) with x, y >= 2**s
+			var p, q Int
+			p.Mul(&m.m00, x)
+			p.Add(&p, q.Mul(&m.m01, y))
+			if p.Cmp(a) != 0 {
+				t.Errorf("hgcd(%d bits, %d bits): a != m00*x + m01*y", a.BitLen(), b.BitLen())
+			}
+			p.Mul(&m.m10, x)
+			p.Add(&p, q.Mul(&m.m11, y))
+			if p.Cmp(b) != 0 {
+				t.Errorf("hgcd(%d bits, %d bits): b != m10*x + m11*y", a.BitLen(), b.BitLen())
+			}
+			if x.BitLen() <= int(s) || y.BitLen() <= int(s) {
+				t.Errorf("hgcd(%d bits, %d bits) reduced to %d and %d bits; want > %d", a.BitLen(), b.BitLen(), x.BitLen(), y.BitLen(), s)
+			}
+			if d := new(Int).Sub(x, y); d.Abs(d).Cmp(q.Lsh(intOne, s)) >= 0 {
+				t.Errorf("hgcd(%d bits, %d bits) stopped early", a.BitLen(), b.BitLen())
+			}
+		}
+	}
+}
+
+func TestHalfGCD(t *testing.T) {
+	defer func(th, rec int) { hgcdThreshold, hgcdRecWords = th, rec }(hgcdThreshold, hgcdRecWords)
+	r := rand.New(rand.NewSource(1))
+	for _, th := range []int{2, 5} {
+		hgcdThreshold, hgcdRecWords = th, th
+		for _, size := range []uint{200, 1000, 4000} {
+			for i := 0; i < 10; i++ {
+				a := randInt(r, size)
+				b := randInt(r, size-uint(i)*size/30)
+				if i%3 == 0 {
+					// a large common factor
+					g := randInt(r, size/3)
+					a.Mul(a, g)
+					b.Mul(b, g)
+				}
+				if i == 9 {
+					b.Set(a)
+				}
+				d, x, y := euclidGCD(a, b)
+				D, X, Y := new(Int), new(Int), new(Int)
+				D.halfGCD(X, Y, a, b)
+				if D.Cmp(d) != 0 || X.Cmp(x) != 0 || Y.Cmp(y) != 0 {
+					t.Errorf("halfGCD(%s, %s) = %s, %s, %s; want %s, %s, %s", a, b, D, X, Y, d, x, y)
+				}
+				if D.halfGCD(nil, nil, a, b).Cmp(d) != 0 {
+					t.Errorf("halfGCD(%s, %s) = %s; want %s", a, b, D, d)
+				}
+			}
+		}
+	}
+}
+
+func TestModInverseLarge(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	n := randInt(r, 100000)
+	n.SetBit(n, 0, 1)
+	for i := 0; i < 3; i++ {
+		g := new(Int).Rand(r, n)
+		if new(Int).GCD(nil, nil, g, n).Cmp(intOne) != 0 {
+			continue
+		}
+		inv := new(Int).ModInverse(g, n)
+		if inv.Sign() < 0 || inv.Cmp(n) >= 0 {
+			t.Errorf("ModInverse out of range")
+		}
+		if p := new(Int).Mul(g, inv); p.Mod(p, n).Cmp(intOne) != 0 {
+			t.Errorf("ModInverse(g, n)*g mod n != 1")
+		}
+	}
+}
+
+func BenchmarkHalfGCD(b *testing.B) {
+	r := rand.New(rand.NewSource(1234))
+	for _, words := range []int{500, 1000, 2000, 4000, 10000} {
+		x := randInt(r, uint(words*_W))
+		y := randInt(r, uint(words*_W))
+		b.Run(fmt.Sprintf("%d/half", words), func(b *testing.B) {
+			// Reduce at least once, even below the threshold.
+			defer func(th int) { hgcdThreshold = th }(hgcdThreshold)
+			hgcdThreshold = min(hgcdThreshold, words)
+			for i := 0; i < b.N; i++ {
+				new(Int).halfGCD(new(Int), nil, x, y)
+			}
+		})
+		b.Run(fmt.Sprintf("%d/lehmer", words), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				new(Int).lehmerGCD(new(Int), nil, x, y)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the IEEE 754 binary128 and binary256 interchange
+// encodings of Floats.
+
+package big
+
+import "fmt"
+
+// An ieeeFormat describes an IEEE 754 binary interchange format.
+type ieeeFormat struct {
+	name  string // name of the Float method, for panics
+	ebits uint   // exponent size
+	mbits uint   // mantissa size (excluding implicit msb)
+}
+
+var (
+	binary128 = ieeeFormat{"Quad", 15, 112}
+	binary256 = ieeeFormat{"Octuple", 19, 236}
+)
+
+// size returns the size of an encoding in bytes.
+func (f ieeeFormat) size() int {
+	return int(1+f.ebits+f.mbits) / 8
+}
+
+// Quad returns the IEEE 754 binary128 (quadruple precision) encoding of
+// the value nearest to x, as 16 bytes in big-endian order, and its
+// accuracy. Rounding and the handling of values out of range are as for
+// Float64: if x is too small to be represented, the result is the
+// encoding of (0, Below) or (-0, Above), respectively, depending on the
+// sign of x; if x is too large, it is the encoding of (+Inf, Above) or
+// (-Inf, Below).
+func (x *Float) Quad() ([]byte, Accuracy) {
+	return x.ieee(binary128)
+}
+
+// Octuple is like Quad but returns the IEEE 754 binary256 (octuple
+// precision) encoding of x, as 32 bytes in big-endian order.
+func (x *Float) Octuple() ([]byte, Accuracy) {
+	return x.ieee(binary256)
+}
+
+// SetQuad sets z to the (possibly rounded) value of the IEEE 754 binary128
+// number encoded in buf in big-endian order, as returned by Quad, and
+// returns z. If z's precision is 0, it is changed to 113 (and rounding
+// will have no effect). SetQuad panics if len(buf) != 16, and with ErrNaN
+// if buf encodes a NaN.
+func (z *Float) SetQuad(buf []byte) *Float {
+	return z.setIEEE(binary128, buf)
+}
+
+// SetOctuple is like SetQuad but decodes the IEEE 754 binary256 number
+// encoded in buf, as returned by Octuple. If z's precision is 0, it is
+// changed to 237. SetOctuple panics if len(buf) != 32, and with ErrNaN if
+// buf encodes a NaN.
+func (z *Float) SetOctuple(buf []byte) *Float {
+	return z.setIEEE(binary256, buf)
+}
+
+// ieee implements Quad and Octuple for the format f, as Float64 does for
+// the IEEE 754 binary64 format, with the encoding in a nat.
+func (x *Float) ieee(f ieeeFormat) ([]byte, Accuracy) {
+	if debugFloat {
+		x.validate()
+	}
+
+	var (
+		bias = int64(1)<<(f.ebits-1) - 1 // exponent bias
+		emin = 1 - bias                  // smallest unbiased exponent (normal)
+		emax = bias                      // largest unbiased exponent (normal)
+	)
+
+	var bits nat
+	acc := Exact
+	switch x.form {
+	case finite:
+		// Float mantissa m is 0.5 <= m < 1.0; compute exponent e for a
+		// normal mantissa m with 1.0 <= m < 2.0.
+		e := int64(x.exp) - 1
+
+		// Compute precision p for the mantissa; a denormal number has
+		// fewer bits of precision (see Float64).
+		p := int64(f.mbits) + 1
+		if e < emin {
+			p = int64(f.mbits) + 1 - emin + e
+			if p < 0 /* m <= 0.25 */ || p == 0 && x.mant.sticky(uint(len(x.mant))*_W-1) == 0 /* m == 0.5 */ {
+				// underflow to ±0
+				acc = makeAcc(x.neg)
+				break
+			}
+			if p == 0 {
+				// round up to the smallest denormal
+				bits = bits.setWord(1)
+				acc = makeAcc(!x.neg)
+				break
+			}
+		}
+		// p > 0
+
+		// round
+		var r Float
+		r.prec = uint32(p)
+		r.Set(x)
+		e = int64(r.exp) - 1
+
+		if r.form == inf || e > emax {
+			// overflow
+			bits = ieeeInf(f)
+			acc = makeAcc(!x.neg)
+			break
+		}
+		// e <= emax
+
+		// Rounding may have caused a denormal number to become normal.
+		if e < emin {
+			// denormal number: the biased exponent is 0
+			p = int64(f.mbits) + 1 - emin + e
+			bits = msbits(r.mant, uint(p))
+		} else {
+			// normal number: emin <= e <= emax
+			bits = msbits(r.mant, f.mbits+1)
+			bits = bits.setBit(bits, f.mbits, 0) // cut off msb (implicit 1 bit)
+			bexp := nat(nil).setUint64(uint64(e + bias))
+			bits = bits.add(bits, bexp.shl(bexp, f.mbits))
+		}
+		acc = r.acc
+
+	case inf:
+		bits = ieeeInf(f)
+	}
+
+	if x.neg {
+		bits = bits.setBit(bits, f.ebits+f.mbits, 1)
+	}
+	buf := make([]byte, f.size())
+	bits.bytes(buf)
+	return buf, acc
+}
+
+// msbits returns the n most significant bits of the normalized mantissa
+// x, as an integer.
+func msbits(x nat, n uint) nat {
+	s := int64(len(x))*_W - int64(n)
+	if s < 0 {
+		return nat(nil).shl(x, uint(-s))
+	}
+	return nat(nil).shr(x, uint(s))
+}
+
+// ieeeInf returns the encoding of +Inf in the format f.
+func ieeeInf(f ieeeFormat) nat {
+	z := nat(nil).setUint64(1<<f.ebits - 1)
+	return z.shl(z, f.mbits)
+}
+
+// setIEEE implements SetQuad and SetOctuple for the format f.
+func (z *Float) setIEEE(f ieeeFormat, buf []byte) *Float {
+	if len(buf) != f.size() {
+		panic(fmt.Sprintf("math/big: Set%s of %d bytes; want %d", f.name, len(buf), f.size()))
+	}
+	if z.prec == 0 {
+		z.prec = uint32(f.mbits) + 1
+	}
+
+	// buf = sign | biased exponent | mantissa
+	bits := nat(nil).setBytes(buf)
+	neg := bits.bit(f.ebits+f.mbits) != 0
+	bits = bits.setBit(bits, f.ebits+f.mbits, 0)
+	bexp := nat(nil).shr(bits, f.mbits)
+	mant := bits.sub(bits, nat(nil).shl(bexp, f.mbits))
+	be := int64(low64(bexp))
+
+	bias := int64(1)<<(f.ebits-1) - 1
+	switch be {
+	case 1<<f.ebits - 1:
+		if len(mant) != 0 {
+			panic(ErrNaN{"Float.Set" + f.name + "(NaN)"})
+		}
+		z.acc = Exact
+		z.form = inf
+		z.neg = neg
+		return z
+	case 0:
+		// ±0 or denormal number, with the exponent of the smallest normal
+		be = 1
+	default:
+		mant = mant.setBit(mant, f.mbits, 1) // implicit 1 bit
+	}
+	return z.setScaled(&Int{neg: neg, abs: mant}, be-bias-int64(f.mbits))
+}
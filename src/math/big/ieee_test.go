@@ -0,0 +1,207 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// TestFloatIEEE64 checks the encoding and decoding of the IEEE 754 formats
+// against Float64 and SetFloat64, and against Float32, with the
+// same code instantiated for binary64 and binary32.
+func TestFloatIEEE64(t *testing.T) {
+	binary64 := ieeeFormat{"Float64", 11, 52}
+	binary32 := ieeeFormat{"Float32", 8, 23}
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 10000; i++ {
+		// values around the normal and denormal ranges of both formats,
+		// and beyond
+		exp := 200
+		switch i % 4 {
+		case 1:
+			exp = 1100
+		case 2:
+			exp = 1200
+		}
+		x := randFloat(r, uint(r.Intn(100)+1), exp)
+		if i%10 == 0 {
+			x.SetMantExp(x, -1075-x.MantExp(nil)+r.Intn(5))
+		}
+
+		f64, acc64 := x.Float64()
+		buf, acc := x.ieee(binary64)
+		if got := binary.BigEndian.Uint64(buf); got != math.Float64bits(f64) || acc != acc64 {
+			t.Errorf("%s: binary64 encoding %#016x (%s); want %#016x (%s)", x.Text('p', 0), got, acc, math.Float64bits(f64), acc64)
+		}
+		if !math.IsInf(f64, 0) {
+			z := new(Float).setIEEE(binary64, buf)
+			if want := new(Float).SetFloat64(f64); z.Prec() != 53 || z.Cmp(want) != 0 || z.Signbit() != want.Signbit() {
+				t.Errorf("%#016x: decoded %s, precision %d; want %s", binary.BigEndian.Uint64(buf), z.Text('p', 0), z.Prec(), want.Text('p', 0))
+			}
+		}
+
+		f32, acc32 := x.Float32()
+		buf, acc = x.ieee(binary32)
+		if got := binary.BigEndian.Uint32(buf); got != math.Float32bits(f32) || acc != acc32 {
+			t.Errorf("%s: binary32 encoding %#08x (%s); want %#08x (%s)", x.Text('p', 0), got, acc, math.Float32bits(f32), acc32)
+		}
+	}
+}
+
+func TestFloatQuad(t *testing.T) {
+	for _, test := range []struct {
+		x   string
+		enc string
+		acc Accuracy
+	}{
+		{"0", "00000000000000000000000000000000", Exact},
+		{"-0", "80000000000000000000000000000000", Exact},
+		{"1", "3fff0000000000000000000000000000", Exact},
+		{"-2", "c0000000000000000000000000000000", Exact},
+		{"0.1", "3ffb999999999999999999999999999a", Above},
+		{"+Inf", "7fff0000000000000000000000000000", Exact},
+		{"-Inf", "ffff0000000000000000000000000000", Exact},
+		// largest normal, smallest normal, largest and smallest denormal
+		{"0x1.ffffffffffffffffffffffffffffp16383", "7ffeffffffffffffffffffffffffffff", Exact},
+		{"0x1p-16382", "00010000000000000000000000000000", Exact},
+		{"0x0.ffffffffffffffffffffffffffffp-16382", "0000ffffffffffffffffffffffffffff", Exact},
+		{"-0x1p-16494", "80000000000000000000000000000001", Exact},
+		// rounding, overflow, and underflow
+		{"0x1.ffffffffffffffffffffffffffff8p16383", "7fff0000000000000000000000000000", Above},
+		{"-0x1.ffffffffffffffffffffffffffff7p16383", "fffeffffffffffffffffffffffffffff", Above},
+		{"0x1p-16495", "00000000000000000000000000000000", Below},
+		{"-0x1.000001p-16495", "80000000000000000000000000000001", Below},
+		{"0x1.8p-16494", "00000000000000000000000000000002", Above},
+		{"0x1.fffffffffffffffffffffffffffff8p-16383", "00010000000000000000000000000000", Above},
+	} {
+		x := makeFloat(test.x)
+		buf, acc := x.Quad()
+		if got := hex.EncodeToString(buf); got != test.enc || acc != test.acc {
+			t.Errorf("Quad(%s) = %s (%s); want %s (%s)", test.x, got, acc, test.enc, test.acc)
+		}
+		z := new(Float).SetQuad(buf)
+		want := new(Float).SetPrec(113).Set(x)
+		if test.acc != Exact {
+			want = nil
+		}
+		if z.Prec() != 113 || want != nil && (z.Cmp(want) != 0 || z.Signbit() != want.Signbit()) {
+			t.Errorf("SetQuad(%s) = %s, precision %d; want %s", test.enc, z.Text('p', 0), z.Prec(), test.x)
+		}
+		if got, _ := z.Quad(); !bytes.Equal(got, buf) {
+			t.Errorf("Quad(SetQuad(%s)) = %x", test.enc, got)
+		}
+	}
+
+	// π, as in the quadmath library
+	if buf, acc := Pi(200).Quad(); hex.EncodeToString(buf) != "4000921fb54442d18469898cc51701b8" || acc != Below {
+		t.Errorf("Quad(π) = %x (%s); want 4000921fb54442d18469898cc51701b8 (Below)", buf, acc)
+	}
+
+	// a shorter precision rounds the decoded value
+	buf, _ := NewFloat(1.75).Quad()
+	if z := new(Float).SetPrec(1).SetQuad(buf); z.Cmp(NewFloat(2)) != 0 || z.Acc() != Above {
+		t.Errorf("SetQuad(1.75) at precision 1 = %s (%s); want 2 (Above)", z.Text('g', 10), z.Acc())
+	}
+}
+
+func TestFloatOctuple(t *testing.T) {
+	for _, test := range []struct {
+		x   string
+		enc string
+		acc Accuracy
+	}{
+		{"1", "3ffff00000000000000000000000000000000000000000000000000000000000", Exact},
+		{"-0x1.8p-262142", "8000180000000000000000000000000000000000000000000000000000000000", Exact},
+		{"0x1p-262378", "0000000000000000000000000000000000000000000000000000000000000001", Exact},
+		{"-Inf", "fffff00000000000000000000000000000000000000000000000000000000000", Exact},
+		{"0x1p262144", "7ffff00000000000000000000000000000000000000000000000000000000000", Above},
+		{"1e-1000000", "0000000000000000000000000000000000000000000000000000000000000000", Below},
+	} {
+		x := makeFloat(test.x)
+		buf, acc := x.Octuple()
+		if got := hex.EncodeToString(buf); got != test.enc || acc != test.acc {
+			t.Errorf("Octuple(%s) = %s (%s); want %s (%s)", test.x, got, acc, test.enc, test.acc)
+		}
+	}
+
+	// the encoding of a value of precision 237 decodes to that value
+	x := Pi(237)
+	buf, acc := x.Octuple()
+	if z := new(Float).SetOctuple(buf); acc != Exact || z.Prec() != 237 || z.Cmp(x) != 0 {
+		t.Errorf("SetOctuple(Octuple(π)) = %s (%s); want %s", z.Text('p', 0), acc, x.Text('p', 0))
+	}
+}
+
+// TestFloatIEEERoundTrip checks that decoding and encoding random non-NaN
+// encodings gives them back.
+func TestFloatIEEERoundTrip(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, f := range []ieeeFormat{binary128, binary256} {
+		buf := make([]byte, f.size())
+		for i := 0; i < 1000; i++ {
+			r.Read(buf)
+			if i%4 == 0 {
+				// denormal numbers, with a biased exponent of 0
+				buf[0] &= 0x80
+				buf[1] = 0
+				if f.ebits > 15 {
+					buf[2] &= 0x0f
+				}
+			}
+			z, nan := func() (z *Float, nan bool) {
+				defer func() {
+					_, nan = recover().(ErrNaN)
+				}()
+				return new(Float).setIEEE(f, buf), false
+			}()
+			if nan {
+				continue
+			}
+			if got, acc := z.ieee(f); !bytes.Equal(got, buf) || acc != Exact {
+				t.Errorf("%s(Set%s(%x)) = %x (%s)", f.name, f.name, buf, got, acc)
+			}
+		}
+	}
+}
+
+func TestFloatIEEEPanic(t *testing.T) {
+	for _, test := range []struct {
+		f   func(buf []byte) *Float
+		enc string
+		nan bool
+	}{
+		{new(Float).SetQuad, "7fff0000000000000000000000000001", true},
+		{new(Float).SetQuad, "ffff8000000000000000000000000000", true},
+		{new(Float).SetOctuple, "7ffff80000000000000000000000000000000000000000000000000000000000", true},
+		{new(Float).SetQuad, "3fff", false},
+		{new(Float).SetOctuple, "3fff0000000000000000000000000000", false},
+	} {
+		buf, _ := hex.DecodeString(test.enc)
+		func() {
+			defer func() {
+				if _, nan := recover().(ErrNaN); nan != test.nan {
+					t.Errorf("decoding %s: got NaN panic %v; want %v", test.enc, nan, test.nan)
+				}
+			}()
+			test.f(buf)
+			t.Errorf("decoding %s did not panic", test.enc)
+		}()
+	}
+}
+
+func BenchmarkFloatQuad(b *testing.B) {
+	x := Pi(113)
+	buf, _ := x.Quad()
+	z := new(Float)
+	for i := 0; i < b.N; i++ {
+		buf, _ = x.Quad()
+		z.SetQuad(buf)
+	}
+}
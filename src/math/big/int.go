@@ -7,20 +7,23 @@
 package big
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"math"
 	"math/rand"
-	"strings"
+	"sync"
 )
 
 // An Int represents a signed multi-precision integer.
 // The zero value for an Int represents the value 0.
 type Int struct {
-	neg bool // sign
-	abs nat  // absolute value of the integer
+	neg  bool // sign
+	abs  nat  // absolute value of the integer
+	zcap int  // if > 0, constant-time width in words (see SetConstantTime)
 }
 
-var intOne = &Int{false, natOne}
+var intOne = &Int{abs: natOne}
 
 // Sign returns:
 //
@@ -67,6 +70,9 @@ func (z *Int) Set(x *Int) *Int {
 	if z != x {
 		z.abs = z.abs.set(x.abs)
 		z.neg = x.neg
+		if z.zcap|x.zcap != 0 {
+			z.zcap = max(x.ctWords(), 1)
+		}
 	}
 	return z
 }
@@ -80,6 +86,33 @@ func (x *Int) Bits() []Word {
 	return x.abs
 }
 
+// WordLen returns the length of the absolute value of x in Words, the
+// length of x.Bits(). The WordLen of 0 is 0.
+func (x *Int) WordLen() int {
+	return len(x.abs)
+}
+
+// Word returns the i'th least significant Word of the absolute value of
+// x, or 0 if i >= x.WordLen(). The index i must be >= 0. Unlike Bits,
+// Word doesn't give access to the words of x.
+func (x *Int) Word(i int) Word {
+	if i < 0 {
+		panic("math/big: negative word index")
+	}
+	if i >= len(x.abs) {
+		return 0
+	}
+	return x.abs[i]
+}
+
+// CopyBits copies the absolute value of x as a little-endian Word slice
+// into dst, like the built-in copy, and returns the number of Words
+// copied, the minimum of len(dst) and x.WordLen(). Unlike Bits, the words
+// are copied, so dst and x don't share an array.
+func (x *Int) CopyBits(dst []Word) int {
+	return copy(dst, x.abs)
+}
+
 // SetBits provides raw (unchecked but fast) access to z by setting its
 // value to abs, interpreted as a little-endian Word slice, and returning
 // z. The result and abs share the same underlying array.
@@ -107,6 +140,12 @@ func (z *Int) Neg(x *Int) *Int {
 
 // Add sets z to the sum x+y and returns z.
 func (z *Int) Add(x, y *Int) *Int {
+	if z.zcap|x.zcap|y.zcap != 0 {
+		return z.addCT(x, y, boolWord(y.neg))
+	}
+	if varTimeDisabled() {
+		return z.addCT(x, y, boolWord(y.neg)).SetConstantTime(0)
+	}
 	neg := x.neg
 	if x.neg == y.neg {
 		// x + y == x + y
@@ -128,6 +167,12 @@ func (z *Int) Add(x, y *Int) *Int {
 
 // Sub sets z to the difference x-y and returns z.
 func (z *Int) Sub(x, y *Int) *Int {
+	if z.zcap|x.zcap|y.zcap != 0 {
+		return z.addCT(x, y, boolWord(!y.neg))
+	}
+	if varTimeDisabled() {
+		return z.addCT(x, y, boolWord(!y.neg)).SetConstantTime(0)
+	}
 	neg := x.neg
 	if x.neg != y.neg {
 		// x - (-y) == x + y
@@ -153,11 +198,79 @@ func (z *Int) Mul(x, y *Int) *Int {
 	// x * (-y) == -(x * y)
 	// (-x) * y == -(x * y)
 	// (-x) * (-y) == x * y
+	if z.zcap|x.zcap|y.zcap != 0 {
+		return z.mulCT(x, y)
+	}
+	if varTimeDisabled() {
+		return z.mulCT(x, y).SetConstantTime(0)
+	}
 	z.abs = z.abs.mul(x.abs, y.abs)
 	z.neg = len(z.abs) > 0 && x.neg != y.neg // 0 has no sign
 	return z
 }
 
+// MulAdd sets z to the product x*y plus c and returns z.
+//
+// If the product and c have the same sign and the shorter factor is
+// short, the product is accumulated into c's value in z, without an
+// intermediate product. In loops such as Horner's method, z.MulAdd(z,
+// x, c) reuses a temporary instead of allocating one per step.
+func (z *Int) MulAdd(x, y, c *Int) *Int {
+	if z.zcap|x.zcap|y.zcap|c.zcap != 0 || varTimeDisabled() {
+		var t Int
+		t.Mul(x, y)
+		return z.Add(&t, c)
+	}
+	if alias(z.abs, x.abs) || alias(z.abs, y.abs) {
+		t := getInt()
+		t.mulAdd(x, y, c)
+		z.abs = z.abs.set(t.abs) // don't pool storage the caller may own
+		z.neg = t.neg
+		putInt(t)
+		return z
+	}
+	return z.mulAdd(x, y, c)
+}
+
+// mulAdd implements MulAdd for z not aliasing x or y.
+func (z *Int) mulAdd(x, y, c *Int) *Int {
+	neg := x.neg != y.neg
+	u, v := x.abs, y.abs
+	if len(u) < len(v) {
+		u, v = v, u
+	}
+	if len(v) == 0 {
+		return z.Set(c)
+	}
+	if neg != c.neg && len(c.abs) > 0 || len(v) >= karatsubaThreshold {
+		t := getInt()
+		t.abs = t.abs.mul(u, v)
+		t.neg = neg
+		z.Add(t, c)
+		putInt(t)
+		return z
+	}
+
+	// z = c + u*v by schoolbook multiplication into a copy of |c|
+	m := len(c.abs)
+	n := max(m, len(u)+len(v)) + 1
+	r := z.abs.make(n)
+	copy(r, c.abs)
+	r[m:].clear()
+	for i, d := range v {
+		if d != 0 {
+			j := i + len(u)
+			h := addMulVVW(r[i:j], u, d)
+			if r[j] += h; r[j] < h {
+				addVW(r[j+1:], r[j+1:], 1) // no carry out since len(r) > len(u)+len(v)
+			}
+		}
+	}
+	z.abs = r.norm()
+	z.neg = len(z.abs) > 0 && neg
+	return z
+}
+
 // MulRange sets z to the product of all integers
 // in the range [a, b] inclusively and returns z.
 // If a > b (empty range), the result is 1.
@@ -181,23 +294,140 @@ func (z *Int) MulRange(a, b int64) *Int {
 	return z
 }
 
+// FallingFactorial sets z to the falling factorial x*(x-1)*...*(x-n+1)
+// of n factors and returns z. If n <= 0, z is set to the empty product 1.
+func (z *Int) FallingFactorial(x, n int64) *Int {
+	if n <= 0 {
+		return z.SetInt64(1)
+	}
+	if x < 0 {
+		// (-a)*(-a-1)*...*(-a-n+1) = (-1)**n * a*(a+1)*...*(a+n-1)
+		z.abs = z.abs.mulRange(uint64(-x), uint64(-x)+uint64(n)-1)
+		z.neg = n&1 != 0
+		return z
+	}
+	if n > x {
+		return z.SetInt64(0) // x-n+1 <= 0 <= x
+	}
+	z.abs = z.abs.mulRange(uint64(x-n+1), uint64(x))
+	z.neg = false
+	return z
+}
+
+// RisingFactorial sets z to the rising factorial x*(x+1)*...*(x+n-1)
+// of n factors, the Pochhammer symbol (x)_n, and returns z. If n <= 0,
+// z is set to the empty product 1.
+func (z *Int) RisingFactorial(x, n int64) *Int {
+	if n <= 0 {
+		return z.SetInt64(1)
+	}
+	if x < 0 {
+		// (-a)*(-a+1)*...*(-a+n-1) = (-1)**n * a*(a-1)*...*(a-n+1)
+		a := uint64(-x)
+		if uint64(n) > a {
+			return z.SetInt64(0) // x <= 0 <= x+n-1
+		}
+		z.abs = z.abs.mulRange(a-uint64(n)+1, a)
+		z.neg = n&1 != 0
+		return z
+	}
+	z.abs = z.abs.mulRange(uint64(x), uint64(x)+uint64(n)-1)
+	z.neg = false
+	return z
+}
+
+// DoubleFactorial sets z to the double factorial n!! = n*(n-2)*(n-4)*...,
+// the product of the positive integers up to n of the parity of n, and
+// returns z. If n <= 0, z is set to the empty product 1.
+func (z *Int) DoubleFactorial(n int64) *Int {
+	if n <= 0 {
+		return z.SetInt64(1)
+	}
+	z.abs = z.abs.doubleFactorial(uint64(n))
+	z.neg = false
+	return z
+}
+
 // Binomial sets z to the binomial coefficient of (n, k) and returns z.
 func (z *Int) Binomial(n, k int64) *Int {
 	// reduce the number of multiplications by reducing k
 	if n/2 < k && k <= n {
 		k = n - k // Binomial(n, k) == Binomial(n, n-k)
 	}
+	if 0 <= k && k <= n && useFactoredMultinomial(uint64(n), uint64(k)) {
+		z.abs = z.abs.multinomial(uint64(n), []uint64{uint64(k), uint64(n - k)})
+		z.neg = false
+		return z
+	}
 	var a, b Int
 	a.MulRange(n-k+1, n)
 	b.MulRange(1, k)
-	return z.Quo(&a, &b)
+	// k! divides the product of any k consecutive integers
+	z.abs = z.abs.divExact(a.abs, b.abs)
+	z.neg = len(z.abs) > 0 && a.neg // b > 0
+	return z
+}
+
+// Multinomial sets z to the multinomial coefficient
+//
+//	n! / (ks[0]! · ks[1]! · … · ks[m-1]! · (n - ks[0] - … - ks[m-1])!)
+//
+// and returns z, the number of ways to divide n objects into groups of
+// the sizes ks and the remainder; Multinomial(n, k) is Binomial(n, k).
+// If n or any of the ks is negative, or the ks sum to more than n,
+// Multinomial sets z to 0.
+func (z *Int) Multinomial(n int64, ks ...int64) *Int {
+	if n < 0 {
+		return z.SetInt64(0)
+	}
+	parts := make([]uint64, len(ks)+1)
+	rest := n
+	for i, k := range ks {
+		if k < 0 || k > rest {
+			return z.SetInt64(0)
+		}
+		parts[i] = uint64(k)
+		rest -= k
+	}
+	parts[len(ks)] = uint64(rest)
+
+	// The factorial of the largest part cancels out of n!.
+	l := 0
+	for i, k := range parts {
+		if k > parts[l] {
+			l = i
+		}
+	}
+	if useFactoredMultinomial(uint64(n), uint64(n)-parts[l]) {
+		z.abs = z.abs.multinomial(uint64(n), parts)
+		z.neg = false
+		return z
+	}
+	a := nat(nil).mulRange(parts[l]+1, uint64(n))
+	b := nat(nil).setWord(1)
+	for i, k := range parts {
+		if i != l {
+			b = b.mul(b, nat(nil).mulRange(1, k))
+		}
+	}
+	z.abs = z.abs.divExact(a, b)
+	z.neg = false
+	return z
 }
 
 // Quo sets z to the quotient x/y for y != 0 and returns z.
 // If y == 0, a division-by-zero run-time panic occurs.
 // Quo implements truncated division (like Go); see QuoRem for more details.
 func (z *Int) Quo(x, y *Int) *Int {
-	z.abs, _ = z.abs.div(nil, x.abs, y.abs)
+	if z.zcap|x.zcap|y.zcap != 0 {
+		z, _ = z.quoRemCT(x, y, nil)
+		return z
+	}
+	if varTimeDisabled() {
+		z, _ = z.quoRemCT(x, y, nil)
+		return z.SetConstantTime(0)
+	}
+	z.abs = z.abs.quo(x.abs, y.abs)
 	z.neg = len(z.abs) > 0 && x.neg != y.neg // 0 has no sign
 	return z
 }
@@ -206,7 +436,15 @@ func (z *Int) Quo(x, y *Int) *Int {
 // If y == 0, a division-by-zero run-time panic occurs.
 // Rem implements truncated modulus (like Go); see QuoRem for more details.
 func (z *Int) Rem(x, y *Int) *Int {
-	_, z.abs = nat(nil).div(z.abs, x.abs, y.abs)
+	if z.zcap|x.zcap|y.zcap != 0 {
+		new(Int).quoRemCT(x, y, z)
+		return z
+	}
+	if varTimeDisabled() {
+		new(Int).quoRemCT(x, y, z)
+		return z.SetConstantTime(0)
+	}
+	z.abs = z.abs.rem(x.abs, y.abs)
 	z.neg = len(z.abs) > 0 && x.neg // 0 has no sign
 	return z
 }
@@ -224,6 +462,13 @@ func (z *Int) Rem(x, y *Int) *Int {
 // See DivMod for Euclidean division and modulus (unlike Go).
 //
 func (z *Int) QuoRem(x, y, r *Int) (*Int, *Int) {
+	if z.zcap|x.zcap|y.zcap|r.zcap != 0 {
+		return z.quoRemCT(x, y, r)
+	}
+	if varTimeDisabled() {
+		z.quoRemCT(x, y, r)
+		return z.SetConstantTime(0), r.SetConstantTime(0)
+	}
 	z.abs, r.abs = z.abs.div(r.abs, x.abs, y.abs)
 	z.neg, r.neg = len(z.abs) > 0 && x.neg != y.neg, len(r.abs) > 0 && x.neg // 0 has no sign
 	return z, r
@@ -233,10 +478,23 @@ func (z *Int) QuoRem(x, y, r *Int) (*Int, *Int) {
 // If y == 0, a division-by-zero run-time panic occurs.
 // Div implements Euclidean division (unlike Go); see DivMod for more details.
 func (z *Int) Div(x, y *Int) *Int {
+	if z.zcap|x.zcap|y.zcap != 0 {
+		z, _ = z.divModCT(x, y, nil)
+		return z
+	}
+	if varTimeDisabled() {
+		z, _ = z.divModCT(x, y, nil)
+		return z.SetConstantTime(0)
+	}
 	y_neg := y.neg // z may be an alias for y
-	var r Int
-	z.QuoRem(x, y, &r)
-	if r.neg {
+	rp := getNat(0)
+	var r nat
+	z.abs, r = z.abs.div(*rp, x.abs, y.abs)
+	r_neg := len(r) > 0 && x.neg
+	z.neg = len(z.abs) > 0 && x.neg != y_neg // 0 has no sign
+	*rp = r
+	putNat(rp)
+	if r_neg {
 		if y_neg {
 			z.Add(z, intOne)
 		} else {
@@ -250,12 +508,18 @@ func (z *Int) Div(x, y *Int) *Int {
 // If y == 0, a division-by-zero run-time panic occurs.
 // Mod implements Euclidean modulus (unlike Go); see DivMod for more details.
 func (z *Int) Mod(x, y *Int) *Int {
+	if z.zcap|x.zcap|y.zcap != 0 {
+		return z.modCT(x, y)
+	}
+	if varTimeDisabled() {
+		return z.modCT(x, y).SetConstantTime(0)
+	}
 	y0 := y // save y
 	if z == y || alias(z.abs, y.abs) {
 		y0 = new(Int).Set(y)
 	}
-	var q Int
-	q.QuoRem(x, y, z)
+	z.abs = z.abs.rem(x.abs, y.abs)
+	z.neg = len(z.abs) > 0 && x.neg // 0 has no sign
 	if z.neg {
 		if y0.neg {
 			z.Sub(z, y0)
@@ -282,6 +546,13 @@ func (z *Int) Mod(x, y *Int) *Int {
 // See QuoRem for T-division and modulus (like Go).
 //
 func (z *Int) DivMod(x, y, m *Int) (*Int, *Int) {
+	if z.zcap|x.zcap|y.zcap|m.zcap != 0 {
+		return z.divModCT(x, y, m)
+	}
+	if varTimeDisabled() {
+		z.divModCT(x, y, m)
+		return z.SetConstantTime(0), m.SetConstantTime(0)
+	}
 	y0 := y // save y
 	if z == y || alias(z.abs, y.abs) {
 		y0 = new(Int).Set(y)
@@ -299,6 +570,65 @@ func (z *Int) DivMod(x, y, m *Int) (*Int, *Int) {
 	return z, m
 }
 
+// DivRound sets z to the quotient x/y for y != 0, rounded to an integer
+// according to the given rounding mode, and returns z. For instance,
+// the quotient -7/2 is rounded to -4 by ToNearestEven and ToNegativeInf,
+// and to -3 by ToZero, which is the rounding of Quo. If y == 0, a
+// division-by-zero run-time panic occurs. DivRound runs in variable
+// time; if z, x, or y is marked as constant-time (see SetConstantTime),
+// DivRound panics.
+func (z *Int) DivRound(x, y *Int, mode RoundingMode) *Int {
+	if z.zcap|x.zcap|y.zcap != 0 {
+		panic("math/big: DivRound of value marked as constant-time")
+	}
+	if mode > ToPositiveInf {
+		panic("math/big: invalid rounding mode")
+	}
+	// The quotient is computed in temporaries, so that y is intact for
+	// the rounding even if z aliases it.
+	q, r := getInt(), getInt()
+	q.abs, r.abs = q.abs.div(r.abs, x.abs, y.abs)
+	neg := x.neg != y.neg // sign of the exact quotient
+
+	// |x/y| = |q| + |r|/|y| with 0 < |r| < |y| if inexact; increment
+	// |q| to round away from zero
+	inc := false
+	if len(r.abs) > 0 {
+		switch mode {
+		case ToZero:
+		case AwayFromZero:
+			inc = true
+		case ToNegativeInf:
+			inc = neg
+		case ToPositiveInf:
+			inc = !neg
+		case ToNearestEven, ToNearestAway:
+			r.abs = r.abs.shl(r.abs, 1)
+			switch r.abs.cmp(y.abs) {
+			case 1:
+				inc = true
+			case 0:
+				inc = mode == ToNearestAway || len(q.abs) > 0 && q.abs[0]&1 != 0
+			}
+		}
+	}
+	if inc {
+		q.abs = q.abs.add(q.abs, natOne)
+	}
+	z.abs = z.abs.set(q.abs) // don't pool storage the caller may own
+	z.neg = len(z.abs) > 0 && neg  // 0 has no sign
+	putInt(q)
+	putInt(r)
+	return z
+}
+
+// DivCeil sets z to the quotient x/y for y != 0, rounded toward positive
+// infinity, and returns z. It is shorthand for z.DivRound(x, y,
+// ToPositiveInf).
+func (z *Int) DivCeil(x, y *Int) *Int {
+	return z.DivRound(x, y, ToPositiveInf)
+}
+
 // Cmp compares x and y and returns:
 //
 //   -1 if x <  y
@@ -306,6 +636,9 @@ func (z *Int) DivMod(x, y, m *Int) (*Int, *Int) {
 //   +1 if x >  y
 //
 func (x *Int) Cmp(y *Int) (r int) {
+	if x.zcap|y.zcap != 0 || varTimeDisabled() {
+		return x.cmpCT(y)
+	}
 	// x cmp y == x cmp y
 	// x cmp (-y) == x
 	// (-x) cmp y == y
@@ -324,6 +657,69 @@ func (x *Int) Cmp(y *Int) (r int) {
 	return
 }
 
+// CmpAbs compares the absolute values of x and y and returns:
+//
+//   -1 if |x| <  |y|
+//    0 if |x| == |y|
+//   +1 if |x| >  |y|
+//
+func (x *Int) CmpAbs(y *Int) int {
+	if x.zcap|y.zcap != 0 || varTimeDisabled() {
+		return x.cmpAbsCT(y)
+	}
+	return x.abs.cmp(y.abs)
+}
+
+// CmpInt64 compares x and y and returns -1, 0, or +1 like x.Cmp(y) for
+// an Int y, without allocating a temporary Int for y.
+func (x *Int) CmpInt64(y int64) int {
+	u := uint64(y)
+	if y < 0 {
+		u = -u
+	}
+	return x.cmpSmall(u, y < 0)
+}
+
+// CmpUint64 compares x and y and returns -1, 0, or +1 like x.Cmp(y) for
+// an Int y, without allocating a temporary Int for y.
+func (x *Int) CmpUint64(y uint64) int {
+	return x.cmpSmall(y, false)
+}
+
+// EqInt64 reports whether x == y, like x.CmpInt64(y) == 0.
+func (x *Int) EqInt64(y int64) bool {
+	return x.CmpInt64(y) == 0
+}
+
+// cmpSmall compares x and the value y with absolute value u, which is
+// negative if neg is set and u != 0, like Cmp.
+func (x *Int) cmpSmall(u uint64, neg bool) int {
+	neg = neg && u != 0 // 0 has no sign
+	if x.zcap != 0 || varTimeDisabled() {
+		return x.cmpCT(&Int{abs: nat(nil).setUint64(u), neg: neg})
+	}
+	if x.neg != neg {
+		if x.neg {
+			return -1
+		}
+		return 1
+	}
+	// |x| > u if |x| doesn't fit in 64 bits
+	r := 1
+	if len(x.abs) <= 64/_W {
+		switch v := low64(x.abs); {
+		case v < u:
+			r = -1
+		case v == u:
+			r = 0
+		}
+	}
+	if neg {
+		r = -r
+	}
+	return r
+}
+
 // low32 returns the least significant 32 bits of x.
 func low32(x nat) uint32 {
 	if len(x) == 0 {
@@ -374,26 +770,99 @@ func (x *Int) IsUint64() bool {
 	return !x.neg && len(x.abs) <= 64/_W
 }
 
+// Float64 returns the float64 value nearest x,
+// and an indication of any rounding that occurred.
+func (x *Int) Float64() (float64, Accuracy) {
+	n := x.abs.bitLen()
+	if n == 0 {
+		return 0.0, Exact
+	}
+
+	// Fast path: no more than 53 significant bits.
+	if n <= 53 || n < 64 && n-int(x.abs.trailingZeroBits()) <= 53 {
+		f := float64(low64(x.abs))
+		if x.neg {
+			f = -f
+		}
+		return f, Exact
+	}
+
+	return new(Float).SetInt(x).Float64()
+}
+
+// Int64Sat returns the int64 value closest to x: x itself if x can be
+// represented in an int64, math.MinInt64 if x is smaller, and
+// math.MaxInt64 if x is larger.
+func (x *Int) Int64Sat() int64 {
+	switch {
+	case x.IsInt64():
+		return x.Int64()
+	case x.neg:
+		return math.MinInt64
+	}
+	return math.MaxInt64
+}
+
+// Uint64Sat returns the uint64 value closest to x: x itself if x can be
+// represented in a uint64, 0 if x is negative, and math.MaxUint64 if x
+// is larger.
+func (x *Int) Uint64Sat() uint64 {
+	switch {
+	case x.IsUint64():
+		return x.Uint64()
+	case x.neg:
+		return 0
+	}
+	return math.MaxUint64
+}
+
+// ErrRange is returned by Int64Checked and Uint64Checked if the value
+// does not fit the result type.
+var ErrRange = errors.New("math/big: value out of range")
+
+// Int64Checked returns the int64 representation of x and a nil error,
+// or Int64Sat(x) and ErrRange if x cannot be represented in an int64.
+func (x *Int) Int64Checked() (int64, error) {
+	if !x.IsInt64() {
+		return x.Int64Sat(), ErrRange
+	}
+	return x.Int64(), nil
+}
+
+// Uint64Checked returns the uint64 representation of x and a nil error,
+// or Uint64Sat(x) and ErrRange if x cannot be represented in a uint64.
+func (x *Int) Uint64Checked() (uint64, error) {
+	if !x.IsUint64() {
+		return x.Uint64Sat(), ErrRange
+	}
+	return x.Uint64(), nil
+}
+
 // SetString sets z to the value of s, interpreted in the given base,
 // and returns z and a boolean indicating success. The entire string
 // (not just a prefix) must be valid for success. If SetString fails,
 // the value of z is undefined but the returned value is nil.
 //
-// The base argument must be 0 or a value between 2 and MaxBase. If the base
-// is 0, the string prefix determines the actual conversion base. A prefix of
-// ``0x'' or ``0X'' selects base 16; the ``0'' prefix selects base 8, and a
-// ``0b'' or ``0B'' prefix selects base 2. Otherwise the selected base is 10.
+// The base argument must be 0 or a value between 2 and MaxBase. For bases
+// <= 36, lower and upper case letters are considered the same: The letters
+// 'a' to 'z' and 'A' to 'Z' represent digit values 10 to 35. For bases > 36,
+// the upper case letters 'A' to 'Z' represent the digit values 10 to 35,
+// and the lower case letters 'a' to 'z' the digit values 36 to 61.
+//
+// If the base is 0, the string prefix determines the actual conversion
+// base. A prefix of ``0b'' or ``0B'' selects base 2, ``0o'' or ``0O''
+// selects base 8, and ``0x'' or ``0X'' selects base 16. Otherwise, the
+// ``0'' prefix selects base 8, and without a prefix the selected base is
+// 10. For base 0 only, an underscore character ``_'' may appear between a
+// base prefix and an adjacent digit, and between successive digits; such
+// underscores do not change the value of the number.
 //
+// To find out why a string is invalid, use Parse.
 func (z *Int) SetString(s string, base int) (*Int, bool) {
-	r := strings.NewReader(s)
-	if _, _, err := z.scan(r, base); err != nil {
+	if _, err := z.Parse(s, base); err != nil {
 		return nil, false
 	}
-	// entire string must have been consumed
-	if _, err := r.ReadByte(); err != io.EOF {
-		return nil, false
-	}
-	return z, true // err == io.EOF => scan consumed all of s
+	return z, true
 }
 
 // SetBytes interprets buf as the bytes of a big-endian unsigned
@@ -404,24 +873,118 @@ func (z *Int) SetBytes(buf []byte) *Int {
 	return z
 }
 
+// SetTwos interprets buf as the bytes of a big-endian two's complement
+// integer, sets z to that value, and returns z. An empty buf is 0.
+// Unlike SetTwosCT, SetTwos doesn't mark z as constant-time.
+func (z *Int) SetTwos(buf []byte) *Int {
+	z.abs = z.abs.setBytes(buf)
+	z.neg = false
+	if len(buf) > 0 && buf[0]&0x80 != 0 {
+		// |z| = 2**n - z = (^z mod 2**n) + 1 with n = 8*len(buf); z has
+		// its top bit set, so it has the full word length of n bits.
+		for i, w := range z.abs {
+			z.abs[i] = ^w
+		}
+		if k := uint(8*len(buf)) % _W; k != 0 {
+			z.abs[len(z.abs)-1] &= 1<<k - 1
+		}
+		z.abs = z.abs.norm()
+		z.abs = z.abs.add(z.abs, natOne)
+		z.neg = true
+	}
+	return z
+}
+
+// Twos returns the value of x in two's complement as a sign-extended
+// big-endian byte slice of length n. If x is not in the range
+// [-2**(8*n-1), 2**(8*n-1)), Twos will panic.
+func (x *Int) Twos(n int) []byte {
+	return x.FillTwosCT(make([]byte, n))
+}
+
 // Bytes returns the absolute value of x as a big-endian byte slice.
 func (x *Int) Bytes() []byte {
 	buf := make([]byte, len(x.abs)*_S)
 	return buf[x.abs.bytes(buf):]
 }
 
+// AppendBytes appends the absolute value of x as a big-endian byte slice,
+// as returned by Bytes, to buf and returns the extended buffer. If buf
+// has enough spare capacity, AppendBytes doesn't allocate.
+func (x *Int) AppendBytes(buf []byte) []byte {
+	m, n := len(buf), (x.abs.bitLen()+7)/8
+	if m+n > cap(buf) {
+		b := make([]byte, m, 2*cap(buf)+n)
+		copy(b, buf)
+		buf = b
+	}
+	buf = buf[:m+n]
+	x.abs.bytes(buf[m:])
+	return buf
+}
+
+// FillBytes sets buf to the absolute value of x, storing it as a
+// zero-extended big-endian byte slice, and returns buf.
+//
+// If the absolute value of x doesn't fit in buf, FillBytes will panic.
+func (x *Int) FillBytes(buf []byte) []byte {
+	if x.zcap != 0 {
+		return x.FillBytesCT(buf)
+	}
+	// Clear whole buffer. (This gets optimized into a memclr.)
+	for i := range buf {
+		buf[i] = 0
+	}
+	x.abs.bytes(buf)
+	return buf
+}
+
 // BitLen returns the length of the absolute value of x in bits.
 // The bit length of 0 is 0.
 func (x *Int) BitLen() int {
 	return x.abs.bitLen()
 }
 
+// TrailingZeroBits returns the number of consecutive least significant
+// zero bits of |x|. The number of trailing zero bits of 0 is 0.
+func (x *Int) TrailingZeroBits() uint {
+	return x.abs.trailingZeroBits()
+}
+
+// OnesCount returns the number of one bits in the absolute value of x,
+// its population count.
+func (x *Int) OnesCount() int {
+	return popV(x.abs)
+}
+
+// HammingDistance returns the number of bits in which the absolute
+// values of x and y differ.
+func (x *Int) HammingDistance(y *Int) int {
+	a, b := x.abs, y.abs
+	if len(a) < len(b) {
+		a, b = b, a
+	}
+	return popVV(b, a) + popV(a[len(b):])
+}
+
 // Exp sets z = x**y mod |m| (i.e. the sign of m is ignored), and returns z.
-// If y <= 0, the result is 1 mod |m|; if m == nil or m == 0, z = x**y.
+// If m != 0, the result is the Euclidean residue in the range [0, |m|),
+// also for x < 0. If m == nil or m == 0, z = x**y, which is < 0 for x < 0
+// and odd y, or z = 1 if y <= 0. If m != 0 and y < 0, z = (x**-1)**|y|
+// mod |m|, with the inverse of x modulo |m|; if x and m are not
+// relatively prime, z is unchanged and nil is returned.
 //
 // Modular exponentation of inputs of a particular size is not a
-// cryptographically constant-time operation.
+// cryptographically constant-time operation, unless one of z, x, y,
+// or m is marked for constant-time operation with SetConstantTime.
+// For a marked operand and y < 0, m must be odd, as for ModInverse.
 func (z *Int) Exp(x, y, m *Int) *Int {
+	if y.neg && m != nil && len(m.abs) > 0 {
+		if x, y = invertBase(x, y, m); x == nil {
+			return nil
+		}
+	}
+
 	// See Knuth, volume 2, section 4.6.3.
 	var yWords nat
 	if !y.neg {
@@ -432,9 +995,19 @@ func (z *Int) Exp(x, y, m *Int) *Int {
 	var mWords nat
 	if m != nil {
 		mWords = m.abs // m.abs may be nil for m == 0
+		if z.zcap|x.zcap|y.zcap|m.zcap != 0 && len(mWords) > 0 {
+			return z.expCT(x, y, m)
+		}
+		if varTimeDisabled() && len(mWords) > 0 {
+			return z.expCT(x, y, m).SetConstantTime(0)
+		}
 	}
 
-	z.abs = z.abs.expNN(x.abs, yWords, mWords)
+	abs := z.abs
+	if alias(abs, mWords) {
+		abs = nil // z is an alias for m, which is needed below
+	}
+	z.abs = abs.expNN(x.abs, yWords, mWords)
 	z.neg = len(z.abs) > 0 && x.neg && len(yWords) > 0 && yWords[0]&1 == 1 // 0 has no sign
 	if z.neg && len(mWords) > 0 {
 		// make modulus result positive
@@ -445,11 +1018,91 @@ func (z *Int) Exp(x, y, m *Int) *Int {
 	return z
 }
 
+// invertBase returns x**-1 modulo |m| and -y for y < 0, so that their
+// power is x**y mod |m|, and x and y otherwise. It returns nil, nil if
+// y < 0 and x and m are not relatively prime. m must be != 0.
+func invertBase(x, y, m *Int) (*Int, *Int) {
+	if !y.neg {
+		return x, y
+	}
+	n := &Int{abs: m.abs, zcap: m.zcap} // |m|
+	inv, ok := new(Int).ModInverseChecked(x, n)
+	if !ok {
+		return nil, nil
+	}
+	return inv, new(Int).Neg(y)
+}
+
+// ExpMulti sets z = x1**y1 * x2**y2 mod |m|, in the range [0, |m|) for
+// m != 0, and returns z. As for Exp, if m == nil or m == 0,
+// z = x1**y1 * x2**y2, where an exponent <= 0 contributes a factor of 1;
+// if m != 0, a negative exponent applies to the inverse of its base
+// modulo |m|, and if that doesn't exist, z is unchanged and nil is
+// returned.
+//
+// ExpMulti computes both powers in a single pass over the exponents, which
+// takes about as many multiplications as a single Exp. Like Exp, it is not
+// a constant-time operation unless one of z, x1, y1, x2, y2, or m is marked
+// with SetConstantTime; for marked operands, the two powers are computed
+// separately with the constant-time algorithm of Exp (see Exp2CT for a
+// constant-time single pass), and m must be odd if an exponent is < 0.
+func (z *Int) ExpMulti(x1, y1, x2, y2, m *Int) *Int {
+	if m == nil || len(m.abs) == 0 {
+		p1 := new(Int).Exp(x1, y1, nil)
+		p2 := new(Int).Exp(x2, y2, nil)
+		return z.Mul(p1, p2)
+	}
+	if x1, y1 = invertBase(x1, y1, m); x1 == nil {
+		return nil
+	}
+	if x2, y2 = invertBase(x2, y2, m); x2 == nil {
+		return nil
+	}
+	if z.zcap|x1.zcap|y1.zcap|x2.zcap|y2.zcap|m.zcap != 0 || varTimeDisabled() {
+		p1 := new(Int).Exp(x1, y1, m)
+		p2 := new(Int).Exp(x2, y2, m)
+		p1.Mul(p1, p2)
+		z.Mod(p1, m)
+		p1.Wipe()
+		p2.Wipe()
+		return z
+	}
+
+	var yWords1, yWords2 nat
+	if !y1.neg {
+		yWords1 = y1.abs
+	}
+	if !y2.neg {
+		yWords2 = y2.abs
+	}
+	// As for Exp, a negative base with an odd exponent negates the result.
+	neg := x1.neg && len(yWords1) > 0 && yWords1[0]&1 == 1
+	neg = neg != (x2.neg && len(yWords2) > 0 && yWords2[0]&1 == 1)
+	abs := z.abs
+	if alias(abs, m.abs) {
+		abs = nil // z is an alias for m, which is needed below
+	}
+	mWords := m.abs
+	z.abs = abs.expNN2(x1.abs, yWords1, x2.abs, yWords2, mWords)
+	z.neg = false
+	if neg && len(z.abs) > 0 {
+		// make modulus result positive
+		z.abs = z.abs.sub(mWords, z.abs)
+	}
+	return z
+}
+
 // GCD sets z to the greatest common divisor of a and b, which both must
 // be > 0, and returns z.
 // If x and y are not nil, GCD sets x and y such that z = a*x + b*y.
 // If either a or b is <= 0, GCD sets z = x = y = 0.
+//
+// GCD runs in variable time; if a or b is marked as constant-time
+// (see SetConstantTime), GCD panics. The marks of z, x, and y are kept.
 func (z *Int) GCD(x, y, a, b *Int) *Int {
+	if a.zcap|b.zcap != 0 {
+		panic("math/big: GCD of value marked as constant-time")
+	}
 	if a.Sign() <= 0 || b.Sign() <= 0 {
 		z.SetInt64(0)
 		if x != nil {
@@ -460,50 +1113,290 @@ func (z *Int) GCD(x, y, a, b *Int) *Int {
 		}
 		return z
 	}
-	if x == nil && y == nil {
-		return z.binaryGCD(a, b)
+	if min(len(a.abs), len(b.abs)) >= hgcdThreshold {
+		return z.halfGCD(x, y, a, b)
+	}
+	if useBinaryGCD(a.abs, b.abs) {
+		return z.bingcd(x, y, a, b)
+	}
+	return z.lehmerGCD(x, y, a, b)
+}
+
+// Lcm sets z to the least common multiple of a and b, |a*b|/GCD(a, b),
+// and returns z. If a or b is 0, Lcm sets z = 0.
+//
+// Like GCD, Lcm runs in variable time and panics if a or b is marked
+// as constant-time. The mark of z is kept.
+func (z *Int) Lcm(a, b *Int) *Int {
+	if a.zcap|b.zcap != 0 {
+		panic("math/big: Lcm of value marked as constant-time")
+	}
+	if len(a.abs) == 0 || len(b.abs) == 0 {
+		return z.SetInt64(0)
 	}
+	// The divisor GCD(a, b) of |a| is divided out exactly, and the
+	// shorter quotient multiplied by |b|.
+	g, q := getInt(), getInt()
+	var x, y Int
+	x.abs, y.abs = a.abs, b.abs
+	g.GCD(nil, nil, &x, &y)
+	q.abs = q.abs.divExact(a.abs, g.abs)
+	z.abs = z.abs.mul(q.abs, b.abs)
+	z.neg = false
+	putInt(g)
+	putInt(q)
+	return z
+}
 
-	A := new(Int).Set(a)
-	B := new(Int).Set(b)
+// lehmerSimulate attempts to simulate several Euclidean update steps
+// using the leading digits of A and B. It returns u0, u1, v0, v1
+// such that A and B can be updated as:
+//
+//	A = u0*A + v0*B
+//	B = u1*A + v1*B
+//
+// Requirements: A >= B and len(B.abs) >= 2.
+// Since we are calculating with full words to avoid overflow,
+// we use even to track the sign of the cosequences.
+// For even iterations: u0, v1 >= 0 && u1, v0 <= 0.
+// For odd iterations: u0, v1 <= 0 && u1, v0 >= 0.
+func lehmerSimulate(A, B *Int) (u0, u1, v0, v1 Word, even bool) {
+	// initialize the digits
+	var a1, a2, u2, v2 Word
+
+	m := len(B.abs) // m >= 2
+	n := len(A.abs) // n >= m >= 2
+
+	// extract the top Word of bits from A and B
+	h := nlz(A.abs[n-1])
+	a1 = A.abs[n-1]<<h | A.abs[n-2]>>(_W-h)
+	// B may have implicit zero words in the high bits if the lengths differ
+	switch {
+	case n == m:
+		a2 = B.abs[n-1]<<h | B.abs[n-2]>>(_W-h)
+	case n == m+1:
+		a2 = B.abs[n-2] >> (_W - h)
+	default:
+		a2 = 0
+	}
 
-	X := new(Int)
-	Y := new(Int).SetInt64(1)
+	// The first iteration starts with k = 1 (odd).
+	even = false
+	// variables to track the cosequences
+	u0, u1, u2 = 0, 1, 0
+	v0, v1, v2 = 0, 0, 1
+
+	// Calculate the quotient and cosequences using Collins' stopping
+	// condition. Note that overflow of a Word is not possible when
+	// computing the remainder sequence and cosequences since the
+	// cosequence size is bounded by the input size.
+	// See section 4.2 of Jebelean for details.
+	for a2 >= v2 && a1-a2 >= v1+v2 {
+		q, r := a1/a2, a1%a2
+		a1, a2 = a2, r
+		u0, u1, u2 = u1, u2, u1+q*u2
+		v0, v1, v2 = v1, v2, v1+q*v2
+		even = !even
+	}
+	return
+}
 
-	lastX := new(Int).SetInt64(1)
-	lastY := new(Int)
+// lehmerUpdate updates the inputs A and B such that:
+//
+//	A = u0*A + v0*B
+//	B = u1*A + v1*B
+//
+// where the signs of u0, u1, v0, v1 are given by even as described
+// for lehmerSimulate. q, r, s, t are temporary variables to avoid
+// allocations in the multiplications.
+func lehmerUpdate(A, B, q, r, s, t *Int, u0, u1, v0, v1 Word, even bool) {
+	t.abs = t.abs.setWord(u0)
+	s.abs = s.abs.setWord(v0)
+	t.neg = !even && len(t.abs) > 0
+	s.neg = even && len(s.abs) > 0
+
+	t.Mul(A, t)
+	s.Mul(B, s)
+
+	r.abs = r.abs.setWord(u1)
+	q.abs = q.abs.setWord(v1)
+	r.neg = even && len(r.abs) > 0
+	q.neg = !even && len(q.abs) > 0
+
+	r.Mul(A, r)
+	q.Mul(B, q)
+
+	A.Add(t, s)
+	B.Add(r, q)
+}
 
-	q := new(Int)
-	temp := new(Int)
+// euclidUpdate performs a single step of the Euclidean GCD algorithm.
+// If extended is true, it also updates the cosequence Ua, Ub.
+func euclidUpdate(A, B, Ua, Ub, q, r, s, t *Int, extended bool) {
+	q, r = q.QuoRem(A, B, r)
 
-	r := new(Int)
-	for len(B.abs) > 0 {
-		q, r = q.QuoRem(A, B, r)
+	*A, *B, *r = *B, *r, *A
 
-		A, B, r = B, r, A
+	if extended {
+		// Ua, Ub = Ub, Ua - q*Ub
+		t.Set(Ub)
+		s.Mul(Ub, q)
+		Ub.Sub(Ua, s)
+		Ua.Set(t)
+	}
+}
 
-		temp.Set(X)
-		X.Mul(X, q)
-		X.neg = !X.neg
-		X.Add(X, lastX)
-		lastX.Set(temp)
+// getInt returns an *Int with value 0 for a temporary, taken from a
+// pool whose values keep their storage.
+// Values marked with SetConstantTime must not be given back to it.
+func getInt() *Int {
+	if v := intPool.Get(); v != nil {
+		z := v.(*Int)
+		z.abs = z.abs[:0]
+		z.neg = false
+		return z
+	}
+	return new(Int)
+}
+
+// putInt returns the temporary x to the pool of getInt.
+func putInt(x *Int) {
+	intPool.Put(x)
+}
 
-		temp.Set(Y)
-		Y.Mul(Y, q)
-		Y.neg = !Y.neg
-		Y.Add(Y, lastY)
-		lastY.Set(temp)
+var intPool sync.Pool
+
+// lehmerGCD sets z to the greatest common divisor of a and b, which both
+// must be > 0, and returns z. If x or y are not nil, their values are set
+// such that z = a*x + b*y.
+// See Knuth, The Art of Computer Programming, Vol. 2, Section 4.5.2,
+// Algorithm L. This implementation uses the improved condition by Collins
+// requiring only one quotient and avoiding the possibility of single Word
+// overflow. See Jebelean, "Improving the multiprecision Euclidean
+// algorithm", Design and Implementation of Symbolic Computation Systems,
+// pp 45-58. The cosequences are updated according to Algorithm 10.45 from
+// Cohen et al. "Handbook of Elliptic and Hyperelliptic Curve Cryptography",
+// pp 192.
+func (z *Int) lehmerGCD(x, y, a, b *Int) *Int {
+	var A, B, Ua, Ub *Int
+
+	A = getInt().Set(a)
+	B = getInt().Set(b)
+
+	extended := x != nil || y != nil
+
+	if extended {
+		// Ua (Ub) tracks how many times input a has been accumulated into A (B).
+		Ua = getInt().SetInt64(1)
+		Ub = getInt()
 	}
 
-	if x != nil {
-		*x = *lastX
+	// temp variables for multiprecision update
+	q := getInt()
+	r := getInt()
+	s := getInt()
+	t := getInt()
+
+	// The updates swap the values of the temporaries, but not the
+	// pointers, except for Ua and Ub.
+	tmp := [...]*Int{A, B, Ua, Ub, q, r, s, t}
+
+	// ensure A >= B
+	if A.abs.cmp(B.abs) < 0 {
+		A, B = B, A
+		Ub, Ua = Ua, Ub
+	}
+
+	// loop invariant A >= B
+	for len(B.abs) > 1 {
+		// attempt to calculate in single-precision using leading words of A and B
+		u0, u1, v0, v1, even := lehmerSimulate(A, B)
+
+		// multiprecision step
+		if v0 != 0 {
+			// Simulate the effect of the single-precision steps using
+			// the cosequences.
+			lehmerUpdate(A, B, q, r, s, t, u0, u1, v0, v1, even)
+
+			if extended {
+				// Ua = u0*Ua + v0*Ub
+				// Ub = u1*Ua + v1*Ub
+				lehmerUpdate(Ua, Ub, q, r, s, t, u0, u1, v0, v1, even)
+			}
+		} else {
+			// Single-digit calculations failed to simulate any quotients.
+			// Do a standard Euclidean step.
+			euclidUpdate(A, B, Ua, Ub, q, r, s, t, extended)
+		}
+	}
+
+	if len(B.abs) > 0 {
+		// extended Euclidean algorithm base case if B is a single Word
+		if len(A.abs) > 1 {
+			// A is longer than a single Word, so one update is needed.
+			euclidUpdate(A, B, Ua, Ub, q, r, s, t, extended)
+		}
+		if len(B.abs) > 0 {
+			// A and B are both a single Word.
+			aWord, bWord := A.abs[0], B.abs[0]
+			if extended {
+				var ua, ub, va, vb Word
+				ua, ub = 1, 0
+				va, vb = 0, 1
+				even := true
+				for bWord != 0 {
+					q, r := aWord/bWord, aWord%bWord
+					aWord, bWord = bWord, r
+					ua, ub = ub, ua+q*ub
+					va, vb = vb, va+q*vb
+					even = !even
+				}
+
+				t.abs = t.abs.setWord(ua)
+				s.abs = s.abs.setWord(va)
+				t.neg = !even && len(t.abs) > 0
+				s.neg = even && len(s.abs) > 0
+
+				t.Mul(Ua, t)
+				s.Mul(Ub, s)
+
+				Ua.Add(t, s)
+			} else {
+				for bWord != 0 {
+					aWord, bWord = bWord, aWord%bWord
+				}
+			}
+			A.abs[0] = aWord
+		}
 	}
 
 	if y != nil {
-		*y = *lastY
+		// avoid aliasing b needed in the division below
+		if y == b {
+			B.Set(b)
+		} else {
+			B = b
+		}
+		// y = (z - a*x)/b
+		y.Mul(a, Ua) // y can safely alias a
+		y.Sub(A, y)
+		y.Quo(y, B)
+	}
+
+	// The results are copied rather than swapped with the temporaries,
+	// so that the pool never gets hold of storage the caller may own.
+	if x != nil {
+		x.abs = x.abs.set(Ua.abs)
+		x.neg = Ua.neg
 	}
 
-	*z = *A
+	z.abs = z.abs.set(A.abs)
+	z.neg = A.neg
+	for _, v := range tmp {
+		if v != nil {
+			putInt(v)
+		}
+	}
 	return z
 }
 
@@ -578,9 +1471,43 @@ func (z *Int) Rand(rnd *rand.Rand, n *Int) *Int {
 	return z
 }
 
+// RandFrom sets z to a uniformly distributed random number in [0, n),
+// using random bytes read from rand, and returns z. If n <= 0, RandFrom
+// sets z to 0. If reading from rand fails, RandFrom returns nil and the
+// error, and the value of z is undefined.
+//
+// Like Rand, RandFrom uses rejection sampling: it reads as many bytes as
+// n has, and retries until the number drawn is less than n, so the number
+// of bytes read depends on the result. See RandCT for a variant whose
+// reads depend only on the length of n.
+func (z *Int) RandFrom(rand io.Reader, n *Int) (*Int, error) {
+	z.neg = false
+	if n.neg || len(n.abs) == 0 {
+		z.abs = nil
+		return z, nil
+	}
+	abs, err := z.abs.randomFrom(rand, n.abs, n.abs.bitLen())
+	if err != nil {
+		return nil, err
+	}
+	z.abs = abs
+	return z, nil
+}
+
 // ModInverse sets z to the multiplicative inverse of g in the ring ℤ/nℤ
-// and returns z. If g and n are not relatively prime, the result is undefined.
+// and returns z. If g and n are not relatively prime, the result is
+// undefined; ModInverseChecked reports that case.
+//
+// If z, g, or n is marked as constant-time (see SetConstantTime), the
+// inverse is computed in constant time and marked with the width of n,
+// which must then be odd.
 func (z *Int) ModInverse(g, n *Int) *Int {
+	if z.zcap|g.zcap|n.zcap != 0 {
+		return z.modInverseCT(g, n)
+	}
+	if varTimeDisabled() && len(n.abs) > 0 && n.abs[0]&1 != 0 {
+		return z.modInverseCT(g, n).SetConstantTime(0)
+	}
 	if g.neg {
 		// GCD expects parameters a and b to be > 0.
 		var g2 Int
@@ -597,6 +1524,35 @@ func (z *Int) ModInverse(g, n *Int) *Int {
 	return z
 }
 
+// ModInverseChecked sets z to the multiplicative inverse of g in the ring
+// ℤ/nℤ and returns z and true, like ModInverse. If n <= 0 or g and n are
+// not relatively prime, z is unchanged and ModInverseChecked returns nil
+// and false.
+//
+// For marked operands, the inverse and the check are computed in constant
+// time; only whether g is invertible is revealed.
+func (z *Int) ModInverseChecked(g, n *Int) (*Int, bool) {
+	if n.Sign() <= 0 {
+		return nil, false
+	}
+	var inv, t, one Int
+	inv.zcap = z.zcap // keep z's mark
+	inv.ModInverse(g, n)
+	// inv is the inverse if g*inv = 1 (mod n), which holds for any g if
+	// n == 1.
+	t.Mul(g, &inv).Mod(&t, n)
+	ok := t.Cmp(one.Mod(intOne, n)) == 0
+	t.Wipe()
+	if !ok {
+		inv.Wipe()
+		return nil, false
+	}
+	z.abs, inv.abs = inv.abs, z.abs
+	z.neg = false
+	z.zcap = inv.zcap
+	return z, true
+}
+
 // Jacobi returns the Jacobi symbol (x/y), either +1, -1, or 0.
 // The y argument must be an odd integer.
 func Jacobi(x, y *Int) int {
@@ -666,8 +1622,80 @@ func (z *Int) modSqrt3Mod4Prime(x, p *Int) *Int {
 	return z
 }
 
+// modSqrt5Mod8Prime uses Atkin's observation that 2 is not a square mod p
+//     alpha ==  (2*a)^((p-5)/8)    mod p
+//     beta  ==  2*a*alpha^2        mod p  is a square root of -1
+//     b     ==  a*alpha*(beta-1)   mod p  is a square root of a
+// to calculate the square root of any quadratic residue mod p quickly for 5
+// mod 8 primes.
+func (z *Int) modSqrt5Mod8Prime(x, p *Int) *Int {
+	// p == 5 mod 8 implies p >> 3 == (p - 5) / 8
+	e := new(Int).Rsh(p, 3)
+	tx := new(Int).Lsh(x, 1) // tx = 2*x
+	alpha := new(Int).Exp(tx, e, p)
+	beta := new(Int).Mul(alpha, alpha)
+	beta.Mod(beta, p)
+	beta.Mul(beta, tx)
+	beta.Mod(beta, p)
+	beta.Sub(beta, intOne)
+	beta.Mul(beta, x)
+	beta.Mod(beta, p)
+	beta.Mul(beta, alpha)
+	z.Mod(beta, p)
+	return z
+}
+
+// modSqrtCipollaThreshold is the least value of e*e/p.BitLen(), where 2^e is
+// the largest power of 2 that divides p-1, for which ModSqrt uses Cipolla's
+// algorithm rather than Tonelli-Shanks, whose running time grows with e*e.
+var modSqrtCipollaThreshold = 8 // measured with BenchmarkModSqrtHighValuation
+
+// modSqrtCipolla uses Cipolla's algorithm to find the square root of a
+// quadratic residue x modulo any odd prime p: For an a such that
+// w = a^2 - x is not a square mod p, (a + ω)^((p+1)/2) with ω^2 = w is
+// a square root of x in F_p. Unlike that of Tonelli-Shanks, its running
+// time does not depend on the factorization of p-1.
+func (z *Int) modSqrtCipolla(x, p *Int) *Int {
+	// find some a such that a^2 - x is not a square
+	var a, w Int
+	a.SetInt64(1)
+	for {
+		w.Mul(&a, &a).Sub(&w, x).Mod(&w, p)
+		if Jacobi(&w, p) == -1 {
+			break
+		}
+		a.Add(&a, intOne)
+	}
+
+	// u + v*ω = (a + ω)^e with e = (p+1)/2, by left-to-right binary
+	// exponentiation in F_p[ω]/(ω^2 - w)
+	var e, u, v, uu, vv, t Int
+	e.Add(p, intOne).Rsh(&e, 1)
+	u.SetInt64(1)
+	for i := e.BitLen() - 1; i >= 0; i-- {
+		// (u + v*ω)^2 = u^2 + v^2*w + ((u+v)^2 - u^2 - v^2)*ω
+		uu.Mul(&u, &u)
+		vv.Mul(&v, &v)
+		t.Add(&u, &v)
+		t.Mul(&t, &t).Sub(&t, &uu).Sub(&t, &vv)
+		v.Mod(&t, p)
+		vv.Mod(&vv, p).Mul(&vv, &w)
+		u.Add(&uu, &vv).Mod(&u, p)
+		if e.Bit(i) != 0 {
+			// (u + v*ω)(a + ω) = u*a + v*w + (u + v*a)*ω
+			uu.Mul(&u, &a)
+			t.Mul(&v, &a).Add(&t, &u)
+			vv.Mul(&v, &w)
+			u.Add(&uu, &vv).Mod(&u, p)
+			v.Mod(&t, p)
+		}
+	}
+	return z.Set(&u) // v == 0
+}
+
 // modSqrtTonelliShanks uses the Tonelli-Shanks algorithm to find the square
-// root of a quadratic residue modulo any prime.
+// root of a quadratic residue modulo any prime. If it finds that p is not
+// prime, it returns nil.
 func (z *Int) modSqrtTonelliShanks(x, p *Int) *Int {
 	// Break p-1 into s*2^e such that s is odd.
 	var s Int
@@ -700,6 +1728,9 @@ func (z *Int) modSqrtTonelliShanks(x, p *Int) *Int {
 		for t.Cmp(intOne) != 0 {
 			t.Mul(&t, &t).Mod(&t, p)
 			m++
+			if m == r {
+				return nil // p is not prime
+			}
 		}
 
 		if m == 0 {
@@ -717,14 +1748,23 @@ func (z *Int) modSqrtTonelliShanks(x, p *Int) *Int {
 
 // ModSqrt sets z to a square root of x mod p if such a square root exists, and
 // returns z. The modulus p must be an odd prime. If x is not a square mod p,
-// ModSqrt leaves z unchanged and returns nil. This function panics if p is
-// not an odd integer.
+// ModSqrt leaves z unchanged and returns nil. If p is not prime, ModSqrt
+// either finds a square root of x mod p or returns nil, but it never
+// returns a value whose square is not x mod p. This function panics if p
+// is not an odd integer. ModSqrt runs in variable time; if z, x, or p is
+// marked as constant-time (see SetConstantTime), ModSqrt panics.
 func (z *Int) ModSqrt(x, p *Int) *Int {
+	if z.zcap|x.zcap|p.zcap != 0 {
+		panic("math/big: ModSqrt of value marked as constant-time")
+	}
 	switch Jacobi(x, p) {
 	case -1:
 		return nil // x is not a square mod p
 	case 0:
-		return z.SetInt64(0) // sqrt(0) mod p = 0
+		if new(Int).Mod(x, p).Sign() == 0 {
+			return z.SetInt64(0) // sqrt(0) mod p = 0
+		}
+		return nil // gcd(x, p) > 1, so p is not prime
 	case 1:
 		break
 	}
@@ -732,16 +1772,45 @@ func (z *Int) ModSqrt(x, p *Int) *Int {
 		x = new(Int).Mod(x, p)
 	}
 
-	// Check whether p is 3 mod 4, and if so, use the faster algorithm.
-	if len(p.abs) > 0 && p.abs[0]%4 == 3 {
-		return z.modSqrt3Mod4Prime(x, p)
+	// Use the fast algorithms for p == 3 mod 4 and p == 5 mod 8, and
+	// Tonelli-Shanks or Cipolla for p == 1 mod 8.
+	var r Int
+	switch p.abs[0] % 8 {
+	case 3, 7:
+		r.modSqrt3Mod4Prime(x, p)
+	case 5:
+		r.modSqrt5Mod8Prime(x, p)
+	default:
+		// Both algorithms search for a non-square, which doesn't exist
+		// if p is a square.
+		var t Int
+		if t.Sqrt(p).Mul(&t, &t).Cmp(p) == 0 {
+			return nil
+		}
+		e := t.Sub(p, intOne).abs.trailingZeroBits()
+		if int(e*e) >= modSqrtCipollaThreshold*p.BitLen() {
+			r.modSqrtCipolla(x, p)
+		} else if r.modSqrtTonelliShanks(x, p) == nil {
+			return nil
+		}
+	}
+
+	// A composite p may yield a value that is not a square root.
+	var sq Int
+	if sq.Mul(&r, &r).Mod(&sq, p).Cmp(x) != 0 {
+		return nil
 	}
-	// Otherwise, use Tonelli-Shanks.
-	return z.modSqrtTonelliShanks(x, p)
+	return z.Set(&r)
 }
 
 // Lsh sets z = x << n and returns z.
 func (z *Int) Lsh(x *Int, n uint) *Int {
+	if z.zcap|x.zcap != 0 {
+		return z.lshCT(x, n)
+	}
+	if varTimeDisabled() {
+		return z.lshCT(x, n).SetConstantTime(0)
+	}
 	z.abs = z.abs.shl(x.abs, n)
 	z.neg = x.neg
 	return z
@@ -749,6 +1818,12 @@ func (z *Int) Lsh(x *Int, n uint) *Int {
 
 // Rsh sets z = x >> n and returns z.
 func (z *Int) Rsh(x *Int, n uint) *Int {
+	if z.zcap|x.zcap != 0 {
+		return z.rshCT(x, n)
+	}
+	if varTimeDisabled() {
+		return z.rshCT(x, n).SetConstantTime(0)
+	}
 	if x.neg {
 		// (-x) >> s == ^(x-1) >> s == ^((x-1) >> s) == -(((x-1) >> s) + 1)
 		t := z.abs.sub(x.abs, natOne) // no underflow because |x| > 0
@@ -776,6 +1851,9 @@ func (x *Int) Bit(i int) uint {
 	if i < 0 {
 		panic("negative bit index")
 	}
+	if x.zcap != 0 || varTimeDisabled() {
+		return x.bitCT(i)
+	}
 	if x.neg {
 		t := nat(nil).sub(x.abs, natOne)
 		return t.bit(uint(i)) ^ 1
@@ -784,6 +1862,33 @@ func (x *Int) Bit(i int) uint {
 	return x.abs.bit(uint(i))
 }
 
+// NextSetBit returns the index of the least significant bit of x at
+// or above i that is 1, or -1 if there is none. As for Bit, a negative x
+// is taken in two's complement, so it has infinitely many bits set and
+// NextSetBit never returns -1. The bit index i must be >= 0. The set
+// bits of an x >= 0 are visited by
+//
+//	for i := x.NextSetBit(0); i >= 0; i = x.NextSetBit(i + 1) {
+//		...
+//	}
+//
+// NextSetBit runs in variable time; if x is marked as constant-time
+// (see SetConstantTime), NextSetBit panics.
+func (x *Int) NextSetBit(i int) int {
+	if i < 0 {
+		panic("negative bit index")
+	}
+	if x.zcap != 0 {
+		panic("math/big: NextSetBit of value marked as constant-time")
+	}
+	if x.neg {
+		// the bits of -x are the inverted bits of x-1
+		t := nat(nil).sub(x.abs, natOne)
+		return t.nextBit(uint(i), _M)
+	}
+	return x.abs.nextBit(uint(i), 0)
+}
+
 // SetBit sets z to x, with x's i'th bit set to b (0 or 1).
 // That is, if b is 1 SetBit sets z = x | (1 << i);
 // if b is 0 SetBit sets z = x &^ (1 << i). If b is not 0 or 1,
@@ -942,13 +2047,119 @@ func (z *Int) Not(x *Int) *Int {
 	return z
 }
 
+// RotateLeft sets z to x rotated left by (k mod width) bits within a
+// field of width bits, and returns z. The field holds the width least
+// significant bits of x in two's complement, so z is in [0, 2**width).
+// To rotate right by k bits, call RotateLeft(x, width, -k). If width is
+// 0, z is 0.
+func (z *Int) RotateLeft(x *Int, width uint, k int) *Int {
+	if width == 0 {
+		return z.SetInt64(0)
+	}
+	r := k % int(width)
+	if r < 0 {
+		r += int(width)
+	}
+	s := uint(r)
+
+	vp, lp, hp := getNat(0), getNat(0), getNat(0)
+	v := (*vp).trunc(x.abs, width)
+	if x.neg && len(v) > 0 {
+		// -x mod 2**width == 2**width - (x mod 2**width)
+		h := (*hp).setBit(nil, width, 1)
+		v = v.sub(h, v)
+		*hp = h
+	}
+	l := (*lp).shl(v, s)
+	l = l.trunc(l, width)
+	h := (*hp).shr(v, width-s)
+	z.abs = z.abs.or(l, h)
+	z.neg = false
+	*vp, *lp, *hp = v, l, h
+	putNat(vp)
+	putNat(lp)
+	putNat(hp)
+	return z
+}
+
 // Sqrt sets z to ⌊√x⌋, the largest integer such that z² ≤ x, and returns z.
 // It panics if x is negative.
 func (z *Int) Sqrt(x *Int) *Int {
 	if x.neg {
 		panic("square root of negative number")
 	}
+	if z.zcap|x.zcap != 0 {
+		return z.sqrtCT(x)
+	}
+	if varTimeDisabled() {
+		return z.sqrtCT(x).SetConstantTime(0)
+	}
 	z.neg = false
 	z.abs = z.abs.sqrt(x.abs)
 	return z
 }
+
+// SqrtRem sets z to ⌊√x⌋ and r to the remainder x - z², and returns the
+// pair (z, r). It panics if x is negative. Computing the remainder along
+// with the root costs little more than Sqrt alone.
+//
+// If z, x, or r is marked as constant-time (see SetConstantTime), the
+// root and remainder are computed in constant time, as by Sqrt, Mul,
+// and Sub.
+func (z *Int) SqrtRem(x, r *Int) (*Int, *Int) {
+	if x.neg {
+		panic("square root of negative number")
+	}
+	if z.zcap|x.zcap|r.zcap != 0 {
+		return z.sqrtRemCT(x, r)
+	}
+	if varTimeDisabled() {
+		z.sqrtRemCT(x, r)
+		return z.SetConstantTime(0), r.SetConstantTime(0)
+	}
+	z.abs, r.abs = z.abs.sqrtRem(r.abs, x.abs)
+	z.neg, r.neg = false, false
+	return z, r
+}
+
+// Root sets z to the n-th root of x, truncated toward zero, and returns z:
+// z = ⌊x**(1/n)⌋ for x >= 0, and -⌊(-x)**(1/n)⌋ for x < 0 and odd n.
+// It panics if n == 0, or if x is negative and n is even. Root runs in
+// variable time; if z or x is marked as constant-time (see
+// SetConstantTime), Root panics.
+func (z *Int) Root(x *Int, n uint) *Int {
+	checkRoot(x, n, z)
+	z.abs = z.abs.root(x.abs, n)
+	z.neg = x.neg && len(z.abs) > 0
+	return z
+}
+
+// RootRem sets z to the n-th root of x, truncated toward zero as for Root,
+// and r to the remainder x - z**n, and returns the pair (z, r). The
+// remainder has the sign of x. Like Root, RootRem runs in variable time and
+// panics for the same arguments; it also panics if r is marked as
+// constant-time.
+func (z *Int) RootRem(x *Int, n uint, r *Int) (*Int, *Int) {
+	checkRoot(x, n, z)
+	checkRoot(x, n, r)
+	neg := x.neg
+	s := nat(nil).root(x.abs, n)
+	p := nat(nil).expNN(s, nat(nil).setWord(Word(n)), nil)
+	r.abs = r.abs.sub(x.abs, p)
+	r.neg = neg && len(r.abs) > 0
+	z.abs = z.abs.set(s)
+	z.neg = neg && len(z.abs) > 0
+	return z, r
+}
+
+// checkRoot panics if the n-th root of x cannot be computed into z.
+func checkRoot(x *Int, n uint, z *Int) {
+	switch {
+	case z.zcap|x.zcap != 0:
+		panic("math/big: Root of value marked as constant-time")
+	case n == 0:
+		panic("zeroth root")
+	case x.neg && n&1 == 0:
+		panic("even root of negative number")
+	}
+}
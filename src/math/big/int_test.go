@@ -7,6 +7,10 @@ package big
 import (
 	"bytes"
 	"encoding/hex"
+	"fmt"
+	"internal/race"
+	"math"
+	"math/bits"
 	"math/rand"
 	"strconv"
 	"strings"
@@ -174,6 +178,78 @@ func TestMul(t *testing.T) {
 	}
 }
 
+func TestMulAdd(t *testing.T) {
+	r := rand.New(rand.NewSource(4))
+	operand := func() *Int {
+		x := new(Int).Rand(r, new(Int).Lsh(intOne, uint(r.Intn(4000))))
+		if r.Intn(2) == 0 {
+			x.Neg(x)
+		}
+		return x
+	}
+	for i := 0; i < 300; i++ {
+		x, y, c := operand(), operand(), operand()
+		if i%10 == 0 {
+			x.SetInt64(int64(i - 150))
+		}
+		want := new(Int).Mul(x, y)
+		want.Add(want, c)
+		if got := new(Int).MulAdd(x, y, c); got.Cmp(want) != 0 || !isNormalized(got) {
+			t.Errorf("MulAdd(%s, %s, %s) = %s; want %s", x, y, c, got, want)
+		}
+		// aliased operands
+		for j, args := range [][3]int{{0, 1, 2}, {1, 0, 2}, {2, 1, 0}, {0, 0, 1}, {0, 1, 0}} {
+			v := []*Int{x, y, c}
+			z := new(Int).Set(v[0])
+			v[0] = z
+			want := new(Int).Mul(v[args[0]], v[args[1]])
+			want.Add(want, v[args[2]])
+			if got := z.MulAdd(v[args[0]], v[args[1]], v[args[2]]); got.Cmp(want) != 0 {
+				t.Errorf("aliased MulAdd #%d(%s, %s, %s) = %s; want %s", j, x, y, c, got, want)
+			}
+		}
+		cx := new(Int).Set(x).SetConstantTime(x.BitLen() + 1)
+		if got := new(Int).MulAdd(cx, y, c); got.Cmp(want) != 0 {
+			t.Errorf("constant-time MulAdd(%s, %s, %s) = %s; want %s", x, y, c, got, want)
+		}
+	}
+}
+
+func TestMulAddAllocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	if race.Enabled {
+		t.Skip("skipping in race mode: the pools drop items")
+	}
+	// Horner's method for a polynomial of degree 1000 at 12345
+	x := NewInt(12345)
+	coeffs := make([]*Int, 1000)
+	for i := range coeffs {
+		coeffs[i] = NewInt(int64(i) - 500)
+	}
+	z := new(Int)
+	horner := func() {
+		z.SetInt64(1)
+		for _, c := range coeffs {
+			z.MulAdd(z, x, c)
+		}
+	}
+	horner() // storage for the result
+	if allocs := testing.AllocsPerRun(10, horner); allocs > 10 {
+		t.Errorf("Horner's method with MulAdd: got %v allocations; want <= 10", allocs)
+	}
+}
+
+func TestMulAddCallerStorage(t *testing.T) {
+	x := new(Int).Lsh(intOne, 1000)
+	y := NewInt(12345)
+	testCallerStorage(t, "aliased MulAdd", func(z *Int) {
+		z.Set(x)
+		z.MulAdd(z, y, y)
+	})
+}
+
 var mulRangesZ = []struct {
 	a, b int64
 	prod string
@@ -217,7 +293,58 @@ func TestMulRangeZ(t *testing.T) {
 	}
 }
 
+func TestPochhammer(t *testing.T) {
+	// slow computes the product of the n factors x, x+d, x+2*d, ...
+	slow := func(x, n, d int64) *Int {
+		z := NewInt(1)
+		for i := int64(0); i < n; i++ {
+			z.Mul(z, new(Int).Add(NewInt(x), NewInt(i*d)))
+		}
+		return z
+	}
+	for _, x := range []int64{-1000, -100, -11, -10, -9, -1, 0, 1, 2, 9, 10, 11, 100, 1000, 1 << 40} {
+		for _, n := range []int64{-1, 0, 1, 2, 3, 10, 11, 12, 100} {
+			if got, want := new(Int).FallingFactorial(x, n), slow(x, n, -1); got.Cmp(want) != 0 {
+				t.Errorf("FallingFactorial(%d, %d) = %s; want %s", x, n, got, want)
+			}
+			if got, want := new(Int).RisingFactorial(x, n), slow(x, n, 1); got.Cmp(want) != 0 {
+				t.Errorf("RisingFactorial(%d, %d) = %s; want %s", x, n, got, want)
+			}
+		}
+	}
+	for _, x := range []int64{math.MaxInt64, math.MinInt64, math.MinInt64 + 1} {
+		for _, n := range []int64{1, 2, 3} {
+			if got, want := new(Int).FallingFactorial(x, n), slow(x, n, -1); got.Cmp(want) != 0 {
+				t.Errorf("FallingFactorial(%d, %d) = %s; want %s", x, n, got, want)
+			}
+			if got, want := new(Int).RisingFactorial(x, n), slow(x, n, 1); got.Cmp(want) != 0 {
+				t.Errorf("RisingFactorial(%d, %d) = %s; want %s", x, n, got, want)
+			}
+		}
+	}
+	for _, n := range []int64{-3, -1, 0, 1, 2, 3, 4, 5, 10, 11, 100, 101, 1000, 1001, 3000, 3001} {
+		want := NewInt(1)
+		if n > 0 {
+			want = slow(n, (n+1)/2, -2)
+		}
+		if got := new(Int).DoubleFactorial(n); got.Cmp(want) != 0 {
+			t.Errorf("DoubleFactorial(%d) = %s; want %s", n, got, want)
+		}
+	}
+}
+
 func TestBinomial(t *testing.T) {
+	defer func(th, ratio uint64) { multinomialThreshold, multinomialRatio = th, ratio }(multinomialThreshold, multinomialRatio)
+	for _, factors := range []bool{false, true} {
+		multinomialThreshold, multinomialRatio = 1<<62, 1
+		if factors {
+			multinomialThreshold, multinomialRatio = 0, 1<<62
+		}
+		testBinomial(t, factors)
+	}
+}
+
+func testBinomial(t *testing.T, factors bool) {
 	var z Int
 	for _, test := range []struct {
 		n, k int64
@@ -244,7 +371,62 @@ func TestBinomial(t *testing.T) {
 		{1000, 990, "263409560461970212832400"},
 	} {
 		if got := z.Binomial(test.n, test.k).String(); got != test.want {
-			t.Errorf("Binomial(%d, %d) = %s; want %s", test.n, test.k, got, test.want)
+			t.Errorf("factors=%v: Binomial(%d, %d) = %s; want %s", factors, test.n, test.k, got, test.want)
+		}
+	}
+}
+
+func TestMultinomial(t *testing.T) {
+	defer func(th, ratio uint64) { multinomialThreshold, multinomialRatio = th, ratio }(multinomialThreshold, multinomialRatio)
+	for _, test := range []struct {
+		n    int64
+		ks   []int64
+		want string
+	}{
+		{0, nil, "1"},
+		{5, nil, "1"},
+		{4, []int64{2}, "6"},
+		{4, []int64{2, 2}, "6"},
+		{4, []int64{1, 1, 1, 1}, "24"},
+		{10, []int64{3, 3}, "4200"},
+		{10, []int64{0, 10, 0}, "1"},
+		{-1, nil, "0"},
+		{10, []int64{-1}, "0"},
+		{10, []int64{5, 6}, "0"},
+		{1 << 40, []int64{1}, "1099511627776"},
+	} {
+		for _, factors := range []bool{false, true} {
+			multinomialThreshold, multinomialRatio = 1<<62, 1
+			if factors {
+				multinomialThreshold, multinomialRatio = 0, 1<<62
+			}
+			if got := new(Int).Multinomial(test.n, test.ks...).String(); got != test.want {
+				t.Errorf("factors=%v: Multinomial(%d, %v) = %s; want %s", factors, test.n, test.ks, got, test.want)
+			}
+		}
+	}
+
+	// Multinomial of the parts k1, k2, ... is the product of the binomial
+	// coefficients of (n, k1), (n - k1, k2), ...
+	r := rand.New(rand.NewSource(3))
+	for i := 0; i < 50; i++ {
+		n := r.Int63n(3000)
+		ks := make([]int64, r.Intn(6))
+		want := NewInt(1)
+		rest := n
+		for j := range ks {
+			ks[j] = r.Int63n(rest/2 + 1)
+			want.Mul(want, new(Int).Binomial(rest, ks[j]))
+			rest -= ks[j]
+		}
+		for _, factors := range []bool{false, true} {
+			multinomialThreshold, multinomialRatio = 1<<62, 1
+			if factors {
+				multinomialThreshold, multinomialRatio = 0, 1<<62
+			}
+			if got := new(Int).Multinomial(n, ks...); got.Cmp(want) != 0 {
+				t.Errorf("factors=%v: Multinomial(%d, %v) = %s; want %s", factors, n, ks, got, want)
+			}
 		}
 	}
 }
@@ -360,6 +542,32 @@ func TestBits(t *testing.T) {
 		if bits.cmp(want) != 0 {
 			t.Errorf("%v.Bits() = %v; want %v", z.abs, bits, want)
 		}
+
+		if got := z.WordLen(); got != len(want) {
+			t.Errorf("%v.WordLen() = %d; want %d", z.abs, got, len(want))
+		}
+		for i := 0; i <= len(want); i++ {
+			w := Word(0)
+			if i < len(want) {
+				w = want[i]
+			}
+			if got := z.Word(i); got != w {
+				t.Errorf("%v.Word(%d) = %d; want %d", z.abs, i, got, w)
+			}
+		}
+		for n := 0; n <= len(want)+1; n++ {
+			dst := make([]Word, n)
+			m := z.CopyBits(dst)
+			if w := min(n, len(want)); m != w || nat(dst[:m]).cmp(want[:m]) != 0 {
+				t.Errorf("%v.CopyBits(%d words) = %d, %v; want %d, %v", z.abs, n, m, dst[:m], w, want[:w])
+			}
+			if n > 0 && len(want) > 0 {
+				dst[0]++
+				if z.abs[0] == dst[0] {
+					t.Errorf("%v.CopyBits shares its array with the result", z.abs)
+				}
+			}
+		}
 	}
 }
 
@@ -400,6 +608,119 @@ func TestBytes(t *testing.T) {
 	}
 }
 
+func TestFillBytes(t *testing.T) {
+	panics := func(f func()) (panicked bool) {
+		defer func() { panicked = recover() != nil }()
+		f()
+		return
+	}
+	for _, s := range []string{
+		"0",
+		"1000",
+		"0xffffffff",
+		"-0xffffffff",
+		"0xffffffffffffffff",
+		"0x10000000000000000",
+		"0xabababababababababababababababababababababababababa",
+		"0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+	} {
+		x, _ := new(Int).SetString(s, 0)
+		want := new(Int).Abs(x)
+		n := (x.BitLen() + 7) / 8
+		for _, size := range []int{n, n + 1, 100} {
+			buf := make([]byte, size)
+			for i := range buf {
+				buf[i] = 0xff // must be cleared
+			}
+			if got := new(Int).SetBytes(x.FillBytes(buf)); got.Cmp(want) != 0 {
+				t.Errorf("FillBytes(%s) into %d bytes = %x; want %x", s, size, buf, want)
+			}
+		}
+		if n > 0 && !panics(func() { x.FillBytes(make([]byte, n-1)) }) {
+			t.Errorf("FillBytes(%s) into %d bytes did not panic", s, n-1)
+		}
+	}
+}
+
+func TestAppendBytes(t *testing.T) {
+	for _, s := range []string{
+		"0",
+		"1000",
+		"-0xffffffff",
+		"0x10000000000000000",
+		"0xabababababababababababababababababababababababababa",
+	} {
+		x, _ := new(Int).SetString(s, 0)
+		for _, prefix := range []string{"", "abc"} {
+			want := append([]byte(prefix), x.Bytes()...)
+			if got := x.AppendBytes([]byte(prefix)); !bytes.Equal(got, want) {
+				t.Errorf("AppendBytes(%q, %s) = %x; want %x", prefix, s, got, want)
+			}
+			buf := make([]byte, len(prefix), 100)
+			copy(buf, prefix)
+			if got := x.AppendBytes(buf); !bytes.Equal(got, want) || &got[:1][0] != &buf[:1][0] {
+				t.Errorf("AppendBytes(%q, %s) with spare capacity = %x; want %x in place", prefix, s, got, want)
+			}
+		}
+	}
+
+	x, _ := new(Int).SetString("0x123456789abcdef0123456789abcdef", 0)
+	buf := make([]byte, 0, 1000)
+	allocs := testing.AllocsPerRun(10, func() {
+		buf = buf[:0]
+		for i := 0; i < 10; i++ {
+			buf = x.AppendBytes(buf)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("AppendBytes with spare capacity: got %v allocations; want 0", allocs)
+	}
+}
+
+func TestTwos(t *testing.T) {
+	for _, test := range []struct {
+		x   int64
+		buf string
+	}{
+		{0, ""},
+		{0, "00"},
+		{1, "01"},
+		{127, "7f"},
+		{-128, "80"},
+		{-1, "ff"},
+		{-1, "ffffffffffffffffff"},
+		{255, "00ff"},
+		{-256, "ff00"},
+		{math.MinInt64, "8000000000000000"},
+		{math.MaxInt64, "7fffffffffffffff"},
+		{-0x123456789, "fffffffedcba9877"},
+	} {
+		buf, _ := hex.DecodeString(test.buf)
+		z := NewInt(42)
+		if got := z.SetTwos(buf); got.Int64() != test.x || !isNormalized(got) {
+			t.Errorf("SetTwos(%s) = %s; want %d", test.buf, got, test.x)
+		}
+		if got := NewInt(test.x).Twos(len(buf)); !bytes.Equal(got, buf) {
+			t.Errorf("Twos(%d, %d) = %x; want %s", test.x, len(buf), got, test.buf)
+		}
+	}
+
+	r := rand.New(rand.NewSource(0))
+	for n := 1; n <= 40; n++ {
+		buf := make([]byte, n)
+		for i := 0; i < 10; i++ {
+			r.Read(buf)
+			want := new(Int).SetTwosCT(buf)
+			if got := new(Int).SetTwos(buf); got.Cmp(want) != 0 || got.zcap != 0 {
+				t.Errorf("SetTwos(%x) = %s; want %s", buf, got, want)
+			}
+			if got := want.Twos(n); !bytes.Equal(got, buf) {
+				t.Errorf("Twos(%s, %d) = %x; want %x", want, n, got, buf)
+			}
+		}
+	}
+}
+
 func checkQuo(x, y []byte) bool {
 	u := new(Int).SetBytes(x)
 	v := new(Int).SetBytes(y)
@@ -460,12 +781,127 @@ func TestQuo(t *testing.T) {
 	}
 }
 
+// divRound64 is the reference for DivRound on small values.
+func divRound64(x, y int64, mode RoundingMode) int64 {
+	if y < 0 {
+		x, y = -x, -y
+	}
+	f := x / y // floor of x/y
+	if x%y < 0 {
+		f--
+	}
+	r := x - f*y // 0 <= r < y
+	if r == 0 {
+		return f
+	}
+	switch mode {
+	case ToNegativeInf:
+		return f
+	case ToPositiveInf:
+		return f + 1
+	case ToZero:
+		if f < 0 {
+			return f + 1
+		}
+		return f
+	case AwayFromZero:
+		if f < 0 {
+			return f
+		}
+		return f + 1
+	}
+	switch {
+	case 2*r < y:
+		return f
+	case 2*r > y:
+		return f + 1
+	case mode == ToNearestEven:
+		return f + f&1
+	case f < 0: // ToNearestAway
+		return f
+	}
+	return f + 1
+}
+
+func TestDivRound(t *testing.T) {
+	modes := []RoundingMode{ToNearestEven, ToNearestAway, ToZero, AwayFromZero, ToNegativeInf, ToPositiveInf}
+	for x := int64(-30); x <= 30; x++ {
+		for y := int64(-7); y <= 7; y++ {
+			if y == 0 {
+				continue
+			}
+			for _, mode := range modes {
+				want := divRound64(x, y, mode)
+				if got := new(Int).DivRound(NewInt(x), NewInt(y), mode); got.Int64() != want || !isNormalized(got) {
+					t.Errorf("DivRound(%d, %d, %s) = %s; want %d", x, y, mode, got, want)
+				}
+				z := NewInt(y)
+				if got := z.DivRound(NewInt(x), z, mode); got.Int64() != want {
+					t.Errorf("aliased DivRound(%d, %d, %s) = %s; want %d", x, y, mode, got, want)
+				}
+			}
+			if got, want := new(Int).DivCeil(NewInt(x), NewInt(y)), divRound64(x, y, ToPositiveInf); got.Int64() != want {
+				t.Errorf("DivCeil(%d, %d) = %s; want %d", x, y, got, want)
+			}
+		}
+	}
+
+	// large values: the rounding error is bounded, and the results of the
+	// directed modes are related
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 100; i++ {
+		x := new(Int).Rand(r, new(Int).Lsh(intOne, uint(r.Intn(1000)+1)))
+		y := new(Int).Rand(r, new(Int).Lsh(intOne, uint(r.Intn(500)+1)))
+		y.Add(y, intOne)
+		if i&1 != 0 {
+			x.Neg(x)
+		}
+		if i&2 != 0 {
+			y.Neg(y)
+		}
+		q := new(Int).DivRound(x, y, ToNearestEven)
+		e := new(Int).Mul(q, y)
+		e.Sub(e, x).Abs(e).Lsh(e, 1) // 2|q*y - x|
+		if e.Cmp(new(Int).Abs(y)) > 0 {
+			t.Errorf("DivRound(%s, %s, ToNearestEven) = %s is not nearest", x, y, q)
+		}
+		ceil := new(Int).DivCeil(x, y)
+		floor := new(Int).DivRound(x, y, ToNegativeInf)
+		if y.Sign() > 0 && floor.Cmp(new(Int).Div(x, y)) != 0 {
+			t.Errorf("DivRound(%s, %s, ToNegativeInf) = %s; want %s", x, y, floor, new(Int).Div(x, y))
+		}
+		d := new(Int).Sub(ceil, floor)
+		if x.Cmp(new(Int).Mul(floor, y)) == 0 && d.Sign() != 0 || x.Cmp(new(Int).Mul(floor, y)) != 0 && d.Cmp(intOne) != 0 {
+			t.Errorf("DivCeil(%s, %s) = %s, DivRound(ToNegativeInf) = %s", x, y, ceil, floor)
+		}
+	}
+}
+
+func TestDivRoundPanic(t *testing.T) {
+	defer func() {
+		if msg, _ := recover().(string); msg != "math/big: invalid rounding mode" {
+			t.Errorf("got panic %q; want invalid rounding mode", msg)
+		}
+	}()
+	// an exact quotient, which needs no rounding
+	new(Int).DivRound(NewInt(6), NewInt(3), RoundingMode(99))
+	t.Error("DivRound with invalid rounding mode did not panic")
+}
+
+func TestDivRoundCallerStorage(t *testing.T) {
+	x := new(Int).Lsh(intOne, 1000)
+	y := NewInt(3)
+	testCallerStorage(t, "DivRound", func(z *Int) {
+		z.DivRound(x, y, ToNearestEven)
+	})
+}
+
 func TestQuoStepD6(t *testing.T) {
 	// See Knuth, Volume 2, section 4.3.1, exercise 21. This code exercises
 	// a code path which only triggers 1 in 10^{-19} cases.
 
-	u := &Int{false, nat{0, 0, 1 + 1<<(_W-1), _M ^ (1 << (_W - 1))}}
-	v := &Int{false, nat{5, 2 + 1<<(_W-1), 1 << (_W - 1)}}
+	u := &Int{abs: nat{0, 0, 1 + 1<<(_W-1), _M ^ (1 << (_W - 1))}}
+	v := &Int{abs: nat{5, 2 + 1<<(_W-1), 1 << (_W - 1)}}
 
 	r := new(Int)
 	q, r := new(Int).QuoRem(u, v, r)
@@ -523,6 +959,205 @@ func TestBitLen(t *testing.T) {
 	}
 }
 
+func TestRotateLeft(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		x := r.Uint64()
+		k := r.Intn(200) - 100
+		for _, tx := range []*Int{new(Int).SetUint64(x), new(Int).Sub(new(Int).SetUint64(x), new(Int).Lsh(intOne, 64+uint(i%3)))} {
+			want := new(Int).SetUint64(bits.RotateLeft64(x, k))
+			if got := new(Int).RotateLeft(tx, 64, k); got.Cmp(want) != 0 {
+				t.Errorf("RotateLeft(%s, 64, %d) = %s; want %s", tx, k, got, want)
+			}
+			want.SetUint64(uint64(bits.RotateLeft8(uint8(x), k)))
+			if got := new(Int).RotateLeft(tx, 8, k); got.Cmp(want) != 0 {
+				t.Errorf("RotateLeft(%s, 8, %d) = %s; want %s", tx, k, got, want)
+			}
+		}
+	}
+
+	// wide fields, by rotating the binary digits
+	for i := 0; i < 100; i++ {
+		width := uint(r.Intn(500) + 1)
+		x := new(Int).Rand(r, new(Int).Lsh(intOne, width+10))
+		if i&1 != 0 {
+			x.Neg(x)
+		}
+		k := r.Intn(1200) - 600
+		m := new(Int).Lsh(intOne, width)
+		v := new(Int).Mod(x, m)
+		digits := fmt.Sprintf("%0*b", width, v)
+		s := ((k % int(width)) + int(width)) % int(width)
+		want, _ := new(Int).SetString(digits[s:]+digits[:s], 2)
+		if got := new(Int).RotateLeft(x, width, k); got.Cmp(want) != 0 {
+			t.Errorf("RotateLeft(%s, %d, %d) = %s; want %s", x, width, k, got, want)
+		}
+		if z := new(Int).Set(x); z.RotateLeft(z, width, k).Cmp(want) != 0 {
+			t.Errorf("aliased RotateLeft(%s, %d, %d) = %s; want %s", x, width, k, z, want)
+		}
+		if got := new(Int).RotateLeft(want, width, -k); got.Cmp(v) != 0 {
+			t.Errorf("RotateLeft(%s, %d, %d) = %s; want %s", want, width, -k, got, v)
+		}
+	}
+	if got := new(Int).RotateLeft(NewInt(-5), 0, 3); got.Sign() != 0 {
+		t.Errorf("RotateLeft(-5, 0, 3) = %s; want 0", got)
+	}
+}
+
+func TestRandFrom(t *testing.T) {
+	r := &countingReader{rnd: rand.New(rand.NewSource(1))}
+	for _, s := range []string{"1", "2", "3", "255", "256", "257", "12345678901234567890", "0x" + strings.Repeat("f", 100)} {
+		n, _ := new(Int).SetString(s, 0)
+		size := (n.BitLen() + 7) / 8
+		var z Int
+		for i := 0; i < 20; i++ {
+			r.n = 0
+			if _, err := z.RandFrom(r, n); err != nil {
+				t.Fatal(err)
+			}
+			if z.Sign() < 0 || z.Cmp(n) >= 0 || !isNormalized(&z) {
+				t.Errorf("RandFrom(%s) = %s out of range", s, &z)
+			}
+			if r.n == 0 || r.n%size != 0 {
+				t.Errorf("RandFrom(%s) read %d bytes; want a multiple of %d", s, r.n, size)
+			}
+		}
+	}
+
+	// a small limit should produce all possible values
+	var seen [7]bool
+	n := NewInt(7)
+	for i := 0; i < 1000; i++ {
+		z, _ := new(Int).RandFrom(r, n)
+		seen[z.Int64()] = true
+	}
+	for i, ok := range seen {
+		if !ok {
+			t.Errorf("RandFrom(7) never produced %d", i)
+		}
+	}
+
+	// the bytes are used as a big-endian number with the excess bits masked
+	// and numbers >= n are rejected
+	if z, err := new(Int).RandFrom(bytes.NewReader([]byte{0xff, 0x34, 0x12, 0x33}), NewInt(0x1234)); err != nil || z.Int64() != 0x1233 {
+		t.Errorf("RandFrom(0x1234) = %#x, %v; want 0x1233 after rejecting 0x1f34", z, err)
+	}
+
+	for _, n := range []*Int{NewInt(0), NewInt(-5)} {
+		if z, err := new(Int).RandFrom(bytes.NewReader(nil), n); err != nil || z.Sign() != 0 {
+			t.Errorf("RandFrom(%s) = %v, %v; want 0, nil", n, z, err)
+		}
+	}
+	if z, err := new(Int).RandFrom(bytes.NewReader(nil), n); z != nil || err == nil {
+		t.Errorf("RandFrom with empty reader = %v, %v; want nil, error", z, err)
+	}
+}
+
+func TestCmpAbs(t *testing.T) {
+	values := []string{"0", "1", "2", "0xffffffff", "0x100000000", "0xffffffffffffffff",
+		"0x10000000000000000", "0x123456789abcdef0123456789abcdef"}
+	for i, sx := range values {
+		for j, sy := range values {
+			a, _ := new(Int).SetString(sx, 0)
+			b, _ := new(Int).SetString(sy, 0)
+			want := 0
+			switch {
+			case i < j:
+				want = -1
+			case i > j:
+				want = 1
+			}
+			for _, x := range []*Int{a, new(Int).Neg(a)} {
+				for _, y := range []*Int{b, new(Int).Neg(b)} {
+					if got := x.CmpAbs(y); got != want {
+						t.Errorf("(%s).CmpAbs(%s) = %d; want %d", x, y, got, want)
+					}
+					xc := new(Int).Set(x).SetConstantTime(256)
+					if got := xc.CmpAbs(y); got != want {
+						t.Errorf("constant-time (%s).CmpAbs(%s) = %d; want %d", x, y, got, want)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestCmpInt64(t *testing.T) {
+	values := []string{"0", "1", "-1", "2", "-2", "0x7fffffff", "0x80000000", "-0x80000000", "0xffffffff",
+		"0x100000000", "-0x100000000", "0x7fffffffffffffff", "-0x7fffffffffffffff", "-0x8000000000000000",
+		"0x8000000000000000", "-0x8000000000000001", "0xffffffffffffffff", "0x10000000000000000",
+		"-0x10000000000000000", "0x123456789abcdef0123456789abcdef"}
+	for _, sx := range values {
+		for _, sy := range values {
+			x, _ := new(Int).SetString(sx, 0)
+			y, _ := new(Int).SetString(sy, 0)
+			want := x.Cmp(y)
+			for _, x := range []*Int{x, new(Int).Set(x).SetConstantTime(256)} {
+				if y.IsInt64() {
+					if got := x.CmpInt64(y.Int64()); got != want {
+						t.Errorf("(%s).CmpInt64(%s) = %d; want %d", x, y, got, want)
+					}
+					if got := x.EqInt64(y.Int64()); got != (want == 0) {
+						t.Errorf("(%s).EqInt64(%s) = %v; want %v", x, y, got, want == 0)
+					}
+				}
+				if y.IsUint64() {
+					if got := x.CmpUint64(y.Uint64()); got != want {
+						t.Errorf("(%s).CmpUint64(%s) = %d; want %d", x, y, got, want)
+					}
+				}
+			}
+		}
+	}
+
+	x := new(Int).Lsh(intOne, 100)
+	if allocs := testing.AllocsPerRun(100, func() { x.CmpInt64(-1 << 40); x.CmpUint64(1 << 63) }); allocs != 0 {
+		t.Errorf("CmpInt64: got %v allocations; want 0", allocs)
+	}
+}
+
+func TestTrailingZeroBits(t *testing.T) {
+	for _, test := range []struct {
+		in  string
+		out uint
+	}{
+		{"0", 0},
+		{"1", 0},
+		{"-1", 0},
+		{"12", 2},
+		{"-12", 2},
+		{"0x8000000000000000", 63},
+		{"0x10000000000000000", 64},
+		{"-0x3000000000000000000000000000000000", 132},
+	} {
+		x, _ := new(Int).SetString(test.in, 0)
+		if got := x.TrailingZeroBits(); got != test.out {
+			t.Errorf("%s.TrailingZeroBits() = %d; want %d", test.in, got, test.out)
+		}
+	}
+}
+
+func TestOnesCount(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 100; i++ {
+		x := new(Int).Rand(r, new(Int).Lsh(intOne, uint(r.Intn(1000)+1)))
+		y := new(Int).Rand(r, new(Int).Lsh(intOne, uint(r.Intn(1000)+1)))
+		if i&1 != 0 {
+			x.Neg(x)
+		}
+		if got, want := x.OnesCount(), strings.Count(x.Text(2), "1"); got != want {
+			t.Errorf("%s.OnesCount() = %d; want %d", x, got, want)
+		}
+		z := new(Int).Xor(new(Int).Abs(x), new(Int).Abs(y))
+		if got, want := x.HammingDistance(y), strings.Count(z.Text(2), "1"); got != want {
+			t.Errorf("%s.HammingDistance(%s) = %d; want %d", x, y, got, want)
+		}
+		if got := y.HammingDistance(x); got != x.HammingDistance(y) {
+			t.Errorf("%s.HammingDistance(%s) = %d; want %d", y, x, got, x.HammingDistance(y))
+		}
+	}
+}
+
 var expTests = []struct {
 	x, y, m string
 	out     string
@@ -556,7 +1191,25 @@ var expTests = []struct {
 	{"0x8000000000000000", "3", "6719", "5447"},
 	{"0x8000000000000000", "1000", "6719", "1603"},
 	{"0x8000000000000000", "1000000", "6719", "3199"},
-	{"0x8000000000000000", "-1000000", "6719", "1"},
+	{"0x8000000000000000", "-1000000", "6719", "3663"},
+
+	// m < 0 or x < 0, with results in [0, |m|), and m == 0
+	{"5", "1", "-3", "2"},
+	{"-5", "1", "-7", "2"},
+	{"-5", "3", "-7", "1"},
+	{"-5", "2", "-7", "4"},
+	{"-2", "3", "-8", "0"},
+	{"-1", "1", "-1", "0"},
+	{"-2", "3", "0", "-8"},
+	{"-2", "4", "0", "16"},
+	{"-2", "-1", "0", "1"},
+
+	// y < 0 and m != 0, with the inverse of x
+	{"3", "-1", "7", "5"},
+	{"3", "-5", "7", "3"},
+	{"-3", "-1", "7", "2"},
+	{"3", "-1", "-7", "5"},
+	{"10", "-1", "7", "5"},
 
 	{"0xffffffffffffffffffffffffffffffff", "0x12345678123456781234567812345678123456789", "0x01112222333344445555666677778889", "0x36168FA1DB3AAE6C8CE647E137F97A"},
 
@@ -628,6 +1281,14 @@ func TestExp(t *testing.T) {
 			t.Errorf("#%d: got %x want %x", i, z1, out)
 		}
 
+		if m != nil {
+			// z is an alias for m
+			z2 := new(Int).Set(m)
+			if z2.Exp(x, y, z2); z2.Cmp(out) != 0 {
+				t.Errorf("#%d: aliased m: got %x want %x", i, z2, out)
+			}
+		}
+
 		if m == nil {
 			// The result should be the same as for m == 0;
 			// specifically, there should be no div-zero panic.
@@ -640,6 +1301,40 @@ func TestExp(t *testing.T) {
 	}
 }
 
+func TestExpNegativeExponent(t *testing.T) {
+	// x and m are not relatively prime
+	for _, test := range []struct{ x, m int64 }{{0, 7}, {2, 4}, {6, -9}, {-15, 10}} {
+		z := NewInt(42)
+		if got := z.Exp(NewInt(test.x), NewInt(-3), NewInt(test.m)); got != nil || z.Int64() != 42 {
+			t.Errorf("Exp(%d, -3, %d) = %v, z = %s; want nil, 42", test.x, test.m, got, z)
+		}
+		s := new(Scratch)
+		if got := z.ExpScratch(NewInt(test.x), NewInt(-3), NewInt(test.m), s); got != nil || z.Int64() != 42 {
+			t.Errorf("ExpScratch(%d, -3, %d) = %v, z = %s; want nil, 42", test.x, test.m, got, z)
+		}
+	}
+
+	r := rand.New(rand.NewSource(5))
+	m, _ := new(Int).SetString("0xfffffffffffffffffffffffffffffffeffffffffffffffff", 0) // odd
+	for i := 0; i < 20; i++ {
+		x := new(Int).Rand(r, m)
+		y := new(Int).Rand(r, m)
+		want := new(Int).Exp(x, y, m)
+		want.ModInverse(want, m)
+		y.Neg(y)
+		if got := new(Int).Exp(x, y, m); got == nil || got.Cmp(want) != 0 {
+			t.Errorf("Exp(%s, %s, %s) = %v; want %s", x, y, m, got, want)
+		}
+		if got := new(Int).ExpScratch(x, y, m, new(Scratch)); got == nil || got.Cmp(want) != 0 {
+			t.Errorf("ExpScratch(%s, %s, %s) = %v; want %s", x, y, m, got, want)
+		}
+		cx := new(Int).Set(x).SetConstantTime(m.BitLen())
+		if got := new(Int).Exp(cx, y, m); got == nil || got.Cmp(want) != 0 {
+			t.Errorf("constant-time Exp(%s, %s, %s) = %v; want %s", x, y, m, got, want)
+		}
+	}
+}
+
 func BenchmarkExp(b *testing.B) {
 	x, _ := new(Int).SetString("11001289118363089646017359372117963499250546375269047542777928006103246876688756735760905680604646624353196869572752623285140408755420374049317646428185270079555372763503115646054602867593662923894140940837479507194934267532831694565516466765025434902348314525627418515646588160955862839022051353653052947073136084780742729727874803457643848197499548297570026926927502505634297079527299004267769780768565695459945235586892627059178884998772989397505061206395455591503771677500931269477503508150175717121828518985901959919560700853226255420793148986854391552859459511723547532575574664944815966793196961286234040892865", 0)
 	y, _ := new(Int).SetString("0xAC6BDB41324A9A9BF166DE5E1389582FAF72B6651987EE07FC3192943DB56050A37329CBB4A099ED8193E0757767A13DD52312AB4B03310DCD7F48A9DA04FD50E8083969EDB767B0CF6095179A163AB3661A05FBD5FAAAE82918A9962F0B93B855F97993EC975EEAA80D740ADBF4FF747359D041D5C33EA71D281E446B14773BCA97B43A23FB801676BD207A436C6481F1D2B9078717461A5B9D32E688F87748544523B524B0D57D5EA77A2775D2ECFA032CFBDBF52FB3786160279004E57AE6AF874E7303CE53299CCC041C7BC308D82A5698F3A8D0C38271AE35F8E9DBFBB694B5C803D89F7AE435DE236D525F54759B65E372FCD68EF20FA7111F9E4AFF72", 0)
@@ -660,6 +1355,95 @@ func BenchmarkExp2(b *testing.B) {
 	}
 }
 
+func TestExpMulti(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	var moduli []*Int
+	for _, s := range []string{"1", "2", "3", "10", "0xffffffffffffffffffffffffffffffff", "0x100000000000000000000000000000000"} {
+		m, _ := new(Int).SetString(s, 0)
+		moduli = append(moduli, m)
+	}
+	for _, bits := range []int{61, 64, 200, 521, 1024} {
+		m := new(Int).Rand(r, new(Int).Lsh(intOne, uint(bits)))
+		moduli = append(moduli, m.SetBit(m, bits-1, 1), new(Int).SetBit(m, 0, m.Bit(0)^1))
+	}
+	rnd := func(limit *Int) *Int {
+		x := new(Int).Rand(r, limit)
+		if r.Intn(2) == 0 {
+			x.Neg(x)
+		}
+		return x
+	}
+	for i, m := range moduli {
+		for j := 0; j < 10; j++ {
+			x1, x2 := rnd(new(Int).Lsh(m, 1)), rnd(m)
+			e := new(Int).Lsh(intOne, uint(r.Intn(300)))
+			y1, y2 := rnd(e), rnd(e)
+			want, p2 := new(Int).Exp(x1, y1, m), new(Int).Exp(x2, y2, m)
+			if want == nil || p2 == nil {
+				// a negative exponent of a base without an inverse
+				if z := new(Int).ExpMulti(x1, y1, x2, y2, m); z != nil {
+					t.Errorf("#%d.%d: ExpMulti(%v, %v, %v, %v, %v) = %v, want nil", i, j, x1, y1, x2, y2, m, z)
+				}
+				continue
+			}
+			want.Mul(want, p2)
+			want.Mod(want, m)
+
+			z := new(Int).ExpMulti(x1, y1, x2, y2, m)
+			if !isNormalized(z) || z.Cmp(want) != 0 {
+				t.Errorf("#%d.%d: ExpMulti(%v, %v, %v, %v, %v) = %v, want %v", i, j, x1, y1, x2, y2, m, z, want)
+			}
+			if z.Set(x1).ExpMulti(z, y1, x2, y2, m).Cmp(want) != 0 {
+				t.Errorf("#%d.%d: aliased ExpMulti = %v, want %v", i, j, z, want)
+			}
+			if z.Neg(m).ExpMulti(x1, y1, x2, y2, z).Cmp(want) != 0 {
+				t.Errorf("#%d.%d: ExpMulti with z = -m = %v, want %v", i, j, z, want)
+			}
+			if m.Bit(0) == 0 && (y1.Sign() < 0 || y2.Sign() < 0) {
+				continue // marked operands need an odd modulus for the inverse
+			}
+			mct := new(Int).Set(m).SetConstantTime(m.BitLen())
+			if z.ExpMulti(x1, y1, x2, y2, mct).Cmp(want) != 0 {
+				t.Errorf("#%d.%d: marked ExpMulti = %v, want %v", i, j, z, want)
+			}
+		}
+	}
+
+	x1, x2 := NewInt(-3), NewInt(5)
+	y1, y2 := NewInt(3), NewInt(2)
+	want := NewInt(-27 * 25)
+	if z := new(Int).ExpMulti(x1, y1, x2, y2, nil); z.Cmp(want) != 0 {
+		t.Errorf("ExpMulti(-3, 3, 5, 2, nil) = %v, want %v", z, want)
+	}
+	if z := new(Int).ExpMulti(x1, y1, x2, y2, new(Int)); z.Cmp(want) != 0 {
+		t.Errorf("ExpMulti(-3, 3, 5, 2, 0) = %v, want %v", z, want)
+	}
+}
+
+func BenchmarkExpMulti(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	for _, bits := range []uint{256, 1024, 2048} {
+		m := new(Int).Rand(r, new(Int).Lsh(intOne, bits))
+		m.SetBit(m, int(bits)-1, 1).SetBit(m, 0, 1)
+		x1, x2 := new(Int).Rand(r, m), new(Int).Rand(r, m)
+		y1, y2 := new(Int).Rand(r, m), new(Int).Rand(r, m)
+		z := new(Int)
+		b.Run(fmt.Sprintf("%d/ExpMulti", bits), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				z.ExpMulti(x1, y1, x2, y2, m)
+			}
+		})
+		b.Run(fmt.Sprintf("%d/Exp", bits), func(b *testing.B) {
+			t := new(Int)
+			for i := 0; i < b.N; i++ {
+				z.Exp(x1, y1, m)
+				t.Exp(x2, y2, m)
+				z.Mul(z, t).Mod(z, m)
+			}
+		})
+	}
+}
+
 func checkGcd(aBytes, bBytes []byte) bool {
 	x := new(Int)
 	y := new(Int)
@@ -761,6 +1545,50 @@ func TestGcd(t *testing.T) {
 	}
 }
 
+// euclidGCD computes the GCD of a, b > 0 and its cofactors with the
+// classical extended Euclidean algorithm.
+func euclidGCD(a, b *Int) (d, x, y *Int) {
+	A, B := new(Int).Set(a), new(Int).Set(b)
+	X, lastX := new(Int), NewInt(1)
+	Y, lastY := NewInt(1), new(Int)
+	q, r := new(Int), new(Int)
+	for len(B.abs) > 0 {
+		q.QuoRem(A, B, r)
+		A, B, r = B, r, A
+		lastX, X = X, lastX.Sub(lastX, new(Int).Mul(q, X))
+		lastY, Y = Y, lastY.Sub(lastY, new(Int).Mul(q, Y))
+	}
+	return A, lastX, lastY
+}
+
+func TestLehmerGCD(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, size := range []uint{_W - 1, _W + 1, 2 * _W, 500, 3000} {
+		for i := 0; i < 20; i++ {
+			a := new(Int).Rand(r, new(Int).Lsh(intOne, size))
+			b := new(Int).Rand(r, new(Int).Lsh(intOne, size-uint(i)*size/40))
+			if i%4 == 0 {
+				// a large common factor
+				g := new(Int).Rand(r, new(Int).Lsh(intOne, size/2))
+				a.Mul(a, g)
+				b.Mul(b, g)
+			}
+			if a.Sign() == 0 || b.Sign() == 0 {
+				continue
+			}
+			d, x, y := euclidGCD(a, b)
+			D, X, Y := new(Int), new(Int), new(Int)
+			D.GCD(X, Y, a, b)
+			if D.Cmp(d) != 0 || X.Cmp(x) != 0 || Y.Cmp(y) != 0 {
+				t.Errorf("GCD(%s, %s) = %s, %s, %s; want %s, %s, %s", a, b, D, X, Y, d, x, y)
+			}
+			if D.GCD(nil, nil, a, b).Cmp(d) != 0 {
+				t.Errorf("GCD(%s, %s) = %s; want %s", a, b, D, d)
+			}
+		}
+	}
+}
+
 type intShiftTest struct {
 	in    string
 	shift uint
@@ -876,6 +1704,177 @@ func TestLshSelf(t *testing.T) {
 	}
 }
 
+func TestShiftSelfAllocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	x := new(Int).Lsh(intOne, 1000)
+	z := new(Int)
+	// 10000 shifts of a value in place reallocate it a few times only
+	allocs := testing.AllocsPerRun(10, func() {
+		z.Set(x)
+		z.abs = z.abs[:len(z.abs):len(z.abs)] // no spare capacity
+		for i := 0; i < 10000; i++ {
+			z.Lsh(z, 1)
+		}
+	})
+	if allocs > 10 {
+		t.Errorf("10000 times z.Lsh(z, 1): got %v allocations; want <= 10", allocs)
+	}
+	z.Neg(z)
+	allocs = testing.AllocsPerRun(10, func() {
+		for i := 0; i < 1000; i++ {
+			z.Rsh(z, 1)
+		}
+		z.Lsh(z, 1000)
+	})
+	if allocs > 0 {
+		t.Errorf("1000 times z.Rsh(z, 1): got %v allocations; want 0", allocs)
+	}
+}
+
+func TestDivAllocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	if race.Enabled {
+		t.Skip("skipping in race mode: the pools drop items")
+	}
+	x, _ := new(Int).SetString("-0x123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef", 0)
+	y, _ := new(Int).SetString("0xfedcba9876543210fedcba9876543210f", 0)
+	q, r := new(Int).QuoRem(x, y, new(Int)) // storage for the results
+	for _, test := range []struct {
+		name string
+		f    func()
+	}{
+		{"Quo", func() { q.Quo(x, y) }},
+		{"Rem", func() { r.Rem(x, y) }},
+		{"QuoRem", func() { q.QuoRem(x, y, r) }},
+		{"Div", func() { q.Div(x, y) }},
+		{"Mod", func() { r.Mod(x, y) }},
+	} {
+		// 1000 divisions take their temporaries from the pool
+		allocs := testing.AllocsPerRun(10, func() {
+			for i := 0; i < 1000; i++ {
+				test.f()
+			}
+		})
+		if allocs > 10 {
+			t.Errorf("1000 times %s: got %v allocations; want <= 10", test.name, allocs)
+		}
+	}
+}
+
+func TestGCDAllocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	if race.Enabled {
+		t.Skip("skipping in race mode: the pools drop items")
+	}
+	r := rand.New(rand.NewSource(1))
+	z := new(Int)
+	// sizes for both the binary and Lehmer's algorithm
+	for _, bits := range []uint{256, 2048, 20000} {
+		a := new(Int).Rand(r, new(Int).Lsh(intOne, bits))
+		b := new(Int).Rand(r, new(Int).Lsh(intOne, bits))
+		z.GCD(nil, nil, a, b) // storage for the result
+		// 100 GCDs take their temporaries from the pools
+		allocs := testing.AllocsPerRun(10, func() {
+			for i := 0; i < 100; i++ {
+				z.GCD(nil, nil, a, b)
+			}
+		})
+		if allocs > 10 {
+			t.Errorf("100 times GCD of %d-bit values: got %v allocations; want <= 10", bits, allocs)
+		}
+	}
+}
+
+// testCallerStorage checks that f(z), for z using storage of the caller's
+// as set by SetBits, doesn't hand that storage to the pools of temporaries,
+// where other calls of f would overwrite it.
+func testCallerStorage(t *testing.T, name string, f func(z *Int)) {
+	buf := make([]Word, 200)
+	z := new(Int).SetBits(buf[:0])
+	f(z)
+	want := append([]Word(nil), buf...)
+	for i := 0; i < 10; i++ {
+		f(new(Int))
+	}
+	for i := range buf {
+		if buf[i] != want[i] {
+			t.Errorf("%s overwrote the storage of the caller's", name)
+			return
+		}
+	}
+}
+
+func TestGCDCallerStorage(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	// sizes for both the binary and Lehmer's algorithm
+	for _, bits := range []uint{256, 4000} {
+		a := new(Int).Rand(r, new(Int).Lsh(intOne, bits))
+		b := new(Int).Rand(r, new(Int).Lsh(intOne, bits))
+		testCallerStorage(t, fmt.Sprintf("GCD of %d-bit values", bits), func(z *Int) {
+			z.GCD(nil, nil, a, b)
+		})
+		testCallerStorage(t, fmt.Sprintf("GCD cofactor x of %d-bit values", bits), func(z *Int) {
+			new(Int).GCD(z, new(Int), a, b)
+		})
+		testCallerStorage(t, fmt.Sprintf("GCD cofactor y of %d-bit values", bits), func(z *Int) {
+			new(Int).GCD(new(Int), z, a, b)
+		})
+	}
+}
+
+func TestLcm(t *testing.T) {
+	for _, test := range []struct {
+		a, b, lcm int64
+	}{
+		{0, 0, 0},
+		{0, 5, 0},
+		{-7, 0, 0},
+		{1, 1, 1},
+		{4, 6, 12},
+		{-4, 6, 12},
+		{4, -6, 12},
+		{-4, -6, 12},
+		{7, 13, 91},
+		{12, 4, 12},
+		{1 << 40, 1 << 20, 1 << 40},
+	} {
+		a, b := NewInt(test.a), NewInt(test.b)
+		if got := new(Int).Lcm(a, b); got.Int64() != test.lcm {
+			t.Errorf("Lcm(%d, %d) = %s; want %d", test.a, test.b, got, test.lcm)
+		}
+	}
+
+	r := rand.New(rand.NewSource(2))
+	for _, bits := range []uint{64, 256, 2048, 20000} {
+		a := new(Int).Rand(r, new(Int).Lsh(intOne, bits))
+		b := new(Int).Rand(r, new(Int).Lsh(intOne, bits))
+		c := new(Int).Rand(r, new(Int).Lsh(intOne, bits/2))
+		a.Mul(a, c).Neg(a) // a common factor
+		b.Mul(b, c)
+		g := new(Int).GCD(nil, nil, new(Int).Abs(a), b)
+		want := new(Int).Mul(a, b)
+		want.Abs(want).Quo(want, g)
+		if got := new(Int).Lcm(a, b); got.Cmp(want) != 0 {
+			t.Errorf("Lcm of %d-bit values = %s; want %s", bits, got, want)
+		}
+		// z aliases an operand
+		z := new(Int).Set(a)
+		if got := z.Lcm(z, b); got.Cmp(want) != 0 {
+			t.Errorf("aliased Lcm of %d-bit values = %s; want %s", bits, got, want)
+		}
+		z.Set(b)
+		if got := z.Lcm(a, z); got.Cmp(want) != 0 {
+			t.Errorf("aliased Lcm of %d-bit values = %s; want %s", bits, got, want)
+		}
+	}
+}
+
 func TestLshRsh(t *testing.T) {
 	for i, test := range rshTests {
 		in, _ := new(Int).SetString(test.in, 10)
@@ -938,6 +1937,16 @@ func TestInt64(t *testing.T) {
 				if x.IsInt64() {
 					t.Errorf("IsInt64(%s) succeeded unexpectedly", s)
 				}
+				sat := int64(math.MaxInt64)
+				if x.Sign() < 0 {
+					sat = math.MinInt64
+				}
+				if got := x.Int64Sat(); got != sat {
+					t.Errorf("Int64Sat(%s) = %d; want %d", s, got, sat)
+				}
+				if got, err := x.Int64Checked(); got != sat || err != ErrRange {
+					t.Errorf("Int64Checked(%s) = %d, %v; want %d, ErrRange", s, got, err, sat)
+				}
 			} else {
 				t.Errorf("ParseInt(%s) failed", s)
 			}
@@ -952,6 +1961,52 @@ func TestInt64(t *testing.T) {
 		if got != want {
 			t.Errorf("Int64(%s) = %d; want %d", s, got, want)
 		}
+		if got := x.Int64Sat(); got != want {
+			t.Errorf("Int64Sat(%s) = %d; want %d", s, got, want)
+		}
+		if got, err := x.Int64Checked(); got != want || err != nil {
+			t.Errorf("Int64Checked(%s) = %d, %v; want %d, nil", s, got, err, want)
+		}
+	}
+}
+
+func TestIntFloat64(t *testing.T) {
+	for _, test := range []struct {
+		istr string
+		f    float64
+		acc  Accuracy
+	}{
+		{"-1", -1, Exact},
+		{"0", 0, Exact},
+		{"1", 1, Exact},
+		{"4294967296", 4294967296, Exact},
+		{"0x1fffffffffffff", 1<<53 - 1, Exact}, // 53 bits
+		{"0x20000000000000", 1 << 53, Exact},
+		{"0x20000000000001", 1 << 53, Below},   // halfway, rounds to even
+		{"0x20000000000003", 1<<53 + 4, Above}, // halfway, rounds to even
+		{"0x7ffffffffffffc00", 0x7ffffffffffffc00, Exact},
+		{"0x7ffffffffffffc01", 0x7ffffffffffffc00, Below},
+		{"-0x7ffffffffffffc01", -0x7ffffffffffffc00, Above},
+		{"0x8000000000000000", 1 << 63, Exact},
+		{"0xffffffffffffffff", 1 << 64, Above},
+		{"-0xffffffffffffffff", -(1 << 64), Below},
+		{"1" + strings.Repeat("0", 308), 1e308, Above},
+		{"0x" + strings.Repeat("f", 256), math.Inf(1), Above},
+		{"-0x" + strings.Repeat("f", 256), math.Inf(-1), Below},
+	} {
+		i, ok := new(Int).SetString(test.istr, 0)
+		if !ok {
+			t.Fatalf("SetString(%s) failed", test.istr)
+		}
+		// Test against expectation.
+		f, acc := i.Float64()
+		if f != test.f || acc != test.acc {
+			t.Errorf("%s: got %v (%v); want %v (%v)", test.istr, f, acc, test.f, test.acc)
+		}
+		// Cross-check the fast path against Float.
+		if f2, acc2 := new(Float).SetInt(i).Float64(); f != f2 || acc != acc2 {
+			t.Errorf("%s: got %v (%v); Float.Float64 gives %v (%v)", test.istr, f, acc, f2, acc2)
+		}
 	}
 }
 
@@ -989,6 +2044,16 @@ func TestUint64(t *testing.T) {
 				if x.IsUint64() {
 					t.Errorf("IsUint64(%s) succeeded unexpectedly", s)
 				}
+				sat := uint64(math.MaxUint64)
+				if x.Sign() < 0 {
+					sat = 0
+				}
+				if got := x.Uint64Sat(); got != sat {
+					t.Errorf("Uint64Sat(%s) = %d; want %d", s, got, sat)
+				}
+				if got, err := x.Uint64Checked(); got != sat || err != ErrRange {
+					t.Errorf("Uint64Checked(%s) = %d, %v; want %d, ErrRange", s, got, err, sat)
+				}
 			} else {
 				t.Errorf("ParseUint(%s) failed", s)
 			}
@@ -1003,6 +2068,12 @@ func TestUint64(t *testing.T) {
 		if got != want {
 			t.Errorf("Uint64(%s) = %d; want %d", s, got, want)
 		}
+		if got := x.Uint64Sat(); got != want {
+			t.Errorf("Uint64Sat(%s) = %d; want %d", s, got, want)
+		}
+		if got, err := x.Uint64Checked(); got != want || err != nil {
+			t.Errorf("Uint64Checked(%s) = %d, %v; want %d, nil", s, got, err, want)
+		}
 	}
 }
 
@@ -1151,6 +2222,28 @@ var bitsetTests = []struct {
 	{"-0x2000000000000000000000000001", 110, 1},
 }
 
+func TestNextSetBit(t *testing.T) {
+	for _, s := range []string{
+		"0", "1", "2", "-1", "-2", "0x80000000", "0xffffffff", "-0x100000000",
+		"0x8000000000000000", "0x10000000000000001", "-0x10000000000000000",
+		"0x123456789abcdef0123456789abcdef0", "-0x123456789abcdef0123456789abcdef",
+	} {
+		x, _ := new(Int).SetString(s, 0)
+		for i := 0; i < x.BitLen()+2*_W; i++ {
+			want := -1
+			for j := i; j <= x.BitLen()+2*_W; j++ {
+				if x.Bit(j) == 1 {
+					want = j
+					break
+				}
+			}
+			if got := x.NextSetBit(i); got != want {
+				t.Errorf("(%s).NextSetBit(%d) = %d; want %d", s, i, got, want)
+			}
+		}
+	}
+}
+
 func TestBitSet(t *testing.T) {
 	for _, test := range bitwiseTests {
 		x := new(Int)
@@ -1268,6 +2361,44 @@ func BenchmarkModSqrt5430_3Mod4(b *testing.B) {
 	}
 }
 
+// highValuationPrime returns the least prime p = k*2^e + 1 with odd k
+// and p of the given bit length.
+func highValuationPrime(bits int, e uint) *Int {
+	k := new(Int).Lsh(intOne, uint(bits)-e-1)
+	k.Add(k, intOne)
+	p := new(Int)
+	for {
+		p.Lsh(k, e).Add(p, intOne)
+		if p.ProbablyPrime(10) {
+			return p
+		}
+		k.Add(k, NewInt(2))
+	}
+}
+
+func BenchmarkModSqrtHighValuation(b *testing.B) {
+	for _, bits := range []int{256, 1024} {
+		for _, e := range []uint{3, 16, 32, 64, 128} {
+			p := highValuationPrime(bits, e)
+			x := NewInt(7)
+			for Jacobi(x, p) != 1 {
+				x.Add(x, intOne)
+			}
+			z := new(Int)
+			b.Run(fmt.Sprintf("bits=%d/e=%d/Tonelli", bits, e), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					z.modSqrtTonelliShanks(x, p)
+				}
+			})
+			b.Run(fmt.Sprintf("bits=%d/e=%d/Cipolla", bits, e), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					z.modSqrtCipolla(x, p)
+				}
+			})
+		}
+	}
+}
+
 func TestBitwise(t *testing.T) {
 	x := new(Int)
 	y := new(Int)
@@ -1360,6 +2491,46 @@ func TestModInverse(t *testing.T) {
 	}
 }
 
+func TestModInverseChecked(t *testing.T) {
+	for n := int64(-3); n < 60; n++ {
+		for g := -2 * n; g <= 2*n+1; g++ {
+			var want *Int
+			if n > 0 {
+				want = new(Int).Mod(NewInt(g), NewInt(n))
+				if new(Int).GCD(nil, nil, want, NewInt(n)).Cmp(intOne) == 0 || n == 1 {
+					want.ModInverse(want, NewInt(n))
+				} else {
+					want = nil
+				}
+			}
+			z := NewInt(42)
+			got, ok := z.ModInverseChecked(NewInt(g), NewInt(n))
+			if want == nil {
+				if got != nil || ok || z.Int64() != 42 {
+					t.Errorf("ModInverseChecked(%d, %d) = %v, %v, z = %s; want nil, false, 42", g, n, got, ok, z)
+				}
+				continue
+			}
+			if got != z || !ok || got.Cmp(want) != 0 {
+				t.Errorf("ModInverseChecked(%d, %d) = %v, %v; want %s, true", g, n, got, ok, want)
+			}
+			if n&1 == 0 {
+				continue // marked operands need an odd modulus
+			}
+			x := NewInt(g).SetConstantTime(64)
+			got, ok = new(Int).ModInverseChecked(x, NewInt(n))
+			if !ok || got.Cmp(want) != 0 || got.zcap == 0 {
+				t.Errorf("marked ModInverseChecked(%d, %d) = %v (width %d), %v; want %s, true", g, n, got, got.zcap, ok, want)
+			}
+			// aliased operands
+			x = NewInt(g)
+			if got, ok = x.ModInverseChecked(x, NewInt(n)); !ok || x.Cmp(want) != 0 {
+				t.Errorf("aliased ModInverseChecked(%d, %d) = %v, %v; want %s, true", g, n, got, ok, want)
+			}
+		}
+	}
+}
+
 // testModSqrt is a helper for TestModSqrt,
 // which checks that ModSqrt can compute a square-root of elt^2.
 func testModSqrt(t *testing.T, elt, mod, sq, sqrt *Int) bool {
@@ -1445,6 +2616,57 @@ func TestModSqrt(t *testing.T) {
 	}
 }
 
+func TestModSqrtAlgorithms(t *testing.T) {
+	r := rand.New(rand.NewSource(10))
+	for _, test := range []struct {
+		bits int
+		e    uint
+	}{{64, 2}, {256, 2}, {64, 3}, {256, 3}, {256, 20}, {256, 70}, {521, 100}} {
+		p := highValuationPrime(test.bits, test.e)
+		algorithms := map[string]func(z, x, p *Int) *Int{
+			"TonelliShanks": (*Int).modSqrtTonelliShanks,
+			"Cipolla":       (*Int).modSqrtCipolla,
+		}
+		if p.abs[0]%8 == 5 {
+			algorithms["5Mod8"] = (*Int).modSqrt5Mod8Prime
+		}
+		for i := 0; i < 10; i++ {
+			x := new(Int).Rand(r, p)
+			sq := new(Int).Mul(x, x)
+			sq.Mod(sq, p)
+			for name, f := range algorithms {
+				z := f(new(Int), sq, p)
+				if z == nil || new(Int).Mul(z, z).Mod(new(Int).Mul(z, z), p).Cmp(sq) != 0 {
+					t.Errorf("%s(%s, %s) = %v is not a square root", name, sq, p, z)
+				}
+			}
+			if z := new(Int).ModSqrt(sq, p); z == nil || new(Int).Mul(z, z).Mod(new(Int).Mul(z, z), p).Cmp(sq) != 0 {
+				t.Errorf("ModSqrt(%s, %s) = %v is not a square root", sq, p, z)
+			}
+		}
+	}
+
+	// For composite moduli, ModSqrt may fail, but must not return a
+	// value that is not a square root.
+	for n := int64(9); n < 300; n += 2 {
+		p := NewInt(n)
+		if p.ProbablyPrime(10) {
+			continue
+		}
+		for x := int64(0); x < n; x++ {
+			sq := NewInt(x)
+			z := NewInt(-1)
+			if got := z.ModSqrt(sq, p); got == nil {
+				if z.Int64() != -1 {
+					t.Errorf("failing ModSqrt(%d, %d) changed z to %s", x, n, z)
+				}
+			} else if got.Int64()*got.Int64()%n != x {
+				t.Errorf("ModSqrt(%d, %d) = %s is not a square root", x, n, got)
+			}
+		}
+	}
+}
+
 func TestJacobi(t *testing.T) {
 	testCases := []struct {
 		x, y   int64
@@ -1536,6 +2758,114 @@ func TestSqrt(t *testing.T) {
 	}
 }
 
+func TestSqrtRem(t *testing.T) {
+	check := func(x, s, r *Int) {
+		s2 := new(Int).Mul(s, s)
+		if !isNormalized(s) || !isNormalized(r) || s2.Add(s2, r).Cmp(x) != 0 || r.Sign() < 0 || r.Cmp(s2.Lsh(s, 1)) > 0 {
+			t.Errorf("SqrtRem(%x) = %x, %x", x, s, r)
+		}
+	}
+	for _, th := range []int{3, karatsubaSqrtThreshold} {
+		defer func(th int) { karatsubaSqrtThreshold = th }(karatsubaSqrtThreshold)
+		karatsubaSqrtThreshold = th
+		r := rand.New(rand.NewSource(0))
+		for bits := uint(0); bits < 40*_W; bits += 1 + bits/16 {
+			x := new(Int).Rand(r, new(Int).Lsh(intOne, bits))
+			x.SetBit(x, int(bits), 1)
+			for _, x := range []*Int{x, new(Int).Mul(x, x), new(Int).Sub(new(Int).Mul(x, x), intOne)} {
+				if x.Sign() < 0 {
+					continue
+				}
+				s, rem := new(Int).SqrtRem(x, new(Int))
+				check(x, s, rem)
+				if s.Cmp(new(Int).Sqrt(x)) != 0 {
+					t.Errorf("SqrtRem(%x) = %x; Sqrt = %x", x, s, new(Int).Sqrt(x))
+				}
+			}
+		}
+	}
+
+	// aliasing
+	x := new(Int).Lsh(intOne, 1000)
+	x.Sub(x, intOne)
+	want, wantR := new(Int).SqrtRem(x, new(Int))
+	y := new(Int).Set(x)
+	if s, r := new(Int).SqrtRem(y, y); s.Cmp(want) != 0 || r.Cmp(wantR) != 0 {
+		t.Errorf("aliased SqrtRem = %x, %x; want %x, %x", s, r, want, wantR)
+	}
+	z := new(Int).Set(x)
+	if s, r := z.SqrtRem(z, new(Int)); s.Cmp(want) != 0 || r.Cmp(wantR) != 0 {
+		t.Errorf("aliased SqrtRem = %x, %x; want %x, %x", s, r, want, wantR)
+	}
+
+	// marked operands
+	x.SetConstantTime(1000)
+	s, r := new(Int).SqrtRem(x, new(Int))
+	if s.Cmp(want) != 0 || r.Cmp(wantR) != 0 || s.zcap == 0 || r.zcap == 0 {
+		t.Errorf("marked SqrtRem = %x, %x (widths %d, %d); want %x, %x", s, r, s.zcap, r.zcap, want, wantR)
+	}
+}
+
+func TestRoot(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, n := range []uint{1, 2, 3, 4, 5, 7, 10, 64, 100, 1000} {
+		for bits := uint(0); bits < 3000; bits += 1 + bits/8 {
+			x := new(Int).Rand(r, new(Int).Lsh(intOne, bits))
+			if r.Intn(4) == 0 && bits*n < 1<<16 {
+				// a perfect power, or one less
+				x.Exp(x, NewInt(int64(n)), nil)
+				if x.Sign() > 0 {
+					x.Sub(x, NewInt(int64(r.Intn(2))))
+				}
+			}
+			if n&1 == 1 && r.Intn(2) == 0 {
+				x.Neg(x)
+			}
+			z, rem := new(Int).RootRem(x, n, new(Int))
+			if !isNormalized(z) || !isNormalized(rem) {
+				t.Errorf("RootRem(%x, %d) = %x, %x not normalized", x, n, z, rem)
+			}
+			// |z|**n <= |x| < (|z|+1)**n, with signs of x
+			p := new(Int).Exp(z, NewInt(int64(n)), nil)
+			if p.Add(p, rem).Cmp(x) != 0 || rem.Sign()*x.Sign() < 0 {
+				t.Errorf("RootRem(%x, %d) = %x, %x; want z**n + r = x", x, n, z, rem)
+			}
+			a := new(Int).Abs(z)
+			if a.Add(a, intOne).Exp(a, NewInt(int64(n)), nil).Cmp(new(Int).Abs(x)) <= 0 {
+				t.Errorf("RootRem(%x, %d) = %x; root too small", x, n, z)
+			}
+			if got := new(Int).Root(x, n); got.Cmp(z) != 0 {
+				t.Errorf("Root(%x, %d) = %x; want %x", x, n, got, z)
+			}
+			if got := new(Int).Set(x); got.Root(got, n).Cmp(z) != 0 {
+				t.Errorf("aliased Root(%x, %d) = %x; want %x", x, n, got, z)
+			}
+			y := new(Int).Set(x)
+			if new(Int).RootRem(y, n, y); y.Cmp(rem) != 0 {
+				t.Errorf("aliased RootRem(%x, %d) remainder = %x; want %x", x, n, y, rem)
+			}
+		}
+	}
+
+	for _, test := range []struct {
+		x int64
+		n uint
+	}{
+		{8, 0},
+		{-8, 2},
+		{-1, 4},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Root(%d, %d) did not panic", test.x, test.n)
+				}
+			}()
+			new(Int).Root(NewInt(test.x), test.n)
+		}()
+	}
+}
+
 func BenchmarkSqrt(b *testing.B) {
 	n, _ := new(Int).SetString("1"+strings.Repeat("0", 1001), 10)
 	b.ResetTimer()
@@ -1544,3 +2874,37 @@ func BenchmarkSqrt(b *testing.B) {
 		t.Sqrt(n)
 	}
 }
+
+func BenchmarkRoot(b *testing.B) {
+	x := new(Int).Lsh(intOne, 10000)
+	x.Sub(x, intOne)
+	z := new(Int)
+	for _, n := range []uint{3, 17, 1000} {
+		b.Run(fmt.Sprint(n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				z.Root(x, n)
+			}
+		})
+	}
+}
+
+func BenchmarkSqrtRem(b *testing.B) {
+	r := rand.New(rand.NewSource(0))
+	for _, n := range []int{4, 8, 16, 32, 100, 1000} {
+		x := new(Int).Rand(r, new(Int).Lsh(intOne, uint(n*_W)))
+		s, rem := new(Int), new(Int)
+		for _, th := range []int{1 << 30, 3} {
+			name := "newton"
+			if th == 3 {
+				name = "karatsuba"
+			}
+			b.Run(fmt.Sprintf("%d/%s", n, name), func(b *testing.B) {
+				defer func(th int) { karatsubaSqrtThreshold = th }(karatsubaSqrtThreshold)
+				karatsubaSqrtThreshold = th
+				for i := 0; i < b.N; i++ {
+					s.SqrtRem(x, rem)
+				}
+			})
+		}
+	}
+}
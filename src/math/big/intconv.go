@@ -10,6 +10,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
+	"unicode/utf8"
 )
 
 // TODO(gri) Should rename itoa to utoa (there's no sign). That
@@ -19,13 +21,19 @@ import (
 // string copy if the number is negative.
 
 // Text returns the string representation of x in the given base.
-// Base must be between 2 and 36, inclusive. The result uses the
-// lower-case letters 'a' to 'z' for digit values >= 10. No base
-// prefix (such as "0x") is added to the string.
+// Base must be between 2 and 62, inclusive. For bases <= 36, the
+// result uses the lower-case letters 'a' to 'z' for digit values 10
+// to 35. For bases > 36, it uses the upper-case letters 'A' to 'Z'
+// for digit values 10 to 35, and the lower-case letters 'a' to 'z'
+// for digit values 36 to 61.
+// No base prefix (such as "0x") is added to the string.
 func (x *Int) Text(base int) string {
 	if x == nil {
 		return "<nil>"
 	}
+	if x.zcap != 0 || varTimeDisabled() {
+		return string(x.appendCT(nil, base))
+	}
 	return string(x.abs.itoa(x.neg, base))
 }
 
@@ -35,9 +43,49 @@ func (x *Int) Append(buf []byte, base int) []byte {
 	if x == nil {
 		return append(buf, "<nil>"...)
 	}
+	if x.zcap != 0 || varTimeDisabled() {
+		return x.appendCT(buf, base)
+	}
 	return append(buf, x.abs.itoa(x.neg, base)...)
 }
 
+// GroupedText returns the string representation of x in the given base,
+// as generated by x.Text(base), with sep inserted between the groups of
+// n digits counted from the least significant digit. For instance,
+// x.GroupedText(10, 3, ",") returns "-1,234,567" for x == -1234567.
+// If n <= 0, no separators are inserted.
+func (x *Int) GroupedText(base, n int, sep string) string {
+	if x == nil {
+		return "<nil>"
+	}
+	var digits []byte
+	if x.zcap != 0 || varTimeDisabled() {
+		digits = x.digitsCT(base)
+	} else {
+		digits = x.abs.utoa(base)
+	}
+	if n <= 0 || len(digits) <= n {
+		if x.neg {
+			return "-" + string(digits)
+		}
+		return string(digits)
+	}
+
+	// build the result in a single buffer of the final length
+	k := (len(digits) - 1) / n // number of separators
+	buf := make([]byte, 0, 1+len(digits)+k*len(sep))
+	if x.neg {
+		buf = append(buf, '-')
+	}
+	i := len(digits) - k*n // length of the leading group
+	buf = append(buf, digits[:i]...)
+	for ; i < len(digits); i += n {
+		buf = append(buf, sep...)
+		buf = append(buf, digits[i:i+n]...)
+	}
+	return string(buf)
+}
+
 func (x *Int) String() string {
 	return x.Text(10)
 }
@@ -112,7 +160,12 @@ func (x *Int) Format(s fmt.State, ch rune) {
 		}
 	}
 
-	digits := x.abs.utoa(base)
+	var digits []byte
+	if x.zcap != 0 || varTimeDisabled() {
+		digits = x.digitsCT(base)
+	} else {
+		digits = x.abs.utoa(base)
+	}
 	if ch == 'X' {
 		// faster than bytes.ToUpper
 		for i, d := range digits {
@@ -171,8 +224,12 @@ func (x *Int) Format(s fmt.State, ch rune) {
 //
 // The base argument must be 0 or a value from 2 through MaxBase. If the base
 // is 0, the string prefix determines the actual conversion base. A prefix of
-// ``0x'' or ``0X'' selects base 16; the ``0'' prefix selects base 8, and a
-// ``0b'' or ``0B'' prefix selects base 2. Otherwise the selected base is 10.
+// ``0b'' or ``0B'' selects base 2, ``0o'' or ``0O'' selects base 8, and
+// ``0x'' or ``0X'' selects base 16. Otherwise, the ``0'' prefix selects
+// base 8, and without a prefix the selected base is 10. For base 0 only,
+// an underscore character ``_'' may appear between a base prefix and an
+// adjacent digit, and between successive digits; such underscores do not
+// change the value of the number.
 //
 func (z *Int) scan(r io.ByteScanner, base int) (*Int, int, error) {
 	// determine sign
@@ -191,6 +248,85 @@ func (z *Int) scan(r io.ByteScanner, base int) (*Int, int, error) {
 	return z, base, nil
 }
 
+// A ParseError records why Parse failed to convert a string to an Int.
+type ParseError struct {
+	Text   string // the string being parsed
+	Offset int    // byte offset of the invalid character in Text, or len(Text)
+	Base   int    // conversion base, as determined by Text's prefix for base 0
+	Rune   rune   // the invalid character, or -1 if Text ends prematurely
+}
+
+func (e *ParseError) Error() string {
+	if e.Rune < 0 {
+		return fmt.Sprintf("math/big: missing digits at end of base %d number (offset %d)", e.Base, e.Offset)
+	}
+	return fmt.Sprintf("math/big: invalid character %q in base %d number at offset %d", e.Rune, e.Base, e.Offset)
+}
+
+// Parse is like SetString, but if s is not a valid number in the given
+// base, it returns nil and a *ParseError that locates the first invalid
+// character of s.
+func (z *Int) Parse(s string, base int) (*Int, error) {
+	r := strings.NewReader(s)
+	_, b, err := z.scan(r, base)
+	if err == nil {
+		// entire string must have been consumed
+		if _, err = r.ReadByte(); err == io.EOF {
+			return z, nil // err == io.EOF => scan consumed all of s
+		}
+		r.UnreadByte()
+	}
+	// A failing scan leaves the character where it stopped unread. A
+	// misplaced underscore is reported after the number has been
+	// scanned, so it is the error only if it comes before that
+	// character, as in "1__2" but not in "0_8".
+	e := &ParseError{Text: s, Offset: len(s) - r.Len(), Base: b, Rune: -1}
+	if err == errInvalSep {
+		e.Offset = min(e.Offset, invalidSeparator(s))
+	}
+	if e.Offset < len(s) {
+		e.Rune, _ = utf8.DecodeRuneInString(s[e.Offset:])
+	}
+	if base == 0 && e.Base == 10 && octalPrefix(s) {
+		// scan reports a lone octal prefix "0" as base 10
+		e.Base = 8
+	}
+	if e.Base == 0 {
+		e.Base = base
+		if base == 0 {
+			e.Base = 10
+		}
+	}
+	return nil, e
+}
+
+// octalPrefix reports whether the number s, possibly signed, starts with
+// the octal prefix "0" followed by something other than a base letter.
+func octalPrefix(s string) bool {
+	if len(s) > 0 && (s[0] == '+' || s[0] == '-') {
+		s = s[1:]
+	}
+	return len(s) > 1 && s[0] == '0' && strings.IndexByte("bBoOxX", s[1]) < 0
+}
+
+// invalidSeparator returns the offset of the first underscore in s that
+// doesn't separate a base prefix or digit from a digit, or len(s).
+func invalidSeparator(s string) int {
+	alnum := func(i int) bool {
+		if i < 0 || i >= len(s) {
+			return false
+		}
+		ch := s[i] | 0x20 // lower case letter
+		return '0' <= s[i] && s[i] <= '9' || 'a' <= ch && ch <= 'z'
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] == '_' && (!alnum(i-1) || !alnum(i+1)) {
+			return i
+		}
+	}
+	return len(s)
+}
+
 func scanSign(r io.ByteScanner) (neg bool, err error) {
 	var ch byte
 	if ch, err = r.ReadByte(); err != nil {
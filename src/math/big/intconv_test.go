@@ -7,6 +7,7 @@ package big
 import (
 	"bytes"
 	"fmt"
+	"internal/race"
 	"testing"
 )
 
@@ -56,6 +57,25 @@ var stringTests = []struct {
 	{"-0b111", "-7", 0, -7, true},
 	{"0b1001010111", "599", 0, 0x257, true},
 	{"1001010111", "1001010111", 2, 0x257, true},
+
+	// base prefixes and separators
+	{in: "0o"},
+	{in: "0o8"},
+	{in: "_1"},
+	{in: "1_"},
+	{in: "1__0"},
+	{in: "-_1"},
+	{in: "0x_"},
+	{in: "0_x1"},
+	{in: "1_0", base: 10},
+	{in: "0o17", base: 8},
+	{"0o17", "15", 0, 15, true},
+	{"-0O17", "-15", 0, -15, true},
+	{"0_7", "7", 0, 7, true},
+	{"1_000_000", "1000000", 0, 1e6, true},
+	{"0x_cafe_babe", "3405691582", 0, 0xcafebabe, true},
+	{"0b_1001_0101", "149", 0, 0x95, true},
+	{"0o_7_7", "63", 0, 63, true},
 }
 
 func TestIntText(t *testing.T) {
@@ -109,6 +129,29 @@ func TestAppendText(t *testing.T) {
 	}
 }
 
+func TestAppendAllocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	if race.Enabled {
+		t.Skip("skipping in race mode: the pools drop items")
+	}
+	for _, n := range []uint{100, 2000, 50000} {
+		x := new(Int).Sub(new(Int).Lsh(intOne, n), intOne)
+		buf := x.Append(nil, 10)
+		// 100 conversions take their temporaries from the pool, but
+		// convert the digits into a buffer of their own
+		allocs := testing.AllocsPerRun(10, func() {
+			for i := 0; i < 100; i++ {
+				buf = x.Append(buf[:0], 10)
+			}
+		})
+		if allocs > 110 {
+			t.Errorf("100 times Append of a %d-bit value: got %v allocations; want <= 110", n, allocs)
+		}
+	}
+}
+
 func format(base int) string {
 	switch base {
 	case 2:
@@ -183,6 +226,101 @@ func TestSetString(t *testing.T) {
 	}
 }
 
+func TestParse(t *testing.T) {
+	for _, test := range []struct {
+		in     string
+		base   int
+		offset int
+		b      int
+		r      rune
+	}{
+		{"", 0, 0, 10, -1},
+		{"-", 10, 1, 10, -1},
+		{"0x", 0, 2, 16, -1},
+		{"0xg", 0, 2, 16, 'g'},
+		{"12a4", 10, 2, 10, 'a'},
+		{"-0b1012", 0, 6, 2, '2'},
+		{"ff_ff", 16, 2, 16, '_'},
+		{"123 ", 0, 3, 10, ' '},
+		{"7€", 8, 1, 8, '€'},
+		{"zz.", 36, 2, 36, '.'},
+		{"0o18", 0, 3, 8, '8'},
+		{"1__0", 0, 1, 10, '_'},
+		{"0x12_", 0, 4, 16, '_'},
+		{"-_1", 0, 1, 10, '_'},
+		{"0_8", 0, 2, 8, '8'},
+		{"0_x1", 0, 2, 8, 'x'},
+		{"08_1", 0, 1, 8, '8'},
+		{"-09", 0, 2, 8, '9'},
+		{"0_", 0, 1, 8, '_'},
+		{"1_x", 0, 2, 10, 'x'},
+	} {
+		z, err := new(Int).Parse(test.in, test.base)
+		e, ok := err.(*ParseError)
+		if z != nil || !ok {
+			t.Errorf("Parse(%q, %d) = %v, %v; want nil, *ParseError", test.in, test.base, z, err)
+			continue
+		}
+		if e.Text != test.in || e.Offset != test.offset || e.Base != test.b || e.Rune != test.r {
+			t.Errorf("Parse(%q, %d): got %+v; want offset %d, base %d, rune %q", test.in, test.base, *e, test.offset, test.b, test.r)
+		}
+		if e.Error() == "" {
+			t.Errorf("Parse(%q, %d): empty error message", test.in, test.base)
+		}
+	}
+	for _, test := range stringTests {
+		z, err := new(Int).Parse(test.in, test.base)
+		if (err == nil) != test.ok || test.ok && z.Int64() != test.val {
+			t.Errorf("Parse(%q, %d) = %v, %v; want %d, ok = %v", test.in, test.base, z, err, test.val, test.ok)
+		}
+	}
+}
+
+func TestGroupedText(t *testing.T) {
+	for _, test := range []struct {
+		x      string
+		base   int
+		n      int
+		sep    string
+		output string
+	}{
+		{"0", 10, 3, ",", "0"},
+		{"999", 10, 3, ",", "999"},
+		{"1000", 10, 3, ",", "1,000"},
+		{"-1234567", 10, 3, ",", "-1,234,567"},
+		{"-123456", 10, 3, ",", "-123,456"},
+		{"1234567", 10, 0, ",", "1234567"},
+		{"1234567", 10, 1, "", "1234567"},
+		{"12345678901234567890", 10, 3, "\u202f", "12\u202f345\u202f678\u202f901\u202f234\u202f567\u202f890"},
+		{"0xdeadbeefcafe", 16, 4, "_", "dead_beef_cafe"},
+		{"0b101010", 2, 4, " ", "10 1010"},
+	} {
+		x, _ := new(Int).SetString(test.x, 0)
+		if got := x.GroupedText(test.base, test.n, test.sep); got != test.output {
+			t.Errorf("%s.GroupedText(%d, %d, %q) = %q; want %q", test.x, test.base, test.n, test.sep, got, test.output)
+		}
+		x.SetConstantTime(x.BitLen() + 1)
+		if got := x.GroupedText(test.base, test.n, test.sep); got != test.output {
+			t.Errorf("constant-time %s.GroupedText(%d, %d, %q) = %q; want %q", test.x, test.base, test.n, test.sep, got, test.output)
+		}
+	}
+
+	// grouping a large number must agree with inserting the separators
+	// into its Text
+	x := new(Int).Lsh(NewInt(3), 10000)
+	s := x.Text(10)
+	var want []byte
+	for i := range s {
+		if i > 0 && (len(s)-i)%3 == 0 {
+			want = append(want, ',')
+		}
+		want = append(want, s[i])
+	}
+	if got := x.GroupedText(10, 3, ","); got != string(want) {
+		t.Errorf("GroupedText of 3<<10000 = %s; want %s", got, want)
+	}
+}
+
 var formatTests = []struct {
 	input  string
 	format string
@@ -0,0 +1,677 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements constant-time operations on Ints.
+// See natct.go for the underlying nat operations.
+
+package big
+
+import (
+	"io"
+	"sync/atomic"
+)
+
+// FillBytesCT sets buf to the absolute value of x, storing it as a
+// zero-extended big-endian byte slice, and returns buf.
+//
+// Unlike that of FillBytes, the running time of FillBytesCT depends
+// only on len(buf) and the width of x if it is marked with
+// SetConstantTime, not on the number of leading zero words of x, so it is
+// suitable for serializing secret values such as shared Diffie-Hellman
+// secrets.
+//
+// If the absolute value of x doesn't fit in buf, FillBytesCT will panic.
+func (x *Int) FillBytesCT(buf []byte) []byte {
+	abs := nat(nil).cpad(x.abs, max(x.ctWords(), (len(buf)+_S-1)/_S))
+	abs.cbytes(buf)
+	abs.wipe()
+	return buf
+}
+
+// FillTwosCT sets buf to the value of x in two's complement, storing it
+// as a sign-extended big-endian byte slice, and returns buf. Like
+// FillBytesCT, the running time depends only on len(buf) and the word
+// length of x, so it is suitable for serializing signed secret values.
+//
+// If x is not in the range [-2**(8*len(buf)-1), 2**(8*len(buf)-1)),
+// FillTwosCT will panic.
+func (x *Int) FillTwosCT(buf []byte) []byte {
+	x.abs.ctwos(boolWord(x.neg), buf)
+	return buf
+}
+
+// SetTwosCT interprets buf as the bytes of a big-endian two's complement
+// integer, sets z to that value, and returns z. The value is decoded in
+// constant time with respect to the contents of buf, and z is marked as
+// constant-time with the width of buf (see SetConstantTime).
+func (z *Int) SetTwosCT(buf []byte) *Int {
+	abs, neg := z.abs.csetTwos(buf)
+	return z.setCT(abs, neg, len(abs))
+}
+
+// RandCT sets z to a random number in [0, n), using random bytes read
+// from rand, and returns z. If n <= 0, RandCT sets z to 0. If reading
+// from rand fails, RandCT returns nil and the error; z is left unchanged.
+//
+// Unlike Rand, which uses rejection sampling, RandCT reads a number of
+// bytes from rand that depends only on the bit length of n, and reduces
+// the result modulo n in constant time. The result is uniformly
+// distributed up to a statistical distance of at most 2**-64, so RandCT
+// is suitable for generating secret nonces and blinding factors when
+// used with a cryptographically secure source such as crypto/rand.Reader.
+func (z *Int) RandCT(rand io.Reader, n *Int) (*Int, error) {
+	if n.neg || len(n.abs) == 0 {
+		z.neg = false
+		z.abs = nil
+		return z, nil
+	}
+	abs, err := z.abs.crandom(rand, n.abs)
+	if err != nil {
+		return nil, err
+	}
+	z.abs = abs.norm()
+	z.neg = false
+	return z, nil
+}
+
+// CondSelect sets z to x if v == 1 and to y if v == 0, and returns z.
+// If v is not 0 or 1, CondSelect will panic.
+//
+// CondSelect does not branch on v or on the values of x and y: its
+// running time and memory access pattern depend only on the word
+// lengths of x and y. Since the result is normalized, its word length
+// may still reveal which of x and y was selected if their lengths differ.
+func (z *Int) CondSelect(x, y *Int, v uint) *Int {
+	if v > 1 {
+		panic("condition is not 0 or 1")
+	}
+	n := max(len(x.abs), len(y.abs))
+	xp := nat(nil).cpad(x.abs, n)
+	yp := nat(nil).cpad(y.abs, n)
+	xneg, yneg := boolWord(x.neg), boolWord(y.neg)
+	neg := yneg ^ -Word(v)&(xneg^yneg)
+	z.abs = z.abs.sel(xp, yp, Word(v)).norm()
+	z.neg = len(z.abs) > 0 && neg != 0 // 0 has no sign
+	return z
+}
+
+// CondSwap swaps the values of x and y if v == 1 and leaves
+// them unchanged if v == 0. If v is not 0 or 1, CondSwap will panic.
+//
+// Like CondSelect, CondSwap does not branch on v or on the values
+// of x and y.
+func (x *Int) CondSwap(y *Int, v uint) {
+	if v > 1 {
+		panic("condition is not 0 or 1")
+	}
+	n := max(len(x.abs), len(y.abs))
+	xp := x.abs.cpad(x.abs, n)
+	yp := y.abs.cpad(y.abs, n)
+	cswap(xp, yp, Word(v))
+	xneg, yneg := boolWord(x.neg), boolWord(y.neg)
+	t := -Word(v) & (xneg ^ yneg)
+	xneg, yneg = xneg^t, yneg^t
+	x.abs, y.abs = xp.norm(), yp.norm()
+	x.neg = len(x.abs) > 0 && xneg != 0 // 0 has no sign
+	y.neg = len(y.abs) > 0 && yneg != 0
+}
+
+// ModWordCT returns |x| mod d together with a mask that is all ones
+// if d divides x and zero otherwise. If d == 0, a division-by-zero
+// run-time panic occurs.
+//
+// Unlike computing the remainder with Mod, ModWordCT uses no hardware
+// division and runs in time that depends only on the word length of x,
+// so it is suitable for testing secret values for divisibility, as in
+// primality tests of secret candidates or padding checks. The mask makes
+// it possible to combine several such tests without branching.
+func (x *Int) ModWordCT(d Word) (r, mask Word) {
+	if d == 0 {
+		panic("division by zero")
+	}
+	return x.abs.cdivisibleW(d)
+}
+
+// ModInversePow2CT sets z to the multiplicative inverse of x modulo
+// 2**k and returns z. The result is in the range [0, 2**k). Such inverses,
+// which exist for all odd x, are used in Montgomery multiplication with
+// multi-word moduli and in the CRT decomposition of even moduli.
+//
+// The running time depends only on k, and the result is marked as
+// constant-time with the width of k bits (see SetConstantTime). Only the
+// sign and the least significant bit of x affect the flow of control:
+// if x is even, ModInversePow2CT panics.
+func (z *Int) ModInversePow2CT(x *Int, k uint) *Int {
+	if k > 0 && (len(x.abs) == 0 || x.abs[0]&1 == 0) {
+		panic("math/big: ModInversePow2CT of even number")
+	}
+	abs := nat(nil).cinverse2k(x.abs, k)
+	if x.neg {
+		// (-x)**-1 = -(x**-1) mod 2**k
+		abs.cneg(1)
+		if r := k % _W; r != 0 {
+			abs[len(abs)-1] &= 1<<r - 1
+		}
+	}
+	return z.setCT(abs, 0, len(abs))
+}
+
+// HasSmallPrimeFactorCT reports whether |x| is divisible by any prime
+// less than 54. If |x| is itself such a prime, the result is true.
+//
+// HasSmallPrimeFactorCT is intended for sieving secret prime candidates
+// during key generation. Unlike the trial division performed by
+// ProbablyPrime, it runs in time that depends only on the word length of
+// x and always tests every prime, so it reveals nothing about which small
+// prime divides a rejected candidate.
+func (x *Int) HasSmallPrimeFactorCT() bool {
+	return x.abs.csmallPrimeFactor() != 0
+}
+
+// SetConstantTime marks z as holding a secret value of at most the given
+// number of bits, and returns z. If bits <= 0, the mark is removed.
+// The width is rounded up to a whole number of Words. If the current
+// value of z does not fit in the declared width, SetConstantTime panics.
+//
+// Whenever the receiver or any operand of one of the operations listed
+// below is marked, the operation is computed with constant-time algorithms
+// whose running time and memory access pattern depend only on the declared
+// widths of the operands, and the result is marked with a width large
+// enough to hold it for any operands of these widths:
+//
+//	Set, Rsh          the width of the operand
+//	Lsh               the width of the operand plus the shift
+//	Sqrt              half the width of the operand
+//	Add, Sub          one Word more than the largest operand width
+//	Mul               the sum of the operand widths
+//	Quo, Div          the width of the dividend
+//	Rem, Mod          the width of the divisor
+//	QuoRem, DivMod    the widths of Quo and Rem, or of Div and Mod
+//	Exp, ModInverse   the width of the modulus
+//
+// Unmarked operands count with their actual length. The width of the
+// result replaces the previous mark of the receiver, so that the widths of
+// values that are repeatedly reduced modulo the same modulus stay bounded.
+// Exp is computed in constant time only with a non-zero modulus, and
+// ModInverse only with an odd one; it panics for a marked value and an
+// even modulus.
+//
+// Likewise, Cmp, Bit, Text, Append, String, and Format compute their
+// results in constant time if x or y is marked; the formatted digits
+// omit leading zeros, but they reveal no more than the value itself.
+// GCD and ModSqrt, which have no constant-time algorithms, panic if
+// an operand is marked. All other operations, such as BitLen or And,
+// run in variable time regardless of the mark.
+//
+// Results are stored in normalized form, so the number of leading zero
+// Words of a secret value is still observable; for values of the declared
+// width this reveals information only with negligible probability.
+//
+// To run the constant-time algorithms for unmarked operands as well, see
+// SetVarTimeAllowed.
+func (z *Int) SetConstantTime(bits int) *Int {
+	if bits <= 0 {
+		z.zcap = 0
+		return z
+	}
+	zcap := (bits + _W - 1) / _W
+	if len(z.abs) > zcap {
+		panic("math/big: value exceeds declared constant-time width")
+	}
+	z.zcap = zcap
+	return z
+}
+
+// varTimeOff is 1 if variable-time algorithms have been disabled
+// with SetVarTimeAllowed, and 0 otherwise.
+var varTimeOff int32
+
+// varTimeDisabled reports whether variable-time algorithms are disabled.
+func varTimeDisabled() bool {
+	return atomic.LoadInt32(&varTimeOff) != 0
+}
+
+// SetVarTimeAllowed sets the policy for operations on Int values that are
+// not marked with SetConstantTime, and returns the previous policy. By
+// default, variable-time algorithms are allowed. If allowed is false, all
+// operations that SetConstantTime lists as computed in constant time for
+// marked values use their constant-time algorithms for all operands, as if
+// every operand were marked with its actual length, but they compute the
+// same values and leave the results unmarked, so that results don't change
+// and no operation panics on account of the policy. The operations without
+// constant-time algorithms, GCD, ModSqrt, Root, and ModInverse with an even
+// modulus, still run in variable time, as do the operations that ignore
+// the mark.
+//
+// The policy applies to the whole program and is intended for security
+// audits: running an application with variable-time algorithms disabled
+// makes it possible to compare its behavior and timings and to find
+// secret values that were not marked. It is safe to change the policy
+// at any time from any goroutine, but operations already in progress
+// are not affected.
+func SetVarTimeAllowed(allowed bool) (previous bool) {
+	var off int32
+	if !allowed {
+		off = 1
+	}
+	return atomic.SwapInt32(&varTimeOff, off) == 0
+}
+
+// VarTimeAllowed reports whether variable-time algorithms are allowed
+// for unmarked operands; see SetVarTimeAllowed.
+func VarTimeAllowed() bool {
+	return !varTimeDisabled()
+}
+
+// Wipe sets z to 0 after overwriting all words of its underlying buffer,
+// including any excess capacity, with zeros, and detaches the buffer from z.
+// It is intended for scrubbing long-lived secrets such as private keys
+// from memory once they are no longer needed. The constant-time mark of z
+// (see SetConstantTime) is not changed.
+//
+// Wipe cannot reach copies of the value that were made by earlier
+// operations, such as values held by other Ints or in buffers that were
+// released to the garbage collector; those must be wiped separately.
+func (z *Int) Wipe() {
+	z.abs.wipe()
+	z.abs = nil
+	z.neg = false
+}
+
+// ctWords returns the length in words at which x is processed by
+// constant-time operations: its declared width if it is marked
+// and its actual length otherwise.
+func (x *Int) ctWords() int {
+	return max(x.zcap, len(x.abs))
+}
+
+// setCT sets z to the constant-time result abs with sign bit neg
+// and width zcap, and returns z.
+func (z *Int) setCT(abs nat, neg Word, zcap int) *Int {
+	neg &= abs.cnonzero() // 0 has no sign
+	z.abs = abs.norm()
+	z.neg = neg != 0
+	z.zcap = zcap
+	return z
+}
+
+// addCT sets z to x + y, with the sign of y replaced by yneg,
+// in constant time and returns z. It implements Add and Sub.
+func (z *Int) addCT(x, y *Int, yneg Word) *Int {
+	n := max(x.ctWords(), y.ctWords())
+	zcap := n + 1 // room for the carry
+	xa := nat(nil).cpad(x.abs, n)
+	ya := nat(nil).cpad(y.abs, n)
+	xneg := boolWord(x.neg)
+
+	// If the signs are equal, the result is ±(|x| + |y|) with the sign
+	// of x; otherwise it is ±||x| - |y||, with the sign of x flipped if
+	// the subtraction borrowed.
+	same := ctIsZero(xneg ^ yneg)
+	s := nat(nil).cadd(xa, ya, zcap)
+	d, b := nat(nil).csub(xa, ya)
+	d.cneg(b)
+	d = d.cpad(d, zcap)
+	abs := s.sel(s, d, same).cnorm(zcap)
+	neg := same&xneg | (same^1)&(xneg^b)
+	return z.setCT(abs, neg, zcap)
+}
+
+// mulCT sets z to x * y in constant time and returns z.
+func (z *Int) mulCT(x, y *Int) *Int {
+	zcap := max(x.ctWords()+y.ctWords(), 1)
+	xa := nat(nil).cpad(x.abs, x.ctWords())
+	ya := nat(nil).cpad(y.abs, y.ctWords())
+	var abs nat
+	if x == y {
+		abs = nat(nil).csqr(xa, zcap)
+	} else {
+		abs = nat(nil).cmul(xa, ya, zcap)
+	}
+	return z.setCT(abs, boolWord(x.neg)^boolWord(y.neg), zcap)
+}
+
+// modCT sets z to the Euclidean modulus x mod y in constant time
+// and returns z. If y == 0, a division-by-zero run-time panic occurs.
+func (z *Int) modCT(x, y *Int) *Int {
+	if len(y.abs) == 0 {
+		panic("division by zero")
+	}
+	zcap := y.ctWords()
+	xa := nat(nil).cpad(x.abs, x.ctWords())
+	m := nat(nil).cpad(y.abs, zcap)
+	r := nat(nil).cmod(xa, m)
+	// For negative x with r != 0, the Euclidean modulus is |y| - r.
+	t, _ := nat(nil).csub(m, r)
+	r = r.sel(t, r, boolWord(x.neg)&r.cnonzero())
+	return z.setCT(r.cnorm(zcap), 0, zcap)
+}
+
+// quoRemCT sets z to the truncated quotient x/y and, if r is not nil,
+// r to the remainder x%y in constant time, and returns z and r. If y == 0,
+// a division-by-zero run-time panic occurs.
+func (z *Int) quoRemCT(x, y, r *Int) (*Int, *Int) {
+	if len(y.abs) == 0 {
+		panic("division by zero")
+	}
+	qcap, rcap := max(x.ctWords(), 1), y.ctWords()
+	xa := nat(nil).cpad(x.abs, x.ctWords())
+	q, rem := nat(nil).cdiv(nil, xa, nat(nil).cpad(y.abs, rcap))
+	xneg, yneg := boolWord(x.neg), boolWord(y.neg)
+	xa.wipe()
+	if r != nil {
+		r.setCT(rem.cnorm(rcap), xneg, rcap)
+	}
+	return z.setCT(q.cnorm(qcap), xneg^yneg, qcap), r
+}
+
+// divModCT sets z to the Euclidean quotient x div y and, if m is not nil,
+// m to the modulus x mod y in constant time, and returns z and m. If y == 0,
+// a division-by-zero run-time panic occurs.
+func (z *Int) divModCT(x, y, m *Int) (*Int, *Int) {
+	if len(y.abs) == 0 {
+		panic("division by zero")
+	}
+	qcap, rcap := max(x.ctWords(), 1), y.ctWords()
+	xa := nat(nil).cpad(x.abs, x.ctWords())
+	ya := nat(nil).cpad(y.abs, rcap)
+	q, r := nat(nil).cdiv(nil, xa, ya)
+	xneg, yneg := boolWord(x.neg), boolWord(y.neg)
+	xa.wipe()
+
+	// For negative x with r != 0, the truncated remainder -r is
+	// increased by |y|, and |q| by 1.
+	adj := xneg & r.cnonzero()
+	if m != nil {
+		t, _ := nat(nil).csub(ya, r)
+		r = r.sel(t, r, adj)
+		m.setCT(r.cnorm(rcap), 0, rcap)
+	}
+	q = q.cpad(q, qcap)
+	addVW(q, q, adj) // |q| < |x| if the remainder is not zero
+	return z.setCT(q, xneg^yneg, qcap), m
+}
+
+// lshCT sets z = x << s in constant time and returns z.
+func (z *Int) lshCT(x *Int, s uint) *Int {
+	n, k := x.ctWords(), int(s/_W)
+	zcap := max(n+int((s+_W-1)/_W), 1)
+	abs := nat(nil).make(zcap)
+	abs.clear()
+	xa := nat(nil).cpad(x.abs, n)
+	if s%_W != 0 {
+		abs[n+k] = shlVU(abs[k:n+k], xa, s%_W)
+	} else {
+		copy(abs[k:], xa)
+	}
+	xa.wipe()
+	return z.setCT(abs, boolWord(x.neg), zcap)
+}
+
+// rshCT sets z = x >> s in constant time and returns z.
+func (z *Int) rshCT(x *Int, s uint) *Int {
+	// (-x) >> s == -(((x-1) >> s) + 1), as for Rsh
+	zcap := max(x.ctWords(), 1)
+	neg := boolWord(x.neg)
+	t := nat(nil).cpad(x.abs, zcap)
+	subVW(t, t, neg) // no underflow because |x| > 0 if x is negative
+	abs := nat(nil).make(zcap)
+	abs.clear()
+	if k := int(s / _W); k < zcap {
+		shrVU(abs[:zcap-k], t[k:], s%_W)
+	}
+	addVW(abs, abs, neg)
+	t.wipe()
+	return z.setCT(abs, neg, zcap)
+}
+
+// sqrtCT sets z to ⌊√x⌋ in constant time and returns z. x must be >= 0.
+func (z *Int) sqrtCT(x *Int) *Int {
+	n := max(x.ctWords(), 1)
+	xa := nat(nil).cpad(x.abs, n)
+	abs := nat(nil).csqrt(xa)
+	xa.wipe()
+	return z.setCT(abs, 0, len(abs))
+}
+
+// sqrtRemCT sets z to ⌊√x⌋ and r to x - z² in constant time, and returns
+// z and r. x must not be negative.
+func (z *Int) sqrtRemCT(x, r *Int) (*Int, *Int) {
+	var s, s2 Int
+	s.sqrtCT(x)
+	s2.mulCT(&s, &s)
+	r.addCT(x, &s2, 1)
+	z.Set(&s)
+	s.Wipe()
+	s2.Wipe()
+	return z, r
+}
+
+// cmpCT compares x and y in constant time, like Cmp.
+func (x *Int) cmpCT(y *Int) int {
+	n := max(x.ctWords(), y.ctWords())
+	xa := nat(nil).cpad(x.abs, n)
+	ya := nat(nil).cpad(y.abs, n)
+	lt, gt := ccmpVV(xa, ya) // |x| < |y|, |x| > |y|
+	xa.wipe()
+	ya.wipe()
+
+	// For negative operands, the comparison of the absolute values is
+	// reversed. If the signs differ, the negative operand is the smaller,
+	// since 0 has no sign.
+	xneg, yneg := boolWord(x.neg), boolWord(y.neg)
+	same := ctIsZero(xneg ^ yneg)
+	lt, gt = lt^(xneg&(lt^gt)), gt^(xneg&(lt^gt))
+	lt = same&lt | (same^1)&xneg
+	gt = same&gt | (same^1)&yneg
+	return int(gt) - int(lt)
+}
+
+// cmpAbsCT compares the absolute values of x and y in constant time,
+// like CmpAbs.
+func (x *Int) cmpAbsCT(y *Int) int {
+	n := max(x.ctWords(), y.ctWords())
+	xa := nat(nil).cpad(x.abs, n)
+	ya := nat(nil).cpad(y.abs, n)
+	lt, gt := ccmpVV(xa, ya)
+	xa.wipe()
+	ya.wipe()
+	return int(gt) - int(lt)
+}
+
+// bitCT returns the value of the i'th bit of x in constant time, like Bit.
+// i must be >= 0.
+func (x *Int) bitCT(i int) uint {
+	// bit i of -x is the inverse of bit i of x-1
+	n := x.ctWords()
+	neg := boolWord(x.neg)
+	t := nat(nil).cpad(x.abs, n)
+	subVW(t, t, neg) // no underflow because |x| > 0 if x is negative
+	var b Word
+	if i/_W < n {
+		b = t[i/_W] >> (uint(i) % _W) & 1
+	}
+	t.wipe()
+	return uint(b ^ neg)
+}
+
+// appendCT appends the string representation of x in the given base to
+// buf, like Append, and returns the extended buffer. The digits are
+// computed with digitsCT.
+func (x *Int) appendCT(buf []byte, base int) []byte {
+	if x.neg {
+		buf = append(buf, '-')
+	}
+	return append(buf, x.digitsCT(base)...)
+}
+
+// digitsCT returns the digits of |x| in the given base, like utoa, but
+// converts x in constant time (see TextCT) before it strips the leading
+// zeros, which reveals only what the digits reveal anyway.
+func (x *Int) digitsCT(base int) []byte {
+	n := x.ctWords()
+	abs := nat(nil).cpad(x.abs, n)
+	s := abs.cutoa(base, ndigitsCT(Word(base), n*_W))
+	abs.wipe()
+	i := 0
+	for i < len(s)-1 && s[i] == '0' {
+		i++
+	}
+	return s[i:]
+}
+
+// modInverseCT sets z to the inverse of g modulo n in constant time
+// and returns z. n must be odd.
+func (z *Int) modInverseCT(g, n *Int) *Int {
+	if len(n.abs) == 0 || n.abs[0]&1 == 0 {
+		panic("math/big: ModInverse of value marked as constant-time requires an odd modulus")
+	}
+	zcap := n.ctWords()
+	var r Int
+	r.modCT(g, n)
+	xa := nat(nil).cpad(r.abs, zcap)
+	m := nat(nil).cpad(n.abs, zcap)
+	abs := nat(nil).cmodInverse(xa, m)
+	xa.wipe()
+	r.Wipe()
+	return z.setCT(abs, 0, zcap)
+}
+
+// expCT sets z to x**y mod |m| in constant time and returns z.
+// m must be != 0. If y <= 0, the result is 1 mod |m|.
+func (z *Int) expCT(x, y, m *Int) *Int {
+	zcap := m.ctWords()
+	yWords := y.expWordsCT()
+	xa := nat(nil).cpad(x.abs, x.ctWords())
+	var abs nat
+	if m.zcap == 0 && !varTimeDisabled() && m.abs[0]&1 == 1 {
+		// The modulus is public, so its parity may select the faster
+		// algorithm.
+		abs = nat(nil).cexpNNOdd(xa, yWords, m.abs, zcap)
+	} else {
+		abs = nat(nil).cexpNN(xa, yWords, nat(nil).cpad(m.abs, zcap), zcap)
+	}
+	return z.setExpCT(abs, x, yWords, m, zcap)
+}
+
+// invertBaseCT is like invertBase, but computes the inverse of x in
+// constant time, as for x marked with its length. m must be odd.
+func invertBaseCT(x, y, m *Int) (*Int, *Int) {
+	if !y.neg {
+		return x, y
+	}
+	xs := new(Int).Set(x).SetConstantTime(max(x.ctWords(), 1) * _W)
+	defer xs.Wipe()
+	return invertBase(xs, y, m)
+}
+
+// expWordsCT returns the exponent y zero-extended to its constant-time
+// width, or nil if y <= 0.
+func (y *Int) expWordsCT() nat {
+	if y.neg {
+		return nil
+	}
+	return nat(nil).cpad(y.abs, y.ctWords())
+}
+
+// setExpCT sets z to the result of a constant-time exponentiation of x
+// to the power yWords, given abs = |x|**yWords mod |m| with zcap words,
+// and returns z.
+func (z *Int) setExpCT(abs nat, x *Int, yWords nat, m *Int, zcap int) *Int {
+	// For negative x and odd y, the result is |m| - (|x|**y mod |m|),
+	// unless that is 0.
+	var odd Word
+	if len(yWords) > 0 {
+		odd = yWords[0] & 1
+	}
+	t, _ := nat(nil).csub(m.abs, abs)
+	abs = abs.sel(t.cnorm(zcap), abs, boolWord(x.neg)&odd&abs.cnonzero())
+	return z.setCT(abs, 0, zcap)
+}
+
+// ExpBlinded sets z = x**y mod |m| and returns z, like Exp, but computes
+// the result in constant time and blinds the computation with random
+// values read from rand, as a defense in depth against timing and fault
+// attacks. The modulus m must be non-zero. As for Exp, if y == 0, the
+// result is 1 mod |m|, and if y < 0, it is (x**-1)**|y| mod |m|, with the
+// inverse of x modulo |m| computed in constant time, which requires an
+// odd m; if x and m are not relatively prime, ExpBlinded returns nil and
+// a nil error, and z is left unchanged.
+//
+// If e is not nil, the base is blinded by multiplying it with r**e for a
+// random r, and the result is unblinded by multiplying it with r**-1. For
+// the result to be correct, e*y must be 1 modulo the multiplicative order
+// of every r invertible modulo m. This holds for the public exponent e and
+// the private exponent y of an RSA key with modulus m. Since e is public,
+// blinding the base costs little more than an exponentiation by e. The
+// inverse of r is computed in variable time, so that with e, the modulus
+// is treated as public, as it is for RSA.
+//
+// If order is not nil, the exponent is additionally blinded by adding a
+// random 64-bit multiple of order to it. For the result to be correct,
+// order must then be a multiple of the multiplicative order of x modulo m,
+// such as the group order in DSA or φ(m) in RSA, and x must be invertible
+// modulo m.
+//
+// If reading from rand fails, ExpBlinded returns nil and the error;
+// z is left unchanged.
+func (z *Int) ExpBlinded(x, y, m, e, order *Int, rand io.Reader) (*Int, error) {
+	if m == nil || len(m.abs) == 0 {
+		panic("math/big: ExpBlinded requires a non-zero modulus")
+	}
+	if y.neg {
+		if x, y = invertBaseCT(x, y, m); x == nil {
+			return nil, nil
+		}
+		defer x.Wipe()
+	}
+	if len(y.abs) == 0 {
+		// x**0 needs no blinding, which would only give r**-1
+		return z.expCT(x, y, m), nil
+	}
+	zcap := m.ctWords()
+	yWords := y.expWordsCT()
+	xa := nat(nil).cpad(x.abs, x.ctWords())
+	defer func() {
+		yWords.wipe()
+		xa.wipe()
+	}()
+	var pub, ord nat
+	if e != nil {
+		if e.Sign() <= 0 {
+			panic("math/big: ExpBlinded requires a positive public exponent")
+		}
+		pub = e.abs
+	}
+	if order != nil {
+		ord = order.abs
+	}
+	abs, err := nat(nil).cexpNNBlinded(xa, yWords, nat(nil).cpad(m.abs, zcap), pub, ord, rand, zcap)
+	if err != nil {
+		return nil, err
+	}
+	return z.setExpCT(abs, x, yWords, m, zcap), nil
+}
+
+// TextCT returns the string representation of x in the given base,
+// using exactly as many digits as are needed to represent any value of
+// the given bit width, zero-padded on the left. Base must be between 2
+// and MaxBase, and a negative value is prefixed with '-', as for Text. If |x|
+// does not fit in the given number of bits, TextCT panics.
+//
+// Unlike Text, TextCT does not use the recursive subdivision algorithm
+// and its running time, memory access pattern, and output length do not
+// depend on the value of |x|, so it is suitable for formatting secret
+// values such as private keys. The sign of x is not concealed.
+func (x *Int) TextCT(base, bits int) string {
+	if x.abs.bitLen() > bits {
+		panic("math/big: value exceeds bit width for TextCT")
+	}
+	abs := nat(nil).cpad(x.abs, (bits+_W-1)/_W)
+	s := abs.cutoa(base, ndigitsCT(Word(base), bits))
+	if x.neg {
+		return "-" + string(s)
+	}
+	return string(s)
+}
@@ -0,0 +1,673 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+	"testing/quick"
+)
+
+func checkFillBytesCT(b []byte) bool {
+	x := new(Int).SetBytes(b)
+	buf := make([]byte, len(b)+3)
+	for i := range buf {
+		buf[i] = 0xff // make sure padding is overwritten
+	}
+	want := append([]byte{0, 0, 0}, b...)
+	return bytes.Equal(x.FillBytesCT(buf), want)
+}
+
+func TestFillBytesCT(t *testing.T) {
+	if err := quick.Check(checkFillBytesCT, nil); err != nil {
+		t.Error(err)
+	}
+
+	// exact fit, including a value with leading zero bytes
+	for _, s := range []string{"0", "1", "ff", "0100", "deadbeefcafebabe", "0123456789abcdef0123"} {
+		x, _ := new(Int).SetString(s, 16)
+		n := (len(s) + 1) / 2
+		got := x.FillBytesCT(make([]byte, n))
+		if new(Int).SetBytes(got).Cmp(x) != 0 || len(got) != n {
+			t.Errorf("FillBytesCT(%s) = %x", s, got)
+		}
+	}
+
+	// overflow must panic
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("FillBytesCT did not panic on overflow")
+			}
+		}()
+		x, _ := new(Int).SetString("10000", 16)
+		x.FillBytesCT(make([]byte, 2))
+	}()
+}
+
+func TestTwosCT(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for n := 0; n <= 3*_S; n++ {
+		mod := new(Int).Lsh(intOne, uint(8*n))
+		half := new(Int).Rsh(mod, 1)
+		lo := new(Int).Neg(half)         // -2**(8n-1)
+		hi := new(Int).Sub(half, intOne) // 2**(8n-1) - 1
+		values := []*Int{new(Int)}
+		out := []*Int{NewInt(-1), NewInt(1)}
+		if n > 0 {
+			values = append(values, lo, hi, NewInt(-1), new(Int).Rand(r, half), new(Int).Neg(new(Int).Rand(r, half)))
+			out = []*Int{new(Int).Sub(lo, intOne), half}
+		}
+		for _, x := range values {
+			want := new(Int).Mod(x, mod).Bytes()
+			want = append(make([]byte, n-len(want)), want...)
+			buf := make([]byte, n)
+			for i := range buf {
+				buf[i] = 0x5a // make sure the buffer is overwritten
+			}
+			if got := x.FillTwosCT(buf); !bytes.Equal(got, want) {
+				t.Errorf("FillTwosCT(%s) with %d bytes = %x; want %x", x, n, got, want)
+			}
+			y := new(Int).SetTwosCT(want)
+			if y.Cmp(x) != 0 {
+				t.Errorf("SetTwosCT(%x) = %s; want %s", want, y, x)
+			}
+			if w := (n + _S - 1) / _S; y.zcap != w {
+				t.Errorf("SetTwosCT(%x): width %d; want %d", want, y.zcap, w)
+			}
+		}
+
+		// values just out of range must panic
+		for _, x := range out {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("FillTwosCT(%s) with %d bytes did not panic", x, n)
+					}
+				}()
+				x.FillTwosCT(make([]byte, n))
+			}()
+		}
+	}
+}
+
+// countingReader is an io.Reader that produces pseudo-random bytes
+// and counts how many were read.
+type countingReader struct {
+	rnd *rand.Rand
+	n   int
+}
+
+func (r *countingReader) Read(buf []byte) (int, error) {
+	for i := range buf {
+		buf[i] = byte(r.rnd.Intn(256))
+	}
+	r.n += len(buf)
+	return len(buf), nil
+}
+
+func TestRandCT(t *testing.T) {
+	r := &countingReader{rnd: rand.New(rand.NewSource(1))}
+	for _, s := range []string{"1", "2", "3", "255", "256", "12345678901234567890", "0x" + strings.Repeat("f", 100)} {
+		n, _ := new(Int).SetString(s, 0)
+		want := (n.BitLen() + 64 + 7) / 8
+		var z Int
+		for i := 0; i < 20; i++ {
+			r.n = 0
+			if _, err := z.RandCT(r, n); err != nil {
+				t.Fatal(err)
+			}
+			if z.Sign() < 0 || z.Cmp(n) >= 0 || !isNormalized(&z) {
+				t.Errorf("RandCT(%s) = %s out of range", s, &z)
+			}
+			if r.n != want {
+				t.Errorf("RandCT(%s) read %d bytes; want %d", s, r.n, want)
+			}
+		}
+	}
+
+	// a small limit should produce all possible values
+	var seen [7]bool
+	n := NewInt(7)
+	for i := 0; i < 1000; i++ {
+		z, _ := new(Int).RandCT(r, n)
+		seen[z.Int64()] = true
+	}
+	for i, ok := range seen {
+		if !ok {
+			t.Errorf("RandCT(7) never produced %d", i)
+		}
+	}
+
+	if z, err := new(Int).RandCT(bytes.NewReader(nil), n); z != nil || err == nil {
+		t.Errorf("RandCT with empty reader = %v, %v; want nil, error", z, err)
+	}
+}
+
+var condTests = []struct {
+	x, y string
+}{
+	{"0", "0"},
+	{"0", "1"},
+	{"-1", "0"},
+	{"-123456789012345678901234567890", "42"},
+	{"0x" + strings.Repeat("ab", 40), "-0x" + strings.Repeat("cd", 20)},
+}
+
+func TestCondSelect(t *testing.T) {
+	for _, test := range condTests {
+		x, _ := new(Int).SetString(test.x, 0)
+		y, _ := new(Int).SetString(test.y, 0)
+		for v, want := range []*Int{y, x} {
+			var z Int
+			z.CondSelect(x, y, uint(v))
+			if z.Cmp(want) != 0 || !isNormalized(&z) {
+				t.Errorf("CondSelect(%s, %s, %d) = %s; want %s", x, y, v, &z, want)
+			}
+			// aliased receiver
+			z.Set(x)
+			z.CondSelect(&z, y, uint(v))
+			if z.Cmp(want) != 0 {
+				t.Errorf("z.CondSelect(z=%s, %s, %d) = %s; want %s", x, y, v, &z, want)
+			}
+		}
+	}
+}
+
+func TestCondSwap(t *testing.T) {
+	for _, test := range condTests {
+		x0, _ := new(Int).SetString(test.x, 0)
+		y0, _ := new(Int).SetString(test.y, 0)
+		for v := uint(0); v <= 1; v++ {
+			x := new(Int).Set(x0)
+			y := new(Int).Set(y0)
+			x.CondSwap(y, v)
+			wantX, wantY := x0, y0
+			if v == 1 {
+				wantX, wantY = y0, x0
+			}
+			if x.Cmp(wantX) != 0 || y.Cmp(wantY) != 0 || !isNormalized(x) || !isNormalized(y) {
+				t.Errorf("CondSwap(%s, %s, %d) = %s, %s; want %s, %s", x0, y0, v, x, y, wantX, wantY)
+			}
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("CondSwap did not panic with v == 2")
+		}
+	}()
+	new(Int).CondSwap(new(Int), 2)
+}
+
+func TestModWordCT(t *testing.T) {
+	for _, test := range []struct {
+		x string
+		d Word
+		r Word
+	}{
+		{"0", 1, 0},
+		{"0", 7, 0},
+		{"13", 7, 6},
+		{"-14", 7, 0},
+		{"123456789012345678901234567890", 10, 0},
+		{"123456789012345678901234567891", 1000000007, 197434843},
+	} {
+		x, _ := new(Int).SetString(test.x, 10)
+		r, mask := x.ModWordCT(test.d)
+		wantMask := Word(0)
+		if test.r == 0 {
+			wantMask = _M
+		}
+		if r != test.r || mask != wantMask {
+			t.Errorf("%s.ModWordCT(%d) = %d, %#x; want %d, %#x", test.x, test.d, r, mask, test.r, wantMask)
+		}
+	}
+}
+
+func TestModInversePow2CT(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for k := uint(0); k <= 5*_W; k++ {
+		mod := new(Int).Lsh(intOne, k)
+		for i := 0; i < 5; i++ {
+			x := new(Int).Rand(r, new(Int).Lsh(intOne, k+uint(r.Intn(2*_W))))
+			x.SetBit(x, 0, 1)
+			if i&1 == 1 {
+				x.Neg(x)
+			}
+			z := new(Int).ModInversePow2CT(x, k)
+			want := new(Int).Mod(x, mod)
+			if k > 0 {
+				want.ModInverse(want, mod)
+			}
+			if z.Cmp(want) != 0 {
+				t.Errorf("ModInversePow2CT(%s, %d) = %s; want %s", x, k, z, want)
+			}
+			if w := int(k+_W-1) / _W; z.zcap != w {
+				t.Errorf("ModInversePow2CT(%s, %d): width %d; want %d", x, k, z.zcap, w)
+			}
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("ModInversePow2CT of an even number did not panic")
+		}
+	}()
+	new(Int).ModInversePow2CT(NewInt(6), 10)
+}
+
+func TestModInverseCT(t *testing.T) {
+	r := rand.New(rand.NewSource(6))
+	for _, bits := range []uint{2, _W - 1, _W, 3 * _W, 521} {
+		for i := 0; i < 20; i++ {
+			n := randInt(r, bits)
+			n.SetBit(n, 0, 1)
+			if n.Cmp(intOne) == 0 {
+				continue
+			}
+			g := new(Int).Rand(r, n)
+			if i%4 == 1 {
+				g.Neg(g)
+			}
+			if i%4 == 2 {
+				g.Add(g, new(Int).Lsh(n, 3))
+			}
+			if new(Int).GCD(nil, nil, new(Int).Abs(g), n).Cmp(intOne) != 0 {
+				continue
+			}
+			want := new(Int).ModInverse(g, n)
+			gs := new(Int).Set(g).SetConstantTime(int(bits) + 8)
+			ns := new(Int).Set(n).SetConstantTime(int(bits) + _W)
+			for _, got := range []*Int{
+				new(Int).ModInverse(gs, n),
+				new(Int).ModInverse(g, ns),
+				new(Int).SetConstantTime(_W).ModInverse(g, n),
+			} {
+				if got.Cmp(want) != 0 || !isNormalized(got) {
+					t.Errorf("ModInverse(%s, %s) = %s; want %s", g, n, got, want)
+				}
+				if got.zcap == 0 {
+					t.Errorf("ModInverse(%s, %s): result is not marked", g, n)
+				}
+			}
+		}
+	}
+
+	// GCD of marked values panics
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Errorf("GCD of marked value did not panic")
+			}
+		}()
+		new(Int).GCD(nil, nil, NewInt(6).SetConstantTime(_W), NewInt(4))
+	}()
+
+	// GCD keeps the marks of its results
+	z, x, y := new(Int).SetConstantTime(_W), new(Int).SetConstantTime(2*_W), new(Int)
+	for _, a := range []*Int{NewInt(6), new(Int).Lsh(NewInt(6), 10*_W), new(Int).Lsh(NewInt(6), 2000*_W)} {
+		b := new(Int).Add(new(Int).Lsh(a, 1), NewInt(4))
+		z.GCD(x, y, a, b)
+		if z.zcap != 1 || x.zcap != 2 || y.zcap != 0 {
+			t.Errorf("GCD: widths %d, %d, %d; want 1, 2, 0", z.zcap, x.zcap, y.zcap)
+		}
+	}
+}
+
+func TestHasSmallPrimeFactorCT(t *testing.T) {
+	for _, test := range []struct {
+		x    string
+		want bool
+	}{
+		{"0", true},
+		{"1", false},
+		{"2", true},
+		{"-53", true},
+		{"59", false},
+		{"3481", false}, // 59*59
+		{"170141183460469231731687303715884105727", false}, // 2**127-1
+		{"7486658675213528821296671296438616106050319230600477488359440479985273617294704922422677085420275547699593410595339200075597614045843177777124097309445274494507", true},
+	} {
+		x, _ := new(Int).SetString(test.x, 10)
+		if got := x.HasSmallPrimeFactorCT(); got != test.want {
+			t.Errorf("%s.HasSmallPrimeFactorCT() = %v; want %v", test.x, got, test.want)
+		}
+	}
+}
+
+func TestSetConstantTime(t *testing.T) {
+	r := rand.New(rand.NewSource(3))
+	rnd := func(words int) *Int {
+		x := &Int{abs: rndNat(words)}
+		x.neg = len(x.abs) > 0 && r.Intn(2) == 0
+		return x
+	}
+	for i := 0; i < 100; i++ {
+		x, y := rnd(r.Intn(4)), rnd(r.Intn(4))
+		m := rnd(1 + r.Intn(3))
+		m.neg = false
+		if len(m.abs) == 0 {
+			continue
+		}
+		e := rnd(r.Intn(3))
+		e.neg = false
+
+		xs := new(Int).Set(x).SetConstantTime(5 * _W)
+		ys := new(Int).Set(y).SetConstantTime(5 * _W)
+		d := new(Int).Set(m)
+		if i&1 != 0 {
+			d.Neg(d)
+		}
+		ds := new(Int).Set(d).SetConstantTime(4 * _W)
+		n := new(Int).SetBit(m, 0, 1)
+		if n.Cmp(intOne) == 0 || new(Int).GCD(nil, nil, new(Int).Mod(x, n), n).Cmp(intOne) != 0 {
+			n = NewInt(1)
+		}
+		s := uint(r.Intn(3 * _W))
+		q1, r1 := new(Int).QuoRem(xs, d, new(Int))
+		q2, r2 := new(Int).QuoRem(x, d, new(Int))
+		q3, r3 := new(Int).DivMod(x, ds, new(Int))
+		q4, r4 := new(Int).DivMod(x, d, new(Int))
+
+		for _, test := range []struct {
+			name      string
+			got, want *Int
+		}{
+			{"Add", new(Int).Add(xs, ys), new(Int).Add(x, y)},
+			{"Sub", new(Int).Sub(xs, y), new(Int).Sub(x, y)},
+			{"Sub", new(Int).Sub(x, ys), new(Int).Sub(x, y)},
+			{"Mul", new(Int).Mul(xs, ys), new(Int).Mul(x, y)},
+			{"Mod", new(Int).Mod(xs, m), new(Int).Mod(x, m)},
+			{"Exp", new(Int).Exp(xs, e, m), new(Int).Exp(x, e, m)},
+			{"Exp", new(Int).Exp(x, new(Int).Set(e).SetConstantTime(3*_W), m), new(Int).Exp(x, e, m)},
+			{"Quo", new(Int).Quo(xs, d), new(Int).Quo(x, d)},
+			{"Quo", new(Int).Quo(x, ds), new(Int).Quo(x, d)},
+			{"Rem", new(Int).Rem(xs, d), new(Int).Rem(x, d)},
+			{"Div", new(Int).Div(xs, d), new(Int).Div(x, d)},
+			{"Div", new(Int).Div(x, ds), new(Int).Div(x, d)},
+			{"Mod", new(Int).Mod(xs, d), new(Int).Mod(x, d)},
+			{"QuoRem", q1, q2},
+			{"QuoRem", r1, r2},
+			{"DivMod", q3, q4},
+			{"DivMod", r3, r4},
+			{"Lsh", new(Int).Lsh(xs, s), new(Int).Lsh(x, s)},
+			{"Rsh", new(Int).Rsh(xs, s), new(Int).Rsh(x, s)},
+			{"Sqrt", new(Int).Sqrt(new(Int).Abs(xs)), new(Int).Sqrt(new(Int).Abs(x))},
+			{"ModInverse", new(Int).ModInverse(xs, n), new(Int).ModInverse(x, n)},
+		} {
+			if test.got.Cmp(test.want) != 0 || !isNormalized(test.got) {
+				t.Errorf("%s(x=%s, y=%s, e=%s, m=%s) = %s; want %s", test.name, x, y, e, m, test.got, test.want)
+			}
+			if test.got.zcap == 0 {
+				t.Errorf("%s: result is not marked", test.name)
+			}
+		}
+
+		if got, want := xs.Cmp(y), x.Cmp(y); got != want {
+			t.Errorf("Cmp(x=%s, y=%s) = %d; want %d", x, y, got, want)
+		}
+		if got, want := x.Cmp(ys), x.Cmp(y); got != want {
+			t.Errorf("Cmp(x=%s, y=%s) = %d; want %d", x, y, got, want)
+		}
+		if got, want := xs.Bit(int(s)), x.Bit(int(s)); got != want {
+			t.Errorf("Bit(x=%s, %d) = %d; want %d", x, s, got, want)
+		}
+		for _, base := range []int{2, 10, 16} {
+			if got, want := xs.Text(base), x.Text(base); got != want {
+				t.Errorf("Text(x=%s, %d) = %s; want %s", x, base, got, want)
+			}
+		}
+		if got, want := fmt.Sprintf("%#08x|%v", xs, xs), fmt.Sprintf("%#08x|%v", x, x); got != want {
+			t.Errorf("Format(x=%s) = %s; want %s", x, got, want)
+		}
+	}
+
+	// the mark of the receiver is replaced by the width of the result
+	var z Int
+	z.SetConstantTime(128)
+	z.SetInt64(-3)
+	z.Add(&z, NewInt(1))
+	if z.zcap != 128/_W+1 || z.Int64() != -2 {
+		t.Errorf("got %d (zcap %d); want -2 (zcap %d)", z.Int64(), z.zcap, 128/_W+1)
+	}
+	if z.SetConstantTime(0).zcap != 0 {
+		t.Errorf("mark not removed")
+	}
+	if z.Set(new(Int).SetConstantTime(_W)).zcap != 1 {
+		t.Errorf("Set: width %d; want 1", z.zcap)
+	}
+
+	// a carry widens the result
+	x := new(Int).Sub(new(Int).Lsh(intOne, _W), intOne).SetConstantTime(_W)
+	for _, s := range []*Int{new(Int).Add(x, x), new(Int).Sub(x, new(Int).Neg(x))} {
+		if want := new(Int).Lsh(x, 1); s.Cmp(want) != 0 || s.zcap != 2 {
+			t.Errorf("x + x = %s (zcap %d); want %s (zcap 2)", s, s.zcap, want)
+		}
+	}
+
+	// operations without constant-time algorithms panic
+	for _, f := range []func(){
+		func() { new(Int).ModSqrt(NewInt(4).SetConstantTime(_W), NewInt(7)) },
+		func() { new(Int).ModInverse(NewInt(3).SetConstantTime(_W), NewInt(8)) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("operation on marked value did not panic")
+				}
+			}()
+			f()
+		}()
+	}
+
+	// widths stay bounded when reducing modulo the same modulus
+	m := new(Int).Sub(new(Int).Lsh(intOne, 5*_W), NewInt(159))
+	y := new(Int).Sub(m, NewInt(2)).SetConstantTime(5 * _W)
+	z.Set(y)
+	for i := 0; i < 20; i++ {
+		z.Mul(&z, y)
+		z.Mod(&z, m)
+		if z.zcap != 5 {
+			t.Fatalf("width %d after %d multiplications; want 5", z.zcap, i+1)
+		}
+	}
+}
+
+func TestTextCT(t *testing.T) {
+	for _, test := range []struct {
+		x    string
+		base int
+		bits int
+		want string
+	}{
+		{"0", 10, 0, "0"},
+		{"0", 10, 8, "000"},
+		{"255", 10, 8, "255"},
+		{"255", 16, 8, "ff"},
+		{"1", 16, 9, "001"},
+		{"5", 2, 4, "0101"},
+		{"-42", 10, 64, "-00000000000000000042"},
+		{"18446744073709551615", 10, 64, "18446744073709551615"},
+		{"35", 36, 16, "000z"},
+		{"3843", 62, 16, "0zz"},
+		{"2242", 62, 16, "0aA"},
+		{"0x1f", 8, 130, "00000000000000000000000000000000000000000037"},
+		{"0x123456789abcdef0123456789abcdef", 16, 128, "0123456789abcdef0123456789abcdef"},
+	} {
+		x, _ := new(Int).SetString(test.x, 0)
+		if got := x.TextCT(test.base, test.bits); got != test.want {
+			t.Errorf("%s.TextCT(%d, %d) = %q; want %q", test.x, test.base, test.bits, got, test.want)
+		}
+	}
+
+	// compare with Text for random values
+	r := rand.New(rand.NewSource(4))
+	for i := 0; i < 50; i++ {
+		x := &Int{abs: rndNat(r.Intn(5))}
+		bits := len(x.abs)*_W + r.Intn(10)
+		for _, base := range []int{2, 3, 8, 10, 16, 36, 37, 62} {
+			got := x.TextCT(base, bits)
+			if len(got) != ndigitsCT(Word(base), bits) {
+				t.Errorf("TextCT(%d, %d) has %d digits; want %d", base, bits, len(got), ndigitsCT(Word(base), bits))
+			}
+			if y, ok := new(Int).SetString(got, base); !ok || y.Cmp(x) != 0 {
+				t.Errorf("TextCT(%d, %d) = %s; want %s", base, bits, got, x.Text(base))
+			}
+		}
+	}
+}
+
+func TestExpBlinded(t *testing.T) {
+	r := &countingReader{rnd: rand.New(rand.NewSource(5))}
+	p, _ := new(Int).SetString("0xffffffff00000001000000000000000000000000ffffffffffffffffffffffff", 0) // prime
+	pm1 := new(Int).Sub(p, intOne)
+	e := NewInt(7)
+	d := new(Int).ModInverse(e, pm1)
+	n := NewInt(61 * 53)
+	for _, test := range []struct {
+		x, y, m, e, order *Int
+	}{
+		{NewInt(3), NewInt(100), NewInt(101), nil, NewInt(100)},
+		{NewInt(-3), NewInt(101), NewInt(101), nil, NewInt(100)},
+		{NewInt(5), NewInt(67), NewInt(101), NewInt(3), nil},
+		{NewInt(-5), NewInt(67), NewInt(101), NewInt(3), NewInt(100)},
+		{NewInt(1234), NewInt(2753), n, NewInt(17), nil}, // RSA
+		{NewInt(61), NewInt(2753), n, NewInt(17), nil},   // not invertible
+		{NewInt(2), NewInt(0), NewInt(101), nil, nil},
+		{NewInt(0), NewInt(5), NewInt(100), nil, nil},
+		{NewInt(7), NewInt(-5), NewInt(99), nil, nil},
+		{NewInt(7), NewInt(-7), NewInt(99), NewInt(13), nil},  // 7*13 = 1 mod λ(99)
+		{NewInt(33), NewInt(-7), NewInt(99), NewInt(13), nil}, // not invertible
+		{NewInt(2), NewInt(0), NewInt(101), NewInt(3), nil},
+		{NewInt(2), NewInt(0), NewInt(1), NewInt(3), nil},
+		{NewInt(12345), NewInt(67), NewInt(1000), nil, nil}, // even modulus
+		{NewInt(1), NewInt(1), NewInt(1), nil, nil},
+		{NewInt(1), NewInt(1), NewInt(1), NewInt(3), nil},
+		{new(Int).Lsh(NewInt(12345), 300), new(Int).Lsh(NewInt(999), 200), p, nil, pm1},
+		{new(Int).Lsh(NewInt(-5), 100), new(Int).Sub(p, NewInt(2)), p, nil, pm1}, // inverse
+		{new(Int).Lsh(NewInt(12345), 200), d, p, e, pm1},
+	} {
+		want := new(Int).Exp(test.x, test.y, test.m)
+		for i := 0; i < 3; i++ {
+			got, err := new(Int).ExpBlinded(test.x, test.y, test.m, test.e, test.order, r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want == nil {
+				if got != nil {
+					t.Errorf("ExpBlinded(%s, %s, %s, %v, %v) = %s; want nil", test.x, test.y, test.m, test.e, test.order, got)
+				}
+				continue
+			}
+			if got == nil || got.Cmp(want) != 0 || !isNormalized(got) {
+				t.Errorf("ExpBlinded(%s, %s, %s, %v, %v) = %s; want %s", test.x, test.y, test.m, test.e, test.order, got, want)
+			}
+		}
+	}
+
+	if z, err := new(Int).ExpBlinded(NewInt(2), NewInt(3), NewInt(5), NewInt(3), nil, bytes.NewReader(nil)); z != nil || err == nil {
+		t.Errorf("ExpBlinded with empty reader = %v, %v; want nil, error", z, err)
+	}
+}
+
+func TestSetVarTimeAllowed(t *testing.T) {
+	if !VarTimeAllowed() {
+		t.Fatal("variable-time algorithms are disabled by default")
+	}
+	defer SetVarTimeAllowed(true)
+
+	r := rand.New(rand.NewSource(0))
+	max := new(Int).Lsh(intOne, 4*_W)
+	max.Sub(max, intOne) // all ones, so that additions carry out
+	// a prime, so that x is invertible
+	p := new(Int).Sub(new(Int).Lsh(intOne, 127), intOne)
+	for i := 0; i < 50; i++ {
+		x := new(Int).Rand(r, max)
+		y := new(Int).Rand(r, max)
+		m := new(Int).Rand(r, max)
+		switch i {
+		case 0:
+			x.Set(max)
+			y.Set(max)
+		case 1:
+			y.Neg(x)
+		}
+		if i&1 == 1 {
+			x.Neg(x)
+		}
+		m.Add(m, intOne)
+		ops := []struct {
+			name string
+			f    func(z *Int) *Int
+		}{
+			{"Add", func(z *Int) *Int { return z.Add(x, y) }},
+			{"Sub", func(z *Int) *Int { return z.Sub(x, y) }},
+			{"Mul", func(z *Int) *Int { return z.Mul(x, y) }},
+			{"Mod", func(z *Int) *Int { return z.Mod(x, m) }},
+			{"Exp", func(z *Int) *Int { return z.Exp(x, y, m) }},
+			{"Quo", func(z *Int) *Int { return z.Quo(x, m) }},
+			{"Rem", func(z *Int) *Int { return z.Rem(x, m) }},
+			{"Div", func(z *Int) *Int { return z.Div(x, m) }},
+			{"QuoRem", func(z *Int) *Int { _, r := z.QuoRem(x, m, new(Int)); return r }},
+			{"DivMod", func(z *Int) *Int { z.DivMod(x, m, new(Int)); return z }},
+			{"Lsh", func(z *Int) *Int { return z.Lsh(x, uint(i)) }},
+			{"Rsh", func(z *Int) *Int { return z.Rsh(x, uint(i)) }},
+			{"Sqrt", func(z *Int) *Int { return z.Sqrt(new(Int).Abs(x)) }},
+			{"ModInverse", func(z *Int) *Int { return z.ModInverse(x, p) }},
+			{"Cmp", func(z *Int) *Int { return z.SetInt64(int64(x.Cmp(y))) }},
+			{"CmpAbs", func(z *Int) *Int { return z.SetInt64(int64(x.CmpAbs(y))) }},
+			{"Bit", func(z *Int) *Int { return z.SetInt64(int64(x.Bit(i))) }},
+		}
+		for _, op := range ops {
+			SetVarTimeAllowed(true)
+			want := op.f(new(Int))
+			if prev := SetVarTimeAllowed(false); !prev {
+				t.Fatalf("SetVarTimeAllowed(false) = %v; want true", prev)
+			}
+			got := op.f(new(Int))
+			if got.Cmp(want) != 0 {
+				t.Errorf("%s(%s, %s) with variable-time algorithms disabled = %s; want %s", op.name, x, y, got, want)
+			}
+			if got.zcap != 0 {
+				t.Errorf("%s: result marked with width %d", op.name, got.zcap)
+			}
+		}
+		SetVarTimeAllowed(true)
+		want := fmt.Sprintf("%x %s", x, x.Text(7))
+		SetVarTimeAllowed(false)
+		if got := fmt.Sprintf("%x %s", x, x.Text(7)); got != want {
+			t.Errorf("formatting %s with variable-time algorithms disabled = %s; want %s", x, got, want)
+		}
+	}
+	if SetVarTimeAllowed(true) || !VarTimeAllowed() {
+		t.Errorf("SetVarTimeAllowed(true) did not restore the policy")
+	}
+}
+
+func TestWipe(t *testing.T) {
+	x, _ := new(Int).SetString("-0x123456789abcdef0123456789abcdef0123456789abcdef", 0)
+	x.SetConstantTime(256)
+	buf := x.abs[:cap(x.abs)]
+	x.Wipe()
+	if x.Sign() != 0 || x.abs != nil {
+		t.Errorf("Wipe: x = %s, abs = %v; want 0, nil", x, x.abs)
+	}
+	for i, w := range buf {
+		if w != 0 {
+			t.Errorf("Wipe: word %d of buffer = %#x; want 0", i, w)
+		}
+	}
+	if x.zcap != fixedWords(256) {
+		t.Errorf("Wipe: width %d; want %d", x.zcap, fixedWords(256))
+	}
+	// x remains usable
+	if x.Add(x, intOne).Cmp(intOne) != 0 {
+		t.Errorf("x + 1 after Wipe = %s; want 1", x)
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements constant-time reductions modulo special primes,
+// used by Modulus in place of Montgomery multiplication.
+
+package big
+
+import "math/big/ctword"
+
+// cpseudoMersenne sets z to x mod m and returns z, for a modulus of
+// the form m = 2**k - c with c < 2**32, where k = m.bitLen() must be
+// > 66. x must be < m**2. The result has len(m) words.
+//
+// Each fold replaces x = h*2**k + l by h*c + l, which is congruent
+// modulo m. For x < m**2, two folds leave a value < 2*m, and a final
+// masked subtraction yields the result.
+func (z nat) cpseudoMersenne(x, m nat, k uint, c Word) nat {
+	x = x.cfold(k, c)
+	x = x.cfold(k, c)
+	n := len(m)
+	if len(x) < n+1 {
+		x = nat(nil).cpad(x, n+1)
+	}
+	return z.creduceSigned(x[:n+1], m)
+}
+
+// cfold returns h*c + l for x = h*2**k + l with l < 2**k. The result
+// length depends only on len(x) and k. len(x) must be > k/_W.
+func (x nat) cfold(k uint, c Word) nat {
+	kw, kb := int(k/_W), k%_W
+	h := nat(nil).make(len(x) - kw)
+	if kb == 0 {
+		copy(h, x[kw:])
+	} else {
+		shrVU(h, x[kw:], kb)
+	}
+	l := nat(nil).make(kw + 1)
+	copy(l, x[:kw+1])
+	l[kw] &= 1<<kb - 1
+	t := nat(nil).make(len(h) + 1)
+	t[len(h)] = mulAddVWW(t[:len(h)], h, c, 0)
+	return nat(nil).cadd(l, t, max(len(l), len(t))+1)
+}
+
+// creduceSigned sets z to v mod m and returns z, where v has len(m)+1
+// words and is interpreted in two's complement, and its value is in the
+// range (-m, 2*m). The result has len(m) words.
+func (z nat) creduceSigned(v, m nat) nat {
+	n := len(m)
+	mm := nat(nil).cpad(m, n+1)
+
+	// add m if v is negative
+	t := nat(nil).make(n + 1)
+	copy(t, v)
+	ctword.CondAdd(uints(t), uints(mm), uint(v[n]>>(_W-1)))
+
+	// subtract m if the result is still >= m
+	d, b := nat(nil).csub(t, mm)
+	t = t.sel(t, d, b)
+	z = z.make(n)
+	copy(z, t)
+	return z
+}
+
+// A solinasTerm is one of the terms of a Solinas reduction: the 32-bit
+// limbs at positions src (or 0 where src is -1) of the double-length
+// input, multiplied by coef.
+type solinasTerm struct {
+	coef int64
+	src  []int8
+}
+
+// p256Terms and p384Terms are the Solinas reductions for the NIST
+// primes P-256 and P-384, from FIPS 186-4, appendix D.2. The limbs of
+// each term are listed from least to most significant.
+var p256Terms = []solinasTerm{
+	{+1, []int8{0, 1, 2, 3, 4, 5, 6, 7}},
+	{+2, []int8{-1, -1, -1, 11, 12, 13, 14, 15}},
+	{+2, []int8{-1, -1, -1, 12, 13, 14, 15, -1}},
+	{+1, []int8{8, 9, 10, -1, -1, -1, 14, 15}},
+	{+1, []int8{9, 10, 11, 13, 14, 15, 13, 8}},
+	{-1, []int8{11, 12, 13, -1, -1, -1, 8, 10}},
+	{-1, []int8{12, 13, 14, 15, -1, -1, 9, 11}},
+	{-1, []int8{13, 14, 15, 8, 9, 10, -1, 12}},
+	{-1, []int8{14, 15, -1, 9, 10, 11, -1, 13}},
+}
+
+var p384Terms = []solinasTerm{
+	{+1, []int8{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}},
+	{+2, []int8{-1, -1, -1, -1, 21, 22, 23, -1, -1, -1, -1, -1}},
+	{+1, []int8{12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23}},
+	{+1, []int8{21, 22, 23, 12, 13, 14, 15, 16, 17, 18, 19, 20}},
+	{+1, []int8{-1, 23, -1, 20, 12, 13, 14, 15, 16, 17, 18, 19}},
+	{+1, []int8{-1, -1, -1, -1, 20, 21, 22, 23, -1, -1, -1, -1}},
+	{+1, []int8{20, -1, -1, 21, 22, 23, -1, -1, -1, -1, -1, -1}},
+	{-1, []int8{23, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22}},
+	{-1, []int8{-1, 20, 21, 22, 23, -1, -1, -1, -1, -1, -1, -1}},
+	{-1, []int8{-1, -1, -1, 23, 23, -1, -1, -1, -1, -1, -1, -1}},
+}
+
+// p256 and p384 are the NIST primes P-256 and P-384.
+var (
+	p256 = hexNat("ffffffff00000001000000000000000000000000ffffffffffffffffffffffff")
+	p384 = hexNat("fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffeffffffff0000000000000000ffffffff")
+)
+
+// hexNat returns the value of the hexadecimal string s.
+func hexNat(s string) nat {
+	x, ok := new(Int).SetString(s, 16)
+	if !ok {
+		panic("math/big: invalid constant " + s)
+	}
+	return x.abs
+}
+
+// p256Fold and p384Fold are the signed 32-bit limbs of 2**256 - P-256
+// and 2**384 - P-384, from least to most significant.
+var (
+	p256Fold = []int64{1, 0, 0, -1, 0, 0, -1, 1}
+	p384Fold = []int64{1, -1, 0, 1, 1, 0, 0, 0, 0, 0, 0, 0}
+)
+
+// csolinas sets z to x mod m and returns z, where m is a Solinas prime
+// of len(fold) 32-bit limbs, terms is its reduction, and fold is the
+// difference between m and the next power of 2. x must be < m**2.
+// The result has len(m) words.
+func (z nat) csolinas(x, m nat, terms []solinasTerm, fold []int64) nat {
+	l := len(fold)
+	c := limbs32(x, 2*l)
+	acc := make([]int64, l)
+	for _, s := range terms {
+		for i, j := range s.src {
+			if j >= 0 {
+				acc[i] += s.coef * c[j]
+			}
+		}
+	}
+	// The sum is acc + t*2**(32*l) ≡ acc + t*fold, with a small t;
+	// the latter has a magnitude far smaller than m.
+	t := carry32(acc)
+	for i, f := range fold {
+		acc[i] += t * f
+	}
+	t = carry32(acc)
+	v := nat(nil).make(len(m) + 1)
+	v.setLimbs32(acc)
+	v[len(m)] = Word(t) // sign-extended
+	return z.creduceSigned(v, m)
+}
+
+// limbs32 returns the n least significant 32-bit limbs of x.
+func limbs32(x nat, n int) []int64 {
+	const per = _W / 32
+	c := make([]int64, n)
+	for i := range c {
+		if i/per < len(x) {
+			c[i] = int64(uint32(x[i/per] >> (32 * uint(i%per))))
+		}
+	}
+	return c
+}
+
+// setLimbs32 sets the len(c)*32 least significant bits of z from the
+// limbs c, which must each be in the range [0, 2**32).
+func (z nat) setLimbs32(c []int64) {
+	const per = _W / 32
+	for i := range z[:len(c)/per] {
+		z[i] = 0
+	}
+	for i, w := range c {
+		z[i/per] |= Word(w) << (32 * uint(i%per))
+	}
+}
+
+// carry32 propagates the carries of the signed limbs c, leaving each
+// limb in the range [0, 2**32), and returns the signed carry out of the
+// most significant limb.
+func carry32(c []int64) int64 {
+	var t int64
+	for i := range c {
+		t += c[i]
+		c[i] = t & (1<<32 - 1)
+		t >>= 32 // arithmetic shift
+	}
+	return t
+}
@@ -0,0 +1,94 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"math/rand"
+	"testing"
+)
+
+var specialReductionTests = []struct {
+	m       string
+	special bool
+}{
+	{"0xffffffff00000001000000000000000000000000ffffffffffffffffffffffff", true},                                                                        // P-256
+	{"0xfffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffeffffffff0000000000000000ffffffff", true},                                        // P-384
+	{"0x7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed", true},                                                                        // 2**255 - 19
+	{"0x7fffffffffffffffffffffffffffffff", true},                                                                                                        // 2**127 - 1
+	{"0x1ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", true}, // 2**521 - 1
+	{"0xffffffffffffffffffffffffffffffffffffffffffffffffffffffff00000001", true},                                                                        // 2**256 - 2**32 + 1
+	{"0xfffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f", false},                                                                       // secp256k1, c >= 2**32
+	{"0xffffffffffffffc5", false},                                         // too small
+	{"0xffffffff00000001000000000000000000000000fffffffffffffffd", false}, // not a recognized prime
+}
+
+func TestSpecialReduction(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, test := range specialReductionTests {
+		m := natFromString(test.m)
+		red := specialReduction(m)
+		if (red != nil) != test.special {
+			t.Errorf("specialReduction(%s) != nil: got %v; want %v", test.m, red != nil, test.special)
+		}
+		if red == nil {
+			continue
+		}
+		n := len(m)
+		m1 := nat(nil).sub(m, natOne)
+		xs := []nat{
+			nil,
+			natOne,
+			m1,
+			nat(nil).mul(m1, m1),
+		}
+		for i := 0; i < 200; i++ {
+			// random values, and values with long runs of ones
+			// and zeros that produce large carries and borrows
+			x := nat(nil).random(r, nat(nil).mul(m, m), 2*n*_W)
+			if i%2 == 1 {
+				for j := range x {
+					x[j] = ^Word(0) * Word(r.Intn(2))
+				}
+				_, x = nat(nil).div(nil, x.norm(), nat(nil).mul(m, m))
+			}
+			xs = append(xs, x)
+		}
+		for _, x := range xs {
+			_, want := nat(nil).div(nil, x, m)
+			got := red(nil, nat(nil).cpad(x, 2*n))
+			if len(got) != n {
+				t.Errorf("reduction of %s mod %s: got %d words; want %d", x.utoa(16), test.m, len(got), n)
+			}
+			if got.norm().cmp(want.norm()) != 0 {
+				t.Errorf("%s mod %s = %s; want %s", x.utoa(16), test.m, got.norm().utoa(16), want.norm().utoa(16))
+			}
+		}
+	}
+}
+
+func TestCarry32(t *testing.T) {
+	c := []int64{-1, 0, 1 << 33}
+	if got := carry32(c); got != 1 || c[0] != 1<<32-1 || c[1] != 1<<32-1 || c[2] != 1<<32-1 {
+		t.Errorf("carry32 = %d, %v; want 1, all ones", got, c)
+	}
+	c = []int64{0, -1}
+	if got := carry32(c); got != -1 || c[0] != 0 || c[1] != 1<<32-1 {
+		t.Errorf("carry32 = %d, %v; want -1, [0 %d]", got, c, int64(1<<32-1))
+	}
+}
+
+func benchmarkModulusMul(b *testing.B, s string) {
+	m, _ := new(Int).SetString(s, 0)
+	mod := NewModulus(m)
+	x := new(Int).Sub(m, intOne)
+	z := new(Int)
+	for i := 0; i < b.N; i++ {
+		z.MulCT(x, x, mod)
+	}
+}
+
+func BenchmarkModulusMulP256(b *testing.B)  { benchmarkModulusMul(b, specialReductionTests[0].m) }
+func BenchmarkModulusMulP384(b *testing.B)  { benchmarkModulusMul(b, specialReductionTests[1].m) }
+func BenchmarkModulusMul25519(b *testing.B) { benchmarkModulusMul(b, specialReductionTests[2].m) }
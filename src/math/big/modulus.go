@@ -0,0 +1,360 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Modulus, a fixed modulus with precomputed
+// constants for repeated modular arithmetic.
+
+package big
+
+// A Modulus represents a fixed, odd, positive modulus together with the
+// constants for Montgomery multiplication modulo it, which Exp otherwise
+// recomputes on every call, and the modulus normalized for long division.
+// The methods with the CT suffix and the methods for the Montgomery form
+// compute in constant time with respect to the operand values, as for Int
+// values marked with SetConstantTime. The methods Exp, Mul, Mod, and
+// Inverse are faster, variable-time versions of the Int methods of the
+// same names that reuse these constants; like them, they compute in
+// constant time if an operand is marked.
+//
+// For some moduli commonly used in elliptic curve cryptography, MulCT
+// and SqrCT use faster special-purpose reductions instead of Montgomery
+// multiplication: the NIST primes P-256 and P-384, and moduli of the
+// form 2**k - c with c < 2**32, such as 2**255 - 19 and 2**521 - 1.
+// NewModulus selects them automatically.
+//
+// A Modulus is immutable and safe for concurrent use by multiple
+// goroutines. The zero value is not a valid Modulus; use NewModulus.
+type Modulus struct {
+	m  nat  // modulus, normalized, odd
+	k0 Word // -m**-1 mod 2**_W
+	rr nat  // 2**(2*_W*len(m)) mod m, with len(m) words
+
+	mn    nat  // m << shift, the normalized divisor for division by m
+	shift uint // nlz(m[len(m)-1])
+
+	// red, if not nil, reduces a product of 2*len(m) words modulo m
+	// using a special reduction for m; see modred.go
+	red func(z, x nat) nat
+}
+
+// NewModulus returns a new Modulus for the value of m. The value of m
+// is copied, so m may be changed afterwards without affecting the result.
+// If m is not odd and positive, NewModulus panics.
+func NewModulus(m *Int) *Modulus {
+	if m.neg || len(m.abs) == 0 || m.abs[0]&1 == 0 {
+		panic("math/big: modulus is not odd and positive")
+	}
+	abs := nat(nil).set(m.abs)
+	mod := &Modulus{m: abs, k0: montgomeryK0(abs[0]), rr: nat(nil).montgomeryRR(abs, nil)}
+	mod.red = specialReduction(abs)
+	mod.shift = nlz(abs[len(abs)-1])
+	mod.mn = nat(nil).make(len(abs))
+	shlVU(mod.mn, abs, mod.shift)
+	return mod
+}
+
+// specialReduction returns a function reducing double-length values
+// modulo m, if m has a special form for which one is implemented,
+// and nil otherwise.
+func specialReduction(m nat) func(z, x nat) nat {
+	switch {
+	case m.cmp(p256) == 0:
+		return func(z, x nat) nat { return z.csolinas(x, m, p256Terms, p256Fold) }
+	case m.cmp(p384) == 0:
+		return func(z, x nat) nat { return z.csolinas(x, m, p384Terms, p384Fold) }
+	}
+	k := uint(m.bitLen())
+	if k <= 66 {
+		return nil
+	}
+	c := nat(nil).sub(nat(nil).shl(natOne, k), m) // 2**k - m
+	if len(c) != 1 || uint64(c[0]) >= 1<<32 {
+		return nil
+	}
+	return func(z, x nat) nat { return z.cpseudoMersenne(x, m, k, c[0]) }
+}
+
+// BitLen returns the length of the modulus in bits.
+func (m *Modulus) BitLen() int {
+	return m.m.bitLen()
+}
+
+// Int returns the value of the modulus. If a non-nil *Int argument z
+// is provided, Int stores the result in z instead of allocating a new Int.
+func (m *Modulus) Int(z *Int) *Int {
+	if z == nil {
+		z = new(Int)
+	}
+	z.abs = z.abs.set(m.m)
+	z.neg = false
+	return z
+}
+
+// reduce returns the Euclidean modulus x mod m in constant time,
+// zero-extended to len(m.m) words.
+func (m *Modulus) reduce(x *Int) nat {
+	r := nat(nil).cmodPublic(nat(nil).cpad(x.abs, x.ctWords()), m.m)
+	r = r.cpad(r, len(m.m))
+	// For negative x with r != 0, the Euclidean modulus is m - r.
+	t, _ := nat(nil).csub(m.m, r)
+	return r.sel(t, r, boolWord(x.neg)&r.cnonzero())
+}
+
+// mul returns x*y mod m, for x and y reduced modulo m and zero-extended
+// to len(m.m) words, using the special reduction for m if it has one.
+func (m *Modulus) mul(x, y nat) nat {
+	if m.red == nil {
+		return nat(nil).cmulMontgomery(x, y, m.m, m.k0, m.rr)
+	}
+	n := len(m.m)
+	return m.red(nil, nat(nil).cmul(x, y, 2*n))
+}
+
+// AddModCT sets z to x+y mod m and returns z. Like MulCT, it computes
+// the result in the range [0, m) in constant time and marks it as
+// constant-time with the width of the modulus. Operands of
+// about the width of m, such as the results of previous operations with
+// m, are reduced cheaply, so AddModCT can replace the pattern of Add
+// followed by Mod in sequences of field operations.
+func (z *Int) AddModCT(x, y *Int, m *Modulus) *Int {
+	abs := nat(nil).caddMod(m.reduce(x), m.reduce(y), m.m)
+	return z.setCT(abs, 0, len(m.m))
+}
+
+// SubModCT sets z to x-y mod m and returns z, like AddModCT.
+func (z *Int) SubModCT(x, y *Int, m *Modulus) *Int {
+	abs := nat(nil).csubMod(m.reduce(x), m.reduce(y), m.m)
+	return z.setCT(abs, 0, len(m.m))
+}
+
+// MulCT sets z to x*y mod m and returns z. The result is in the range
+// [0, m), and it is computed in constant time, with a running time and
+// memory access pattern that depend only on the declared widths of x and y
+// (see SetConstantTime) and on the modulus. The result is marked as
+// constant-time with the width of the modulus.
+func (z *Int) MulCT(x, y *Int, m *Modulus) *Int {
+	xa := m.reduce(x)
+	ya := m.reduce(y)
+	abs := m.mul(xa, ya)
+	return z.setCT(abs, 0, len(m.m))
+}
+
+// SqrCT sets z to x*x mod m and returns z, like MulCT.
+func (z *Int) SqrCT(x *Int, m *Modulus) *Int {
+	xa := m.reduce(x)
+	abs := m.mul(xa, xa)
+	return z.setCT(abs, 0, len(m.m))
+}
+
+// ExpCT sets z to x**y mod m and returns z; the result is in the range
+// [0, m). As for Exp, if y == 0, the result is 1 mod m, and if y < 0, it
+// is (x**-1)**|y| mod m; if x and m are not relatively prime, z is
+// unchanged and nil is returned. Like z.Exp(x, y, m) for operands marked
+// with SetConstantTime, ExpCT runs in constant time with respect to the
+// values of x and y, including the inversion, but it uses the constants
+// precomputed for m instead of deriving them on each call. The result is
+// marked as constant-time with the width of the modulus.
+func (z *Int) ExpCT(x, y *Int, m *Modulus) *Int {
+	if y.neg {
+		if x, y = invertBaseCT(x, y, &Int{abs: m.m}); x == nil {
+			return nil
+		}
+		defer x.Wipe()
+	}
+	zcap := len(m.m)
+	yWords := y.expWordsCT()
+	xa := m.reduce(x)
+	abs := nat(nil).cexpNNMontgomery(xa, yWords, m.m, m.k0, m.rr)
+	return z.setCT(abs.cnorm(zcap), 0, zcap)
+}
+
+// Exp2CT sets z to x1**y1 * x2**y2 mod m and returns z. As for ExpCT, a
+// negative exponent applies to the inverse of its base modulo m, and if
+// that doesn't exist, z is unchanged and nil is returned. Like ExpCT,
+// Exp2CT runs in constant time with
+// respect to the values of its operands, with a running time that depends
+// on the declared widths of the exponents (see SetConstantTime) and on the
+// modulus, and marks the result as constant-time with the width of
+// the modulus. Computing both powers in a single pass over the exponents
+// takes about as many squarings as a single ExpCT.
+func (z *Int) Exp2CT(x1, y1, x2, y2 *Int, m *Modulus) *Int {
+	n := &Int{abs: m.m}
+	if y1.neg {
+		if x1, y1 = invertBaseCT(x1, y1, n); x1 == nil {
+			return nil
+		}
+		defer x1.Wipe()
+	}
+	if y2.neg {
+		if x2, y2 = invertBaseCT(x2, y2, n); x2 == nil {
+			return nil
+		}
+		defer x2.Wipe()
+	}
+	zcap := len(m.m)
+	xa1 := m.reduce(x1)
+	xa2 := m.reduce(x2)
+	abs := nat(nil).cexpNN2Montgomery(xa1, y1.expWordsCT(), xa2, y2.expWordsCT(), m.m, m.k0, m.rr)
+	xa1.wipe()
+	xa2.wipe()
+	return z.setCT(abs.cnorm(zcap), 0, zcap)
+}
+
+// ToMont sets z to the Montgomery form x*R mod m of x, where R is
+// 2**(n*w) for a modulus of n words of w bits each, and returns z.
+// Values in Montgomery form can be multiplied with MontMul without the
+// conversion that MulCT performs on every call, which pays off for long
+// chains of multiplications; FromMont converts the final result back.
+// Sums and differences of values in Montgomery form, as computed by
+// AddModCT and SubModCT, are in Montgomery form, too. Like MulCT, ToMont
+// runs in constant time and marks the result as constant-time with the
+// width of the modulus.
+func (m *Modulus) ToMont(z, x *Int) *Int {
+	abs := nat(nil).cmontMul(m.reduce(x), m.rr, m.m, m.k0)
+	return z.setCT(abs, 0, len(m.m))
+}
+
+// FromMont sets z to x*R**-1 mod m, the value represented by x in
+// Montgomery form (see ToMont), and returns z.
+func (m *Modulus) FromMont(z, x *Int) *Int {
+	one := nat(nil).make(len(m.m))
+	one.clear()
+	one[0] = 1
+	abs := nat(nil).cmontMul(m.reduce(x), one, m.m, m.k0)
+	return z.setCT(abs, 0, len(m.m))
+}
+
+// MontMul sets z to the Montgomery product x*y*R**-1 mod m and returns z.
+// For x and y in Montgomery form (see ToMont), z is the Montgomery form
+// of their product.
+func (m *Modulus) MontMul(z, x, y *Int) *Int {
+	abs := nat(nil).cmontMul(m.reduce(x), m.reduce(y), m.m, m.k0)
+	return z.setCT(abs, 0, len(m.m))
+}
+
+// mod returns x mod m, using z as storage if possible. It divides with
+// the normalized divisor m.mn instead of normalizing m on each call.
+func (m *Modulus) mod(z, x nat) nat {
+	n := len(m.m)
+	switch {
+	case x.cmp(m.m) < 0:
+		return z.set(x)
+	case n == 1:
+		_, r := nat(nil).divW(x, m.m[0])
+		return z.setWord(r)
+	}
+	if alias(z, x) {
+		z = nil
+	}
+	qp := getNat(len(x) - n + 1)
+	_, r := qp.divNormalized(z.make(len(x)+1), x, m.mn, m.shift, 0, false)
+	putNat(qp)
+	return r
+}
+
+// Mod sets z to the modulus x mod m, in the range [0, m), and returns z.
+// Unlike the constant-time operations of m, Mod is a variable-time
+// operation, unless x or z is marked with SetConstantTime; then the
+// result is computed in constant time, as by x.Mod(x, m), and marked
+// with the width of m.
+func (m *Modulus) Mod(z, x *Int) *Int {
+	if z.zcap|x.zcap != 0 {
+		return z.setCT(m.reduce(x), 0, len(m.m))
+	}
+	if varTimeDisabled() {
+		return z.setCT(m.reduce(x), 0, len(m.m)).SetConstantTime(0)
+	}
+	z.abs = m.mod(z.abs, x.abs)
+	if x.neg && len(z.abs) > 0 {
+		z.abs = z.abs.sub(m.m, z.abs)
+	}
+	z.neg = false
+	return z
+}
+
+// Mul sets z to x*y mod m, in the range [0, m), and returns z. Like Mod,
+// Mul is a variable-time operation unless one of z, x, or y is marked
+// with SetConstantTime; then it computes the result like MulCT.
+func (m *Modulus) Mul(z, x, y *Int) *Int {
+	if z.zcap|x.zcap|y.zcap != 0 {
+		return z.MulCT(x, y, m)
+	}
+	if varTimeDisabled() {
+		return z.MulCT(x, y, m).SetConstantTime(0)
+	}
+	tp := getNat(0)
+	t := tp.mul(x.abs, y.abs)
+	z.abs = m.mod(z.abs, t)
+	*tp = t
+	putNat(tp)
+	if x.neg != y.neg && len(z.abs) > 0 {
+		z.abs = z.abs.sub(m.m, z.abs)
+	}
+	z.neg = false
+	return z
+}
+
+// Exp sets z to x**y mod m, in the range [0, m), and returns z. As for
+// Int.Exp, if y == 0, the result is 1 mod m, and if y < 0, it is
+// (x**-1)**|y| mod m; if x and m are not relatively prime, z is unchanged
+// and nil is returned. Like Mod, Exp is a variable-time operation unless
+// one of z, x, or y is marked with SetConstantTime; then it computes the
+// result like ExpCT.
+func (m *Modulus) Exp(z, x, y *Int) *Int {
+	if z.zcap|x.zcap|y.zcap != 0 {
+		return z.ExpCT(x, y, m)
+	}
+	if varTimeDisabled() {
+		if z.ExpCT(x, y, m) == nil {
+			return nil
+		}
+		return z.SetConstantTime(0)
+	}
+	if y.neg {
+		if x, y = invertBase(x, y, &Int{abs: m.m}); x == nil {
+			return nil
+		}
+	}
+	var yWords nat
+	if !y.neg {
+		yWords = y.abs
+	}
+	abs := z.abs
+	if alias(abs, x.abs) || alias(abs, yWords) {
+		abs = nil
+	}
+	if len(yWords) > 1 && x.abs.cmp(natOne) > 0 && len(m.m) > 1 {
+		abs = abs.expNNMontgomery(m.mod(nil, x.abs), yWords, m.m, m.k0, m.rr, nil)
+	} else {
+		abs = abs.expNN(x.abs, yWords, m.m)
+	}
+	z.abs = abs
+	if x.neg && len(yWords) > 0 && yWords[0]&1 == 1 && len(z.abs) > 0 {
+		z.abs = z.abs.sub(m.m, z.abs)
+	}
+	z.neg = false
+	return z
+}
+
+// Inverse sets z to the multiplicative inverse of x in the ring ℤ/mℤ,
+// in the range [0, m), and returns z. If x and m are not relatively prime,
+// the result is undefined. Like Mod, Inverse is a variable-time operation
+// unless x or z is marked with SetConstantTime; then it computes the
+// result in constant time, as ModInverse does, and marks it with the
+// width of m.
+func (m *Modulus) Inverse(z, x *Int) *Int {
+	n := &Int{abs: m.m}
+	if z.zcap|x.zcap != 0 {
+		return z.modInverseCT(x, n)
+	}
+	if varTimeDisabled() {
+		return z.modInverseCT(x, n).SetConstantTime(0)
+	}
+	var r Int
+	r.abs = m.mod(nil, x.abs)
+	if x.neg && len(r.abs) > 0 {
+		r.abs = r.abs.sub(m.m, r.abs)
+	}
+	return z.ModInverse(&r, n)
+}
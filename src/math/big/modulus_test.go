@@ -0,0 +1,357 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"math/rand"
+	"testing"
+)
+
+var modulusTests = []string{
+	"1",
+	"3",
+	"0xffffffff",
+	"0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed",
+	"0xffffffff00000001000000000000000000000000ffffffffffffffffffffffff",
+	"0x10000000000000000000000000000000000000000000000000000000000000001",
+	"0x7fffffffffffffffffffffffffffffff",
+	"0xfffffffffffffffffffffffffffffffffffffffffffffffffffffffefffffc2f",
+	"0x1ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff",
+	"0xfffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffeffffffff0000000000000000ffffffff",
+}
+
+func TestNewModulusPanics(t *testing.T) {
+	for _, s := range []string{"0", "-3", "2", "0x100000000000000000000"} {
+		m, _ := new(Int).SetString(s, 0)
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewModulus(%s) did not panic", s)
+				}
+			}()
+			NewModulus(m)
+		}()
+	}
+}
+
+func TestModulusInt(t *testing.T) {
+	for _, s := range modulusTests {
+		m, _ := new(Int).SetString(s, 0)
+		mod := NewModulus(m)
+		m.Add(m, intOne) // must not affect mod
+		m.Sub(m, intOne)
+		if got := mod.Int(nil); got.Cmp(m) != 0 {
+			t.Errorf("Int() = %s; want %s", got, m)
+		}
+		if got := mod.BitLen(); got != m.BitLen() {
+			t.Errorf("BitLen() = %d; want %d", got, m.BitLen())
+		}
+	}
+}
+
+// randModInt returns a random, possibly negative Int of about the
+// size of m, or larger.
+func randModInt(r *rand.Rand, m *Int) *Int {
+	x := new(Int).Rand(r, new(Int).Lsh(m, uint(r.Intn(2*_W))))
+	if r.Intn(4) == 0 {
+		x.Neg(x)
+	}
+	return x
+}
+
+// modExp returns x**y mod m in the range [0, m), or nil if y < 0 and x
+// is not invertible modulo m, the result Modulus.Exp is expected to return.
+func modExp(x, y, m *Int) *Int {
+	z := new(Int).Exp(x, y, m)
+	if z != nil && z.Sign() < 0 {
+		z.Add(z, m) // Exp may return a negative result for negative x
+	}
+	return z
+}
+
+// sameResult reports whether x and y are both nil or have the same value.
+func sameResult(x, y *Int) bool {
+	if x == nil || y == nil {
+		return x == y
+	}
+	return x.Cmp(y) == 0
+}
+
+func TestModulusMulCT(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, s := range modulusTests {
+		m, _ := new(Int).SetString(s, 0)
+		mod := NewModulus(m)
+		for i := 0; i < 20; i++ {
+			x := randModInt(r, m)
+			y := randModInt(r, m)
+			want := new(Int).Mul(x, y)
+			want.Mod(want, m)
+			if got := new(Int).MulCT(x, y, mod); got.Cmp(want) != 0 {
+				t.Errorf("MulCT(%s, %s, %s) = %s; want %s", x, y, m, got, want)
+			}
+			want.Mul(x, x).Mod(want, m)
+			got := new(Int).SqrCT(x, mod)
+			if got.Cmp(want) != 0 {
+				t.Errorf("SqrCT(%s, %s) = %s; want %s", x, m, got, want)
+			}
+			if got.zcap != len(m.abs) {
+				t.Errorf("SqrCT(%s, %s): width %d; want %d", x, m, got.zcap, len(m.abs))
+			}
+			// aliased operands
+			x.MulCT(x, x, mod)
+			if x.Cmp(want) != 0 {
+				t.Errorf("aliased MulCT = %s; want %s", x, want)
+			}
+		}
+	}
+}
+
+func TestModulusAddSubModCT(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, s := range modulusTests {
+		m, _ := new(Int).SetString(s, 0)
+		mod := NewModulus(m)
+		for i := 0; i < 20; i++ {
+			x := randModInt(r, m)
+			y := randModInt(r, m)
+			want := new(Int).Add(x, y)
+			want.Mod(want, m)
+			got := new(Int).AddModCT(x, y, mod)
+			if got.Cmp(want) != 0 {
+				t.Errorf("AddModCT(%s, %s, %s) = %s; want %s", x, y, m, got, want)
+			}
+			if got.zcap != len(m.abs) {
+				t.Errorf("AddModCT(%s, %s, %s): width %d; want %d", x, y, m, got.zcap, len(m.abs))
+			}
+			want.Sub(x, y).Mod(want, m)
+			if got := new(Int).SubModCT(x, y, mod); got.Cmp(want) != 0 {
+				t.Errorf("SubModCT(%s, %s, %s) = %s; want %s", x, y, m, got, want)
+			}
+			// aliased operands
+			if x.SubModCT(x, x, mod).Sign() != 0 {
+				t.Errorf("SubModCT(x, x) = %s; want 0", x)
+			}
+		}
+	}
+}
+
+func TestModulusExpCT(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, s := range modulusTests {
+		m, _ := new(Int).SetString(s, 0)
+		mod := NewModulus(m)
+		for i := 0; i < 10; i++ {
+			x := randModInt(r, m)
+			y := new(Int).Rand(r, m)
+			switch i {
+			case 0:
+				y.SetInt64(0)
+			case 1, 2:
+				y.Neg(y) // x is inverted
+			}
+			want := modExp(x, y, m)
+			got := new(Int).ExpCT(x, y, mod)
+			if !sameResult(got, want) {
+				t.Errorf("ExpCT(%s, %s, %s) = %s; want %s", x, y, m, got, want)
+			}
+			// the exponent is processed at its declared width
+			y.SetConstantTime(2 * m.BitLen())
+			if got := new(Int).ExpCT(x, y, mod); !sameResult(got, want) {
+				t.Errorf("ExpCT(%s, %s, %s) with wide exponent = %s; want %s", x, y, m, got, want)
+			}
+		}
+	}
+}
+
+func TestModulusExp2CT(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, s := range modulusTests {
+		m, _ := new(Int).SetString(s, 0)
+		mod := NewModulus(m)
+		for i := 0; i < 10; i++ {
+			x1 := randModInt(r, m)
+			x2 := randModInt(r, m)
+			y1 := new(Int).Rand(r, m)
+			y2 := new(Int).Rand(r, new(Int).Lsh(m, uint(r.Intn(2*_W))))
+			switch i {
+			case 0:
+				y1.SetInt64(0)
+			case 1:
+				y2.SetInt64(-1)
+			}
+			p1, p2 := new(Int).ExpCT(x1, y1, mod), new(Int).ExpCT(x2, y2, mod)
+			got := new(Int).Exp2CT(x1, y1, x2, y2, mod)
+			if p1 == nil || p2 == nil {
+				if got != nil {
+					t.Errorf("Exp2CT(%s, %s, %s, %s, %s) = %s; want nil", x1, y1, x2, y2, m, got)
+				}
+				continue
+			}
+			want := new(Int).MulCT(p1, p2, mod)
+			if got.Cmp(want) != 0 {
+				t.Errorf("Exp2CT(%s, %s, %s, %s, %s) = %s; want %s", x1, y1, x2, y2, m, got, want)
+			}
+			if got.zcap != len(m.abs) {
+				t.Errorf("Exp2CT: width %d; want %d", got.zcap, len(m.abs))
+			}
+		}
+	}
+}
+
+func TestModulusVarTime(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, s := range modulusTests {
+		m, _ := new(Int).SetString(s, 0)
+		mod := NewModulus(m)
+		for i := 0; i < 20; i++ {
+			x := randModInt(r, m)
+			y := randModInt(r, m)
+			e := randModInt(r, m)
+			if i == 0 {
+				e.SetInt64(0)
+			}
+
+			want := new(Int).Mod(x, m)
+			got := mod.Mod(new(Int), x)
+			if got.Cmp(want) != 0 || got.zcap != 0 {
+				t.Errorf("Mod(%s) mod %s = %s (width %d); want %s", x, m, got, got.zcap, want)
+			}
+			want.Mul(x, y).Mod(want, m)
+			if got := mod.Mul(new(Int), x, y); got.Cmp(want) != 0 {
+				t.Errorf("Mul(%s, %s) mod %s = %s; want %s", x, y, m, got, want)
+			}
+			want = modExp(x, e, m)
+			if got := mod.Exp(new(Int), x, e); !sameResult(got, want) {
+				t.Errorf("Exp(%s, %s) mod %s = %s; want %s", x, e, m, got, want)
+			}
+			if new(Int).GCD(nil, nil, new(Int).Abs(x), m).Cmp(intOne) == 0 && m.Cmp(intOne) != 0 {
+				want.ModInverse(x, m)
+				if got := mod.Inverse(new(Int), x); got.Cmp(want) != 0 {
+					t.Errorf("Inverse(%s) mod %s = %s; want %s", x, m, got, want)
+				}
+			}
+
+			// aliased operands
+			want = new(Int).Mul(x, x)
+			want.Mod(want, m)
+			if z := new(Int).Set(x); mod.Mul(z, z, z).Cmp(want) != 0 {
+				t.Errorf("aliased Mul = %s; want %s", z, want)
+			}
+			want = modExp(x, x, m)
+			if z := new(Int).Set(x); !sameResult(mod.Exp(z, z, z), want) {
+				t.Errorf("aliased Exp = %s; want %s", z, want)
+			}
+
+			// marked operands are reduced in constant time
+			x.SetConstantTime(x.BitLen() + 1)
+			want = new(Int).Mod(x, m)
+			got = mod.Mod(new(Int), x)
+			if got.Cmp(want) != 0 || got.zcap != len(m.abs) {
+				t.Errorf("marked Mod(%s) mod %s = %s (width %d); want %s (width %d)", x, m, got, got.zcap, want, len(m.abs))
+			}
+			want.Mul(x, y).Mod(want, m)
+			if got := mod.Mul(new(Int), x, y); got.Cmp(want) != 0 || got.zcap != len(m.abs) {
+				t.Errorf("marked Mul(%s, %s) mod %s = %s (width %d); want %s", x, y, m, got, got.zcap, want)
+			}
+		}
+	}
+}
+
+func TestModulusMont(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for _, s := range modulusTests {
+		m, _ := new(Int).SetString(s, 0)
+		mod := NewModulus(m)
+		R := new(Int).Lsh(intOne, uint(len(m.abs)*_W))
+		for i := 0; i < 10; i++ {
+			x := randModInt(r, m)
+			y := randModInt(r, m)
+			xm := mod.ToMont(new(Int), x)
+			want := new(Int).Mul(x, R)
+			want.Mod(want, m)
+			if xm.Cmp(want) != 0 {
+				t.Errorf("ToMont(%s) mod %s = %s; want %s", x, m, xm, want)
+			}
+			want.Mod(x, m)
+			if got := mod.FromMont(new(Int), xm); got.Cmp(want) != 0 {
+				t.Errorf("FromMont(ToMont(%s)) mod %s = %s; want %s", x, m, got, want)
+			}
+
+			// a chain of products in Montgomery form
+			zm := mod.MontMul(new(Int), xm, mod.ToMont(new(Int), y))
+			zm = mod.MontMul(zm, zm, xm)
+			got := mod.FromMont(new(Int), zm)
+			want.Mul(x, y).Mul(want, x).Mod(want, m)
+			if got.Cmp(want) != 0 {
+				t.Errorf("x*y*x mod %s in Montgomery form = %s; want %s", m, got, want)
+			}
+			if got.zcap != len(m.abs) {
+				t.Errorf("FromMont: width %d; want %d", got.zcap, len(m.abs))
+			}
+		}
+	}
+}
+
+func BenchmarkModulusMontMul(b *testing.B) {
+	m, _ := new(Int).SetString(modulusTests[len(modulusTests)-1], 0)
+	mod := NewModulus(m)
+	x := mod.ToMont(new(Int), new(Int).Sub(m, intOne))
+	z := new(Int)
+	for i := 0; i < b.N; i++ {
+		mod.MontMul(z, x, x)
+	}
+}
+
+func BenchmarkModulusExpCT(b *testing.B) {
+	m, _ := new(Int).SetString(modulusTests[len(modulusTests)-1], 0)
+	mod := NewModulus(m)
+	x := new(Int).Sub(m, intOne)
+	z := new(Int)
+	for i := 0; i < b.N; i++ {
+		z.ExpCT(x, x, mod)
+	}
+}
+
+func BenchmarkModulusExp2CT(b *testing.B) {
+	m, _ := new(Int).SetString(modulusTests[len(modulusTests)-1], 0)
+	mod := NewModulus(m)
+	x := new(Int).Sub(m, intOne)
+	z := new(Int)
+	for i := 0; i < b.N; i++ {
+		z.Exp2CT(x, x, x, x, mod)
+	}
+}
+
+func BenchmarkModulusMulCT(b *testing.B) {
+	m, _ := new(Int).SetString(modulusTests[len(modulusTests)-1], 0)
+	mod := NewModulus(m)
+	x := new(Int).Sub(m, intOne)
+	z := new(Int)
+	for i := 0; i < b.N; i++ {
+		z.MulCT(x, x, mod)
+	}
+}
+
+func BenchmarkModulusExp(b *testing.B) {
+	m, _ := new(Int).SetString(modulusTests[len(modulusTests)-1], 0)
+	mod := NewModulus(m)
+	x := new(Int).Sub(m, intOne)
+	z := new(Int)
+	for i := 0; i < b.N; i++ {
+		mod.Exp(z, x, x)
+	}
+}
+
+func BenchmarkModulusMod(b *testing.B) {
+	m, _ := new(Int).SetString(modulusTests[len(modulusTests)-1], 0)
+	mod := NewModulus(m)
+	x := new(Int).Mul(m, m)
+	x.Sub(x, intOne)
+	z := new(Int)
+	for i := 0; i < b.N; i++ {
+		mod.Mod(z, x)
+	}
+}
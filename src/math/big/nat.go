@@ -9,6 +9,8 @@
 package big
 
 import (
+	"io"
+	"math"
 	"math/bits"
 	"math/rand"
 	"sync"
@@ -151,19 +153,14 @@ func (x nat) cmp(y nat) (r int) {
 		}
 		return
 	}
-
-	i := m - 1
-	for i > 0 && x[i] == y[i] {
-		i--
-	}
-
-	switch {
-	case x[i] < y[i]:
-		r = -1
-	case x[i] > y[i]:
-		r = 1
+	// Most operands differ in the top word already.
+	switch xm, ym := x[m-1], y[m-1]; {
+	case xm < ym:
+		return -1
+	case xm > ym:
+		return 1
 	}
-	return
+	return cmpVV(x[:m-1], y[:m-1])
 }
 
 func (z nat) mulAddWW(x nat, y, r Word) nat {
@@ -231,6 +228,60 @@ func (z nat) montgomery(x, y, m nat, k Word, n int) nat {
 	return z
 }
 
+// Operands shorter than montgomerySqrThreshold words are squared with
+// montgomery, which is faster for them than montgomerySqr.
+var montgomerySqrThreshold = 20 // measured with BenchmarkMontgomerySqr
+
+// montgomerySqr computes z mod m = x*x*2**(-n*_W) mod m, like
+// z.montgomery(x, x, m, k, n), but faster for long operands: it first
+// squares x, computing each product x[i]*x[j] with i != j only once,
+// and then reduces the double-length square word by word.
+// z must not alias x or m. The bounds on x and z are as for montgomery.
+func (z nat) montgomerySqr(x, m nat, k Word, n int) nat {
+	if len(x) != n || len(m) != n {
+		panic("math/big: mismatched montgomery number lengths")
+	}
+	if n < montgomerySqrThreshold {
+		return z.montgomery(x, x, m, k, n)
+	}
+	z = z.make(2 * n)
+	z.clear()
+
+	// z = x*x: the products x[i]*x[j] with j < i, doubled,
+	// plus the squares x[i]*x[i] on the diagonal
+	for i := 1; i < n; i++ {
+		z[2*i] = addMulVVW(z[i:2*i], x[0:i], x[i])
+	}
+	z[2*n-1] = shlVU(z[1:2*n-1], z[1:2*n-1], 1)
+	var c Word
+	for i := 0; i < n; i++ {
+		hi, lo := mulWW(x[i], x[i])
+		c, z[2*i] = addWW_g(z[2*i], lo, c)
+		c, z[2*i+1] = addWW_g(z[2*i+1], hi, c)
+	}
+
+	// z = z/2**(n*_W) mod m: clear the low words one at a time
+	// by adding multiples of m
+	c = 0
+	for i := 0; i < n; i++ {
+		c2 := addMulVVW(z[i:i+n], m, z[i]*k)
+		cx := z[i+n] + c2
+		cy := cx + c
+		z[i+n] = cy
+		if cx < c2 || cy < c {
+			c = 1
+		} else {
+			c = 0
+		}
+	}
+	copy(z, z[n:])
+	z = z[:n]
+	if c != 0 {
+		subVV(z, z, m)
+	}
+	return z
+}
+
 // Fast version of z[0:n+n>>1].add(z[0:n+n>>1], x[0:n]) w/o bounds checks.
 // Factored out for readability - do not use outside karatsuba.
 func karatsubaAdd(z, x nat, n int) {
@@ -305,29 +356,22 @@ func karatsuba(z, x, y nat) {
 	// caller's z.
 
 	// compute z0 and z2 with the result "in place" in z
-	karatsuba(z, x0, y0)     // z0 = x0*y0
-	karatsuba(z[n:], x1, y1) // z2 = x1*y1
-
-	// compute xd (or the negative value if underflow occurs)
-	s := 1 // sign of product xd*yd
-	xd := z[2*n : 2*n+n2]
-	if subVV(xd, x1, x0) != 0 { // x1-x0
-		s = -s
-		subVV(xd, x0, x1) // x0-x1
-	}
+	var s int // sign of product xd*yd
+	var p nat
+	if n >= parallelMulWords && acquireWorker() {
+		s, p = karatsubaParallel(z, x1, x0, y1, y0)
+	} else {
+		karatsuba(z, x0, y0)     // z0 = x0*y0
+		karatsuba(z[n:], x1, y1) // z2 = x1*y1
 
-	// compute yd (or the negative value if underflow occurs)
-	yd := z[2*n+n2 : 3*n]
-	if subVV(yd, y0, y1) != 0 { // y0-y1
-		s = -s
-		subVV(yd, y1, y0) // y1-y0
+		// compute xd, yd, and p = xd*yd
+		s = karatsubaDiffs(z[2*n:], x1, x0, y1, y0)
+		xd := z[2*n : 2*n+n2]
+		yd := z[2*n+n2 : 3*n]
+		p = z[n*3:]
+		karatsuba(p, xd, yd)
 	}
 
-	// p = (x1-x0)*(y0-y1) == x1*y0 - x1*y1 - x0*y0 + x0*y1 for s > 0
-	// p = (x0-x1)*(y0-y1) == x0*y0 - x0*y1 - x1*y0 + x1*y1 for s < 0
-	p := z[n*3:]
-	karatsuba(p, xd, yd)
-
 	// save original z2:z0
 	// (ok to use upper half of z since we're done recursing)
 	r := z[n*4:]
@@ -350,6 +394,76 @@ func karatsuba(z, x, y nat) {
 	}
 }
 
+// karatsubaDiffs sets d[:n2] to xd = |x1-x0| and d[n2:2*n2] to
+// yd = |y0-y1|, for n2 = len(x0), and returns the sign of xd*yd; see
+// karatsuba.
+//
+//   p = (x1-x0)*(y0-y1) == x1*y0 - x1*y1 - x0*y0 + x0*y1 for s > 0
+//   p = (x0-x1)*(y0-y1) == x0*y0 - x0*y1 - x1*y0 + x1*y1 for s < 0
+func karatsubaDiffs(d, x1, x0, y1, y0 nat) (s int) {
+	n2 := len(x0)
+	s = 1
+
+	// compute xd (or the negative value if underflow occurs)
+	xd := d[:n2]
+	if subVV(xd, x1, x0) != 0 { // x1-x0
+		s = -s
+		subVV(xd, x0, x1) // x0-x1
+	}
+
+	// compute yd (or the negative value if underflow occurs)
+	yd := d[n2 : 2*n2]
+	if subVV(yd, y0, y1) != 0 { // y0-y1
+		s = -s
+		subVV(yd, y1, y0) // y1-y0
+	}
+	return s
+}
+
+// Karatsuba multiplications of at least parallelMulWords Words compute
+// their partial products concurrently, within the limit set by
+// SetParallelism.
+var parallelMulWords = 1 << 11
+
+// karatsubaParallel computes the partial products z0 = x0*y0 and
+// z2 = x1*y1 of karatsuba in place in z, and xd*yd, and returns the sign
+// of xd*yd and the product. z2 is computed by another goroutine in a
+// separate buffer, for which the caller must have called acquireWorker,
+// and so is xd*yd if acquireWorker allows.
+func karatsubaParallel(z, x1, x0, y1, y0 nat) (s int, p nat) {
+	n2 := len(x0)
+	n := 2 * n2
+	buf := make(nat, 7*n)
+	z2 := buf[:3*n]     // z2 and the scratch space for computing it
+	d := buf[3*n : 4*n] // xd:yd
+	p = buf[4*n:]       // xd*yd and the scratch space for computing it
+	s = karatsubaDiffs(d, x1, x0, y1, y0)
+	xd, yd := d[:n2], d[n2:]
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		karatsuba(z2, x1, y1)
+		releaseWorker()
+		wg.Done()
+	}()
+	if acquireWorker() {
+		wg.Add(1)
+		go func() {
+			karatsuba(p, xd, yd)
+			releaseWorker()
+			wg.Done()
+		}()
+		karatsuba(z, x0, y0)
+	} else {
+		karatsuba(z, x0, y0)
+		karatsuba(p, xd, yd)
+	}
+	wg.Wait()
+	copy(z[n:2*n], z2[:n])
+	return s, p[:n]
+}
+
 // alias reports whether x and y share the same base array.
 func alias(x, y nat) bool {
 	return cap(x) > 0 && cap(y) > 0 && &x[0:cap(x)][cap(x)-1] == &y[0:cap(y)][cap(y)-1]
@@ -416,6 +530,11 @@ func (z nat) mul(x, y nat) nat {
 	}
 	// m >= n && n >= karatsubaThreshold && n >= 2
 
+	// use Schönhage-Strassen multiplication if the numbers are huge
+	if n >= fftThreshold {
+		return z.fftMul(x, y)
+	}
+
 	// determine Karatsuba length k such that
 	//
 	//   x = xh*b + x0  (0 <= x0 < b)
@@ -484,11 +603,20 @@ func (z nat) mulRange(a, b uint64) nat {
 		return z.setUint64(1)
 	case a == b:
 		return z.setUint64(a)
-	case a+1 == b:
-		return z.mul(nat(nil).setUint64(a), nat(nil).setUint64(b))
+	case a <= 2 && b >= factorialThreshold && b <= maxFactorial:
+		return z.factorial(b)
+	case b-a < 32:
+		return z.mulRangeWords(a, b)
+	}
+	m := a + (b-a)/2
+	var x, y nat
+	if rangeParallel(a, b) {
+		parallelDo(func() { x = nat(nil).mulRange(a, m) }, func() { y = nat(nil).mulRange(m+1, b) })
+	} else {
+		x = nat(nil).mulRange(a, m)
+		y = nat(nil).mulRange(m+1, b)
 	}
-	m := (a + b) / 2
-	return z.mul(nat(nil).mulRange(a, m), nat(nil).mulRange(m+1, b))
+	return z.mul(x, y)
 }
 
 // q = (x-r)/y, with 0 <= r < y
@@ -529,10 +657,34 @@ func (z nat) div(z2, u, v nat) (q, r nat) {
 		return
 	}
 
+	if q, r, ok := z.divSpecial(z2, u, v); ok {
+		return q, r
+	}
+
 	q, r = z.divLarge(z2, u, v)
 	return
 }
 
+// quo returns z = x/y, using a pooled temporary for the remainder.
+func (z nat) quo(x, y nat) nat {
+	rp := getNat(0)
+	var r nat
+	z, r = z.div(*rp, x, y)
+	*rp = r
+	putNat(rp)
+	return z
+}
+
+// rem returns z = x%y, using a pooled temporary for the quotient.
+func (z nat) rem(x, y nat) nat {
+	qp := getNat(0)
+	var q nat
+	q, z = (*qp).div(z, x, y)
+	*qp = q
+	putNat(qp)
+	return z
+}
+
 // getNat returns a *nat of len n. The contents may not be zero.
 // The pool holds *nat to avoid allocation when converting to interface{}.
 func getNat(n int) *nat {
@@ -553,6 +705,30 @@ func putNat(x *nat) {
 
 var natPool sync.Pool
 
+// getSecretNat is like getNat, but for temporaries of the constant-time
+// operations, which may hold secret values. They are recycled through a
+// separate pool, so that they are never handed to other operations,
+// and they are zeroed by putSecretNat before being returned to it.
+func getSecretNat(n int) *nat {
+	var z *nat
+	if v := secretNatPool.Get(); v != nil {
+		z = v.(*nat)
+	}
+	if z == nil {
+		z = new(nat)
+	}
+	*z = z.make(n)
+	return z
+}
+
+// putSecretNat wipes x and returns it to the pool of getSecretNat.
+func putSecretNat(x *nat) {
+	x.wipe()
+	secretNatPool.Put(x)
+}
+
+var secretNatPool sync.Pool
+
 // q = (uIn-r)/v, with 0 <= r < y
 // Uses z as storage for q, and u as storage for r if possible.
 // See Knuth, Volume 2, section 4.3.1, Algorithm D.
@@ -571,13 +747,10 @@ func (z nat) divLarge(u, uIn, v nat) (q, r nat) {
 	}
 	q = z.make(m + 1)
 
-	qhatvp := getNat(n + 1)
-	qhatv := *qhatvp
 	if alias(u, uIn) || alias(u, v) {
 		u = nil // u is an alias for uIn or v - cannot reuse
 	}
-	u = u.make(len(uIn) + 1)
-	u.clear() // TODO(gri) no need to clear if we allocated a new u
+	u = u.make(len(uIn) + 1) // all words are set by divNormalized
 
 	// D1.
 	var v1p *nat
@@ -589,6 +762,26 @@ func (z nat) divLarge(u, uIn, v nat) (q, r nat) {
 		shlVU(v1, v, shift)
 		v = v1
 	}
+	q, r = q.divNormalized(u, uIn, v, shift, 0, false)
+	if v1p != nil {
+		putNat(v1p)
+	}
+	return q, r
+}
+
+// divNormalized is the main loop of divLarge, for a divisor v that is
+// already normalized by shifting it left by shift bits, so that the high
+// bit of v[len(v)-1] is set. It computes q and r as divLarge does for
+// the divisor v>>shift, with q and u as storage for q and r, which must
+// not alias uIn and v, and len(q) == len(uIn) - len(v) + 1 and
+// len(u) == len(uIn) + 1. If rec is set, inv is the reciprocal3by2 of
+// the two most significant words of v, and each q̂ is computed exactly
+// by div3by2 instead of being estimated and corrected.
+func (q nat) divNormalized(u, uIn, v nat, shift uint, inv Word, rec bool) (nat, nat) {
+	n := len(v)
+	m := len(uIn) - n
+	qhatvp := getNat(n + 1)
+	qhatv := *qhatvp
 	u[len(uIn)] = shlVU(u[0:len(uIn)], uIn, shift)
 
 	// D2.
@@ -596,7 +789,9 @@ func (z nat) divLarge(u, uIn, v nat) (q, r nat) {
 	for j := m; j >= 0; j-- {
 		// D3.
 		qhat := Word(_M)
-		if ujn := u[j+n]; ujn != vn1 {
+		if ujn := u[j+n]; ujn != vn1 && rec {
+			qhat, _, _ = div3by2(ujn, u[j+n-1], u[j+n-2], vn1, v[n-2], inv)
+		} else if ujn != vn1 {
 			var rhat Word
 			qhat, rhat = divWW(ujn, u[j+n-1], vn1)
 
@@ -629,16 +824,76 @@ func (z nat) divLarge(u, uIn, v nat) (q, r nat) {
 
 		q[j] = qhat
 	}
-	if v1p != nil {
-		putNat(v1p)
-	}
 	putNat(qhatvp)
 
 	q = q.norm()
 	shrVU(u, u, shift)
-	r = u.norm()
+	return q, u.norm()
+}
 
-	return q, r
+// divExact sets z to the quotient x/y and returns z, for a divisor
+// y != 0 that divides x exactly (the result is undefined otherwise).
+// The quotient is computed from the least significant word upwards
+// by exact division: each word of the quotient follows from the lowest
+// remaining word of x and the inverse of y modulo 2**_W, without the
+// estimations and corrections of long division. Only the words of x
+// below the length of the quotient are used.
+// See Tudor Jebelean, "An algorithm for exact division", Journal of
+// Symbolic Computation 15(2), 1993.
+func (z nat) divExact(x, y nat) nat {
+	if len(y) == 0 {
+		panic("division by zero")
+	}
+	if alias(z, x) || alias(z, y) {
+		z = nil // z is an alias for x or y - cannot reuse
+	}
+
+	// remove the common factors 2, so that y is odd
+	s := y.trailingZeroBits()
+	rp := getNat(len(x))
+	r := (*rp).shr(x, s)
+	yp := getNat(len(y))
+	v := (*yp).shr(y, s)
+
+	n := len(r) - len(v) + 1 // the quotient has at most n words
+	if n <= 0 {
+		putNat(rp)
+		putNat(yp)
+		return z[:0]
+	}
+	z = z.make(n)
+
+	// With k0 = -v**-1 mod 2**_W, adding q*v*2**(i*_W) with q = r[i]*k0
+	// clears word i of r. The sum of these multiples of v is -x/y modulo
+	// 2**(n*_W), which is the quotient since it is less than 2**(n*_W).
+	k0 := montgomeryK0(v[0])
+	var cb Word // carry into r[i+len(v)]
+	for i := 0; i < n; i++ {
+		q := r[i] * k0
+		z[i] = q
+		l := min(len(v), n-i) // words at and above n don't matter
+		c := addMulVVW(r[i:i+l], v[:l], q)
+		if j := i + l; j < n {
+			t := r[j] + c
+			u := t + cb
+			r[j] = u
+			if t < c || u < t {
+				cb = 1
+			} else {
+				cb = 0
+			}
+		}
+	}
+
+	// negate z modulo 2**(n*_W)
+	for i := range z {
+		z[i] = ^z[i]
+	}
+	addVW(z, z, 1)
+
+	putNat(rp)
+	putNat(yp)
+	return z.norm()
 }
 
 // Length of x in bits. x must be normalized.
@@ -672,7 +927,15 @@ func (z nat) shl(x nat, s uint) nat {
 	// m > 0
 
 	n := m + int(s/_W)
-	z = z.make(n + 1)
+	if n+1 > cap(z) && alias(z, x) {
+		// x is shifted in place and does not fit anymore: leave room for
+		// it to grow by half, so that loops that repeatedly shift a value
+		// left reallocate it only a logarithmic number of times instead
+		// of every few words.
+		z = make(nat, n+1, n+1+n/2)
+	} else {
+		z = z.make(n + 1)
+	}
 	z[n] = shlVU(z[n-m:n], x, s%_W)
 	z[0 : n-m].clear()
 
@@ -733,6 +996,27 @@ func (x nat) bit(i uint) uint {
 	return uint(x[j] >> (i % _W) & 1)
 }
 
+// nextBit returns the index of the least significant bit at or above i
+// that is 1 in x^flip, with x extended by zeros, or -1 if there is none.
+// flip must be 0 or _M.
+func (x nat) nextBit(i uint, flip Word) int {
+	if j := i / _W; j < uint(len(x)) {
+		if w := (x[j] ^ flip) >> (i % _W); w != 0 {
+			return int(i) + bits.TrailingZeros(uint(w))
+		}
+		for j++; j < uint(len(x)); j++ {
+			if w := x[j] ^ flip; w != 0 {
+				return int(j)*_W + bits.TrailingZeros(uint(w))
+			}
+		}
+		i = j * _W
+	}
+	if flip != 0 {
+		return int(i)
+	}
+	return -1
+}
+
 // sticky returns 1 if there's a 1 bit within the
 // i least significant bits, otherwise it returns 0.
 func (x nat) sticky(i uint) uint {
@@ -764,9 +1048,7 @@ func (z nat) and(x, y nat) nat {
 	// m <= n
 
 	z = z.make(m)
-	for i := 0; i < m; i++ {
-		z[i] = x[i] & y[i]
-	}
+	andVV(z, x, y)
 
 	return z.norm()
 }
@@ -780,9 +1062,7 @@ func (z nat) andNot(x, y nat) nat {
 	// m >= n
 
 	z = z.make(m)
-	for i := 0; i < n; i++ {
-		z[i] = x[i] &^ y[i]
-	}
+	andNotVV(z[:n], x, y)
 	copy(z[n:m], x[n:m])
 
 	return z.norm()
@@ -799,9 +1079,7 @@ func (z nat) or(x, y nat) nat {
 	// m >= n
 
 	z = z.make(m)
-	for i := 0; i < n; i++ {
-		z[i] = x[i] | y[i]
-	}
+	orVV(z[:n], x, y)
 	copy(z[n:m], s[n:m])
 
 	return z.norm()
@@ -818,9 +1096,7 @@ func (z nat) xor(x, y nat) nat {
 	// m >= n
 
 	z = z.make(m)
-	for i := 0; i < n; i++ {
-		z[i] = x[i] ^ y[i]
-	}
+	xorVV(z[:n], x, y)
 	copy(z[n:m], s[n:m])
 
 	return z.norm()
@@ -875,9 +1151,34 @@ func (z nat) random(rand *rand.Rand, limit nat, n int) nat {
 	return z.norm()
 }
 
+// randomFrom is like random, but reads the random bits from the bytes of
+// rand. It returns the error of a failed read.
+func (z nat) randomFrom(rand io.Reader, limit nat, n int) (nat, error) {
+	if alias(z, limit) {
+		z = nil // z is an alias for limit - cannot reuse
+	}
+	buf := make([]byte, (n+7)/8)
+	mask := byte(1<<(uint(n-1)%8+1) - 1) // of the bits of limit's top byte
+	for {
+		if _, err := io.ReadFull(rand, buf); err != nil {
+			return z, err
+		}
+		buf[0] &= mask
+		z = z.setBytes(buf)
+		if z.cmp(limit) < 0 {
+			return z, nil
+		}
+	}
+}
+
 // If m != 0 (i.e., len(m) != 0), expNN sets z to x**y mod m;
 // otherwise it sets z to x**y. The result is the value of z.
 func (z nat) expNN(x, y, m nat) nat {
+	return z.expNNScratch(x, y, m, nil)
+}
+
+// expNNScratch is like expNN, but takes its temporaries from s.
+func (z nat) expNNScratch(x, y, m nat, s *Scratch) nat {
 	if alias(z, x) || alias(z, y) {
 		// We cannot allow in-place modification of x or y.
 		z = nil
@@ -897,7 +1198,9 @@ func (z nat) expNN(x, y, m nat) nat {
 
 	// x**1 mod m == x mod m
 	if len(y) == 1 && y[0] == 1 && len(m) != 0 {
-		_, z = z.div(z, x, m)
+		qp := s.getNat(0)
+		*qp, z = (*qp).div(z, x, m)
+		s.putNat(qp)
 		return z
 	}
 	// y > 1
@@ -912,18 +1215,22 @@ func (z nat) expNN(x, y, m nat) nat {
 	// 4-bit, windowed exponentiation. This involves precomputing 14 values
 	// (x^2...x^15) but then reduces the number of multiply-reduces by a
 	// third. Even for a 32-bit exponent, this reduces the number of
-	// operations. Uses Montgomery method for odd moduli.
+	// operations. Uses Montgomery method for odd moduli; for even moduli,
+	// the window slides and its width grows with the exponent.
 	if x.cmp(natOne) > 0 && len(y) > 1 && len(m) > 0 {
 		if m[0]&1 == 1 {
-			return z.expNNMontgomery(x, y, m)
+			rrp := s.getNat(0)
+			*rrp = (*rrp).montgomeryRR(m, s)
+			z = z.expNNMontgomery(x, y, m, montgomeryK0(m[0]), *rrp, s)
+			s.putNat(rrp)
+			return z
 		}
-		return z.expNNWindowed(x, y, m)
+		return z.expNNWindowed(x, y, m, s)
 	}
 
 	v := y[len(y)-1] // v > 0 because y is normalized and y > 0
 	shift := nlz(v) + 1
 	v <<= shift
-	var q nat
 
 	const mask = 1 << (_W - 1)
 
@@ -934,7 +1241,8 @@ func (z nat) expNN(x, y, m nat) nat {
 	w := _W - int(shift)
 	// zz and r are used to avoid allocating in mul and div as
 	// otherwise the arguments would alias.
-	var zz, r nat
+	zzp, rp, qp := s.getNat(0), s.getNat(0), s.getNat(0)
+	zz, r, q := *zzp, *rp, *qp
 	for j := 0; j < w; j++ {
 		zz = zz.mul(z, z)
 		zz, z = z, zz
@@ -972,117 +1280,147 @@ func (z nat) expNN(x, y, m nat) nat {
 			v <<= 1
 		}
 	}
+	*zzp, *rp, *qp = zz, r, q
+	s.putNat(zzp)
+	s.putNat(rp)
+	s.putNat(qp)
 
 	return z.norm()
 }
 
-// expNNWindowed calculates x**y mod m using a fixed, 4-bit window.
-func (z nat) expNNWindowed(x, y, m nat) nat {
+// expWindowBits returns the width of the sliding window that
+// expNNWindowed uses for an exponent of the given bit length. Wider
+// windows need fewer multiplications per exponent bit, but a table of
+// more odd powers of the base; the thresholds are those of OpenSSL's
+// BN_window_bits_for_exponent_size.
+func expWindowBits(bits int) uint {
+	switch {
+	case bits > 671:
+		return 6
+	case bits > 239:
+		return 5
+	case bits > 79:
+		return 4
+	case bits > 23:
+		return 3
+	}
+	return 1
+}
+
+// expNNWindowed calculates x**y mod m using a sliding window, whose
+// width is chosen from the bit length of y by expWindowBits. The
+// temporaries are taken from s.
+func (z nat) expNNWindowed(x, y, m nat, s *Scratch) nat {
 	// zz and r are used to avoid allocating in mul and div as otherwise
 	// the arguments would alias.
-	var zz, r nat
-
-	const n = 4
-	// powers[i] contains x^i.
-	var powers [1 << n]nat
-	powers[0] = natOne
-	powers[1] = x
-	for i := 2; i < 1<<n; i += 2 {
-		p2, p, p1 := &powers[i/2], &powers[i], &powers[i+1]
-		*p = p.mul(*p2, *p2)
-		zz, r = zz.div(r, *p, m)
-		*p, r = r, *p
-		*p1 = p1.mul(*p, x)
-		zz, r = zz.div(r, *p1, m)
-		*p1, r = r, *p1
+	zzp, rp := s.getNat(0), s.getNat(0)
+	zz, r := *zzp, *rp
+
+	k := expWindowBits(y.bitLen())
+	// powers[i] contains x^(2*i+1).
+	var pp [32]*nat
+	var table [32]nat
+	powers := table[:1<<(k-1)]
+	for i := range powers {
+		pp[i] = s.getNat(0)
+	}
+	zz, powers[0] = zz.div(*pp[0], x, m)
+	if k > 1 {
+		x2p := s.getNat(0)
+		x2 := *x2p
+		zz = zz.mul(powers[0], powers[0])
+		r, x2 = r.div(x2, zz, m)
+		for i := 1; i < len(powers); i++ {
+			zz = zz.mul(powers[i-1], x2)
+			r, powers[i] = r.div(*pp[i], zz, m)
+		}
+		*x2p = x2
+		s.putNat(x2p)
 	}
 
+	// The windows start and end with a 1 bit and are at most k bits
+	// wide; the 0 bits between them are squarings only.
 	z = z.setWord(1)
-
-	for i := len(y) - 1; i >= 0; i-- {
-		yi := y[i]
-		for j := 0; j < _W; j += n {
-			if i != len(y)-1 || j != 0 {
-				// Unrolled loop for significant performance
-				// gain. Use go test -bench=".*" in crypto/rsa
-				// to check performance before making changes.
-				zz = zz.mul(z, z)
-				zz, z = z, zz
-				zz, r = zz.div(r, z, m)
-				z, r = r, z
-
-				zz = zz.mul(z, z)
-				zz, z = z, zz
-				zz, r = zz.div(r, z, m)
-				z, r = r, z
-
-				zz = zz.mul(z, z)
-				zz, z = z, zz
-				zz, r = zz.div(r, z, m)
-				z, r = r, z
-
-				zz = zz.mul(z, z)
-				zz, z = z, zz
-				zz, r = zz.div(r, z, m)
-				z, r = r, z
+	for i := y.bitLen() - 1; i >= 0; {
+		if y.bit(uint(i)) == 0 {
+			zz = zz.mul(z, z)
+			zz, z = z, zz
+			zz, r = zz.div(r, z, m)
+			z, r = r, z
+			i--
+			continue
+		}
+		j := max(i-int(k)+1, 0)
+		for y.bit(uint(j)) == 0 {
+			j++
+		}
+		var w uint
+		for l := i; l >= j; l-- {
+			w = w<<1 | y.bit(uint(l))
+			if len(z) == 1 && z[0] == 1 {
+				continue // no need to square 1
 			}
-
-			zz = zz.mul(z, powers[yi>>(_W-n)])
+			zz = zz.mul(z, z)
 			zz, z = z, zz
 			zz, r = zz.div(r, z, m)
 			z, r = r, z
-
-			yi <<= n
 		}
+		zz = zz.mul(z, powers[w>>1])
+		zz, z = z, zz
+		zz, r = zz.div(r, z, m)
+		z, r = r, z
+		i = j - 1
 	}
 
+	for i, p := range powers {
+		*pp[i] = p
+		s.putNat(pp[i])
+	}
+	*zzp, *rp = zz, r
+	s.putNat(zzp)
+	s.putNat(rp)
 	return z.norm()
 }
 
 // expNNMontgomery calculates x**y mod m using a fixed, 4-bit window.
-// Uses Montgomery representation.
-func (z nat) expNNMontgomery(x, y, m nat) nat {
+// Uses Montgomery representation; k0 and RR are the Montgomery constants
+// for m, as computed by montgomeryK0 and montgomeryRR. The temporaries
+// are taken from s.
+func (z nat) expNNMontgomery(x, y, m nat, k0 Word, RR nat, s *Scratch) nat {
 	numWords := len(m)
 
 	// We want the lengths of x and m to be equal.
 	// It is OK if x >= m as long as len(x) == len(m).
+	xp := s.getNat(numWords)
+	xx := *xp
 	if len(x) > numWords {
-		_, x = nat(nil).div(nil, x, m)
+		qp, rp := s.getNat(0), s.getNat(0)
+		*qp, *rp = (*qp).div(*rp, x, m)
 		// Note: now len(x) <= numWords, not guaranteed ==.
+		copy(xx, *rp)
+		xx[len(*rp):].clear()
+		s.putNat(qp)
+		s.putNat(rp)
+	} else {
+		copy(xx, x)
+		xx[len(x):].clear()
 	}
-	if len(x) < numWords {
-		rr := make(nat, numWords)
-		copy(rr, x)
-		x = rr
-	}
-
-	// Ideally the precomputations would be performed outside, and reused
-	// k0 = -m**-1 mod 2**_W. Algorithm from: Dumas, J.G. "On Newton–Raphson
-	// Iteration for Multiplicative Inverses Modulo Prime Powers".
-	k0 := 2 - m[0]
-	t := m[0] - 1
-	for i := 1; i < _W; i <<= 1 {
-		t *= t
-		k0 *= (t + 1)
-	}
-	k0 = -k0
+	x = xx
 
-	// RR = 2**(2*_W*len(m)) mod m
-	RR := nat(nil).setWord(1)
-	zz := nat(nil).shl(RR, uint(2*numWords*_W))
-	_, RR = RR.div(RR, zz, m)
-	if len(RR) < numWords {
-		zz = zz.make(numWords)
-		copy(zz, RR)
-		RR = zz
-	}
 	// one = 1, with equal length to that of m
-	one := make(nat, numWords)
+	onep := s.getNat(numWords)
+	one := *onep
+	one.clear()
 	one[0] = 1
 
 	const n = 4
 	// powers[i] contains x^i
+	var pp [1 << n]*nat
 	var powers [1 << n]nat
+	for i := range powers {
+		pp[i] = s.getNat(0)
+		powers[i] = *pp[i]
+	}
 	powers[0] = powers[0].montgomery(one, RR, m, k0, numWords)
 	powers[1] = powers[1].montgomery(x, RR, m, k0, numWords)
 	for i := 2; i < 1<<n; i++ {
@@ -1093,17 +1431,18 @@ func (z nat) expNNMontgomery(x, y, m nat) nat {
 	z = z.make(numWords)
 	copy(z, powers[0])
 
-	zz = zz.make(numWords)
+	zzp := s.getNat(numWords)
+	zz := *zzp
 
 	// same windowed exponent, but with Montgomery multiplications
 	for i := len(y) - 1; i >= 0; i-- {
 		yi := y[i]
 		for j := 0; j < _W; j += n {
 			if i != len(y)-1 || j != 0 {
-				zz = zz.montgomery(z, z, m, k0, numWords)
-				z = z.montgomery(zz, zz, m, k0, numWords)
-				zz = zz.montgomery(z, z, m, k0, numWords)
-				z = z.montgomery(zz, zz, m, k0, numWords)
+				zz = zz.montgomerySqr(z, m, k0, numWords)
+				z = z.montgomerySqr(zz, m, k0, numWords)
+				zz = zz.montgomerySqr(z, m, k0, numWords)
+				z = z.montgomerySqr(zz, m, k0, numWords)
 			}
 			zz = zz.montgomery(z, powers[yi>>(_W-n)], m, k0, numWords)
 			z, zz = zz, z
@@ -1129,23 +1468,159 @@ func (z nat) expNNMontgomery(x, y, m nat) nat {
 		}
 	}
 
+	// zz holds the result, and z goes back to s in its place
+	for i, p := range powers {
+		*pp[i] = p
+		s.putNat(pp[i])
+	}
+	*xp, *onep, *zzp = x, one, z
+	s.putNat(xp)
+	s.putNat(onep)
+	s.putNat(zzp)
 	return zz.norm()
 }
 
+// expNN2 calculates x1**y1 * x2**y2 mod m for m > 0 using Shamir's trick:
+// the exponents are scanned together in 2-bit windows, and each window
+// multiplies by one entry of a joint table of the 16 products
+// x1**i * x2**j for 0 <= i, j < 4, so that the squarings are shared
+// between the two powers. Uses Montgomery multiplication for odd m.
+func (z nat) expNN2(x1, y1, x2, y2, m nat) nat {
+	if len(m) == 1 && m[0] == 1 {
+		return z.setWord(0)
+	}
+	n := len(m)
+	_, x1 = nat(nil).div(nil, x1, m)
+	_, x2 = nat(nil).div(nil, x2, m)
+
+	// mul sets z = x*y mod m in the representation used for the
+	// products, in which one is the value 1, and sqr sets z = x*x;
+	// z must not alias x or y.
+	var mul func(z, x, y nat) nat
+	var sqr func(z, x nat) nat
+	var one nat
+	mont := m[0]&1 == 1
+	if mont {
+		k0 := montgomeryK0(m[0])
+		RR := nat(nil).montgomeryRR(m, nil)
+		mul = func(z, x, y nat) nat {
+			return z.montgomery(x, y, m, k0, n)
+		}
+		sqr = func(z, x nat) nat {
+			return z.montgomerySqr(x, m, k0, n)
+		}
+		pad := func(x nat) nat {
+			p := make(nat, n)
+			copy(p, x)
+			return mul(nil, p, RR)
+		}
+		one, x1, x2 = pad(natOne), pad(x1), pad(x2)
+	} else {
+		var zz, q nat
+		mul = func(z, x, y nat) nat {
+			zz = zz.mul(x, y)
+			q, z = q.div(z, zz, m)
+			return z
+		}
+		sqr = func(z, x nat) nat {
+			return mul(z, x, x)
+		}
+		one = natOne
+	}
+
+	var table [16]nat
+	table[0], table[1], table[4] = one, x2, x1
+	table[2] = sqr(nil, x2)
+	table[3] = mul(nil, table[2], x2)
+	table[8] = sqr(nil, x1)
+	table[12] = mul(nil, table[8], x1)
+	for i := 4; i < 16; i += 4 {
+		for j := 1; j < 4; j++ {
+			table[i|j] = mul(nil, table[i], table[j])
+		}
+	}
+
+	bits := max(y1.bitLen(), y2.bitLen())
+	z = z.set(one)
+	var zz nat
+	for i := bits + bits&1 - 2; i >= 0; i -= 2 {
+		if !(len(z) == len(one) && z.cmp(one) == 0) {
+			zz = sqr(zz, z)
+			z = sqr(z, zz)
+		}
+		w := y1.bit(uint(i+1))<<3 | y1.bit(uint(i))<<2 | y2.bit(uint(i+1))<<1 | y2.bit(uint(i))
+		if w != 0 {
+			zz = mul(zz, z, table[w])
+			z, zz = zz, z
+		}
+	}
+	if !mont {
+		return z.norm()
+	}
+
+	// convert to regular number and reduce, as in expNNMontgomery
+	one = make(nat, n)
+	one[0] = 1
+	zz = mul(zz, z, one)
+	if zz.cmp(m) >= 0 {
+		zz = zz.sub(zz, m)
+		if zz.cmp(m) >= 0 {
+			_, zz = nat(nil).div(nil, zz, m)
+		}
+	}
+	return zz.norm()
+}
+
+// montgomeryK0 returns k0 = -m**-1 mod 2**_W for the least significant
+// word m0 of an odd modulus m.
+// Algorithm from: Dumas, J.G. "On Newton–Raphson Iteration for
+// Multiplicative Inverses Modulo Prime Powers".
+func montgomeryK0(m0 Word) Word {
+	k0 := 2 - m0
+	t := m0 - 1
+	for i := 1; i < _W; i <<= 1 {
+		t *= t
+		k0 *= (t + 1)
+	}
+	return -k0
+}
+
+// montgomeryRR returns RR = 2**(2*_W*len(m)) mod m, zero-extended to
+// len(m) words, using z as storage and temporaries from s.
+func (z nat) montgomeryRR(m nat, s *Scratch) nat {
+	numWords := len(m)
+	tp, qp := s.getNat(0), s.getNat(0)
+	*tp = (*tp).shl(natOne, uint(2*numWords*_W))
+	*qp, z = (*qp).div(z, *tp, m)
+	for len(z) < numWords {
+		z = append(z, 0)
+	}
+	s.putNat(tp)
+	s.putNat(qp)
+	return z
+}
+
 // bytes writes the value of z into buf using big-endian encoding.
-// len(buf) must be >= len(z)*_S. The value of z is encoded in the
-// slice buf[i:]. The number i of unused bytes at the beginning of
-// buf is returned as result.
+// The value of z is encoded in the slice buf[i:]. If the value of z
+// cannot be represented in buf, bytes panics. The number i of unused
+// bytes at the beginning of buf is returned as result.
 func (z nat) bytes(buf []byte) (i int) {
 	i = len(buf)
 	for _, d := range z {
 		for j := 0; j < _S; j++ {
 			i--
-			buf[i] = byte(d)
+			if i >= 0 {
+				buf[i] = byte(d)
+			} else if byte(d) != 0 {
+				panic("math/big: buffer too small to fit value")
+			}
 			d >>= 8
 		}
 	}
 
+	if i < 0 {
+		i = 0
+	}
 	for i < len(buf) && buf[i] == 0 {
 		i++
 	}
@@ -1182,6 +1657,10 @@ func (z nat) sqrt(x nat) nat {
 	if x.cmp(natOne) <= 0 {
 		return z.set(x)
 	}
+	if useKaratsubaSqrt(x) {
+		z, _ = z.sqrtRem(nil, x)
+		return z
+	}
 	if alias(z, x) {
 		z = nil
 	}
@@ -1210,3 +1689,152 @@ func (z nat) sqrt(x nat) nat {
 		z1, z2 = z2, z1
 	}
 }
+
+// root sets z = ⌊x**(1/n)⌋ for n > 0.
+func (z nat) root(x nat, n uint) nat {
+	if n == 2 {
+		return z.sqrt(x)
+	}
+	switch {
+	case n == 1 || x.cmp(natOne) <= 0:
+		return z.set(x)
+	case n >= uint(x.bitLen()):
+		return z.setWord(1) // 1 < x < 2**n
+	}
+	if alias(z, x) {
+		z = nil
+	}
+
+	// Start with a value known to be too large and repeat
+	// "y = ⌊((n-1)*y + ⌊x/y**(n-1)⌋)/n⌋" until it stops getting smaller,
+	// as for sqrt.
+	y := z.rootBound(x, n)
+	e := nat(nil).setWord(Word(n - 1))
+	var t, q, r, y1 nat
+	for {
+		t = t.expNN(y, e, nil)
+		q, r = q.div(r, x, t)
+		y1 = y1.mulAddWW(y, Word(n-1), 0)
+		y1 = y1.add(y1, q)
+		y1, _ = y1.divW(y1, Word(n))
+		if y1.cmp(y) >= 0 {
+			return z.set(y)
+		}
+		y, y1 = y1, y
+	}
+}
+
+// rootBound returns z > x**(1/n), for x > 1 and n > 1. It uses an estimate
+// of the root from the bit length and the leading 64 bits of x, computed in
+// floating-point arithmetic and rounded up by a relative margin of about
+// 2**-16, if the n-th power of the estimate exceeds x. The error of the
+// estimate is much smaller than the margin even for x of billions of bits,
+// so that the iteration in root converges quadratically from the start.
+// Otherwise, z = 2**⌈b/n⌉ for x of b bits, which is larger than the root
+// because x < 2**b.
+func (z nat) rootBound(x nat, n uint) nat {
+	b := uint(x.bitLen())
+	var s uint
+	if b > 64 {
+		s = b - 64
+	}
+	l := (float64(s)+math.Log2(float64(low64(nat(nil).shr(x, s)))))/float64(n) + 1.0/(1<<16)
+	if l < 53 {
+		z = z.setUint64(uint64(math.Exp2(l)) + 1)
+	} else {
+		k := math.Floor(l) - 52
+		z = z.shl(z.setUint64(uint64(math.Exp2(l-k))), uint(k))
+	}
+	if nat(nil).expNN(z, nat(nil).setWord(Word(n)), nil).cmp(x) > 0 {
+		return z
+	}
+	return z.shl(natOne, (b+n-1)/n)
+}
+
+// Operands of at least karatsubaSqrtThreshold words use Zimmermann's
+// recursive square root instead of Newton's iteration; the recursion
+// needs at least three words.
+var karatsubaSqrtThreshold = 4 // measured with BenchmarkSqrtRem
+
+func useKaratsubaSqrt(x nat) bool {
+	return len(x) >= karatsubaSqrtThreshold && len(x) >= 3
+}
+
+// sqrtRem sets z = ⌊√x⌋ and z2 = x - z², and returns them.
+func (z nat) sqrtRem(z2, x nat) (s, r nat) {
+	if useKaratsubaSqrt(x) {
+		s, r = karatsubaSqrt(x)
+	} else {
+		s = nat(nil).sqrt(x)
+		r = nat(nil).sub(x, nat(nil).mul(s, s))
+	}
+	if alias(z, x) {
+		z = nil
+	}
+	if alias(z2, x) {
+		z2 = nil
+	}
+	return z.set(s), z2.set(r)
+}
+
+// karatsubaSqrt returns s = ⌊√x⌋ and r = x - s², with Zimmermann's
+// recursive square root: with x = a3*β**3 + a2*β**2 + a1*β + a0 for
+// β = 2**b and a3 >= β/4, the root and remainder s1, r1 of a3*β + a2
+// yield the high half s1*β of s, and the division of r1*β + a1 by 2*s1
+// the low half, with at most one correction. It costs about as much as
+// a multiplication of numbers of the length of x.
+// See Brent and Zimmermann, Modern Computer Arithmetic, Algorithm 1.12
+// (SqrtRem), and Paul Zimmermann, "Karatsuba Square Root", INRIA
+// Research Report 3805, 1999.
+func karatsubaSqrt(x nat) (s, r nat) {
+	if !useKaratsubaSqrt(x) {
+		s = nat(nil).sqrt(x)
+		return s, nat(nil).sub(x, nat(nil).mul(s, s))
+	}
+
+	// Shift x left by 0 or 2 bits, to a length of 4*b or 4*b-1 bits,
+	// so that a3 >= β/4.
+	n := uint(x.bitLen())
+	var t uint
+	if n%4 == 1 || n%4 == 2 {
+		t = 1
+		x = nat(nil).shl(x, 2)
+		n += 2
+	}
+	b := (n + 3) / 4
+
+	a0 := nat(nil).trunc(x, b)
+	a1 := nat(nil).shr(x, b)
+	a1 = a1.trunc(a1, b)
+	s1, r1 := karatsubaSqrt(nat(nil).shr(x, 2*b))
+
+	// q, u = (r1*β + a1) / (2*s1)
+	r1 = r1.shl(r1, b)
+	r1 = r1.add(r1, a1)
+	q, u := nat(nil).div(nil, r1, nat(nil).shl(s1, 1))
+
+	// s = s1*β + q, r = u*β + a0 - q²
+	s = s1.shl(s1, b)
+	s = s.add(s, q)
+	r = u.shl(u, b)
+	r = r.add(r, a0)
+	q2 := nat(nil).mul(q, q)
+	if r.cmp(q2) < 0 {
+		// s is one too large: r + 2*s - 1 - q² is the remainder of s - 1
+		r = r.add(r, nat(nil).shl(s, 1))
+		r = r.sub(r, natOne)
+		s = s.sub(s, natOne)
+	}
+	r = r.sub(r, q2)
+
+	if t != 0 {
+		// With s = 2*s0 + e for e = 0 or 1, x/4 = s0² + (r + e*(2*s - 1))/4.
+		if s[0]&1 != 0 {
+			r = r.add(r, nat(nil).shl(s, 1))
+			r = r.sub(r, natOne)
+		}
+		r = r.shr(r, 2)
+		s = s.shr(s, 1)
+	}
+	return s, r
+}
@@ -6,6 +6,7 @@ package big
 
 import (
 	"fmt"
+	"math/rand"
 	"runtime"
 	"strings"
 	"testing"
@@ -294,6 +295,61 @@ func runModWTests(t *testing.T, tests []modWTest) {
 	}
 }
 
+func TestDivExact(t *testing.T) {
+	for _, test := range []struct{ nq, ny int }{
+		{0, 1},
+		{1, 1},
+		{1, 5},
+		{5, 1},
+		{10, 10},
+		{3, 40},
+		{100, 7},
+	} {
+		for _, shift := range []uint{0, 1, _W, 3*_W + 5} {
+			q := rndNat(test.nq)
+			y := nat(nil).shl(rndNat(test.ny), shift)
+			if len(y) == 0 {
+				y = y.setWord(1)
+			}
+			x := nat(nil).mul(q, y)
+			if got := nat(nil).divExact(x, y); got.cmp(q) != 0 {
+				t.Errorf("divExact(%s, %s) = %s; want %s", x.utoa(16), y.utoa(16), got.utoa(16), q.utoa(16))
+			}
+			// aliased operands
+			if got := x.divExact(x, y); got.cmp(q) != 0 {
+				t.Errorf("aliased divExact(x, %s) = %s; want %s", y.utoa(16), got.utoa(16), q.utoa(16))
+			}
+		}
+	}
+
+	// all ones, for the largest carries
+	q := nat(nil).sub(nat(nil).shl(natOne, 20*_W), natOne)
+	y := nat(nil).sub(nat(nil).shl(natOne, 7*_W), natOne)
+	if got := nat(nil).divExact(nat(nil).mul(q, y), y); got.cmp(q) != 0 {
+		t.Errorf("divExact of all ones = %s; want %s", got.utoa(16), q.utoa(16))
+	}
+}
+
+func BenchmarkDivExact(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		q := rndNat(n)
+		y := rndNat(n)
+		x := nat(nil).mul(q, y)
+		var z nat
+		b.Run(fmt.Sprintf("%d/divExact", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				z = z.divExact(x, y)
+			}
+		})
+		b.Run(fmt.Sprintf("%d/div", n), func(b *testing.B) {
+			var r nat
+			for i := 0; i < b.N; i++ {
+				z, r = z.div(r, x, y)
+			}
+		})
+	}
+}
+
 func TestModW(t *testing.T) {
 	if _W >= 32 {
 		runModWTests(t, modWTests32)
@@ -433,6 +489,75 @@ func TestMontgomery(t *testing.T) {
 	}
 }
 
+func TestMontgomerySqr(t *testing.T) {
+	// check the squaring code for short operands too
+	defer func(th int) { montgomerySqrThreshold = th }(montgomerySqrThreshold)
+	montgomerySqrThreshold = 1
+
+	r := rand.New(rand.NewSource(1))
+	for n := 1; n <= 40; n++ {
+		// m with all bits set maximizes the carries; 2**(n*_W) - 1 is odd
+		ones := nat(nil).make(n)
+		for i := range ones {
+			ones[i] = _M
+		}
+		for j := 0; j < 20; j++ {
+			m := ones
+			if j > 0 {
+				m = nat(nil).random(r, ones, n*_W)
+				m = append(m, make(nat, n-len(m))...)
+				m[0] |= 1
+				m[n-1] |= 1 << (_W - 1) >> uint(r.Intn(_W))
+			}
+			k0 := montgomeryK0(m[0])
+			// x need not be reduced mod m, only less than 2**(n*_W)
+			x := ones
+			if j > 1 {
+				x = nat(nil).random(r, ones, n*_W)
+				x = append(x, make(nat, n-len(x))...)
+			}
+			want := nat(nil).montgomery(x, x, m, k0, n)
+			z := nat(nil).montgomerySqr(x, m, k0, n)
+			_, want = nat(nil).div(nil, want.norm(), m)
+			_, got := nat(nil).div(nil, z.norm(), m)
+			if len(z) != n || got.cmp(want) != 0 {
+				t.Errorf("n=%d #%d: montgomerySqr(%s, %s) = %s, want %s", n, j, x.utoa(16), m.utoa(16), z.utoa(16), want.utoa(16))
+			}
+		}
+	}
+}
+
+func BenchmarkMontgomerySqr(b *testing.B) {
+	defer func(th int) { montgomerySqrThreshold = th }(montgomerySqrThreshold)
+	montgomerySqrThreshold = 1
+
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []int{4, 8, 12, 16, 32, 64} {
+		ones := nat(nil).make(n)
+		for i := range ones {
+			ones[i] = _M
+		}
+		m := nat(nil).random(r, ones, n*_W)
+		m = append(m, make(nat, n-len(m))...)
+		m[0] |= 1
+		m[n-1] |= 1 << (_W - 1)
+		x := nat(nil).random(r, m, m.bitLen())
+		x = append(x, make(nat, n-len(x))...)
+		k0 := montgomeryK0(m[0])
+		var z nat
+		b.Run(fmt.Sprintf("%d/montgomery", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				z = z.montgomery(x, x, m, k0, n)
+			}
+		})
+		b.Run(fmt.Sprintf("%d/montgomerySqr", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				z = z.montgomerySqr(x, m, k0, n)
+			}
+		})
+	}
+}
+
 var expNNTests = []struct {
 	x, y, m string
 	out     string
@@ -480,6 +605,48 @@ func TestExpNN(t *testing.T) {
 	}
 }
 
+func TestExpNNWindowed(t *testing.T) {
+	r := rand.New(rand.NewSource(9))
+	for _, bits := range []uint{2, 23, 24, 80, 240, 672, 1500} {
+		for i := 0; i < 5; i++ {
+			x := rndNat(5)
+			y := nat(nil).random(r, nat(nil).shl(natOne, bits), int(bits)+1)
+			y = y.setBit(y, bits-1, 1)
+			m := rndNat(3).norm()
+			m = m.setBit(m, 0, 0)
+			if len(m) == 0 {
+				continue
+			}
+			// reference: binary exponentiation without windows
+			want := nat(nil).setWord(1)
+			for j := int(bits) - 1; j >= 0; j-- {
+				_, want = nat(nil).div(nil, nat(nil).mul(want, want), m)
+				if y.bit(uint(j)) != 0 {
+					_, want = nat(nil).div(nil, nat(nil).mul(want, x), m)
+				}
+			}
+			if got := nat(nil).expNNWindowed(x, y, m, nil); got.cmp(want.norm()) != 0 {
+				t.Errorf("expNNWindowed(%s, %s, %s) = %s; want %s", x.utoa(16), y.utoa(16), m.utoa(16), got.utoa(16), want.utoa(16))
+			}
+		}
+	}
+}
+
+func BenchmarkExpNNWindowed(b *testing.B) {
+	r := rand.New(rand.NewSource(10))
+	m := rndNat(64).norm()
+	m[0] &^= 1
+	x := rndNat(64).norm()
+	for _, bits := range []uint{64, 256, 1024, 4096} {
+		y := nat(nil).random(r, nat(nil).shl(natOne, bits), int(bits)+1)
+		b.Run(fmt.Sprint(bits), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				nat(nil).expNNWindowed(x, y, m, nil)
+			}
+		})
+	}
+}
+
 func BenchmarkExp3Power(b *testing.B) {
 	const x = 3
 	for _, y := range []Word{
@@ -15,13 +15,23 @@ import (
 	"sync"
 )
 
-const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+// digits are the digit characters for bases up to maxBaseSmall, and
+// digits62 those for the larger bases, in the order used by GMP.
+const (
+	digits   = "0123456789abcdefghijklmnopqrstuvwxyz"
+	digits62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+)
 
-// Note: MaxBase = len(digits), but it must remain a rune constant
+// Note: MaxBase = len(digits62), but it must remain a rune constant
 //       for API compatibility.
 
 // MaxBase is the largest number base accepted for string conversions.
-const MaxBase = 'z' - 'a' + 10 + 1
+const MaxBase = 10 + ('Z' - 'A' + 1) + ('z' - 'a' + 1)
+
+// Up to base maxBaseSmall, the upper and lower case letters stand for
+// the same digit values; above it, the lower case letters have values
+// maxBaseSmall and up.
+const maxBaseSmall = 10 + ('z' - 'a' + 1)
 
 // maxPow returns (b**n, n) such that b**n is the largest power b**n <= _M.
 // For instance maxPow(10) == (1e19, 19) for 19 decimal digits in a 64bit Word.
@@ -54,37 +64,51 @@ func pow(x Word, n int) (p Word) {
 	return
 }
 
+var (
+	errNoDigits = errors.New("syntax error scanning number")
+	errInvalSep = errors.New("'_' must separate successive digits")
+)
+
 // scan scans the number corresponding to the longest possible prefix
 // from r representing an unsigned number in a given conversion base.
 // It returns the corresponding natural number res, the actual base b,
 // a digit count, and a read or syntax error err, if any.
 //
 //	number   = [ prefix ] mantissa .
-//	prefix   = "0" [ "x" | "X" | "b" | "B" ] .
-//      mantissa = digits | digits "." [ digits ] | "." digits .
-//	digits   = digit { digit } .
-//	digit    = "0" ... "9" | "a" ... "z" | "A" ... "Z" .
+//	prefix   = "0" [ "b" | "B" | "o" | "O" | "x" | "X" ] .
+//	mantissa = digits | digits "." [ digits ] | "." digits .
+//	digits   = digit { [ "_" ] digit } .
+//	digit    = "0" ... "9" | "A" ... "Z" | "a" ... "z" .
 //
 // Unless fracOk is set, the base argument must be 0 or a value between
-// 2 and MaxBase. If fracOk is set, the base argument must be one of
+// 2 and MaxBase. For bases <= 36, lower and upper case letters are
+// considered the same: the letters 'a' to 'z' and 'A' to 'Z' represent
+// digit values 10 to 35. For bases > 36, the upper case letters 'A' to
+// 'Z' represent the digit values 10 to 35, and the lower case letters
+// 'a' to 'z' the digit values 36 to 61. If fracOk is set, the base argument must be one of
 // 0, 2, 10, or 16. Providing an invalid base argument leads to a run-
 // time panic.
 //
 // For base 0, the number prefix determines the actual base: A prefix of
-// ``0x'' or ``0X'' selects base 16; if fracOk is not set, the ``0'' prefix
-// selects base 8, and a ``0b'' or ``0B'' prefix selects base 2. Otherwise
-// the selected base is 10 and no prefix is accepted.
+// “0b” or “0B” selects base 2, “0o” or “0O” selects base 8, and
+// “0x” or “0X” selects base 16. If fracOk is not set, the “0”
+// prefix alone selects base 8 as well. Otherwise the selected base is 10
+// and no prefix is accepted. For base 0 only, an underscore character
+// “_” may appear between a base prefix and an adjacent digit, and
+// between successive digits; such underscores do not change the value
+// or the digit count. Misplaced underscores are reported as errInvalSep,
+// after the number has been scanned.
 //
-// If fracOk is set, an octal prefix is ignored (a leading ``0'' simply
-// stands for a zero digit), and a period followed by a fractional part
-// is permitted. The result value is computed as if there were no period
-// present; and the count value is used to determine the fractional part.
+// If fracOk is set, an octal prefix “0” is ignored (a leading “0”
+// simply stands for a zero digit), and a period followed by a fractional
+// part is permitted. The result value is computed as if there were no
+// period present; and the count value is used to determine the
+// fractional part.
 //
 // A result digit count > 0 corresponds to the number of (non-prefix) digits
 // parsed. A digit count <= 0 indicates the presence of a period (if fracOk
 // is set, only), and -count is the number of fractional digits found.
 // In this case, the actual value of the scanned number is res * b**count.
-//
 func (z nat) scan(r io.ByteScanner, base int, fracOk bool) (res nat, b, count int, err error) {
 	// reject illegal bases
 	baseOk := base == 0 ||
@@ -94,6 +118,11 @@ func (z nat) scan(r io.ByteScanner, base int, fracOk bool) (res nat, b, count in
 		panic(fmt.Sprintf("illegal number base %d", base))
 	}
 
+	// prev encodes the previously seen char: '_' for an underscore, '.'
+	// for the start or the period, and '0' for a digit or a base prefix
+	prev := '.'
+	invalSep := false
+
 	// one char look-ahead
 	ch, err := r.ReadByte()
 	if err != nil {
@@ -101,33 +130,35 @@ func (z nat) scan(r io.ByteScanner, base int, fracOk bool) (res nat, b, count in
 	}
 
 	// determine actual base
-	b = base
+	b, prefix := base, 0
 	if base == 0 {
 		// actual base is 10 unless there's a base prefix
 		b = 10
 		if ch == '0' {
+			prev = '0'
 			count = 1
 			switch ch, err = r.ReadByte(); err {
 			case nil:
-				// possibly one of 0x, 0X, 0b, 0B
-				if !fracOk {
-					b = 8
-				}
 				switch ch {
-				case 'x', 'X':
-					b = 16
 				case 'b', 'B':
-					b = 2
+					b, prefix = 2, 'b'
+				case 'o', 'O':
+					b, prefix = 8, 'o'
+				case 'x', 'X':
+					b, prefix = 16, 'x'
+				default:
+					if !fracOk {
+						b, prefix = 8, '0'
+					}
 				}
-				switch b {
-				case 16, 2:
+				if prefix != 0 {
 					count = 0 // prefix is not counted
-					if ch, err = r.ReadByte(); err != nil {
-						// io.EOF is also an error in this case
-						return
+					if prefix != '0' {
+						if ch, err = r.ReadByte(); err != nil {
+							// io.EOF is also an error in this case
+							return
+						}
 					}
-				case 8:
-					count = 0 // prefix is not counted
 				}
 			case io.EOF:
 				// input is "0"
@@ -155,43 +186,45 @@ func (z nat) scan(r io.ByteScanner, base int, fracOk bool) (res nat, b, count in
 		if fracOk && ch == '.' {
 			fracOk = false
 			dp = count
-			// advance
-			if ch, err = r.ReadByte(); err != nil {
-				if err == io.EOF {
-					err = nil
-					break
+			invalSep = invalSep || prev == '_'
+			prev = '.'
+		} else if ch == '_' && base == 0 {
+			invalSep = invalSep || prev != '0'
+			prev = '_'
+		} else {
+			// convert rune into digit value d1
+			var d1 Word
+			switch {
+			case '0' <= ch && ch <= '9':
+				d1 = Word(ch - '0')
+			case 'A' <= ch && ch <= 'Z':
+				d1 = Word(ch - 'A' + 10)
+			case 'a' <= ch && ch <= 'z':
+				if b <= maxBaseSmall {
+					d1 = Word(ch - 'a' + 10)
+				} else {
+					d1 = Word(ch - 'a' + maxBaseSmall)
 				}
-				return
+			default:
+				d1 = MaxBase + 1
 			}
-		}
-
-		// convert rune into digit value d1
-		var d1 Word
-		switch {
-		case '0' <= ch && ch <= '9':
-			d1 = Word(ch - '0')
-		case 'a' <= ch && ch <= 'z':
-			d1 = Word(ch - 'a' + 10)
-		case 'A' <= ch && ch <= 'Z':
-			d1 = Word(ch - 'A' + 10)
-		default:
-			d1 = MaxBase + 1
-		}
-		if d1 >= b1 {
-			r.UnreadByte() // ch does not belong to number anymore
-			break
-		}
-		count++
+			if d1 >= b1 {
+				r.UnreadByte() // ch does not belong to number anymore
+				break
+			}
+			prev = '0'
+			count++
 
-		// collect d1 in di
-		di = di*b1 + d1
-		i++
+			// collect d1 in di
+			di = di*b1 + d1
+			i++
 
-		// if di is "full", add it to the result
-		if i == n {
-			z = z.mulAddWW(z, bn, di)
-			di = 0
-			i = 0
+			// if di is "full", add it to the result
+			if i == n {
+				z = z.mulAddWW(z, bn, di)
+				di = 0
+				i = 0
+			}
 		}
 
 		// advance
@@ -204,18 +237,20 @@ func (z nat) scan(r io.ByteScanner, base int, fracOk bool) (res nat, b, count in
 		}
 	}
 
+	if invalSep || prev == '_' {
+		err = errInvalSep
+	}
+
 	if count == 0 {
 		// no digits found
-		switch {
-		case base == 0 && b == 8:
-			// there was only the octal prefix 0 (possibly followed by digits > 7);
-			// count as one digit and return base 10, not 8
-			count = 1
-			b = 10
-		case base != 0 || b != 8:
-			// there was neither a mantissa digit nor the octal prefix 0
-			err = errors.New("syntax error scanning number")
+		if prefix == '0' {
+			// there was only the octal prefix 0 (possibly followed by
+			// separators and digits > 7); count as one digit and return
+			// base 10, not 8
+			return z[:0], 10, 1, err
 		}
+		// there was neither a mantissa digit nor the octal prefix 0
+		err = errNoDigits
 		return
 	}
 	// count > 0
@@ -310,10 +345,12 @@ func (x nat) itoa(neg bool, base int) []byte {
 		table := divisors(len(x), b, ndigits, bb)
 
 		// preserve x, create local copy for use by convertWords
-		q := nat(nil).set(x)
+		qp := getNat(0)
+		*qp = (*qp).set(x)
 
 		// convert q to string s in base b
-		q.convertWords(s, b, ndigits, bb, table)
+		(*qp).convertWords(s, b, ndigits, bb, table)
+		putNat(qp)
 
 		// strip leading zeros
 		// (x != 0; thus s must contain at least one non-zero digit
@@ -347,14 +384,15 @@ func (x nat) itoa(neg bool, base int) []byte {
 // range 2..64 shows that values of 8 and 16 work well, with a 4x speedup at medium lengths and
 // ~30x for 20000 digits. Use nat_test.go's BenchmarkLeafSize tests to optimize leafSize for
 // specific hardware.
-//
 func (q nat) convertWords(s []byte, b Word, ndigits int, bb Word, table []divisor) {
 	// split larger blocks recursively
+	var qs [2]*nat // pooled quotients; q itself belongs to the caller
 	if table != nil {
+		var wg *sync.WaitGroup // allocated for the first goroutine only
 		// len(q) > leafSize > 0
-		var r nat
+		rp := getNat(0)
 		index := len(table) - 1
-		for len(q) > leafSize {
+		for k := 0; len(q) > leafSize; k ^= 1 {
 			// find divisor close to sqrt(q) if possible, but in any case < q
 			maxLength := q.bitLen()     // ~= log2 q, or at of least largest possible q of this bit length
 			minLength := maxLength >> 1 // ~= log2 sqrt(q)
@@ -369,13 +407,34 @@ func (q nat) convertWords(s []byte, b Word, ndigits int, bb Word, table []diviso
 			}
 
 			// split q into the two digit number (q'*bbb + r) to form independent subblocks
-			q, r = q.div(r, q, table[index].bbb)
+			if qs[k] == nil {
+				qs[k] = getNat(0)
+			}
+			*qs[k], *rp = (*qs[k]).div(*rp, q, table[index].bbb)
+			q = *qs[k]
 
 			// convert subblocks and collect results in s[:h] and s[h:]
 			h := len(s) - table[index].ndigits
-			r.convertWords(s[h:], b, ndigits, bb, table[0:index])
+			if len(*rp) >= parallelConvWords && acquireWorker() {
+				// the subblocks are independent; convert r concurrently
+				if wg == nil {
+					wg = new(sync.WaitGroup)
+					defer wg.Wait()
+				}
+				wg.Add(1)
+				go func(wg *sync.WaitGroup, rp *nat, s []byte, table []divisor) {
+					(*rp).convertWords(s, b, ndigits, bb, table)
+					putNat(rp)
+					releaseWorker()
+					wg.Done()
+				}(wg, rp, s[h:], table[0:index])
+				rp = getNat(0) // r is owned by the goroutine
+			} else {
+				(*rp).convertWords(s[h:], b, ndigits, bb, table[0:index])
+			}
 			s = s[:h] // == q.convertWords(s, b, ndigits, bb, table[0:index+1])
 		}
+		putNat(rp)
 	}
 
 	// having split any large blocks now process the remaining (small) block iteratively
@@ -397,12 +456,16 @@ func (q nat) convertWords(s []byte, b Word, ndigits int, bb Word, table []diviso
 			}
 		}
 	} else {
+		ds := digits
+		if b > maxBaseSmall {
+			ds = digits62
+		}
 		for len(q) > 0 {
 			// extract least significant, base bb "digit"
 			q, r = q.divW(q, bb)
 			for j := 0; j < ndigits && i > 0; j++ {
 				i--
-				s[i] = digits[r%b]
+				s[i] = ds[r%b]
 				r /= b
 			}
 		}
@@ -413,12 +476,23 @@ func (q nat) convertWords(s []byte, b Word, ndigits int, bb Word, table []diviso
 		i--
 		s[i] = '0'
 	}
+
+	for _, qp := range qs {
+		if qp != nil {
+			putNat(qp)
+		}
+	}
 }
 
+// Subblocks of at least parallelConvWords Words are converted by
+// separate goroutines, within the limit set by SetParallelism.
+var parallelConvWords = 1 << 12
+
 // Split blocks greater than leafSize Words (or set to 0 to disable recursive conversion)
 // Benchmark and configure leafSize using: go test -bench="Leaf"
-//   8 and 16 effective on 3.0 GHz Xeon "Clovertown" CPU (128 byte cache lines)
-//   8 and 16 effective on 2.66 GHz Core 2 Duo "Penryn" CPU
+//
+//	8 and 16 effective on 3.0 GHz Xeon "Clovertown" CPU (128 byte cache lines)
+//	8 and 16 effective on 2.66 GHz Core 2 Duo "Penryn" CPU
 var leafSize int = 8 // number of Word-size binary values treat as a monolithic block
 
 type divisor struct {
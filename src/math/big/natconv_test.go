@@ -9,7 +9,9 @@ import (
 	"fmt"
 	"io"
 	"math/bits"
+	"runtime"
 	"strings"
+	"sync/atomic"
 	"testing"
 )
 
@@ -36,12 +38,17 @@ func itoa(x nat, base int) []byte {
 	// don't destroy x
 	q := nat(nil).set(x)
 
+	ds := digits
+	if base > maxBaseSmall {
+		ds = digits62
+	}
+
 	// convert
 	for len(q) > 0 {
 		i--
 		var r Word
 		q, r = q.divW(q, Word(base))
-		s[i] = digits[r]
+		s[i] = ds[r]
 	}
 
 	return s[i:]
@@ -61,6 +68,10 @@ var strTests = []struct {
 	{nat{0xdeadbeef}, 16, "deadbeef"},
 	{nat{0x229be7}, 17, "1a2b3c"},
 	{nat{0x309663e6}, 32, "o9cov6"},
+	{nat{10}, 37, "A"},
+	{nat{36}, 37, "a"},
+	{nat{0x309663e6}, 62, "tAKxi"},
+	{nat{62*62 - 1}, 62, "zz"},
 }
 
 func TestString(t *testing.T) {
@@ -137,6 +148,11 @@ var natScanTests = []struct {
 	{"1234567890", 0, false, nat{1234567890}, 10, 10, true, 0},
 	{"xyz", 36, false, nat{(33*36+34)*36 + 35}, 36, 3, true, 0},
 	{"xyz?", 36, false, nat{(33*36+34)*36 + 35}, 36, 3, true, '?'},
+	{"XYZ", 36, false, nat{(33*36+34)*36 + 35}, 36, 3, true, 0},
+	{"XYZ", 62, false, nat{(33*62+34)*62 + 35}, 62, 3, true, 0},
+	{"xyz", 62, false, nat{(59*62+60)*62 + 61}, 62, 3, true, 0},
+	{"aA0", 62, false, nat{(36*62+10)*62 + 0}, 62, 3, true, 0},
+	{"Zz", 61, false, nat{35}, 61, 1, true, 'z'},
 	{"0x", 16, false, nil, 16, 1, true, 'x'},
 	{"0xdeadbeef", 0, false, nat{0xdeadbeef}, 16, 8, true, 0},
 	{"0XDEADBEEF", 0, false, nat{0xdeadbeef}, 16, 8, true, 0},
@@ -282,6 +298,40 @@ func BenchmarkStringPiParallel(b *testing.B) {
 	})
 }
 
+func TestParallelConversion(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	defer func(n int) { parallelConvWords = n }(parallelConvWords)
+	for _, n := range []int{100, 1000, 5000} {
+		x := rndNat(n)
+		for _, base := range []int{3, 10, 36, 62} {
+			parallelConvWords = 1 << 30
+			want := string(x.utoa(base))
+			parallelConvWords = 16
+			if got := string(x.utoa(base)); got != want {
+				t.Errorf("parallel conversion of %d words in base %d differs from sequential conversion", n, base)
+			}
+		}
+	}
+	if n := atomic.LoadInt32(&workers); n != 0 {
+		t.Errorf("%d workers still counted", n)
+	}
+}
+
+func BenchmarkStringHuge(b *testing.B) {
+	x := rndNat(1e5)
+	for _, parallel := range []bool{false, true} {
+		b.Run(fmt.Sprintf("parallel=%v", parallel), func(b *testing.B) {
+			defer func(n int) { parallelConvWords = n }(parallelConvWords)
+			if !parallel {
+				parallelConvWords = 1 << 30
+			}
+			for i := 0; i < b.N; i++ {
+				x.utoa(10)
+			}
+		})
+	}
+}
+
 func BenchmarkScan(b *testing.B) {
 	const x = 10
 	for _, base := range []int{2, 8, 10, 16} {
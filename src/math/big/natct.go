@@ -0,0 +1,1190 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements constant-time operations on nats, for use with
+// secret values such as cryptographic keys. Unlike most nat operations,
+// the running time and memory access pattern of these functions depend
+// only on the lengths of their operands, never on their values.
+
+package big
+
+import (
+	"io"
+	"math/big/ctword"
+	"unsafe"
+)
+
+// cbytes writes the value of z into buf using big-endian encoding,
+// zero-extended on the left so that all of buf is filled. Unlike bytes,
+// the number and sequence of memory accesses depend only on len(z) and
+// len(buf), not on the value of z, and no scan for leading zeros is made.
+// If the value of z does not fit in buf, cbytes panics.
+func (z nat) cbytes(buf []byte) {
+	var over Word // accumulates bytes of z that don't fit
+	i := len(buf)
+	for _, d := range z {
+		for j := 0; j < _S; j++ {
+			if i > 0 {
+				i--
+				buf[i] = byte(d)
+			} else {
+				over |= d & 0xff
+			}
+			d >>= 8
+		}
+	}
+	for i > 0 {
+		i--
+		buf[i] = 0
+	}
+	if over != 0 {
+		panic("math/big: buffer too small to fit value")
+	}
+}
+
+// csetBytes interprets buf as the bytes of a big-endian unsigned
+// integer, sets z to that value, and returns z. Unlike setBytes, the
+// result always has exactly (len(buf)+_S-1)/_S words and is not
+// normalized, so the length of z does not reveal the value.
+func (z nat) csetBytes(buf []byte) nat {
+	z = z.make((len(buf) + _S - 1) / _S)
+	z.clear()
+	for i, b := range buf {
+		k := len(buf) - 1 - i // byte index from the least significant end
+		z[k/_S] |= Word(b) << (uint(k%_S) * 8)
+	}
+	return z
+}
+
+// ctwos writes the value of z, negated if neg == 1, into buf as a
+// big-endian two's complement integer of len(buf) bytes. neg must be 0
+// or 1. As for cbytes, the memory accesses depend only on len(z) and
+// len(buf). If the value does not fit in buf, ctwos panics.
+func (z nat) ctwos(neg Word, buf []byte) {
+	// one extra word guarantees that the sign is not lost
+	t := nat(nil).cpad(z, max(len(z), (len(buf)+_S-1)/_S)+1)
+	t.cneg(neg)
+	sign := -(t[len(t)-1] >> (_W - 1)) // all ones if the value is negative
+
+	var over Word // accumulates bytes that differ from the sign extension
+	i := len(buf)
+	for _, d := range t {
+		for j := 0; j < _S; j++ {
+			if i > 0 {
+				i--
+				buf[i] = byte(d)
+			} else {
+				over |= (d ^ sign) & 0xff
+			}
+			d >>= 8
+		}
+	}
+	// the most significant bit of buf must match the sign
+	if len(buf) > 0 {
+		over |= (Word(buf[0]>>7) ^ sign) & 1
+	} else {
+		over |= sign
+	}
+	t.wipe()
+	if over != 0 {
+		panic("math/big: buffer too small to fit value")
+	}
+}
+
+// csetTwos interprets buf as a big-endian two's complement integer, sets
+// z to its absolute value, and returns z and 1 if the value is negative
+// or 0 otherwise. As for csetBytes, the result always has exactly
+// (len(buf)+_S-1)/_S words, and the running time and memory accesses
+// depend only on len(buf).
+func (z nat) csetTwos(buf []byte) (nat, Word) {
+	z = z.csetBytes(buf)
+	if len(buf) == 0 {
+		return z, 0
+	}
+	neg := Word(buf[0] >> 7)
+	// sign-extend the most significant word, then negate
+	if k := uint(len(buf)%_S) * 8; k != 0 {
+		z[len(z)-1] |= -neg << k
+	}
+	z.cneg(neg)
+	return z, neg
+}
+
+// sel sets z to x if v == 1 and to y if v == 0, and returns z.
+// x and y must have the same length, and v must be 0 or 1.
+// The selection is made without branching on v, so neither v nor
+// the values of x and y can be observed through timing or memory
+// access patterns. z may alias x or y.
+func (z nat) sel(x, y nat, v Word) nat {
+	z = z.make(len(x))
+	ctword.SelectSlice(uints(z), uints(x), uints(y), uint(v))
+	return z
+}
+
+// uints returns the words of x as a []uint, for the functions of
+// package ctword. A Word is a uint, so the two share a representation.
+func uints(x nat) []uint {
+	return *(*[]uint)(unsafe.Pointer(&x))
+}
+
+// cmod sets z to x mod m and returns z, in time that depends only on
+// len(x) and len(m). The result has exactly len(m) words and is not
+// normalized. m must be > 0; z must not alias x or m.
+//
+// cmod uses simple bitwise long division: each bit of x is shifted
+// into a partial remainder, from which m is subtracted if it fits.
+// This is quadratic in the operand lengths, but unlike divLarge it
+// makes no data-dependent quotient estimates or corrections.
+func (z nat) cmod(x, m nat) nat {
+	if len(m) == 0 {
+		panic("division by zero")
+	}
+	if alias(z, x) || alias(z, m) {
+		z = nil // z is an alias for x or m - cannot reuse
+	}
+	z = z.make(len(m))
+	clongDiv(nil, z, x, m, 0)
+	return z
+}
+
+// cmodPublic is like cmod for a modulus m whose value is public, but
+// runs in time that depends on len(x) and the bit length of m: since the
+// partial remainder stays below m until m.bitLen() bits have been shifted
+// in, the top m.bitLen()-1 bits of x are copied directly. This makes
+// reducing values of about the size of m cheap.
+func (z nat) cmodPublic(x, m nat) nat {
+	if len(m) == 0 {
+		panic("division by zero")
+	}
+	if alias(z, x) || alias(z, m) {
+		z = nil // z is an alias for x or m - cannot reuse
+	}
+	z = z.make(len(m))
+	clongDiv(nil, z, x, m, m.norm().bitLen()-1)
+	return z
+}
+
+// cdiv sets z to the quotient x/m and r to the remainder x mod m and
+// returns them, like cmod. The quotient has exactly len(x) words and
+// the remainder len(m) words; neither is normalized. m must be > 0;
+// z and r must not alias x, m, or each other.
+func (z nat) cdiv(r, x, m nat) (q, rr nat) {
+	if len(m) == 0 {
+		panic("division by zero")
+	}
+	if alias(z, x) || alias(z, m) {
+		z = nil
+	}
+	if alias(r, x) || alias(r, m) {
+		r = nil
+	}
+	z = z.make(len(x))
+	z.clear()
+	r = r.make(len(m))
+	clongDiv(z, r, x, m, 0)
+	return z, r
+}
+
+// clongDiv sets r to x mod m and, if q is not nil, q to x/m by the
+// bitwise long division of cmod. r must have len(m) words, and q, if not
+// nil, len(x) words that are zero. The top bits of x, with top <
+// m.bitLen(), are copied to r directly, so that the number of division
+// steps is len(x)*_W - top; for a secret m, top must be 0.
+func clongDiv(q, r, x, m nat, top int) {
+	n := len(m)
+	r.clear()
+
+	i := len(x)*_W - min(len(x)*_W, top) // lowest copied bit
+	if k := uint(i / _W); int(k) < len(x) {
+		tp := getSecretNat(len(x) - int(k))
+		shrVU(*tp, x[k:], uint(i)%_W)
+		copy(r, *tp)
+		putSecretNat(tp)
+	}
+
+	tp := getSecretNat(n)
+	t := *tp
+	for i--; i >= 0; i-- {
+		// r = 2r + bit i of x
+		c := shlVU(r, r, 1)
+		r[0] |= x[i/_W] >> (uint(i) % _W) & 1
+		// subtract m if the result is either too large
+		// to fit in r (c == 1) or the subtraction doesn't borrow
+		b := subVV(t, r, m)
+		r.sel(t, r, c|(b^1))
+		if q != nil {
+			q[i/_W] |= (c | (b ^ 1)) << (uint(i) % _W)
+		}
+	}
+	putSecretNat(tp)
+}
+
+// csqrt sets z to ⌊√x⌋ and returns z, in time that depends only on
+// len(x). The result has exactly (len(x)+1)/2 words and is not normalized.
+//
+// csqrt determines the bits of the root from the top down: in each of
+// len(x)*_W/2 steps, the remainder x - z**2 is compared with the increase
+// of z**2 that setting the next bit of z would cause, and the bit is set
+// with masked selection if the remainder is large enough.
+func (z nat) csqrt(x nat) nat {
+	n := len(x)
+	tp := getSecretNat(3 * n)
+	t := *tp
+	// rem = x - root**2 and res = root * 2**(p+1), where p is the
+	// position of the bit that is determined next.
+	rem, res, d := t[:n], t[n:2*n], t[2*n:]
+	copy(rem, x)
+	res.clear()
+	for p := n*_W - 2; p >= 0; p -= 2 {
+		// d = rem - (res + 2**p); the bits of res are above p
+		res[p/_W] |= 1 << (uint(p) % _W)
+		ge := subVV(d, rem, res) ^ 1
+		res[p/_W] &^= 1 << (uint(p) % _W)
+		rem.sel(d, rem, ge)
+		shrVU(res, res, 1)
+		res[p/_W] |= ge << (uint(p) % _W)
+	}
+	z = z.make((n + 1) / 2)
+	copy(z, res)
+	putSecretNat(tp)
+	return z
+}
+
+// crandom sets z to a random number in [0, limit), using bytes read
+// from rand, and returns z. The result has exactly len(limit) words and
+// is not normalized.
+//
+// Unlike random, which uses rejection sampling, crandom always reads the
+// same number of random bytes for a given bit length of limit: it reads
+// bitLen(limit)+64 random bits and reduces them modulo limit in constant
+// time. The distribution of the result differs from uniform by a
+// statistical distance of at most 2**-64.
+func (z nat) crandom(rand io.Reader, limit nat) (nat, error) {
+	buf := make([]byte, (limit.bitLen()+64+7)/8)
+	if _, err := io.ReadFull(rand, buf); err != nil {
+		return z, err
+	}
+	x := nat(nil).csetBytes(buf)
+	for i := range buf {
+		buf[i] = 0
+	}
+	z = z.cmod(x, limit)
+	x.clear()
+	return z, nil
+}
+
+// cswap swaps the values of x and y if v == 1 and leaves them
+// unchanged if v == 0. x and y must have the same length, and v
+// must be 0 or 1. Like sel, cswap does not branch on v.
+func cswap(x, y nat, v Word) {
+	ctword.Swap(uints(x), uints(y), uint(v))
+}
+
+// cpad sets z to x zero-extended to n words and returns z.
+// len(x) must be <= n; z must be nil or share the start of x.
+func (z nat) cpad(x nat, n int) nat {
+	if len(x) > n {
+		panic("math/big: value too long to pad")
+	}
+	z = z.make(n)
+	copy(z, x)
+	z[len(x):].clear()
+	return z
+}
+
+// boolWord returns 1 if b is true and 0 otherwise.
+func boolWord(b bool) Word {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// ctIsZero returns 1 if x == 0 and 0 otherwise, without branching.
+func ctIsZero(x Word) Word {
+	return Word(ctword.IsZero(uint(x)))
+}
+
+// cdivWW returns q = (u1<<_W + u0 - r)/d and r = (u1<<_W + u0) mod d,
+// for u1 < d, in constant time. It uses bitwise long division rather
+// than a hardware divide instruction, whose latency depends on the
+// operand values on many processors. d must be > 0.
+func cdivWW(u1, u0, d Word) (q, r Word) {
+	r = u1
+	for i := _W - 1; i >= 0; i-- {
+		// r = 2r + bit i of u0; c is the bit shifted out
+		c := r >> (_W - 1)
+		r = r<<1 | u0>>uint(i)&1
+		// subtract d if 2r+bit overflowed or r >= d
+		t := r - d
+		b := (d&^r | (d|^r)&t) >> (_W - 1) // borrow of r - d
+		qi := c | (b ^ 1)
+		m := -qi
+		r = t&m | r&^m
+		q = q<<1 | qi
+	}
+	return
+}
+
+// cdivW sets z to x / d and returns z and the remainder x mod d, in
+// time that depends only on len(x). The result has exactly len(x)
+// words and is not normalized. d must be > 0.
+func (z nat) cdivW(x nat, d Word) (q nat, r Word) {
+	z = z.make(len(x))
+	for i := len(x) - 1; i >= 0; i-- {
+		z[i], r = cdivWW(r, x[i], d)
+	}
+	return z, r
+}
+
+// cmodWW returns x mod d in constant time. d must be > 0.
+func cmodWW(x, d Word) Word {
+	_, r := cdivWW(0, x, d)
+	return r
+}
+
+// cmodW returns x mod d in time that depends only on len(x).
+// Unlike modW, it does not use hardware division. d must be > 0.
+func (x nat) cmodW(d Word) (r Word) {
+	for i := len(x) - 1; i >= 0; i-- {
+		_, r = cdivWW(r, x[i], d)
+	}
+	return
+}
+
+// cdivisibleW returns x mod d and a mask that is all ones if d divides x
+// and zero otherwise, in time that depends only on len(x). d must be > 0.
+func (x nat) cdivisibleW(d Word) (r, mask Word) {
+	r = x.cmodW(d)
+	return r, -ctIsZero(r)
+}
+
+// smallPrimesA and smallPrimesB list the prime factors of primesA and primesB.
+var (
+	smallPrimesA = [...]Word{3, 5, 7, 11, 13, 17, 19, 23, 37}
+	smallPrimesB = [...]Word{29, 31, 41, 43, 47, 53}
+)
+
+// csmallPrimeFactor returns 1 if x is divisible by any of the primes
+// < 54, and 0 otherwise. Like ProbablyPrime's trial division, it reduces
+// x modulo products of small primes once and then tests the word-sized
+// residues, but all steps run in constant time and every prime is tested,
+// so nothing is revealed about which prime, if any, divides x.
+func (x nat) csmallPrimeFactor() Word {
+	if len(x) == 0 {
+		return 1 // 0 is divisible by every prime
+	}
+	var rA, rB Word
+	switch _W {
+	case 32:
+		rA = x.cmodW(primesA)
+		rB = x.cmodW(primesB)
+	case 64:
+		r := x.cmodW((primesA * primesB) & _M)
+		rA = cmodWW(r, primesA)
+		rB = cmodWW(r, primesB)
+	default:
+		panic("math/big: invalid word size")
+	}
+	f := x[0]&1 ^ 1 // divisible by 2
+	for _, p := range smallPrimesA {
+		f |= ctIsZero(cmodWW(rA, p))
+	}
+	for _, p := range smallPrimesB {
+		f |= ctIsZero(cmodWW(rB, p))
+	}
+	return f
+}
+
+// The c-variants below (cadd, csub, cmul, cexpNN, ...) are constant-time
+// counterparts of the corresponding nat operations. They take operands of
+// arbitrary but public lengths, which may include leading zero words, and
+// an additional argument zcap giving the length in words of the result.
+// The result is computed at full length and then trimmed or zero-extended
+// to exactly zcap words by cnorm, which panics if the value does not fit.
+
+// cnorm returns z trimmed or zero-extended to exactly zcap words.
+// If the value of z does not fit in zcap words, cnorm panics.
+// If zcap <= 0, cnorm is the same as norm.
+func (z nat) cnorm(zcap int) nat {
+	if zcap <= 0 {
+		return z.norm()
+	}
+	var over Word
+	for i := zcap; i < len(z); i++ {
+		over |= z[i]
+	}
+	if over != 0 {
+		panic("math/big: constant-time result exceeds capacity")
+	}
+	if len(z) >= zcap {
+		return z[:zcap]
+	}
+	return z.cpad(z, zcap)
+}
+
+// cnonzero returns 1 if x != 0 and 0 otherwise,
+// in time that depends only on len(x).
+func (x nat) cnonzero() Word {
+	var acc Word
+	for _, w := range x {
+		acc |= w
+	}
+	return ctIsZero(acc) ^ 1
+}
+
+// ceq returns 1 if x == y and 0 otherwise, in time that depends
+// only on len(x). x and y must have the same length.
+func (x nat) ceq(y nat) Word {
+	return ceqVV(x, y[:len(x)])
+}
+
+// wipe overwrites all words of the underlying array of z, including
+// any excess capacity, with zeros, so that secret values held in z
+// do not remain in memory after z is no longer used.
+func (z nat) wipe() {
+	z = z[:cap(z)]
+	for i := range z {
+		z[i] = 0
+	}
+}
+
+// cneg sets z to -z mod 2**(len(z)*_W) if v == 1
+// and leaves it unchanged if v == 0.
+func (z nat) cneg(v Word) {
+	ctword.Neg(uints(z), uint(v))
+}
+
+// cadd sets z to x + y, with zcap result words, and returns z.
+func (z nat) cadd(x, y nat, zcap int) nat {
+	n := max(len(x), len(y))
+	x = nat(nil).cpad(x, n)
+	y = nat(nil).cpad(y, n)
+	z = z.make(n + 1)
+	z[n] = addVV(z[:n], x, y)
+	return z.cnorm(zcap)
+}
+
+// csub sets z to x - y mod 2**(n*_W), with n = max(len(x), len(y)),
+// and returns z and the borrow b, which is 1 if x < y and 0 otherwise.
+// The result has n words; it is not trimmed to a capacity because its
+// value is only meaningful at full width if b == 1.
+func (z nat) csub(x, y nat) (nat, Word) {
+	n := max(len(x), len(y))
+	x = nat(nil).cpad(x, n)
+	y = nat(nil).cpad(y, n)
+	z = z.make(n)
+	b := subVV(z, x, y)
+	return z, b
+}
+
+// cbasicMul multiplies x and y and leaves the result in z.
+// The (non-normalized) result is placed in z[0 : len(x) + len(y)].
+// Unlike basicMul, it does not skip zero words of y.
+func cbasicMul(z, x, y nat) {
+	z[0 : len(x)+len(y)].clear() // initialize z
+	for i, d := range y {
+		z[len(x)+i] = addMulVVW(z[i:i+len(x)], x, d)
+	}
+}
+
+// cmul sets z to x * y, with zcap result words, and returns z.
+func (z nat) cmul(x, y nat, zcap int) nat {
+	if alias(z, x) || alias(z, y) {
+		z = nil // z is an alias for x or y - cannot reuse
+	}
+	if len(x) == 0 || len(y) == 0 {
+		return z[:0].cnorm(zcap)
+	}
+	z = z.make(len(x) + len(y))
+	cbasicMul(z, x, y)
+	return z.cnorm(zcap)
+}
+
+// cbasicSqr squares x and leaves the result in z, like cbasicMul(z, x, x),
+// but computes each product x[i]*x[j] with i != j only once. The
+// (non-normalized) result is placed in z[0 : 2*len(x)]. len(x) must be > 0.
+func cbasicSqr(z, x nat) {
+	n := len(x)
+	t := nat(nil).make(2 * n) // the products x[i]*x[j] with j < i
+	t.clear()
+	z[1], z[0] = mulWW(x[0], x[0])
+	for i := 1; i < n; i++ {
+		d := x[i]
+		z[2*i+1], z[2*i] = mulWW(d, d)
+		t[2*i] = addMulVVW(t[i:2*i], x[0:i], d)
+	}
+	t[2*n-1] = shlVU(t[1:2*n-1], t[1:2*n-1], 1) // double them
+	addVV(z[0:2*n], z[0:2*n], t)
+}
+
+// Operands shorter than cbasicSqrThreshold words are squared with
+// cbasicMul, which is faster for them than cbasicSqr; operands of at
+// least ckaratsubaSqrThreshold words use Karatsuba squaring.
+var (
+	cbasicSqrThreshold     = 20
+	ckaratsubaSqrThreshold = 48
+)
+
+// ckaratsubaSqr squares x and leaves the result in z[0 : 2*len(x)], using
+// Karatsuba squaring for long operands.
+// Unlike karatsuba, it handles operands of any length, and it computes
+// the absolute difference of the halves of x without branching on its
+// sign, which squaring makes irrelevant. z must not alias x.
+func ckaratsubaSqr(z, x nat) {
+	n := len(x)
+	switch {
+	case n < cbasicSqrThreshold:
+		cbasicMul(z, x, x)
+		return
+	case n < ckaratsubaSqrThreshold || n < 2:
+		cbasicSqr(z, x)
+		return
+	}
+
+	// With x = x1*b + x0 and d = |x1 - x0|,
+	//
+	//   x*x = x1*x1*b*b + (x1*x1 + x0*x0 - d*d)*b + x0*x0
+	n2 := n >> 1
+	x1, x0 := x[n2:], x[:n2]
+	ckaratsubaSqr(z, x0)        // z[0:2*n2] = x0*x0
+	ckaratsubaSqr(z[2*n2:], x1) // z[2*n2:2*n] = x1*x1
+
+	// The temporaries hold secret values, so they come from the pool of
+	// getSecretNat, which wipes them when they are returned.
+	dp := getSecretNat(len(x1))
+	d := *dp
+	d[copy(d, x0):].clear()
+	d.cneg(subVV(d, x1, d)) // d = |x1 - x0|
+	ddp := getSecretNat(2 * len(d))
+	dd := *ddp
+	ckaratsubaSqr(dd, d)
+
+	// mid = x1*x1 + x0*x0 - d*d = 2*x1*x0, which fits in m words,
+	// zero-extended to the length of z[n2:2*n]
+	m := 2*len(x1) + 1
+	midp := getSecretNat(2*n - n2)
+	mid := *midp
+	mid[copy(mid, z[:2*n2]):].clear()
+	mid[m-1] += addVV(mid[:m-1], mid[:m-1], z[2*n2:2*n])
+	mid[m-1] -= subVV(mid[:m-1], mid[:m-1], dd)
+
+	// z += mid*b
+	addVV(z[n2:2*n], z[n2:2*n], mid)
+	putSecretNat(dp)
+	putSecretNat(ddp)
+	putSecretNat(midp)
+}
+
+// csqr sets z to x*x, with zcap result words, and returns z.
+// It is faster than cmul(x, x, zcap).
+func (z nat) csqr(x nat, zcap int) nat {
+	if alias(z, x) {
+		z = nil // z is an alias for x - cannot reuse
+	}
+	if len(x) == 0 {
+		return z[:0].cnorm(zcap)
+	}
+	z = z.make(2 * len(x))
+	ckaratsubaSqr(z, x)
+	return z.cnorm(zcap)
+}
+
+// cmontgomery is like montgomery, but performs the final conditional
+// subtraction of m without branching on the carry.
+func (z nat) cmontgomery(x, y, m nat, k Word, n int) nat {
+	if len(x) != n || len(y) != n || len(m) != n {
+		panic("math/big: mismatched montgomery number lengths")
+	}
+	z = z.make(n)
+	z.clear()
+	var c Word
+	for i := 0; i < n; i++ {
+		d := y[i]
+		c2 := addMulVVW(z, x, d)
+		t := z[0] * k
+		c3 := addMulVVW(z, m, t)
+		copy(z, z[1:])
+		cx := c + c2
+		cy := cx + c3
+		z[n-1] = cy
+		c = ctLess(cx, c2) | ctLess(cy, c3) // cx < c2 || cy < c3
+	}
+	tp := getSecretNat(n)
+	t := *tp
+	subVV(t, z, m)
+	z.sel(t, z, c)
+	putSecretNat(tp)
+	return z
+}
+
+// ctLess returns 1 if x < y and 0 otherwise, without branching.
+func ctLess(x, y Word) Word {
+	return Word(ctword.Less(uint(x), uint(y)))
+}
+
+// cexpNN sets z to x**y mod m, with zcap result words, and returns z.
+// m must be > 0; the running time depends only on len(x), len(y), and
+// len(m), but not on the value of m, so that m may be secret: odd and
+// even moduli, and moduli with leading zero words, take the same path.
+// The exponent y is processed at its full length, including leading
+// zero words.
+func (z nat) cexpNN(x, y, m nat, zcap int) nat {
+	if len(m) == 0 {
+		panic("math/big: constant-time exponentiation requires a modulus")
+	}
+	if alias(z, x) || alias(z, y) || alias(z, m) {
+		z = nil
+	}
+	// The powers are computed modulo the multiple m*2**s of m with the
+	// top bit set, which Barrett reduction needs, and reduced modulo m
+	// at the end.
+	var b cbarrett
+	b.init(m)
+	x = nat(nil).cmod(x, m)
+	t := b.exp(x, y)
+	z = z.cmod(t, m)
+	x.wipe()
+	t.wipe()
+	b.wipe()
+	return z.cnorm(zcap)
+}
+
+// cexpNNOdd is like cexpNN, but for an odd modulus m whose value is
+// public, for which it uses the faster Montgomery multiplication.
+func (z nat) cexpNNOdd(x, y, m nat, zcap int) nat {
+	if len(m) == 1 && m[0] == 1 {
+		// x**y mod 1 == 0
+		return z[:0].cnorm(zcap)
+	}
+	if alias(z, x) || alias(z, y) || alias(z, m) {
+		z = nil
+	}
+	x = nat(nil).cmodPublic(x, m)
+	z = z.cexpNNMontgomery(x, y, m, montgomeryK0(m[0]), cmontgomeryRR(m))
+	x.wipe()
+	return z.cnorm(zcap)
+}
+
+// A cbarrett holds a modulus m with the top bit set, the Barrett
+// constant mu = ⌊2**(2*n*_W)/m⌋ with n = len(m), and the temporaries of
+// the reductions modulo m (see HAC, algorithm 14.42).
+type cbarrett struct {
+	m, mu      nat
+	q, p, r, d nat
+}
+
+// init sets b to the modulus m*2**s with the top bit set, for m > 0, in
+// time that depends only on len(m). Values reduced modulo m*2**s are
+// congruent modulo m.
+func (b *cbarrett) init(m nat) {
+	n := len(m)
+	b.m = nat(nil).cpad(m, n)
+	t := nat(nil).make(n)
+	for i := 0; i < n*_W-1; i++ {
+		// shift by one bit while the top bit is 0
+		shlVU(t, b.m, 1)
+		b.m.sel(t, b.m, ctIsZero(b.m[n-1]>>(_W-1)))
+	}
+	t.wipe()
+	x := nat(nil).make(2*n + 1)
+	x.clear()
+	x[2*n] = 1
+	q, r := nat(nil).cdiv(nil, x, b.m)
+	b.mu = q[:n+1] // mu <= 2**(n*_W+1)
+	r.wipe()
+}
+
+// reduce sets z to x mod b.m and returns z, for x < b.m**2 with 2*n
+// words. The result has n words.
+func (b *cbarrett) reduce(z, x nat) nat {
+	n := len(b.m)
+	// The estimate q of x/m is at most 2 too small, so at most two
+	// subtractions of m, selected without branching, reduce r = x - q*m.
+	b.q = b.q.cmul(x[n-1:], b.mu, 2*n+2)
+	b.p = b.p.cmul(b.q[n+1:], b.m, 2*n+1)
+	b.r = b.r.make(n + 1)
+	subVV(b.r, x[:n+1], b.p[:n+1]) // mod 2**((n+1)*_W)
+	for i := 0; i < 2; i++ {
+		var c Word
+		b.d, c = b.d.csub(b.r, b.m)
+		b.r.sel(b.r, b.d, c)
+	}
+	z = z.make(n)
+	copy(z, b.r)
+	return z
+}
+
+// exp returns x**y mod b.m, for x < b.m with at most n words, using a
+// fixed, 4-bit window and masked table lookups as cexpNNMontgomery does.
+// The result has n words.
+func (b *cbarrett) exp(x, y nat) nat {
+	numWords := len(b.m)
+	x = nat(nil).cpad(x, numWords)
+
+	const n = 4
+	// powers[i] contains x^i
+	var powers [1 << n]nat
+	powers[0] = nat(nil).make(numWords)
+	powers[0].clear()
+	powers[0][0] = 1
+	powers[1] = x
+	t := nat(nil).make(2 * numWords)
+	for i := 2; i < 1<<n; i++ {
+		t = t.cmul(powers[i-1], x, 2*numWords)
+		powers[i] = b.reduce(powers[i], t)
+	}
+
+	z := nat(nil).make(numWords)
+	copy(z, powers[0])
+	p := nat(nil).make(numWords)
+	for i := len(y) - 1; i >= 0; i-- {
+		yi := y[i]
+		for j := 0; j < _W; j += n {
+			for k := 0; k < n; k++ {
+				t = t.csqr(z, 2*numWords)
+				z = b.reduce(z, t)
+			}
+			p.clookup(powers[:], yi>>(_W-n))
+			t = t.cmul(z, p, 2*numWords)
+			z = b.reduce(z, t)
+			yi <<= n
+		}
+	}
+	t.wipe()
+	p.wipe()
+	for _, e := range powers {
+		e.wipe()
+	}
+	return z
+}
+
+// wipe overwrites the modulus and the temporaries of b.
+func (b *cbarrett) wipe() {
+	for _, x := range []nat{b.m, b.mu, b.q, b.p, b.r, b.d} {
+		x.wipe()
+	}
+}
+
+// cexpNNMontgomery calculates x**y mod m using a fixed, 4-bit window and
+// Montgomery multiplication. m must be odd and x must be reduced modulo m;
+// k0 and RR are the Montgomery constants for m, as computed by montgomeryK0
+// and montgomeryRR. Unlike expNNMontgomery, the window values of y select
+// the table entries with masked loads, so that the memory access pattern
+// does not depend on y, and all windows of y are processed including
+// leading zero words.
+func (z nat) cexpNNMontgomery(x, y, m nat, k0 Word, RR nat) nat {
+	numWords := len(m)
+
+	x = nat(nil).cpad(x, numWords)
+	one := make(nat, numWords)
+	one[0] = 1
+
+	const n = 4
+	// powers[i] contains x^i in Montgomery form
+	var powers [1 << n]nat
+	powers[0] = powers[0].montgomery(one, RR, m, k0, numWords)
+	powers[1] = powers[1].cmontgomery(x, RR, m, k0, numWords)
+	for i := 2; i < 1<<n; i++ {
+		powers[i] = powers[i].cmontgomery(powers[i-1], powers[1], m, k0, numWords)
+	}
+
+	// initialize z = 1 (Montgomery 1)
+	z = z.make(numWords)
+	copy(z, powers[0])
+
+	zz := nat(nil).make(numWords)
+	p := nat(nil).make(numWords)
+	for i := len(y) - 1; i >= 0; i-- {
+		yi := y[i]
+		for j := 0; j < _W; j += n {
+			zz = zz.cmontgomery(z, z, m, k0, numWords)
+			z = z.cmontgomery(zz, zz, m, k0, numWords)
+			zz = zz.cmontgomery(z, z, m, k0, numWords)
+			z = z.cmontgomery(zz, zz, m, k0, numWords)
+			p.clookup(powers[:], yi>>(_W-n))
+			zz = zz.cmontgomery(z, p, m, k0, numWords)
+			z, zz = zz, z
+			yi <<= n
+		}
+	}
+	// convert to regular number
+	zz = zz.cmontgomery(z, one, m, k0, numWords)
+
+	// One last reduction, as in expNNMontgomery, but without branching.
+	t, b := nat(nil).csub(zz, m)
+	zz = zz.sel(zz, t, b)
+
+	x.wipe()
+	z.wipe()
+	p.wipe()
+	t.wipe()
+	for _, e := range powers {
+		e.wipe()
+	}
+	return zz
+}
+
+// cexpNN2Montgomery sets z to x1**y1 * x2**y2 mod m and returns z, for
+// odd m with Montgomery constants k0 and RR. x1 and x2 must be reduced
+// modulo m. The exponents are processed together, in 4-bit windows over
+// max(len(y1), len(y2)) words, and the powers of x1 and x2 for each
+// window are selected with masked table lookups, so the running time and
+// memory access pattern depend only on len(m) and the exponent lengths.
+// The result has len(m) words.
+func (z nat) cexpNN2Montgomery(x1, y1, x2, y2, m nat, k0 Word, RR nat) nat {
+	numWords := len(m)
+	ny := max(len(y1), len(y2))
+	y1 = nat(nil).cpad(y1, ny)
+	y2 = nat(nil).cpad(y2, ny)
+
+	one := make(nat, numWords)
+	one[0] = 1
+
+	const n = 4
+	// powers1[i] and powers2[i] contain x1^i and x2^i in Montgomery form
+	var powers1, powers2 [1 << n]nat
+	for _, t := range []struct {
+		powers *[1 << n]nat
+		x      nat
+	}{{&powers1, x1}, {&powers2, x2}} {
+		powers := t.powers
+		x := nat(nil).cpad(t.x, numWords)
+		powers[0] = powers[0].montgomery(one, RR, m, k0, numWords)
+		powers[1] = powers[1].cmontgomery(x, RR, m, k0, numWords)
+		for i := 2; i < 1<<n; i++ {
+			powers[i] = powers[i].cmontgomery(powers[i-1], powers[1], m, k0, numWords)
+		}
+		x.wipe()
+	}
+
+	// initialize z = 1 (Montgomery 1)
+	z = z.make(numWords)
+	copy(z, powers1[0])
+
+	zz := nat(nil).make(numWords)
+	p := nat(nil).make(numWords)
+	for i := ny - 1; i >= 0; i-- {
+		y1i, y2i := y1[i], y2[i]
+		for j := 0; j < _W; j += n {
+			zz = zz.cmontgomery(z, z, m, k0, numWords)
+			z = z.cmontgomery(zz, zz, m, k0, numWords)
+			zz = zz.cmontgomery(z, z, m, k0, numWords)
+			z = z.cmontgomery(zz, zz, m, k0, numWords)
+			p.clookup(powers1[:], y1i>>(_W-n))
+			zz = zz.cmontgomery(z, p, m, k0, numWords)
+			p.clookup(powers2[:], y2i>>(_W-n))
+			z = z.cmontgomery(zz, p, m, k0, numWords)
+			y1i <<= n
+			y2i <<= n
+		}
+	}
+	// convert to regular number
+	zz = zz.cmontgomery(z, one, m, k0, numWords)
+
+	t, b := nat(nil).csub(zz, m)
+	zz = zz.sel(zz, t, b)
+
+	z.wipe()
+	p.wipe()
+	t.wipe()
+	for i := range powers1 {
+		powers1[i].wipe()
+		powers2[i].wipe()
+	}
+	return zz
+}
+
+// cmulMontgomery sets z to x*y mod m and returns z. m must be odd, x and y
+// must be reduced modulo m and zero-extended to len(m) words, and k0 and RR
+// are the Montgomery constants for m. The result has len(m) words.
+func (z nat) cmulMontgomery(x, y, m nat, k0 Word, RR nat) nat {
+	n := len(m)
+	t := nat(nil).cmontgomery(x, y, m, k0, n) // x*y/R
+	z = z.cmontgomery(t, RR, m, k0, n)        // x*y, < 2*m
+	d, b := t.csub(z, m)
+	return z.sel(z, d, b)
+}
+
+// cmontMul sets z to x*y/R mod m, with R = 2**(len(m)*_W), and returns z.
+// Unlike cmontgomery, it reduces the result fully, so that it can be
+// compared and used with caddMod and csubMod. x and y must be reduced
+// modulo m and have len(m) words; z must not alias x or y.
+func (z nat) cmontMul(x, y, m nat, k0 Word) nat {
+	z = z.cmontgomery(x, y, m, k0, len(m)) // < 2*m
+	d, b := nat(nil).csub(z, m)
+	return z.sel(z, d, b)
+}
+
+// cinverse2k sets z to x**-1 mod 2**k and returns z, for odd x. The
+// result has exactly (k+_W-1)/_W words, and only that many words of x
+// are used. Starting from the inverse of x[0] modulo 2**_W, as computed
+// for montgomeryK0, each Newton step y = y*(2 - x*y) doubles the number
+// of correct bits; the number of steps depends only on k, and all of
+// them work on full-length values.
+func (z nat) cinverse2k(x nat, k uint) nat {
+	n := int((k + _W - 1) / _W)
+	if len(x) > n {
+		x = x[:n]
+	}
+	x = nat(nil).cpad(x, n)
+	z = z.make(n)
+	z.clear()
+	if n == 0 {
+		return z
+	}
+	z[0] = -montgomeryK0(x[0])
+	two := nat(nil).cpad(natTwo, n)
+	for p := 1; p < n; p <<= 1 {
+		t := nat(nil).cmul(x, z, 2*n)[:n]
+		t, _ = t.csub(two, t)
+		t = nat(nil).cmul(z, t, 2*n)
+		copy(z, t)
+	}
+	if r := k % _W; r != 0 {
+		z[n-1] &= 1<<r - 1
+	}
+	return z
+}
+
+// cmontgomeryRR is like montgomeryRR, but computes RR in constant time
+// with respect to the value of m, for secret moduli.
+func cmontgomeryRR(m nat) nat {
+	n := len(m)
+	x := nat(nil).make(2*n + 1)
+	x.clear()
+	x[2*n] = 1
+	return nat(nil).cmod(x, m)
+}
+
+// caddMod sets z to x+y mod m and returns z. x and y must be reduced
+// modulo m and zero-extended to len(m) words. The result has len(m) words.
+func (z nat) caddMod(x, y, m nat) nat {
+	n := len(m)
+	s := nat(nil).make(n + 1)
+	s[n] = addVV(s[:n], x, y) // x+y < 2*m
+	return z.creduceSigned(s, m)
+}
+
+// csubMod sets z to x-y mod m and returns z. x and y must be reduced
+// modulo m and zero-extended to len(m) words. The result has len(m) words.
+func (z nat) csubMod(x, y, m nat) nat {
+	d, b := nat(nil).csub(x, y) // x-y > -m
+	// add m if the subtraction borrowed
+	mask := -b
+	t := nat(nil).make(len(m))
+	for i := range t {
+		t[i] = m[i] & mask
+	}
+	addVV(d, d, t)
+	z = z.make(len(m))
+	copy(z, d)
+	return z
+}
+
+// cmodInverse sets z to x**-1 mod m and returns z, for odd m and x
+// reduced modulo m and zero-extended to len(m) words. If x and m are not
+// relatively prime, the result is undefined. The result has len(m) words.
+//
+// cmodInverse uses the binary extended Euclidean algorithm with a fixed
+// number of steps: in each step, a is halved after subtracting b from it
+// if a is odd, and a and b are swapped first if a < b. Each step shortens
+// a or b by at least one bit until a == 0, so 2*len(m)*_W steps suffice.
+// The swaps and subtractions are selected with masks, so the running
+// time depends only on len(m).
+func (z nat) cmodInverse(x, m nat) nat {
+	n := len(m)
+	tp := getSecretNat(5 * n)
+	t := *tp
+	a, b, u, v, d := t[:n], t[n:2*n], t[2*n:3*n], t[3*n:4*n], t[4*n:]
+
+	// a = u*x and b = v*x modulo m, where b stays odd.
+	copy(a, x)
+	copy(b, m)
+	u.clear()
+	u[0] = 1
+	v.clear()
+	for i := 0; i < 2*n*_W; i++ {
+		odd := a[0] & 1
+		swap := odd & subVV(d, a, b)
+		cswap(a, b, swap)
+		cswap(u, v, swap)
+		subVV(d, a, b)
+		a.sel(d, a, odd)
+		c := subVV(d, u, v)
+		ctword.CondAdd(uints(d), uints(m), uint(c))
+		u.sel(d, u, odd)
+
+		// a is even; halve a, and u modulo m
+		shrVU(a, a, 1)
+		c = Word(ctword.CondAdd(uints(u), uints(m), uint(u[0]&1)))
+		shrVU(u, u, 1)
+		u[n-1] |= c << (_W - 1)
+	}
+
+	// b = gcd(x, m) = 1
+	z = z.make(n)
+	copy(z, v)
+	putSecretNat(tp)
+	return z
+}
+
+// clookup sets z to table[i] by reading every entry of table and keeping
+// the one at index i with masked selection, so that the memory access
+// pattern does not depend on i. All entries must have len(z) words.
+func (z nat) clookup(table []nat, i Word) {
+	z.clear()
+	for k, e := range table {
+		mask := -ctIsZero(Word(k) ^ i)
+		for j := range z {
+			z[j] |= e[j] & mask
+		}
+	}
+}
+
+// ctDigit returns the character for the digit d < base, as in digits or
+// digits62, computed arithmetically rather than by a table lookup indexed
+// by d.
+func ctDigit(d, base Word) byte {
+	if base <= maxBaseSmall {
+		const lower = 'a' - '0' - 10 // offset of 'a' from the digits
+		return byte('0' + d + -(ctLess(d, 10)^1)&lower)
+	}
+	const upper = 'A' - '0' - 10                // offset of 'A' from the digits
+	const lower = 'a' - 'A' - maxBaseSmall + 10 // offset of 'a' from the upper case letters
+	return byte('0' + d + -(ctLess(d, 10)^1)&upper + -(ctLess(d, maxBaseSmall)^1)&lower)
+}
+
+// ndigitsCT returns the number of digits in the given base needed to
+// represent any value of the given bit length, but at least 1.
+func ndigitsCT(base Word, bits int) int {
+	lim := nat(nil).shl(natOne, uint(bits)) // 2**bits
+	p := nat(nil).setWord(base)
+	n := 1
+	for p.cmp(lim) < 0 {
+		p = p.mulAddWW(p, base, 0)
+		n++
+	}
+	return n
+}
+
+// cutoa converts x to an ASCII representation in the given base with
+// exactly ndigits digits, zero-padded on the left. base must be between
+// 2 and MaxBase, and the value of x must fit in ndigits digits.
+// The running time and memory access pattern depend only on len(x),
+// base, and ndigits.
+func (x nat) cutoa(base int, ndigits int) []byte {
+	if base < 2 || base > MaxBase {
+		panic("invalid base")
+	}
+	b := Word(base)
+	bb, ndig := maxPow(b) // convert one word-sized chunk of digits at a time
+	s := make([]byte, ndigits)
+	q := nat(nil).set(x)
+	var r Word
+	for i := ndigits; i > 0; {
+		q, r = q.cdivW(q, bb)
+		for j := 0; j < ndig && i > 0; j++ {
+			var d Word
+			r, d = cdivWW(0, r, b)
+			i--
+			s[i] = ctDigit(d, b)
+		}
+	}
+	q.clear()
+	return s
+}
+
+// cexpNNBlinded is like cexpNN, but if e != 0, it blinds the base x by
+// multiplying it with r**e for a random r in [1, m) read from rand, and,
+// if order != 0, it blinds the exponent y by adding a random 64-bit
+// multiple of order. With e*y = 1 modulo the multiplicative order of r,
+// the blinded power is x**y * r, which is unblinded by multiplying it
+// with r**-1. See Int.ExpBlinded for the requirements on e and order.
+func (z nat) cexpNNBlinded(x, y, m, e, order nat, rand io.Reader, zcap int) (nat, error) {
+	n := len(m)
+
+	// exponent blinding: y = y + k*order
+	if len(order) > 0 {
+		var buf [8]byte
+		if _, err := io.ReadFull(rand, buf[:]); err != nil {
+			return z, err
+		}
+		k := nat(nil).csetBytes(buf[:])
+		buf = [8]byte{}
+		t := nat(nil).cmul(order, k, len(order)+len(k))
+		y = nat(nil).cadd(y, t, max(len(y), len(t))+1)
+		defer y.wipe()
+		k.wipe()
+		t.wipe()
+	}
+
+	x = nat(nil).cmod(x, m)
+	defer x.wipe()
+	if len(e) == 0 {
+		z1 := nat(nil).cexpNN(x, y, m, n)
+		z = z.make(n)
+		copy(z, z1)
+		z1.wipe()
+		return z.cnorm(zcap), nil
+	}
+
+	// base blinding: choose a random r invertible mod m, which treats m
+	// as public
+	mn := m.norm()
+	if len(mn) == 1 && mn[0] == 1 {
+		return z[:0].cnorm(zcap), nil
+	}
+	var r, rinv nat
+	defer func() {
+		r.wipe()
+		rinv.wipe()
+	}()
+	for {
+		var err error
+		if r, err = r.crandom(rand, m); err != nil {
+			return z, err
+		}
+		// The inverse is computed in variable time, but r is a fresh
+		// random value unrelated to the secret inputs.
+		var ri, mi Int
+		ri.abs = r.norm()
+		mi.abs = mn
+		if len(ri.abs) == 0 {
+			continue
+		}
+		inv := new(Int).ModInverse(&ri, &mi).abs
+		if len(inv) > n {
+			inv.wipe()
+			continue
+		}
+		rinv = rinv.cpad(inv, n)
+		inv.wipe()
+		// check that r was invertible
+		t := nat(nil).cmul(r, rinv, 2*n)
+		u := nat(nil).cmod(t, m)
+		one := u.norm().cmp(natOne) == 0
+		t.wipe()
+		u.wipe()
+		if one {
+			break
+		}
+	}
+
+	// x*r**e, where the public exponent e need not be concealed
+	re := nat(nil).cexpNN(r, e, m, n)
+	t := nat(nil).cmul(x, re, 2*n)
+	xr := nat(nil).cmod(t, m)
+	re.wipe()
+	t.wipe()
+
+	// (x*r**e)**y * r**-1 = x**y
+	z1 := nat(nil).cexpNN(xr, y, m, n)
+	t = t.cmul(z1, rinv, 2*n)
+	z = z.cmod(t, m)
+	xr.wipe()
+	z1.wipe()
+	t.wipe()
+	return z.cnorm(zcap), nil
+}
@@ -0,0 +1,357 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestCMod(t *testing.T) {
+	for _, test := range []struct {
+		x, m string
+	}{
+		{"0", "1"},
+		{"1", "1"},
+		{"5", "7"},
+		{"7", "7"},
+		{"123456789012345678901234567890", "987654321"},
+		{"987654321", "123456789012345678901234567890"},
+		{"340282366920938463463374607431768211455", "18446744073709551615"},
+		{"340282366920938463463374607431768211456", "340282366920938463463374607431768211455"},
+		{"0x" + "ffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff", "0xfffffffffffffffffffffffffffffffffffffffffffffffffffffffeffffffffffffffff"},
+	} {
+		x := natFromString(test.x)
+		m := natFromString(test.m)
+		_, want := nat(nil).div(nil, x, m)
+		got := nat(nil).cmod(x, m)
+		if len(got) != len(m) {
+			t.Errorf("cmod(%s, %s): got %d words; want %d", test.x, test.m, len(got), len(m))
+		}
+		if got.norm().cmp(want) != 0 {
+			t.Errorf("cmod(%s, %s) = %s; want %s", test.x, test.m, got.norm().utoa(10), want.utoa(10))
+		}
+		// non-normalized x must give the same result
+		xx := make(nat, len(x)+2)
+		copy(xx, x)
+		if got := nat(nil).cmod(xx, m); got.norm().cmp(want) != 0 {
+			t.Errorf("cmod(%s (padded), %s) = %s; want %s", test.x, test.m, got.norm().utoa(10), want.utoa(10))
+		}
+	}
+}
+
+func TestSel(t *testing.T) {
+	x := nat{1, 2, 3}
+	y := nat{4, 5, 6}
+	if got := nat(nil).sel(x, y, 1); got.cmp(x) != 0 {
+		t.Errorf("sel(x, y, 1) = %v; want %v", got, x)
+	}
+	if got := nat(nil).sel(x, y, 0); got.cmp(y) != 0 {
+		t.Errorf("sel(x, y, 0) = %v; want %v", got, y)
+	}
+}
+
+func TestCModRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		k := 1 + r.Intn(5*_W)
+		m := nat(nil).random(r, nat(nil).shl(natOne, uint(k)), k+1)
+		if len(m) == 0 {
+			continue
+		}
+		x := rndNat(r.Intn(8))
+		_, want := nat(nil).div(nil, x, m)
+		if got := nat(nil).cmod(x, m); got.norm().cmp(want.norm()) != 0 {
+			t.Errorf("cmod(%s, %s) = %s; want %s", x.utoa(16), m.utoa(16), got.norm().utoa(16), want.utoa(16))
+		}
+	}
+}
+
+func TestCAddSubMod(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 500; i++ {
+		k := 1 + r.Intn(4*_W)
+		m := nat(nil).random(r, nat(nil).shl(natOne, uint(k)), k+1)
+		if len(m) == 0 {
+			continue
+		}
+		x := nat(nil).cmod(rndNat(r.Intn(6)), m)
+		y := nat(nil).cmod(rndNat(r.Intn(6)), m)
+		if i%4 == 0 {
+			y = nat(nil).cpad(nat(nil).sub(m, natOne), len(m))
+		}
+
+		_, want := nat(nil).div(nil, nat(nil).add(x.norm(), y.norm()), m)
+		got := nat(nil).caddMod(x, y, m)
+		if len(got) != len(m) || got.norm().cmp(want.norm()) != 0 {
+			t.Errorf("caddMod(%s, %s, %s) = %s; want %s", x.utoa(16), y.utoa(16), m.utoa(16), got.utoa(16), want.utoa(16))
+		}
+
+		// x - y mod m == x + (m - y) mod m
+		want = nat(nil).add(x.norm(), nat(nil).sub(m, y.norm()))
+		_, want = nat(nil).div(nil, want, m)
+		got = nat(nil).csubMod(x, y, m)
+		if len(got) != len(m) || got.norm().cmp(want.norm()) != 0 {
+			t.Errorf("csubMod(%s, %s, %s) = %s; want %s", x.utoa(16), y.utoa(16), m.utoa(16), got.utoa(16), want.utoa(16))
+		}
+	}
+}
+
+func TestCModW(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, d := range []Word{1, 2, 3, 10, 255, primesA, primesB, _M, _M - 1, 1 << (_W - 1), 1<<(_W-1) + 1} {
+		for _, n := range []int{0, 1, 2, 5, 20} {
+			x := rndNat(n)
+			if n > 0 && r.Intn(2) == 0 {
+				x[n-1] |= 1 << (_W - 1) // exercise the overflow path
+			}
+			if got, want := x.cmodW(d), x.modW(d); got != want {
+				t.Errorf("%v.cmodW(%#x) = %#x; want %#x", x, d, got, want)
+			}
+		}
+	}
+}
+
+func TestCSmallPrimeFactor(t *testing.T) {
+	for i := Word(0); i < 3000; i++ {
+		x := nat(nil).setWord(i)
+		want := Word(0)
+		for _, p := range []Word{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47, 53} {
+			if i%p == 0 {
+				want = 1
+			}
+		}
+		if got := x.csmallPrimeFactor(); got != want {
+			t.Errorf("%d.csmallPrimeFactor() = %d; want %d", i, got, want)
+		}
+	}
+
+	// a large prime and a multiple of 53
+	p := natFromString("0xffffffff00000001000000000000000000000000ffffffffffffffffffffffff")
+	if p.csmallPrimeFactor() != 0 {
+		t.Errorf("P-256 prime reported to have a small factor")
+	}
+	if q := nat(nil).mulAddWW(p, 53, 0); q.csmallPrimeFactor() != 1 {
+		t.Errorf("53*p not reported to have a small factor")
+	}
+}
+
+func TestCExpNN(t *testing.T) {
+	for i, test := range expNNTests {
+		if len(test.m) == 0 {
+			continue // cexpNN requires a modulus
+		}
+		x := natFromString(test.x)
+		y := natFromString(test.y)
+		m := natFromString(test.m)
+		if len(m) == 0 {
+			continue
+		}
+		want := natFromString(test.out)
+		zcap := len(m) + 1
+		z := nat(nil).cexpNN(x, y, m, zcap)
+		if len(z) != zcap {
+			t.Errorf("#%d: got %d words; want %d", i, len(z), zcap)
+		}
+		if z.norm().cmp(want) != 0 {
+			t.Errorf("#%d: got 0x%s want 0x%s", i, z.norm().utoa(16), want.utoa(16))
+		}
+	}
+
+	// the modulus 1, padded
+	if got := nat(nil).cexpNN(nat{5}, nat{3}, nat{1, 0, 0}, 3); got.norm().cmp(nil) != 0 {
+		t.Errorf("cexpNN(5, 3, 1) = %v; want 0", got.norm())
+	}
+	if got := nat(nil).cexpNNOdd(nat{5}, nat{3}, nat{1}, 1); got.norm().cmp(nil) != 0 {
+		t.Errorf("cexpNNOdd(5, 3, 1) = %v; want 0", got.norm())
+	}
+
+	// compare with expNN for random operands, odd and even moduli,
+	// and exponents with leading zero words
+	r := rand.New(rand.NewSource(2))
+	for i := 0; i < 20; i++ {
+		m := rndNat(1 + r.Intn(4))
+		if len(m) == 0 {
+			continue
+		}
+		if i&1 == 0 {
+			m[0] |= 1
+		}
+		if i%3 == 0 {
+			m = append(m, 0) // a leading zero word, as for a marked modulus
+		}
+		x := rndNat(r.Intn(6))
+		y := append(rndNat(1+r.Intn(2)), 0)
+		want := nat(nil).expNN(x, y.norm(), m.norm())
+		if got := nat(nil).cexpNN(x, y, m, len(m)); got.norm().cmp(want) != 0 {
+			t.Errorf("cexpNN(%v, %v, %v) = %v; want %v", x, y, m, got.norm(), want)
+		}
+	}
+}
+
+func TestCSqr(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		n := r.Intn(ckaratsubaSqrThreshold * 5)
+		x := rndNat(n)
+		switch i % 4 {
+		case 1:
+			for j := range x {
+				x[j] = _M // all ones
+			}
+		case 2:
+			if n > 0 {
+				x[n-1] = 0 // leading zero word
+			}
+		}
+		want := nat(nil).cmul(x, x, 2*n)
+		if got := nat(nil).csqr(x, 2*n); got.cmp(want) != 0 {
+			t.Errorf("csqr(%d words) = %s; want %s", n, got.utoa(16), want.utoa(16))
+		}
+	}
+	for n := 1; n < ckaratsubaSqrThreshold; n++ {
+		x := rndNat(n)
+		z := make(nat, 2*n)
+		cbasicSqr(z, x)
+		if want := nat(nil).cmul(x, x, 2*n); z.cmp(want) != 0 {
+			t.Errorf("cbasicSqr(%d words) = %s; want %s", n, z.utoa(16), want.utoa(16))
+		}
+	}
+	// aliased result
+	x := rndNat(ckaratsubaSqrThreshold * 2)
+	want := nat(nil).cmul(x, x, 4*len(x))
+	if x = x.csqr(x, 4*len(x)); x.cmp(want) != 0 {
+		t.Errorf("csqr (aliased) = %s; want %s", x.utoa(16), want.utoa(16))
+	}
+}
+
+func benchmarkCSqr(b *testing.B, n int, sqr bool) {
+	x := rndNat(n)
+	var z nat
+	for i := 0; i < b.N; i++ {
+		if sqr {
+			z = z.csqr(x, 2*n)
+		} else {
+			z = z.cmul(x, x, 2*n)
+		}
+	}
+}
+
+func BenchmarkCSqr8(b *testing.B)     { benchmarkCSqr(b, 8, true) }
+func BenchmarkCSqr32(b *testing.B)    { benchmarkCSqr(b, 32, true) }
+func BenchmarkCSqr64(b *testing.B)    { benchmarkCSqr(b, 64, true) }
+func BenchmarkCSqr256(b *testing.B)   { benchmarkCSqr(b, 256, true) }
+func BenchmarkCMulXX8(b *testing.B)   { benchmarkCSqr(b, 8, false) }
+func BenchmarkCMulXX32(b *testing.B)  { benchmarkCSqr(b, 32, false) }
+func BenchmarkCMulXX64(b *testing.B)  { benchmarkCSqr(b, 64, false) }
+func BenchmarkCMulXX256(b *testing.B) { benchmarkCSqr(b, 256, false) }
+
+func TestWipeNat(t *testing.T) {
+	x := make(nat, 3, 8)
+	for i := range x[:cap(x)] {
+		x[:cap(x)][i] = Word(i + 1)
+	}
+	x.wipe()
+	for i, w := range x[:cap(x)] {
+		if w != 0 {
+			t.Errorf("word %d = %#x after wipe; want 0", i, w)
+		}
+	}
+	nat(nil).wipe() // must not panic
+}
+
+func TestSecretNatPool(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		p := getSecretNat(4)
+		buf := (*p)[:cap(*p)]
+		for j := range buf {
+			if buf[j] != 0 {
+				t.Fatalf("getSecretNat returned a buffer with word %d = %#x", j, buf[j])
+			}
+			buf[j] = _M
+		}
+		putSecretNat(p)
+		for j := range buf {
+			if buf[j] != 0 {
+				t.Fatalf("putSecretNat left word %d = %#x", j, buf[j])
+			}
+		}
+	}
+}
+
+func TestCNorm(t *testing.T) {
+	if z := (nat{1, 2, 0, 0}).cnorm(3); len(z) != 3 || z.norm().cmp(nat{1, 2}) != 0 {
+		t.Errorf("cnorm(3) = %v; want [1 2 0]", z)
+	}
+	if z := (nat{1}).cnorm(4); len(z) != 4 || z.norm().cmp(nat{1}) != 0 {
+		t.Errorf("cnorm(4) = %v; want [1 0 0 0]", z)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("cnorm did not panic on overflow")
+		}
+	}()
+	(nat{1, 2, 3}).cnorm(2)
+}
+
+func TestCDivW(t *testing.T) {
+	for _, d := range []Word{1, 3, 10, 1e9, _M, 1 << (_W - 1), 1<<(_W-1) + 3} {
+		for _, n := range []int{0, 1, 3, 10} {
+			x := rndNat(n)
+			wantQ, wantR := nat(nil).divW(x, d)
+			q, r := nat(nil).cdivW(x, d)
+			if len(q) != len(x) || q.norm().cmp(wantQ) != 0 || r != wantR {
+				t.Errorf("%v.cdivW(%#x) = %v, %#x; want %v, %#x", x, d, q, r, wantQ, wantR)
+			}
+		}
+	}
+}
+
+func TestCDiv(t *testing.T) {
+	r := rand.New(rand.NewSource(7))
+	for i := 0; i < 200; i++ {
+		x := rndNat(r.Intn(6))
+		m := rndNat(1 + r.Intn(5)).norm()
+		if len(m) == 0 {
+			continue
+		}
+		m = m[:1+r.Intn(len(m))].norm()
+		if len(m) == 0 {
+			continue
+		}
+		wantQ, wantR := nat(nil).div(nil, x, m)
+		q, rem := nat(nil).cdiv(nil, x, m)
+		if len(q) != len(x) || len(rem) != len(m) || q.norm().cmp(wantQ) != 0 || rem.norm().cmp(wantR) != 0 {
+			t.Errorf("cdiv(%v, %v) = %v, %v; want %v, %v", x, m, q, rem, wantQ, wantR)
+		}
+	}
+}
+
+func TestCSqrt(t *testing.T) {
+	r := rand.New(rand.NewSource(8))
+	for i := 0; i < 200; i++ {
+		x := rndNat(1 + r.Intn(6))
+		switch i % 4 {
+		case 1:
+			// a perfect square
+			s := x[:len(x)/2].norm()
+			x = x.make(len(x))
+			x[copy(x, nat(nil).mul(s, s)):].clear()
+		case 2:
+			// one less than a perfect square
+			s := x[:len(x)/2].norm()
+			sq := nat(nil).mul(s, s)
+			if len(sq) > 0 {
+				x = x.make(len(x))
+				x[copy(x, nat(nil).sub(sq, natOne)):].clear()
+			}
+		}
+		want := nat(nil).sqrt(x.norm())
+		got := nat(nil).csqrt(x)
+		if len(got) != (len(x)+1)/2 || got.norm().cmp(want) != 0 {
+			t.Errorf("csqrt(%v) = %v; want %v", x, got, want)
+		}
+	}
+}
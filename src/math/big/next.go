@@ -0,0 +1,113 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Float.NextUp, Float.NextDown, and Float.Ulp.
+
+package big
+
+// NextUp sets z to the smallest value of z's precision that is greater
+// than x, and returns z. If z's precision is 0, it is changed to x's
+// precision before the operation, so that z is the successor of x. The
+// result is exact: z.Acc() is Exact.
+//
+// NextUp(±0) is the smallest positive Float, NextUp(-Inf) is the most
+// negative finite Float of z's precision, and NextUp of the largest finite
+// Float of z's precision, or of +Inf, is +Inf. NextUp of the smallest
+// negative Float is -0.
+func (z *Float) NextUp(x *Float) *Float {
+	return z.next(x, false)
+}
+
+// NextDown sets z to the largest value of z's precision that is less than
+// x, and returns z. It is the mirror image of NextUp, with NextDown(x) =
+// -NextUp(-x).
+func (z *Float) NextDown(x *Float) *Float {
+	return z.next(x, true)
+}
+
+// next implements NextUp, and NextDown for down.
+func (z *Float) next(x *Float, down bool) *Float {
+	if debugFloat {
+		x.validate()
+	}
+
+	if z.prec == 0 {
+		z.prec = x.prec
+	}
+
+	switch x.form {
+	case zero:
+		// ±2**(MinExp-1)
+		z.setScaled(&Int{neg: down, abs: natOne}, MinExp-1)
+	case inf:
+		if x.neg == down {
+			z.Set(x)
+			break
+		}
+		// ±(2**prec - 1) × 2**(MaxExp-prec)
+		m := new(Int).Lsh(intOne, uint(z.prec))
+		m.Sub(m, intOne)
+		m.neg = x.neg
+		z.setScaled(m, MaxExp-int64(z.prec))
+	case finite:
+		// round x toward the neighbor; if x is of z's precision, step
+		// from x to it
+		mode := z.mode
+		z.mode = ToPositiveInf
+		if down {
+			z.mode = ToNegativeInf
+		}
+		z.Set(x)
+		if z.acc == Exact && z.form == finite {
+			// z = m × 2**e with prec bits of m
+			m := &Int{neg: z.neg, abs: msbits(z.mant, uint(z.prec))}
+			e := int64(z.exp) - int64(z.prec)
+			if z.neg == down {
+				// |z| + 1 ulp, which may carry into the exponent
+				m.abs = m.abs.add(m.abs, natOne)
+				z.setScaled(m, e)
+			} else {
+				// |z| - 1 ulp, that is 2m - 1 = 2**prec - 1 with one
+				// less in the exponent for m = 2**(prec-1), and 2m - 2
+				// otherwise, after rounding toward zero
+				m.abs = m.abs.shl(m.abs, 1)
+				m.abs = m.abs.sub(m.abs, natOne)
+				z.mode = ToZero
+				z.setScaled(m, e-1) // may underflow to ±0
+			}
+		}
+		z.mode = mode
+	}
+	z.acc = Exact
+	return z
+}
+
+// Ulp sets z to the unit in the last place of x at x's precision, the
+// distance from |x| to the next Float of larger magnitude, 2**(exp-prec)
+// for x = mant × 2**exp with 0.5 <= |mant| < 1.0 and x's precision prec,
+// and returns z. If z's precision is 0, it is changed to x's precision
+// before the operation. The result is a power of two and exact, unless it
+// is too small to be represented; then z is +0 and z.Acc() is Below.
+//
+// Ulp(±0) is the smallest positive Float, and Ulp(±Inf) is +Inf.
+func (z *Float) Ulp(x *Float) *Float {
+	if debugFloat {
+		x.validate()
+	}
+
+	if z.prec == 0 {
+		z.prec = x.prec
+	}
+
+	switch x.form {
+	case zero:
+		return z.setScaled(&Int{abs: natOne}, MinExp-1)
+	case inf:
+		z.acc = Exact
+		z.form = inf
+		z.neg = false
+		return z
+	}
+	return z.setScaled(&Int{abs: natOne}, int64(x.exp)-int64(x.prec))
+}
@@ -0,0 +1,180 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+// TestFloatNext checks NextUp and NextDown at low precisions against the
+// sorted list of all values of that precision in an exponent range, for
+// values of that precision and for values in between.
+func TestFloatNext(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for prec := uint(1); prec <= 5; prec++ {
+		var list []*Float
+		for m := int64(1); m < 1<<prec; m++ {
+			for e := -10; e <= 10; e++ {
+				x := new(Float).SetInt64(m)
+				list = append(list, x.SetMantExp(x, e), new(Float).Neg(x))
+			}
+		}
+		list = append(list, new(Float))
+		sort.Slice(list, func(i, j int) bool { return list[i].Cmp(list[j]) < 0 })
+		for i := 1; i < len(list); i++ {
+			if list[i].Cmp(list[i-1]) == 0 {
+				list = append(list[:i], list[i+1:]...)
+				i--
+			}
+		}
+
+		for i := 1; i+1 < len(list); i++ {
+			x := list[i]
+			// skip zero and the ends of the range, where values of the
+			// precision are missing from the list
+			if x.Sign() == 0 || x.MantExp(nil) < -4 || x.MantExp(nil) > 8 {
+				continue
+			}
+			if z := new(Float).SetPrec(prec).NextUp(x); z.Cmp(list[i+1]) != 0 || z.Acc() != Exact || z.Prec() != prec {
+				t.Errorf("NextUp(%s) at precision %d = %s (%s); want %s", x.Text('p', 0), prec, z.Text('p', 0), z.Acc(), list[i+1].Text('p', 0))
+			}
+			if z := new(Float).SetPrec(prec).NextDown(x); z.Cmp(list[i-1]) != 0 || z.Acc() != Exact {
+				t.Errorf("NextDown(%s) at precision %d = %s (%s); want %s", x.Text('p', 0), prec, z.Text('p', 0), z.Acc(), list[i-1].Text('p', 0))
+			}
+
+			// a value strictly between x and its successor
+			y := new(Float).SetPrec(100).Sub(list[i+1], x)
+			y.Mul(y, new(Float).SetFloat64(r.Float64()*0.98+0.01)).Add(y, x)
+			if z := new(Float).SetPrec(prec).NextUp(y); z.Cmp(list[i+1]) != 0 {
+				t.Errorf("NextUp(%s) at precision %d = %s; want %s", y.Text('p', 0), prec, z.Text('p', 0), list[i+1].Text('p', 0))
+			}
+			if z := new(Float).SetPrec(prec).NextDown(y); z.Cmp(x) != 0 {
+				t.Errorf("NextDown(%s) at precision %d = %s; want %s", y.Text('p', 0), prec, z.Text('p', 0), x.Text('p', 0))
+			}
+		}
+	}
+}
+
+// TestFloatUlp checks that Ulp is the distance to the next larger
+// magnitude, and that NextUp and NextDown are inverses of each other.
+func TestFloatUlp(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		prec := uint(r.Intn(200) + 1)
+		x := randFloat(r, prec, 1000)
+		x.SetPrec(prec)
+		if i%10 == 0 {
+			// a power of two
+			x.SetMantExp(NewFloat(0.5), r.Intn(100)-50)
+			x.SetPrec(prec)
+		}
+		u := new(Float).Ulp(x)
+		if u.Prec() != prec || u.Acc() != Exact || u.Sign() <= 0 || u.MinPrec() != 1 {
+			t.Errorf("Ulp(%s) = %s with precision %d (%s)", x.Text('p', 0), u.Text('p', 0), u.Prec(), u.Acc())
+		}
+		ax := new(Float).Abs(x)
+		next := new(Float).NextUp(ax)
+		if d := new(Float).SetPrec(prec+1).Sub(next, ax); x.Sign() != 0 && d.Cmp(u) != 0 {
+			t.Errorf("Ulp(%s) = %s; want %s", x.Text('p', 0), u.Text('p', 0), d.Text('p', 0))
+		}
+
+		if z := new(Float).NextDown(new(Float).NextUp(x)); z.Cmp(x) != 0 {
+			t.Errorf("NextDown(NextUp(%s)) = %s", x.Text('p', 0), z.Text('p', 0))
+		}
+		if z := new(Float).NextUp(new(Float).NextDown(x)); z.Cmp(x) != 0 {
+			t.Errorf("NextUp(NextDown(%s)) = %s", x.Text('p', 0), z.Text('p', 0))
+		}
+	}
+}
+
+func TestFloatNextSpecial(t *testing.T) {
+	const (
+		tiny = "0x1p-2147483649"  // smallest positive Float
+		max  = "0x1.ep2147483646" // largest Float of precision 4
+	)
+	for _, test := range []struct {
+		x, up, down string
+	}{
+		{"0", tiny, "-" + tiny},
+		{"-0", tiny, "-" + tiny},
+		{tiny, "0x1.2p-2147483649", "0"},
+		{"-" + tiny, "-0", "-0x1.2p-2147483649"},
+		{"0x1p-2147483648", "0x1.2p-2147483648", "0x1.ep-2147483649"},
+		{max, "+Inf", "0x1.cp2147483646"},
+		{"-" + max, "-0x1.cp2147483646", "-Inf"},
+		{"+Inf", "+Inf", max},
+		{"-Inf", "-" + max, "-Inf"},
+		{"1", "1.125", "0.9375"},
+		{"-1", "-0.9375", "-1.125"},
+		{"1.0625", "1.125", "1"},
+		{"0x1.fp2147483646", "+Inf", max},
+	} {
+		x := makeFloat(test.x)
+		for _, dir := range []struct {
+			name string
+			f    func(z, x *Float) *Float
+			want string
+		}{
+			{"NextUp", (*Float).NextUp, test.up},
+			{"NextDown", (*Float).NextDown, test.down},
+		} {
+			z := dir.f(new(Float).SetPrec(4), x)
+			want := makeFloat(dir.want)
+			if z.Cmp(want) != 0 || z.Signbit() != want.Signbit() || z.Acc() != Exact {
+				t.Errorf("%s(%s) = %s (%s); want %s", dir.name, test.x, z.Text('p', 0), z.Acc(), dir.want)
+			}
+			// z may be x
+			y := new(Float).SetPrec(4).Set(x)
+			if dir.f(y, y).Cmp(z) != 0 && x.MinPrec() <= 4 {
+				t.Errorf("aliased %s(%s) = %s; want %s", dir.name, test.x, y.Text('p', 0), z.Text('p', 0))
+			}
+		}
+	}
+
+	for _, test := range []struct {
+		x    string
+		prec uint
+		want string
+		acc  Accuracy
+	}{
+		{"0", 10, tiny, Exact},
+		{"-0", 10, tiny, Exact},
+		{"+Inf", 10, "+Inf", Exact},
+		{"-Inf", 10, "+Inf", Exact},
+		{"1", 1, "1", Exact},
+		{"1", 53, "0x1p-52", Exact},
+		{"-1.5", 53, "0x1p-52", Exact},
+		{"3", 2, "1", Exact},
+		{"0x1p-2147483648", 1, "0x1p-2147483648", Exact},
+		{"0x1p-2147483648", 2, tiny, Exact},
+		{"0x1p-2147483648", 3, "0", Below},
+	} {
+		x := makeFloat(test.x)
+		x.SetPrec(test.prec)
+		z := new(Float).Ulp(x)
+		if want := makeFloat(test.want); z.Cmp(want) != 0 || z.Signbit() || z.Acc() != test.acc || z.Prec() != test.prec {
+			t.Errorf("Ulp(%s) at precision %d = %s (%s) with precision %d; want %s (%s)", test.x, test.prec, z.Text('p', 0), z.Acc(), z.Prec(), test.want, test.acc)
+		}
+	}
+
+	if z := new(Float).NextUp(new(Float).SetPrec(30).SetInt64(1)); z.Prec() != 30 {
+		t.Errorf("NextUp with precision 0: got precision %d; want 30", z.Prec())
+	}
+}
+
+func BenchmarkFloatNextUp(b *testing.B) {
+	for _, prec := range []uint{64, 1000, 10000} {
+		x := new(Float).SetPrec(prec).SetInt64(3)
+		z := new(Float).SetPrec(prec)
+		b.Run(fmt.Sprintf("%v", prec), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				z.NextUp(x)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the limit on the number of goroutines used
+// by operations on very large numbers.
+
+package big
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// parallelism is the maximum number of goroutines used by operations
+// on very large numbers, or 0 for GOMAXPROCS; see SetParallelism.
+var parallelism int32
+
+// workers is the number of goroutines started by operations on very
+// large numbers that are currently running.
+var workers int32
+
+// SetParallelism sets the maximum number of goroutines that may work on
+// multiplications of and conversions to strings of very large numbers at
+// the same time, and returns the previous setting. Such operations split
+// their work among additional goroutines as long as fewer than n-1 of
+// them are running in the whole program. If n == 1, all operations run
+// sequentially on the calling goroutine. If n <= 0, the limit is
+// GOMAXPROCS, which is the default.
+//
+// Only operands of many thousands of words are split, so the setting
+// does not affect the performance of cryptographic operations. Servers
+// that run many large computations concurrently may prefer to disable
+// the parallelism.
+func SetParallelism(n int) (previous int) {
+	if n < 0 {
+		n = 0
+	}
+	return int(atomic.SwapInt32(&parallelism, int32(n)))
+}
+
+// acquireWorker reports whether another goroutine may be started to
+// work on a very large number, and if so, counts it in workers.
+func acquireWorker() bool {
+	max := atomic.LoadInt32(&parallelism)
+	if max == 0 {
+		max = int32(runtime.GOMAXPROCS(0))
+	}
+	if atomic.AddInt32(&workers, 1) < max {
+		return true
+	}
+	atomic.AddInt32(&workers, -1)
+	return false
+}
+
+// releaseWorker records the end of a goroutine started after
+// acquireWorker.
+func releaseWorker() {
+	atomic.AddInt32(&workers, -1)
+}
+
+// parallelFor calls f for consecutive ranges that make up [lo, hi),
+// concurrently as far as acquireWorker allows. Ranges are only split
+// if both halves have at least grain elements.
+func parallelFor(lo, hi, grain int, f func(lo, hi int)) {
+	if hi-lo >= 2*grain && acquireWorker() {
+		mid := lo + (hi-lo)/2
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			parallelFor(mid, hi, grain, f)
+			releaseWorker()
+			wg.Done()
+		}()
+		parallelFor(lo, mid, grain, f)
+		wg.Wait()
+		return
+	}
+	f(lo, hi)
+}
+
+// parallelDo calls f and g, concurrently if acquireWorker allows.
+func parallelDo(f, g func()) {
+	if !acquireWorker() {
+		f()
+		g()
+		return
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		g()
+		releaseWorker()
+		wg.Done()
+	}()
+	f()
+	wg.Wait()
+}
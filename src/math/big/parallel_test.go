@@ -0,0 +1,85 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"runtime"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSetParallelism(t *testing.T) {
+	defer SetParallelism(SetParallelism(3))
+	if got := SetParallelism(-5); got != 3 {
+		t.Errorf("SetParallelism returned %d; want 3", got)
+	}
+	if got := SetParallelism(0); got != 0 {
+		t.Errorf("SetParallelism(-5) set %d; want 0", got)
+	}
+}
+
+func TestParallelFor(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	for _, n := range []int{0, 1, 7, 100, 1000} {
+		seen := make([]int32, n)
+		parallelFor(0, n, 3, func(lo, hi int) {
+			for i := lo; i < hi; i++ {
+				atomic.AddInt32(&seen[i], 1)
+			}
+		})
+		for i, c := range seen {
+			if c != 1 {
+				t.Errorf("n = %d: index %d visited %d times", n, i, c)
+			}
+		}
+	}
+	if w := atomic.LoadInt32(&workers); w != 0 {
+		t.Errorf("%d workers still running", w)
+	}
+}
+
+func TestParallelMul(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	defer func(w, f int) { parallelMulWords, fftThreshold = w, f }(parallelMulWords, fftThreshold)
+	parallelMulWords = 64
+
+	for _, test := range []struct {
+		nx, ny, fft int
+	}{
+		{1000, 1000, 1 << 30}, // Karatsuba
+		{3000, 1700, 1 << 30},
+		{3000, 2500, 1000}, // FFT
+	} {
+		x := rndNat(test.nx)
+		y := rndNat(test.ny)
+		fftThreshold = test.fft
+		SetParallelism(1)
+		want := nat(nil).mul(x, y)
+		SetParallelism(0)
+		if got := nat(nil).mul(x, y); got.cmp(want) != 0 {
+			t.Errorf("parallel mul of %d and %d words differs from sequential mul", test.nx, test.ny)
+		}
+		if w := atomic.LoadInt32(&workers); w != 0 {
+			t.Errorf("%d workers still running", w)
+		}
+	}
+}
+
+func BenchmarkParallelMul(b *testing.B) {
+	x := rndNat(1e5)
+	y := rndNat(1e5)
+	for _, p := range []int{1, 0} {
+		name := "sequential"
+		if p == 0 {
+			name = "parallel"
+		}
+		b.Run(name, func(b *testing.B) {
+			defer SetParallelism(SetParallelism(p))
+			for i := 0; i < b.N; i++ {
+				nat(nil).mul(x, y)
+			}
+		})
+	}
+}
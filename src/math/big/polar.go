@@ -0,0 +1,274 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Float.Hypot and Float.Atan2.
+
+package big
+
+import "math"
+
+// Hypot sets z to the rounded value of √(x² + y²), and returns z.
+// If z's precision is 0, it is changed to the larger of x's or y's
+// precision before the operation. The result is the exact value rounded
+// according to z's precision and rounding mode, and its accuracy is
+// reported as for Add. x² + y² is not rounded to a Float, so Hypot
+// overflows or underflows only if the result is outside the exponent
+// range of Float.
+//
+// Hypot(±Inf, y) = Hypot(x, ±Inf) = +Inf, and Hypot(±0, ±0) = +0.
+func (z *Float) Hypot(x, y *Float) *Float {
+	if debugFloat {
+		x.validate()
+		y.validate()
+	}
+
+	if z.prec == 0 {
+		z.prec = umax32(x.prec, y.prec)
+	}
+
+	z.acc = Exact
+	z.neg = false
+	switch {
+	case x.form == inf || y.form == inf:
+		z.form = inf
+		return z
+	case x.form == zero && y.form == zero:
+		z.form = zero
+		return z
+	case x.form == zero:
+		x = y
+		fallthrough
+	case y.form == zero:
+		m, e := x.oddExp()
+		z.usqrt(m.Mul(m, m).abs, 2*e)
+		return z
+	}
+
+	// x² + y² = m × 2**e, or a value that rounds like it at precision
+	// 2 × z.prec + 2; this includes the squares of all values of
+	// precision z.prec and the midpoints between them
+	xm, xe := x.oddExp()
+	ym, ye := y.oddExp()
+	m, e := addScaled(xm.Mul(xm, xm), 2*xe, ym.Mul(ym, ym), 2*ye, 2*int64(z.prec)+2)
+	z.usqrt(m.abs, e)
+	return z
+}
+
+// Atan2 sets z to the rounded arc tangent of y/x, using the signs of y
+// and x to determine the quadrant of the result, and returns z. If z's
+// precision is 0, it is changed to the larger of y's or x's precision
+// before the operation. The result, in [-π, π], is the exact value rounded
+// according to z's precision and rounding mode, and its accuracy is
+// reported as for Add; it is Exact only for a result of ±0.
+//
+// Special cases are, as for math.Atan2:
+//
+//	Atan2(±0, x >= 0) = ±0
+//	Atan2(±0, x <= -0) = ±π
+//	Atan2(y > 0, ±0) = +π/2
+//	Atan2(y < 0, ±0) = -π/2
+//	Atan2(±Inf, +Inf) = ±π/4
+//	Atan2(±Inf, -Inf) = ±3π/4
+//	Atan2(y, +Inf) = ±0, with the sign of y
+//	Atan2(y, -Inf) = ±π, with the sign of y
+//	Atan2(±Inf, x) = ±π/2
+func (z *Float) Atan2(y, x *Float) *Float {
+	if debugFloat {
+		y.validate()
+		x.validate()
+	}
+
+	if z.prec == 0 {
+		z.prec = umax32(y.prec, x.prec)
+	}
+
+	// z may be y or x
+	neg := y.neg
+	switch {
+	case y.form == zero && !x.neg || y.form != inf && x.form == inf && !x.neg:
+		z.acc = Exact
+		z.form = zero
+		z.neg = neg
+		return z
+	case y.form == zero || y.form != inf && x.form == inf:
+		return z.setPiMultiple(4, neg)
+	case y.form == inf && x.form == inf:
+		if x.neg {
+			return z.setPiMultiple(3, neg)
+		}
+		return z.setPiMultiple(1, neg)
+	case y.form == inf || x.form == zero:
+		return z.setPiMultiple(2, neg)
+	}
+
+	// y and x are finite and non-zero. With r = |y/x| or |x/y|, whichever
+	// is at most 1, the result is ±(c × π/2 ± atan(r)).
+	a, ae := y.oddExp()
+	b, be := x.oddExp()
+	a.neg, b.neg = false, false
+	var c int64
+	minus := x.neg
+	if y.ucmp(x) > 0 {
+		// atan(|y/x|) = π/2 - atan(|x/y|)
+		a, ae, b, be = b, be, a, ae
+		c = 1
+		minus = !minus
+	} else if x.neg {
+		// the angle is π - atan(|y/x|)
+		c = 2
+	}
+	d := ae - be
+	if t := int64(b.BitLen()-a.BitLen()) - d; c == 0 && 2*t > int64(z.prec)+int64(a.BitLen()+b.BitLen())+8 {
+		// r < 2**(1-t) is so small that atan(r) = r - r**3/3 + ... is
+		// too close to r for a fixed point that covers r**3
+		return z.setAtanTiny(a, b, d, neg)
+	}
+	return z.setBracketed(func(w uint) (lo, hi *Int, exp int64) {
+		lo, hi, exp = atanBracket(a, b, d, c, minus, w)
+		if neg {
+			lo, hi = hi.Neg(hi), lo.Neg(lo)
+		}
+		return lo, hi, exp
+	})
+}
+
+// setAtanTiny sets z to the rounded value of ±atan(r), with the sign neg,
+// for r = a/b × 2**d < 2**(1-t), where 2t exceeds z's precision plus the
+// lengths of a and b by more than 8. atan(r) = r - r**3/3 + ... lies below
+// r by less than r × 2**(2-2t). With f = ⌊a/b × 2**k⌋ for the k below, the
+// scaled value r × 2**(k-d) is either f, or lies strictly between f and
+// f + 1, and differs from those by at least 1/b, which is more than the
+// scaled difference. So atan(r) × 2**(k-d) lies strictly between f - 1
+// and f, or between f and f + 1; f has at least two bits more than z's
+// precision, and either interval rounds like its midpoint.
+func (z *Float) setAtanTiny(a, b *Int, d int64, neg bool) *Float {
+	k := int64(z.prec) + 3 + int64(b.BitLen()-a.BitLen())
+	if k < 0 {
+		k = 0
+	}
+	m, rem := new(Int).QuoRem(new(Int).Lsh(a, uint(k)), b, new(Int))
+	m.Lsh(m, 1)
+	if len(rem.abs) > 0 {
+		m.Add(m, intOne)
+	} else {
+		m.Sub(m, intOne)
+	}
+	m.neg = neg
+	return z.setScaled(m, d-k-1)
+}
+
+// setPiMultiple sets z to the rounded value of ±k × π/4, with the sign
+// neg, for k > 0, and returns z.
+func (z *Float) setPiMultiple(k int64, neg bool) *Float {
+	kk := NewInt(k)
+	return z.setBracketed(func(w uint) (lo, hi *Int, exp int64) {
+		lo = piCache.get(w)
+		hi = new(Int).Add(lo, NewInt(2))
+		lo.Mul(lo, kk)
+		hi.Mul(hi, kk)
+		if neg {
+			lo, hi = hi.Neg(hi), lo.Neg(lo)
+		}
+		return lo, hi, -int64(w) - 2
+	})
+}
+
+// atanBracket returns lo, hi, and exp for setBracketed for c × π/2 ±
+// atan(r), with - for minus, for r = a/b × 2**d with 0 < r <= 1 and c in
+// 0, 1, or 2: the result is at least π/4 for c > 0, so that a fixed point
+// with w + 4 fractional bits suffices. For c = 0, the fixed point also
+// covers the t leading zero bits of r.
+func atanBracket(a, b *Int, d, c int64, minus bool, w uint) (lo, hi *Int, exp int64) {
+	if c == 0 {
+		t := int64(b.BitLen()-a.BitLen()) - d
+		if t < 0 {
+			t = 0
+		}
+		q := w + uint(t) + 2
+		sum, err := atanFixed(a, b, d, q)
+		lo = new(Int).Sub(sum, err)
+		hi = sum.Add(sum, err)
+		return lo, hi, -int64(q)
+	}
+
+	q := w + 4
+	sum, err := atanFixed(a, b, d, q)
+	p := piCache.get(q - 1) // π/2, less by less than 2 units
+	p.Mul(p, NewInt(c))
+	err.Add(err, NewInt(2*c))
+	if minus {
+		sum.Sub(p, sum)
+	} else {
+		sum.Add(p, sum)
+	}
+	lo = new(Int).Sub(sum, err)
+	hi = sum.Add(sum, err)
+	return lo, hi, -int64(q)
+}
+
+// atanFixed returns atan(r) × 2**q and a bound on its error in units, for
+// r = a/b × 2**d with 0 < r <= 1. It halves the angle s times with
+// atan(r) = 2 atan(r/(1 + √(1 + r²))), and sums the series atan(u) = u -
+// u**3/3 + u**5/5 - ... for the result u, which is at most 1/2, all in
+// fixed point with q + s fractional bits; the sum is then atan(r) × 2**q.
+//
+// The fixed-point value of r is off by less than 1 unit in the last place.
+// Each halving adds less than 2 units but halves the error before, so u is
+// off by less than 4. Each of the n terms of the series is off by less
+// than 4 units, as is the omitted tail, for a total of less than 4n + 8
+// units.
+func atanFixed(a, b *Int, d int64, q uint) (sum, err *Int) {
+	// each halving halves r, as does each of the t leading zero bits
+	t := int64(b.BitLen()-a.BitLen()) - d
+	if t > int64(q) {
+		// r < 2**-q
+		return new(Int), NewInt(2)
+	}
+	s := uint(math.Sqrt(float64(q))) / 2
+	if t > 0 {
+		if s > uint(t) {
+			s -= uint(t)
+		} else {
+			s = 0
+		}
+	}
+	qs := q + s
+
+	one := new(Int).Lsh(intOne, qs)
+	u := scaledQuo(a, b, int64(qs)+d)
+	var v Int
+	for i := uint(0); i < s; i++ {
+		v.Mul(u, u).Rsh(&v, qs)
+		v.Add(&v, one).Lsh(&v, qs).Sqrt(&v)
+		v.Add(&v, one)
+		u.Lsh(u, qs).Quo(u, &v)
+	}
+
+	u2 := new(Int).Mul(u, u)
+	u2.Rsh(u2, qs)
+	sum = new(Int).Set(u)
+	pow := u // u**(2k+1)
+	var term Int
+	n := int64(1)
+	for len(pow.abs) > 0 {
+		pow.Mul(pow, u2).Rsh(pow, qs)
+		term.QuoInt64(pow, 2*n+1)
+		if n&1 != 0 {
+			sum.Sub(sum, &term)
+		} else {
+			sum.Add(sum, &term)
+		}
+		n++
+	}
+	return sum, NewInt(4*n + 8)
+}
+
+// scaledQuo returns a/b × 2**k truncated to an integer, for a, b > 0.
+func scaledQuo(a, b *Int, k int64) *Int {
+	if k >= 0 {
+		z := new(Int).Lsh(a, uint(k))
+		return z.Quo(z, b)
+	}
+	return new(Int).Quo(a, new(Int).Lsh(b, uint(-k)))
+}
@@ -0,0 +1,299 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// TestFloatPolar64 checks Hypot and Atan2 at precision 53 against
+// math.Hypot and math.Atan2, which are not always correctly rounded.
+func TestFloatPolar64(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		y, x := r.NormFloat64(), r.NormFloat64()
+		switch i % 4 {
+		case 1:
+			y *= 1e300
+		case 2:
+			x *= 1e-300
+		case 3:
+			y, x = math.Ldexp(y, -1060), math.Ldexp(x, -1070)
+		}
+		fy, fx := new(Float).SetFloat64(y), new(Float).SetFloat64(x)
+
+		want := math.Hypot(y, x)
+		got, _ := new(Float).SetPrec(53).Hypot(fy, fx).Float64()
+		if got != want && math.Abs(got-want) > want*0x1p-52 {
+			t.Errorf("Hypot(%g, %g) = %g; want %g", y, x, got, want)
+		}
+
+		want = math.Atan2(y, x)
+		got, _ = new(Float).SetPrec(53).Atan2(fy, fx).Float64()
+		if got != want && math.Abs(got-want) > math.Abs(want)*0x1p-52 {
+			t.Errorf("Atan2(%g, %g) = %g; want %g", y, x, got, want)
+		}
+	}
+}
+
+// TestFloatHypot checks the rounding of Hypot in all modes against the
+// exact sum of squares, with checkSqrt.
+func TestFloatHypot(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, prec := range []uint{1, 2, 10, 53, 64, 100, 500} {
+		for i := 0; i < 20; i++ {
+			exp := 20
+			if i%2 == 1 {
+				exp = 300
+			}
+			x := randFloat(r, uint(r.Intn(500)+1), exp)
+			y := randFloat(r, uint(r.Intn(500)+1), exp)
+			if i < 4 {
+				// Pythagorean triples, whose results are exact
+				m := NewFloat(float64(i + 2))
+				n := NewFloat(float64(i + 1))
+				x.Sub(exactMul(m, m), exactMul(n, n))
+				y.SetMantExp(exactMul(m, n), 1)
+				x.SetMantExp(x, i)
+				y.SetMantExp(y, i)
+			}
+			s := new(Float).SetPrec(4000).Add(exactMul(x, x), exactMul(y, y))
+			for _, mode := range []RoundingMode{ToNearestEven, ToNearestAway, ToZero, AwayFromZero, ToNegativeInf, ToPositiveInf} {
+				z := new(Float).SetPrec(prec).SetMode(mode).Hypot(x, y)
+				checkSqrt(t, s, z, mode)
+			}
+		}
+	}
+}
+
+// atanRef returns atan(r) for 0 <= r <= 1 at precision prec, with a few
+// more bits than that of error, by halving the angle eight times and
+// summing the Taylor series.
+func atanRef(r *Float, prec uint) *Float {
+	one := NewFloat(1)
+	u := new(Float).SetPrec(prec).Set(r)
+	v := new(Float).SetPrec(prec)
+	for i := 0; i < 8; i++ {
+		v.Mul(u, u).Add(v, one).Sqrt(v).Add(v, one)
+		u.Quo(u, v)
+	}
+	u2 := new(Float).SetPrec(prec).Mul(u, u)
+	sum := new(Float).SetPrec(prec).Set(u)
+	pow := new(Float).SetPrec(prec).Set(u)
+	term := new(Float).SetPrec(prec)
+	for n := 1; pow.Sign() != 0; n++ {
+		pow.Mul(pow, u2)
+		term.Quo(pow, NewFloat(float64(2*n+1)))
+		if term.MantExp(nil) < sum.MantExp(nil)-int(prec)-4 {
+			break
+		}
+		if n%2 != 0 {
+			sum.Sub(sum, term)
+		} else {
+			sum.Add(sum, term)
+		}
+	}
+	return sum.SetMantExp(sum, 8)
+}
+
+// atan2Ref returns Atan2(y, x) for finite, non-zero y and x at precision
+// prec, with a few more bits than that of error.
+func atan2Ref(y, x *Float, prec uint) *Float {
+	a := new(Float).Abs(y)
+	b := new(Float).Abs(x)
+	var z *Float
+	if a.Cmp(b) <= 0 {
+		z = atanRef(new(Float).SetPrec(prec).Quo(a, b), prec)
+	} else {
+		z = atanRef(new(Float).SetPrec(prec).Quo(b, a), prec)
+		halfPi := Pi(prec)
+		z.Sub(halfPi.SetMantExp(halfPi, -1), z)
+	}
+	if x.Sign() < 0 {
+		z.Sub(Pi(prec), z)
+	}
+	if y.Sign() < 0 {
+		z.Neg(z)
+	}
+	return z
+}
+
+func TestFloatAtan2(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for _, prec := range []uint{1, 2, 10, 53, 64, 100, 500} {
+		for i := 0; i < 20; i++ {
+			y := randFloat(r, uint(r.Intn(100)+1), 10)
+			x := randFloat(r, uint(r.Intn(100)+1), 10)
+			if y.Sign() == 0 || x.Sign() == 0 {
+				continue
+			}
+			if i < 4 {
+				// |y| = |x|, or one of them short
+				neg := y.Sign() < 0
+				y.SetPrec(uint(i + 1)).Abs(x)
+				if neg {
+					y.Neg(y)
+				}
+			}
+			ref := atan2Ref(y, x, prec+100)
+			for _, mode := range []RoundingMode{ToNearestEven, ToNearestAway, ToZero, AwayFromZero, ToNegativeInf, ToPositiveInf} {
+				z := new(Float).SetPrec(prec).SetMode(mode).Atan2(y, x)
+				want := new(Float).SetPrec(prec).SetMode(mode).Set(ref)
+				if z.Cmp(want) != 0 || z.Acc() != want.Acc() {
+					t.Errorf("prec %d, %s: Atan2(%s, %s) = %s (%s); want %s (%s)", prec, mode, y.Text('p', 0), x.Text('p', 0), z.Text('p', 0), z.Acc(), want.Text('p', 0), want.Acc())
+				}
+			}
+		}
+	}
+}
+
+// TestFloatAtan2Pi checks Atan2 in all quadrants against multiples of π/4,
+// which are scaled values of Pi.
+func TestFloatAtan2Pi(t *testing.T) {
+	for _, prec := range []uint{1, 10, 53, 100, 1000} {
+		for _, mode := range []RoundingMode{ToNearestEven, ToNearestAway, ToZero, AwayFromZero, ToNegativeInf, ToPositiveInf} {
+			for _, test := range []struct {
+				y, x string
+				k    int64 // the result is k × π/4
+			}{
+				{"1", "1", 1},
+				{"-0x1p100", "0x1p100", -1},
+				{"2.5", "-2.5", 3},
+				{"-3", "-3", -3},
+				{"1", "0", 2},
+				{"-1", "-0", -2},
+				{"0", "-1", 4},
+				{"-0", "-Inf", -4},
+				{"+Inf", "+Inf", 1},
+				{"-Inf", "-Inf", -3},
+				{"-Inf", "1", -2},
+				{"1e1000", "-Inf", 4},
+			} {
+				want := new(Float).SetPrec(prec).SetMode(mode)
+				want.setPiMultiple(test.k, false)
+				if test.k < 0 {
+					want.setPiMultiple(-test.k, true)
+				}
+				z := new(Float).SetPrec(prec).SetMode(mode).Atan2(makeFloat(test.y), makeFloat(test.x))
+				if z.Cmp(want) != 0 || z.Acc() != want.Acc() {
+					t.Errorf("prec %d, %s: Atan2(%s, %s) = %s (%s); want %s (%s)", prec, mode, test.y, test.x, z.Text('p', 0), z.Acc(), want.Text('p', 0), want.Acc())
+				}
+				// k × π/4 is k/4 × Pi, rounded alike
+				p := new(Float).SetMode(mode).SetPrec(prec)
+				pi := Pi(prec + 64)
+				p.Mul(pi, NewFloat(float64(test.k)/4))
+				if p.Cmp(want) != 0 {
+					t.Errorf("prec %d, %s: %d × π/4 = %s; want %s", prec, mode, test.k, want.Text('p', 0), p.Text('p', 0))
+				}
+			}
+		}
+	}
+}
+
+func TestFloatPolarSpecial(t *testing.T) {
+	for _, test := range []struct {
+		x, y string
+		want string
+		acc  Accuracy
+	}{
+		{"0", "0", "0", Exact},
+		{"-0", "-0", "0", Exact},
+		{"-3", "0", "3", Exact},
+		{"-0", "-3", "3", Exact},
+		{"3", "-4", "5", Exact},
+		{"1", "1", "0x1.6a09e668p0", Above},
+		{"+Inf", "0", "+Inf", Exact},
+		{"1", "-Inf", "+Inf", Exact},
+		{"-Inf", "+Inf", "+Inf", Exact},
+		// a tiny y only decides the rounding
+		{"0x1.00000002p0", "0x1p-1000000", "0x1.00000002p0", Below},
+		// x² and y² out of range, but not √(x² + y²)
+		{"0x1.8p1073741822", "0x1p1073741823", "0x1.4p1073741823", Exact},
+		{"-0x1.8p-1073741900", "0x1p-1073741899", "0x1.4p-1073741899", Exact},
+		{"0x1.8p2147483646", "0x1.8p2147483646", "+Inf", Above},
+	} {
+		x, y := makeFloat(test.x), makeFloat(test.y)
+		z := new(Float).SetPrec(32).Hypot(x, y)
+		if got := z.Text('p', 0); got != makeFloat(test.want).Text('p', 0) || z.Acc() != test.acc {
+			t.Errorf("Hypot(%s, %s) = %s (%s); want %s (%s)", test.x, test.y, got, z.Acc(), test.want, test.acc)
+		}
+	}
+
+	for _, test := range []struct {
+		y, x string
+		want string
+		acc  Accuracy
+	}{
+		{"0", "1", "0", Exact},
+		{"-0", "+0", "-0", Exact},
+		{"-0", "+Inf", "-0", Exact},
+		{"3", "+Inf", "0", Exact},
+		{"-1e1000", "+Inf", "-0", Exact},
+		{"1", "0x1p1000000", "0x1p-1000000", Above},
+		{"-0x1p-1000000", "-1", "-0x1.921fb544p1", Above},
+		{"0x1.8p0", "0x1p1073741824", "0x1.8p-1073741824", Above},
+		{"0x1p1073741824", "0x1p-1073741824", "0x1.921fb544p0", Below},
+		{"0x1p-1073741824", "0x1p1073741824", "0x1p-2147483648", Above},
+		{"0x1p-1073741830", "0x1p1073741824", "0", Below},
+		{"1", "2", "0x1.dac67056p-2", Below},
+		{"-2", "-1", "-0x1.0468a8acp1", Above},
+	} {
+		y, x := makeFloat(test.y), makeFloat(test.x)
+		z := new(Float).SetPrec(32).Atan2(y, x)
+		if got := z.Text('p', 0); got != makeFloat(test.want).Text('p', 0) || z.Acc() != test.acc {
+			t.Errorf("Atan2(%s, %s) = %s (%s); want %s (%s)", test.y, test.x, got, z.Acc(), test.want, test.acc)
+		}
+	}
+
+	// z may be x or y
+	x, y := NewFloat(1.5), NewFloat(-2.25)
+	for _, f := range []struct {
+		name string
+		op   func(z, x, y *Float) *Float
+	}{
+		{"Hypot", (*Float).Hypot},
+		{"Atan2", (*Float).Atan2},
+	} {
+		want := f.op(new(Float), x, y)
+		for i := 0; i < 2; i++ {
+			ops := []*Float{new(Float).Copy(x), new(Float).Copy(y)}
+			if got := f.op(ops[i], ops[0], ops[1]); got.Cmp(want) != 0 {
+				t.Errorf("%s aliased with operand %d = %s; want %s", f.name, i, got.Text('p', 0), want.Text('p', 0))
+			}
+		}
+		if z := f.op(new(Float), new(Float).SetPrec(20), new(Float).SetPrec(70).SetInt64(1)); z.Prec() != 70 {
+			t.Errorf("%s with precision 0: got precision %d; want 70", f.name, z.Prec())
+		}
+	}
+}
+
+func BenchmarkFloatHypot(b *testing.B) {
+	for _, prec := range []uint{64, 1000, 10000} {
+		x := new(Float).SetPrec(prec).SetFloat64(1.2345)
+		y := new(Float).SetPrec(prec).SetFloat64(6.789)
+		z := new(Float).SetPrec(prec)
+		b.Run(fmt.Sprintf("%v", prec), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				z.Hypot(x, y)
+			}
+		})
+	}
+}
+
+func BenchmarkFloatAtan2(b *testing.B) {
+	for _, prec := range []uint{64, 1000, 10000} {
+		y := new(Float).SetPrec(prec).SetFloat64(1.2345)
+		x := new(Float).SetPrec(prec).SetFloat64(-6.789)
+		z := new(Float).SetPrec(prec)
+		b.Run(fmt.Sprintf("%v", prec), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				z.Atan2(y, x)
+			}
+		})
+	}
+}
@@ -0,0 +1,248 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Float.Pow.
+
+package big
+
+// Pow sets z to the rounded value of x**y, and returns z.
+// If z's precision is 0, it is changed to the larger of x's or y's
+// precision before the operation. The result is the exact value rounded
+// according to z's precision and rounding mode, and its accuracy is
+// reported as for Add. Unlike computing Exp(y × Log(x)), Pow is
+// correctly rounded for all x and y, including negative x with integer
+// y; a result outside the exponent range of Float overflows to ±Inf or
+// underflows to ±0.
+//
+// Special cases are, as for math.Pow:
+//
+//	Pow(x, ±0) = 1 for any x
+//	Pow(1, y) = 1 for any y
+//	Pow(x, 1) = x for any x
+//	Pow(±0, y) = ±Inf for y an odd integer < 0
+//	Pow(±0, -Inf) = +Inf
+//	Pow(±0, y) = +Inf for finite y < 0 and not an odd integer
+//	Pow(±0, y) = ±0 for y an odd integer > 0
+//	Pow(±0, y) = +0 for finite y > 0 and not an odd integer
+//	Pow(-1, ±Inf) = 1
+//	Pow(x, +Inf) = +Inf for |x| > 1
+//	Pow(x, -Inf) = +0 for |x| > 1
+//	Pow(x, +Inf) = +0 for |x| < 1
+//	Pow(x, -Inf) = +Inf for |x| < 1
+//	Pow(+Inf, y) = +Inf for y > 0
+//	Pow(+Inf, y) = +0 for y < 0
+//	Pow(-Inf, y) = Pow(-0, -y)
+//
+// Pow panics with ErrNaN for finite x < 0 and finite y that is not an
+// integer; the value of z is undefined in that case.
+func (z *Float) Pow(x, y *Float) *Float {
+	if debugFloat {
+		x.validate()
+		y.validate()
+	}
+
+	if z.prec == 0 {
+		z.prec = umax32(x.prec, y.prec)
+	}
+
+	switch {
+	case y.form == zero || x.Cmp(floatOne) == 0:
+		return z.SetInt64(1)
+	case y.Cmp(floatOne) == 0:
+		return z.Set(x)
+	}
+
+	// ±x**y for odd integers y
+	neg := x.neg && y.isOddInt()
+
+	z.acc = Exact
+	switch {
+	case x.form == zero:
+		z.neg = neg
+		if y.neg {
+			z.form = inf
+		} else {
+			z.form = zero
+		}
+		return z
+	case y.form == inf:
+		z.neg = false
+		c := 1 // |x| > 1
+		if x.form == finite {
+			c = x.ucmp(floatOne)
+		}
+		switch {
+		case c == 0: // x == -1
+			return z.SetInt64(1)
+		case (c < 0) == y.neg:
+			z.form = inf
+		default:
+			z.form = zero
+		}
+		return z
+	case x.form == inf:
+		z.neg = neg
+		if y.neg {
+			z.form = zero
+		} else {
+			z.form = inf
+		}
+		return z
+	case x.neg && !y.IsInt():
+		// value of z is undefined but make sure it's valid
+		z.form = zero
+		z.neg = false
+		panic(ErrNaN{"power of negative operand with non-integer exponent"})
+	}
+
+	// x, y finite, x != 0, ±1 and y != 0, 1
+	if m, exp, ok := powExact(x, y, z.prec); ok {
+		m.neg = neg
+		return z.setScaled(m, exp)
+	}
+	// x**y is not a value of precision z.prec or a midpoint between two.
+
+	// t = y × log|x| decides overflow, underflow, and results close to 1.
+	ax := new(Float).Abs(x)
+	t := new(Float).SetPrec(64).Log(ax)
+	t.Mul(t, y)
+	switch {
+	case t.form == zero || int64(t.exp) < -int64(z.prec)-2:
+		// |t| < 2**-(z.prec+2), or t underflowed
+		return z.setExpTiny(t.neg, neg)
+	case t.form == inf || t.exp > 31:
+		// |t| >= 2**31 > MaxExp × ln(2)
+		z.neg = neg
+		if t.neg {
+			z.setExpAndRound(MinExp-1, 0) // underflow
+		} else {
+			z.setExpAndRound(MaxExp+1, 0) // overflow
+		}
+		return z
+	}
+
+	f, e, tz := logReduce(ax)
+	ym, yexp := y.oddExp()
+	ybits := uint(0) // |y| < 2**ybits
+	if y.exp > 0 {
+		ybits = uint(y.exp)
+	}
+	return z.setBracketed(func(w uint) (lo, hi *Int, exp int64) {
+		// t = y × log|x| lies between y × l and y × h, in some order,
+		// within 2**-w of it, relative to x**y
+		l, h, lexp := logBracket(f, e, tz, w+ybits)
+		tl := scaledFloat(l.Mul(l, ym), lexp+yexp)
+		th := scaledFloat(h.Mul(h, ym), lexp+yexp)
+		if ym.neg {
+			tl, th = th, tl
+		}
+
+		// x**y lies between e**tl and e**th
+		return expBetween(tl, th, w, neg)
+	})
+}
+
+// expBetween returns lo, hi, and exp for setBracketed for ±e**t, with the
+// sign neg, for a t with tl <= t <= th.
+func expBetween(tl, th *Float, w uint, neg bool) (lo, hi *Int, exp int64) {
+	lo, _, exp = expBracket(tl, w)
+	_, hi, hexp := expBracket(th, w)
+	if hexp > exp {
+		hi.Lsh(hi, uint(hexp-exp))
+	} else {
+		lo.Lsh(lo, uint(exp-hexp))
+		exp = hexp
+	}
+	if neg {
+		lo, hi = hi.Neg(hi), lo.Neg(lo)
+	}
+	return lo, hi, exp
+}
+
+// powExact returns m and exp with |x|**y = m × 2**exp, if this value may
+// be a value of precision prec or a midpoint between two such values,
+// that is, if it is a dyadic rational of at most prec + 1 significant bits;
+// then ok is true. Otherwise, x**y is irrational, or its significant bits
+// are more than that, and ok is false. x and y must be finite and non-zero.
+func powExact(x, y *Float, prec uint32) (m *Int, exp int64, ok bool) {
+	// |x| = xm × 2**xe with odd xm, and y = n / 2**k with odd n for k > 0
+	xm, xe := x.oddExp()
+	xm.neg = false
+	n, ye := y.oddExp()
+	var k uint
+	if ye >= 0 {
+		n.Lsh(n, uint(ye))
+	} else {
+		k = uint(-ye)
+	}
+
+	// For k > 0, x**y is rational only if |x| is a perfect 2**k-th power,
+	// because n is odd.
+	if k > 0 {
+		if k >= 63 && xe != 0 || k < 63 && xe&(1<<k-1) != 0 {
+			return nil, 0, false
+		}
+		xe >>= k
+		var r, sq Int
+		for i := uint(0); i < k && xm.Cmp(intOne) != 0; i++ {
+			r.Sqrt(xm)
+			if sq.Mul(&r, &r).Cmp(xm) != 0 {
+				return nil, 0, false
+			}
+			xm.Set(&r)
+		}
+	}
+
+	// |x|**y = xm**n × 2**(xe × n)
+	bits := xm.BitLen()
+	if bits > 1 {
+		// xm**n is not dyadic for n < 0, and has more than
+		// n × (bits - 1) + 1 > prec + 1 significant bits for a larger n
+		limit := NewInt(int64(prec) / int64(bits-1))
+		if n.neg || n.Cmp(limit) > 0 {
+			return nil, 0, false
+		}
+		xm.Exp(xm, n, nil)
+	}
+
+	// clamp the exponent to overflow or underflow in setScaled
+	const maxExp = 1 << 40
+	e := new(Int).Mul(NewInt(xe), n)
+	switch {
+	case e.Cmp(NewInt(maxExp)) > 0:
+		exp = maxExp
+	case e.Cmp(NewInt(-maxExp)) < 0:
+		exp = -maxExp
+	default:
+		exp = e.Int64()
+	}
+	return xm, exp, true
+}
+
+// oddExp returns the odd integer m and the exponent e with x = m × 2**e,
+// for finite x != 0.
+func (x *Float) oddExp() (m *Int, e int64) {
+	m = new(Int)
+	m.abs = m.abs.set(x.mant)
+	tz := m.abs.trailingZeroBits()
+	m.abs = m.abs.shr(m.abs, tz)
+	m.neg = x.neg
+	return m, int64(x.exp) - int64(len(x.mant))*_W + int64(tz)
+}
+
+// isOddInt reports whether x is an odd integer.
+func (x *Float) isOddInt() bool {
+	if x.form != finite {
+		return false
+	}
+	_, e := x.oddExp()
+	return e == 0
+}
+
+// scaledFloat returns the value of m × 2**exp as a Float of sufficient
+// precision.
+func scaledFloat(m *Int, exp int64) *Float {
+	z := &Float{prec: umax32(uint32(m.BitLen()), 1)}
+	return z.setScaled(m, exp)
+}
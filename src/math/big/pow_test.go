@@ -0,0 +1,227 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// TestFloatPow64 checks Pow at precision 53 against math.Pow: exactly for
+// the special cases, and otherwise roughly, because the errors of
+// math.Pow grow with the magnitude of y × log(x).
+func TestFloatPow64(t *testing.T) {
+	inf := math.Inf(1)
+	vals := []float64{0, 1, 2, 3, 0.5, 0.25, 0.75, 1.5, 2.5, 10, 1e10, 1e-10, 1e300, 1e-300, 0.3, -0.5, -1.5, 1024, inf}
+	for _, v := range vals {
+		vals = append(vals, -v)
+	}
+	var xs, ys []float64
+	xs = append(xs, vals...)
+	ys = append(ys, vals...)
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 50; i++ {
+		xs = append(xs, r.ExpFloat64()*10)
+		ys = append(ys, r.NormFloat64()*10)
+	}
+	for _, x := range xs {
+		for _, y := range ys {
+			want := math.Pow(x, y)
+			if math.IsNaN(want) {
+				continue
+			}
+			got, _ := new(Float).SetPrec(53).Pow(NewFloat(x), NewFloat(y)).Float64()
+			switch {
+			case got == want && math.Signbit(got) == math.Signbit(want):
+			case !math.IsInf(want, 0) && want != 0 && math.Abs(got-want) <= math.Abs(want)*0x1p-40:
+			default:
+				t.Errorf("Pow(%g, %g) = %g; want %g", x, y, got, want)
+			}
+		}
+	}
+}
+
+func TestFloatPowExact(t *testing.T) {
+	for _, test := range []struct {
+		x, y, want string
+	}{
+		{"4", "0.5", "2"},
+		{"9", "0.5", "3"},
+		{"9", "1.5", "27"},
+		{"9", "-0.5", "1/3"},
+		{"27", "-1", "1/27"},
+		{"0.0625", "0.25", "0.5"},
+		{"0.0625", "-0.75", "8"},
+		{"2", "-100", "1/1267650600228229401496703205376"},
+		{"0x1p40", "0.125", "32"},
+		{"3", "20", "3486784401"},
+		{"-3", "21", "-10460353203"},
+		{"-3", "-2", "1/9"},
+		{"6561", "0x1p-3", "3"},
+		{"1.5", "4", "5.0625"},
+		{"-0.5", "-3", "-8"},
+		{"-1", "12345678901234567890", "1"},
+		{"-1", "12345678901234567891", "-1"},
+	} {
+		x, y := makeFloat(test.x), makeFloat(test.y)
+		exact, ok := new(Rat).SetString(test.want)
+		if !ok {
+			t.Fatalf("invalid test value %s", test.want)
+		}
+		for _, prec := range []uint{1, 2, 3, 5, 10, 40, 128} {
+			for _, mode := range []RoundingMode{ToNearestEven, ToNearestAway, ToZero, AwayFromZero, ToNegativeInf, ToPositiveInf} {
+				want := new(Float).SetPrec(prec).SetMode(mode).SetRat(exact)
+				got := new(Float).SetPrec(prec).SetMode(mode).Pow(x, y)
+				if got.Cmp(want) != 0 || got.Acc() != want.Acc() {
+					t.Errorf("Pow(%s, %s) at precision %d in mode %s = %s (%s); want %s (%s)",
+						test.x, test.y, prec, mode, got.Text('g', 20), got.Acc(), want.Text('g', 20), want.Acc())
+				}
+			}
+		}
+	}
+}
+
+// TestFloatPowRounding checks that Pow rounds correctly in all modes,
+// against the results at 100 more bits rounded to nearest, which lie close
+// enough to the exact value for the random arguments.
+func TestFloatPowRounding(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randFloat := func(maxPrec, expRange int) *Float {
+		x := new(Float).SetPrec(uint(r.Intn(maxPrec) + 1))
+		x.SetInt(new(Int).Rand(r, new(Int).Lsh(intOne, x.Prec())))
+		x.SetMantExp(x, r.Intn(2*expRange+1)-expRange-int(x.Prec()))
+		if r.Intn(2) == 0 {
+			x.Neg(x)
+		}
+		return x
+	}
+	for _, prec := range []uint{1, 2, 10, 24, 53, 64, 100, 500} {
+		for i := 0; i < 30; i++ {
+			x, y := randFloat(100, 20), randFloat(80, 10)
+			switch {
+			case x.Sign() == 0 || y.Sign() == 0:
+				continue
+			case i%3 == 0:
+				// integer y, x of either sign
+				yi, _ := y.Int(nil)
+				y.SetInt(yi.Rem(yi, NewInt(200)))
+			default:
+				x.Abs(x)
+			}
+			ref := new(Float).SetPrec(prec+100).Pow(x, y)
+			for _, mode := range []RoundingMode{ToNearestEven, ToNearestAway, ToZero, AwayFromZero, ToNegativeInf, ToPositiveInf} {
+				want := new(Float).SetPrec(prec).SetMode(mode).Set(ref)
+				got := new(Float).SetPrec(prec).SetMode(mode).Pow(x, y)
+				if got.Cmp(want) != 0 || got.Acc() != want.Acc() {
+					t.Errorf("Pow(%s, %s) at precision %d in mode %s = %s (%s); want %s (%s)",
+						x.Text('p', 0), y.Text('p', 0), prec, mode, got.Text('p', 0), got.Acc(), want.Text('p', 0), want.Acc())
+				}
+			}
+		}
+	}
+}
+
+func TestFloatPowIdentities(t *testing.T) {
+	r := rand.New(rand.NewSource(2))
+	for _, prec := range []uint{53, 200, 1000} {
+		for i := 0; i < 20; i++ {
+			x := new(Float).SetPrec(prec).SetFloat64(r.ExpFloat64() * 100)
+			for _, test := range []struct {
+				y    float64
+				want *Float
+			}{
+				{0.5, new(Float).SetPrec(prec).Sqrt(x)},
+				{-1, new(Float).SetPrec(prec).Quo(floatOne, x)},
+				{2, new(Float).SetPrec(prec).Mul(x, x)},
+				{-2, new(Float).SetPrec(prec).Quo(floatOne, new(Float).SetPrec(2*prec).Mul(x, x))},
+				{3, new(Float).SetPrec(prec).Mul(x, new(Float).SetPrec(2*prec).Mul(x, x))},
+			} {
+				if got := new(Float).SetPrec(prec).Pow(x, NewFloat(test.y)); got.Cmp(test.want) != 0 {
+					t.Errorf("precision %d: Pow(%s, %g) = %s; want %s", prec, x.Text('g', 20), test.y, got.Text('g', 30), test.want.Text('g', 30))
+				}
+			}
+			// Pow(e, y) = Exp(y)
+			y := new(Float).SetPrec(prec).SetFloat64(r.NormFloat64() * 10)
+			e := new(Float).SetPrec(prec + 64).Exp(floatOne)
+			p := new(Float).SetPrec(prec).Pow(e, y)
+			want := new(Float).SetPrec(prec).Exp(y)
+			if d := new(Float).Sub(p, want); d.Sign() != 0 && d.MantExp(nil)-want.MantExp(nil) > -int(prec)+1 {
+				t.Errorf("precision %d: Pow(e, %s) = %s; want %s", prec, y.Text('g', 20), p.Text('g', 30), want.Text('g', 30))
+			}
+		}
+	}
+}
+
+func TestFloatPowSpecial(t *testing.T) {
+	for _, test := range []struct {
+		x, y, want string
+		acc        Accuracy
+	}{
+		// results close to 1
+		{"2", "0x1p-100", "1", Below},
+		{"2", "-0x1p-100", "1", Above},
+		{"0x1p-1000000", "0x1p-100", "1", Above},
+		{"3", "0x1p-2000000000", "1", Below},
+		// |x| close to 1, large y
+		{"0x1.000000000000001p0", "0x1p70", "5.218545434e+444", Below},
+		{"-0x1.000000000000001p0", "0x1p70", "5.218545434e+444", Below},
+		{"-0x1.000000000000001p0", "1180591620717411303425", "-5.218545434e+444", Above},
+		{"0x1.000000000000001p0", "0x1p100", "+Inf", Above},
+		{"-0x1.000000000000001p0", "1267650600228229401496703205377", "-Inf", Below},
+		{"0x0.ffffffffffffffffp0", "0x1p100", "0", Below},
+		{"10", "1e10", "+Inf", Above},
+		{"2", "1e15", "+Inf", Above},
+		{"0.5", "1e15", "0", Below},
+		{"-2", "1000000000000001", "-Inf", Below},
+		{"10", "-1e10", "0", Below},
+		{"-10", "-10000000001", "-0", Above},
+		{"1e-1000", "2.5", "1e-2500", Above},
+	} {
+		x, y := makeFloat(test.x), makeFloat(test.y)
+		z := new(Float).SetPrec(32).Pow(x, y)
+		if got := z.Text('g', 10); got != test.want || z.Acc() != test.acc {
+			t.Errorf("Pow(%s, %s) = %s (%s); want %s (%s)", test.x, test.y, got, z.Acc(), test.want, test.acc)
+		}
+		// z may be x or y
+		want := new(Float).SetPrec(x.Prec()).Pow(x, y)
+		if got := new(Float).Copy(x); got.Pow(got, y).Cmp(want) != 0 {
+			t.Errorf("Pow(%s, %s) aliased with x = %s; want %s", test.x, test.y, got.Text('g', 10), want.Text('g', 10))
+		}
+		want.SetPrec(0).SetPrec(y.Prec()).Pow(x, y)
+		if got := new(Float).Copy(y); got.Pow(x, got).Cmp(want) != 0 {
+			t.Errorf("Pow(%s, %s) aliased with y = %s; want %s", test.x, test.y, got.Text('g', 10), want.Text('g', 10))
+		}
+	}
+
+	if z := new(Float).Pow(new(Float).SetPrec(20).SetInt64(3), new(Float).SetPrec(70).SetFloat64(0.5)); z.Prec() != 70 {
+		t.Errorf("Pow with precision 0: got precision %d; want 70", z.Prec())
+	}
+
+	for _, test := range [][2]float64{{-2, 0.5}, {-1, 1.5}, {-0x1p-100, -0.25}} {
+		func() {
+			defer func() {
+				if _, ok := recover().(ErrNaN); !ok {
+					t.Errorf("Pow(%g, %g) did not panic with ErrNaN", test[0], test[1])
+				}
+			}()
+			new(Float).Pow(NewFloat(test[0]), NewFloat(test[1]))
+		}()
+	}
+}
+
+func BenchmarkFloatPow(b *testing.B) {
+	for _, prec := range []uint{64, 256, 1000, 10000} {
+		x := new(Float).SetPrec(prec).SetFloat64(1.2345)
+		y := new(Float).SetPrec(prec).SetFloat64(6.789)
+		z := new(Float).SetPrec(prec)
+		b.Run(fmt.Sprintf("%v", prec), func(b *testing.B) {
+			for n := 0; n < b.N; n++ {
+				z.Pow(x, y)
+			}
+		})
+	}
+}
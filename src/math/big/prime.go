@@ -6,6 +6,13 @@ package big
 
 import "math/rand"
 
+// primesA and primesB are products of the odd primes < 54,
+// grouped so that each product fits into a 32-bit Word.
+const (
+	primesA = 3 * 5 * 7 * 11 * 13 * 17 * 19 * 23 * 37
+	primesB = 29 * 31 * 41 * 43 * 47 * 53
+)
+
 // ProbablyPrime reports whether x is probably prime,
 // applying the Miller-Rabin test with n pseudorandomly chosen bases
 // as well as a Baillie-PSW test.
@@ -54,9 +61,6 @@ func (x *Int) ProbablyPrime(n int) bool {
 		return false // n is even
 	}
 
-	const primesA = 3 * 5 * 7 * 11 * 13 * 17 * 19 * 23 * 37
-	const primesB = 29 * 31 * 41 * 43 * 47 * 53
-
 	var rA, rB uint32
 	switch _W {
 	case 32:
@@ -0,0 +1,249 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements constant-time primality tests,
+// for testing secret candidates during key generation.
+
+package big
+
+import "math/rand"
+
+// ctwosMasks returns a slice t of len(x)*_W+1 words, in which t[i] is 1
+// if the i least significant bits of x are zero and 0 otherwise; that
+// is, for x = 2**r * s with odd s, t[i] is 1 if and only if i ≤ r. The
+// masks are computed without branching on the value of x.
+func (x nat) ctwosMasks() []Word {
+	t := make([]Word, len(x)*_W+1)
+	var acc Word = 1
+	for i := range t {
+		t[i] = acc
+		if i < len(x)*_W {
+			acc &= x[i/_W]>>(uint(i)%_W)&1 ^ 1
+		}
+	}
+	return t
+}
+
+// clucasV computes the Lucas sequence values V(k) and V(k+1) for the
+// parameters (P, 1) modulo n, in Montgomery form. n must be odd, k0 must
+// be its Montgomery constant, and pm and two must be P and 2 in Montgomery
+// form. The Lucas chain runs over all len(k)*_W bits of k, including
+// leading zeros, and selects its steps with masked swaps, so the running
+// time and memory access pattern depend only on len(k) and len(n).
+//
+// If visit is not nil, it is called after each step with the bit
+// position i of k just processed and the values V(k>>i) and V(k>>i + 1).
+func clucasV(k, n nat, k0 Word, pm, two nat, visit func(i int, vk, vk1 nat)) (vk, vk1 nat) {
+	// V(0) = 2, V(1) = P
+	vk = nat(nil).set(two)
+	vk1 = nat(nil).set(pm)
+	t := nat(nil).make(len(n))
+	for i := len(k)*_W - 1; i >= 0; i-- {
+		b := k[i/_W] >> (uint(i) % _W) & 1
+		// For b == 0, (V(k), V(k+1)) becomes (V(2k), V(2k+1)), and for
+		// b == 1, (V(2k+1), V(2k+2)). With the operands swapped for
+		// b == 1, both use V(2j) = V(j)² - 2, V(2j+1) = V(j) V(j+1) - P.
+		cswap(vk, vk1, b)
+		t = t.cmontMul(vk, vk1, n, k0)
+		vk1 = vk1.csubMod(t, pm, n)
+		t = t.cmontMul(vk, vk, n, k0)
+		vk = vk.csubMod(t, two, n)
+		cswap(vk, vk1, b)
+		if visit != nil {
+			visit(i, vk, vk1)
+		}
+	}
+	return vk, vk1
+}
+
+// cprobablyPrimeLucas is like probablyPrimeLucas, but evaluates the
+// Lucas sequence in constant time with respect to the value of n, and
+// returns 1 if n passes the almost extra strong Lucas test and 0 otherwise.
+// n must be normalized, odd, and > 2.
+//
+// The parameter P is chosen as in probablyPrimeLucas, by computing Jacobi
+// symbols in variable time. This reveals which small values D = P² - 4 are
+// quadratic residues modulo n, which for a random candidate amounts to a
+// few bits of information about n, and composites with a small factor
+// P+2 are rejected early. The Lucas chain, however, runs for all bits of
+// n+1 regardless of its value, and the final checks of the test are
+// combined with masks.
+func (n nat) cprobablyPrimeLucas() Word {
+	if len(n) == 0 || n[0]&1 == 0 || n.cmp(natTwo) <= 0 {
+		panic("math/big: cprobablyPrimeLucas requires odd n > 2")
+	}
+
+	// Choose P ≥ 3 such that Jacobi(P² - 4, n) = -1, as in probablyPrimeLucas.
+	p := Word(3)
+	intD := &Int{abs: nat{1}}
+	intN := &Int{abs: n}
+	for ; ; p++ {
+		if p > 10000 {
+			panic("math/big: internal error: cannot find (D/n) = -1")
+		}
+		intD.abs[0] = p*p - 4
+		j := Jacobi(intD, intN)
+		if j == -1 {
+			break
+		}
+		if j == 0 {
+			// P+2 divides n; see probablyPrimeLucas
+			return boolWord(len(n) == 1 && n[0] == p+2)
+		}
+		if p == 40 {
+			// n may be a square, for which there is no such P.
+			t := nat(nil).sqrt(n)
+			if t.mul(t, t).cmp(n) == 0 {
+				return 0
+			}
+		}
+	}
+
+	// P and 2 in Montgomery form
+	l := len(n)
+	k0 := montgomeryK0(n[0])
+	RR := cmontgomeryRR(n)
+	pm := nat(nil).cmod(nat(nil).setWord(p), n)
+	pm = nat(nil).cmontMul(pm, RR, n, k0)
+	two := nat(nil).cmod(natTwo, n)
+	two = nat(nil).cmontMul(two, RR, n, k0)
+
+	// twos[i] is 1 if i ≤ r for n+1 = 2**r * s with odd s.
+	k := nat(nil).cadd(n, natOne, l+1)
+	twos := k.ctwosMasks()
+
+	// Run the chain for k = n+1. After processing bit i, the chain holds
+	// V(k>>i); in particular, V(s) for i == r and V(2**t * s) for r-t == i.
+	vs := nat(nil).make(l)
+	vs1 := nat(nil).make(l)
+	var zero Word
+	clucasV(k, n, k0, pm, two, func(i int, vk, vk1 nat) {
+		atS := twos[i] & (twos[i+1] ^ 1) // i == r
+		vs.sel(vk, vs, atS)
+		vs1.sel(vk1, vs1, atS)
+		// V(2**t * s) ≡ 0 for some 0 ≤ t < r-1, that is, 2 ≤ i ≤ r
+		if i >= 2 {
+			zero |= twos[i] & (vk.cnonzero() ^ 1)
+		}
+	})
+
+	// V(s) ≡ ±2 and U(s) ≡ 0, which is checked as P V(s) ≡ 2 V(s+1);
+	// see probablyPrimeLucas.
+	mtwo := nat(nil).make(l)
+	mtwo.clear()
+	mtwo = mtwo.csubMod(mtwo, two, n)
+	pm2 := vs.ceq(two) | vs.ceq(mtwo)
+	lhs := nat(nil).cmontMul(vs, pm, n, k0)
+	rhs := nat(nil).caddMod(vs1, vs1, n)
+	return pm2&lhs.ceq(rhs) | zero
+}
+
+// mrBases are the bases of the first rounds of cprobablyPrimeMillerRabin.
+// With all of them, the Miller-Rabin test is deterministic for n < 3.3e24.
+var mrBases = [...]Word{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41}
+
+// cprobablyPrimeMillerRabin is like probablyPrimeMillerRabin, but runs
+// in constant time with respect to the value of n, and returns 1 if n
+// passes reps rounds of the Miller-Rabin test and 0 otherwise. n must be
+// odd and > 2.
+//
+// The bases do not depend on n: the first rounds use the primes in
+// mrBases, and further rounds use pseudo-random bases from a fixed seed.
+// Each round computes a**(n-1) over all bits of n-1, recording a**q and
+// the values a**(q * 2**j) for n-1 = 2**k * q with masks, and the verdicts
+// of all rounds are combined with masks, so that no round exits early.
+func (n nat) cprobablyPrimeMillerRabin(reps int) Word {
+	if len(n) == 0 || n[0]&1 == 0 {
+		panic("math/big: cprobablyPrimeMillerRabin requires odd n")
+	}
+	l := len(n)
+	k0 := montgomeryK0(n[0])
+	RR := cmontgomeryRR(n)
+
+	// twos[i] is 1 if i ≤ k for n-1 = 2**k * q with odd q.
+	nm1, _ := nat(nil).csub(n, nat(nil).cpad(natOne, l))
+	twos := nm1.ctwosMasks()
+
+	// 1 and -1 in Montgomery form
+	one := nat(nil).cmontMul(nat(nil).cpad(natOne, l), RR, n, k0)
+	mone := nat(nil).make(l)
+	mone.clear()
+	mone = mone.csubMod(mone, one, n)
+
+	rand := rand.New(rand.NewSource(1))
+	a := nat(nil).make(l)
+	am := nat(nil).make(l)
+	z := nat(nil).make(l)
+	t := nat(nil).make(l)
+	u := nat(nil).make(l)
+	var result Word = 1
+	for r := 0; r < reps; r++ {
+		if r < len(mrBases) {
+			a = a.cmod(nat{mrBases[r]}, n)
+		} else {
+			for i := range a {
+				a[i] = Word(rand.Int63())<<1 ^ Word(rand.Int63())
+			}
+			a = a.cmod(a, n)
+		}
+		// a ≡ 0 only if n is the prime base itself
+		pass := a.cnonzero() ^ 1
+		am = am.cmontMul(a, RR, n, k0)
+
+		// After processing bit i of n-1, z holds a**((n-1)>>i); in
+		// particular, a**q for i == k and a**(q * 2**j) for k-j == i.
+		copy(z, one)
+		for i := len(nm1)*_W - 1; i >= 0; i-- {
+			b := nm1[i/_W] >> (uint(i) % _W) & 1
+			t = t.cmontMul(z, z, n, k0)
+			u = u.cmontMul(t, am, n, k0)
+			z = z.sel(u, t, b)
+			// a**q ≡ 1, or a**(q * 2**j) ≡ -1 for some 0 ≤ j < k
+			pass |= twos[i] & (twos[i+1] ^ 1) & z.ceq(one)
+			if i >= 1 {
+				pass |= twos[i] & z.ceq(mone)
+			}
+		}
+		result &= pass
+	}
+
+	a.wipe()
+	am.wipe()
+	z.wipe()
+	t.wipe()
+	u.wipe()
+	return result
+}
+
+// ProbablyPrimeCT is like ProbablyPrime, reporting whether x is probably
+// prime by applying the Miller-Rabin test with n+1 bases as well as a
+// Baillie-PSW test, but it is intended for testing secret candidates
+// during key generation. Every test is always applied, the Miller-Rabin
+// test uses a fixed schedule of bases, and the verdicts are combined
+// with masks, so the running time does not reveal which test, or which
+// round, rejected a composite candidate.
+//
+// The running time depends on the word length of x. As described for
+// the Lucas test, the selection of its parameter takes a variable number
+// of steps and reveals a few bits of information about x; values that
+// fit in a single Word and are smaller than 64 are looked up directly.
+func (x *Int) ProbablyPrimeCT(n int) bool {
+	if n < 0 {
+		panic("negative n for ProbablyPrimeCT")
+	}
+	if x.neg || len(x.abs) == 0 {
+		return false
+	}
+	if len(x.abs) == 1 && x.abs[0] < 64 {
+		return x.ProbablyPrime(0)
+	}
+
+	// Test the odd value |x| | 1, and reject even x with a mask.
+	odd := x.abs[0] & 1
+	m := nat(nil).set(x.abs)
+	m[0] |= 1
+	r := odd & (m.csmallPrimeFactor() ^ 1) & m.cprobablyPrimeMillerRabin(n+1) & m.cprobablyPrimeLucas()
+	m.wipe()
+	return r != 0
+}
@@ -0,0 +1,153 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCProbablyPrimeLucas(t *testing.T) {
+	n := nat{1}
+	for i := 3; i < 20000; i += 2 {
+		n[0] = Word(i)
+		want := n.probablyPrimeLucas()
+		if got := n.cprobablyPrimeLucas(); got != boolWord(want) {
+			t.Errorf("cprobablyPrimeLucas(%d) = %d; want %v", i, got, want)
+		}
+	}
+
+	for _, list := range [][]string{primes, composites} {
+		for _, s := range list {
+			p, _ := new(Int).SetString(strings.Map(cutSpace, s), 10)
+			if p.abs.cmp(natTwo) <= 0 || p.abs[0]&1 == 0 {
+				continue
+			}
+			want := p.abs.probablyPrimeLucas()
+			if got := p.abs.cprobablyPrimeLucas(); got != boolWord(want) {
+				t.Errorf("cprobablyPrimeLucas(%s) = %d; want %v", s, got, want)
+			}
+		}
+	}
+}
+
+func TestCLucasPseudoprimes(t *testing.T) {
+	// https://oeis.org/A217719
+	for _, i := range []Word{989, 3239, 5777, 10877, 27971, 29681, 30739, 31631, 39059, 72389, 73919, 75077} {
+		if got := (nat{i}).cprobablyPrimeLucas(); got != 1 {
+			t.Errorf("cprobablyPrimeLucas(%d) = %d; want 1", i, got)
+		}
+	}
+}
+
+func TestCLucasV(t *testing.T) {
+	// V(k) for P = 3, Q = 1 is the bisection of the Lucas numbers, 2, 3, 7, 18, 47, ...
+	const P = 3
+	v := []Word{2, P}
+	for len(v) < 20*_W/32 { // while the values fit in a Word
+		v = append(v, P*v[len(v)-1]-v[len(v)-2])
+	}
+	n := nat{1<<(_W-1) + 1}
+	if _W == 64 {
+		var p uint64 = 0xffffffffffffffc5 // a prime
+		n = nat{Word(p)}
+	}
+	k0 := montgomeryK0(n[0])
+	RR := cmontgomeryRR(n)
+	toMont := func(x Word) nat {
+		return nat(nil).cmontMul(nat(nil).cmod(nat{x}, n), RR, n, k0)
+	}
+	one := nat{1}
+	for k := 0; k < len(v)-1; k++ {
+		vk, vk1 := clucasV(nat{Word(k)}, n, k0, toMont(P), toMont(2), nil)
+		vk = nat(nil).cmontMul(vk, one, n, k0)
+		vk1 = nat(nil).cmontMul(vk1, one, n, k0)
+		want := nat(nil).cmod(nat{v[k]}, n)
+		want1 := nat(nil).cmod(nat{v[k+1]}, n)
+		if vk.cmp(want) != 0 || vk1.cmp(want1) != 0 {
+			t.Errorf("clucasV(%d) = %v, %v; want %v, %v", k, vk, vk1, want, want1)
+		}
+	}
+}
+
+func TestCProbablyPrimeMillerRabin(t *testing.T) {
+	// With all of mrBases, the test is deterministic for small n.
+	n := nat{1}
+	for i := 3; i < 5000; i += 2 {
+		n[0] = Word(i)
+		want := new(Int).SetUint64(uint64(i)).ProbablyPrime(20)
+		if got := n.cprobablyPrimeMillerRabin(len(mrBases)); got != boolWord(want) {
+			t.Errorf("cprobablyPrimeMillerRabin(%d) = %d; want %v", i, got, want)
+		}
+	}
+
+	// strong pseudoprimes pass the rounds with the bases they fool,
+	// but not the next one
+	for _, test := range []struct {
+		n    Word
+		reps int
+	}{
+		{2047, 1},
+		{3277, 1},
+		{4033, 1},
+		{1373653, 2},
+		{25326001, 3},
+	} {
+		n[0] = test.n
+		if got := n.cprobablyPrimeMillerRabin(test.reps); got != 1 {
+			t.Errorf("cprobablyPrimeMillerRabin(%d, %d) = %d; want 1", test.n, test.reps, got)
+		}
+		if got := n.cprobablyPrimeMillerRabin(test.reps + 1); got != 0 {
+			t.Errorf("cprobablyPrimeMillerRabin(%d, %d) = %d; want 0", test.n, test.reps+1, got)
+		}
+	}
+
+	// rounds beyond mrBases, and zero-extended n
+	for _, s := range primes {
+		p, _ := new(Int).SetString(strings.Map(cutSpace, s), 10)
+		if p.abs.cmp(natTwo) <= 0 {
+			continue
+		}
+		if got := p.abs.cprobablyPrimeMillerRabin(20); got != 1 {
+			t.Errorf("cprobablyPrimeMillerRabin(%s) = %d; want 1", s, got)
+		}
+		if got := nat(nil).cpad(p.abs, len(p.abs)+1).cprobablyPrimeMillerRabin(2); got != 1 {
+			t.Errorf("cprobablyPrimeMillerRabin(%s) zero-extended = %d; want 1", s, got)
+		}
+	}
+}
+
+func TestProbablyPrimeCT(t *testing.T) {
+	for i := int64(-3); i < 5000; i++ {
+		x := NewInt(i)
+		if got, want := x.ProbablyPrimeCT(1), x.ProbablyPrime(20); got != want {
+			t.Errorf("ProbablyPrimeCT(%d) = %v; want %v", i, got, want)
+		}
+	}
+	for _, list := range [][]string{primes, composites} {
+		for _, s := range list {
+			x, _ := new(Int).SetString(strings.Map(cutSpace, s), 10)
+			for _, x := range []*Int{x, new(Int).Add(x, intOne)} {
+				if got, want := x.ProbablyPrimeCT(10), x.ProbablyPrime(20); got != want {
+					t.Errorf("ProbablyPrimeCT(%s) = %v; want %v", x, got, want)
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkProbablyPrimeCT(b *testing.B) {
+	p, _ := new(Int).SetString(strings.Map(cutSpace, primes[len(primes)-1]), 10)
+	for i := 0; i < b.N; i++ {
+		p.ProbablyPrimeCT(20)
+	}
+}
+
+func BenchmarkCProbablyPrimeLucas(b *testing.B) {
+	p, _ := new(Int).SetString(strings.Map(cutSpace, primes[len(primes)-1]), 10)
+	for i := 0; i < b.N; i++ {
+		p.abs.cprobablyPrimeLucas()
+	}
+}
@@ -423,8 +423,8 @@ func (z *Rat) norm() *Rat {
 		z.a.neg = false
 		z.b.neg = false
 		if f := NewInt(0).binaryGCD(&z.a, &z.b); f.Cmp(intOne) != 0 {
-			z.a.abs, _ = z.a.abs.div(nil, z.a.abs, f.abs)
-			z.b.abs, _ = z.b.abs.div(nil, z.b.abs, f.abs)
+			z.a.abs = z.a.abs.divExact(z.a.abs, f.abs)
+			z.b.abs = z.b.abs.divExact(z.b.abs, f.abs)
 			if z.b.abs.cmp(natOne) == 0 {
 				// z is int - normalize denominator
 				z.b.abs = z.b.abs[:0]
@@ -10,12 +10,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math/bits"
 	"strconv"
 	"strings"
 )
 
 func ratTok(ch rune) bool {
-	return strings.ContainsRune("+-/0123456789.eE", ch)
+	return strings.ContainsRune("+-/0123456789.eE_bBoOxXaAcCdDfF", ch)
 }
 
 var ratZero Rat
@@ -39,9 +40,14 @@ func (z *Rat) Scan(s fmt.ScanState, ch rune) error {
 
 // SetString sets z to the value of s and returns z and a boolean indicating
 // success. s can be given as a fraction "a/b" or as a floating-point number
-// optionally followed by an exponent. The entire string (not just a prefix)
-// must be valid for success. If the operation failed, the value of z is un-
-// defined but the returned value is nil.
+// optionally followed by an exponent. The numbers may have the base prefixes
+// and the underscore digit separators of Int.SetString with base 0, except
+// that a leading "0" does not select base 8 in a floating-point number.
+// A floating-point number with a base prefix may be followed by a binary
+// exponent "p", as in "0x1.8p-3", like for Float.SetString. The
+// entire string (not just a prefix) must be valid for success. If the
+// operation failed, the value of z is undefined but the returned value is
+// nil.
 func (z *Rat) SetString(s string) (*Rat, bool) {
 	if len(s) == 0 {
 		return nil, false
@@ -78,15 +84,16 @@ func (z *Rat) SetString(s string) (*Rat, bool) {
 	}
 
 	// mantissa
-	var ecorr int
-	z.a.abs, _, ecorr, err = z.a.abs.scan(r, 10, true)
+	var b, ecorr int
+	z.a.abs, b, ecorr, err = z.a.abs.scan(r, 0, true)
 	if err != nil {
 		return nil, false
 	}
 
-	// exponent
+	// exponent; a binary exponent follows a mantissa with a base prefix
 	var exp int64
-	exp, _, err = scanExponent(r, false)
+	var ebase int
+	exp, ebase, err = scanExponent(r, b != 10, true)
 	if err != nil {
 		return nil, false
 	}
@@ -102,9 +109,18 @@ func (z *Rat) SetString(s string) (*Rat, bool) {
 	}
 	// len(z.a.abs) > 0
 
-	// correct exponent
+	// correct exponent; the fractional digits of a mantissa in base 2,
+	// 8, or 16 amount to a power of 2 instead, as does a binary exponent
+	var shift int64 // power of 2
+	if ebase == 2 {
+		shift, exp = exp, 0
+	}
 	if ecorr < 0 {
-		exp += int64(ecorr)
+		if b == 10 {
+			exp += int64(ecorr)
+		} else {
+			shift += int64(ecorr) * int64(bits.TrailingZeros(uint(b)))
+		}
 	}
 
 	// compute exponent power
@@ -122,6 +138,17 @@ func (z *Rat) SetString(s string) (*Rat, bool) {
 		z.a.abs = z.a.abs.mul(z.a.abs, powTen)
 		z.b.abs = z.b.abs[:0]
 	}
+	if shift < 0 {
+		d := z.b.abs
+		if len(d) == 0 {
+			d = natOne
+		}
+		z.b.abs = z.b.abs.shl(d, uint(-shift))
+		z.norm()
+	} else if shift > 0 {
+		z.a.abs = z.a.abs.shl(z.a.abs, uint(shift))
+		z.norm()
+	}
 
 	z.a.neg = neg && len(z.a.abs) > 0 // 0 has no sign
 
@@ -134,11 +161,13 @@ func (z *Rat) SetString(s string) (*Rat, bool) {
 //
 //	exponent = ( "E" | "e" | "p" ) [ sign ] digits .
 //	sign     = "+" | "-" .
-//	digits   = digit { digit } .
+//	digits   = digit { [ "_" ] digit } .
 //	digit    = "0" ... "9" .
 //
-// A binary exponent is only permitted if binExpOk is set.
-func scanExponent(r io.ByteScanner, binExpOk bool) (exp int64, base int, err error) {
+// A binary exponent is only permitted if binExpOk is set. If sepOk is
+// set, an underscore character ``_'' may appear between successive
+// exponent digits; misplaced underscores are reported as errInvalSep.
+func scanExponent(r io.ByteScanner, binExpOk, sepOk bool) (exp int64, base int, err error) {
 	base = 10
 
 	var ch byte
@@ -176,6 +205,8 @@ func scanExponent(r io.ByteScanner, binExpOk bool) (exp int64, base int, err err
 	// no need to use nat.scan for exponent digits
 	// since we only care about int64 values - the
 	// from-scratch scan is easy enough and faster
+	prev := '.' // '_' after an underscore, '0' after a digit
+	invalSep := false
 	for i := 0; ; i++ {
 		if ch, err = r.ReadByte(); err != nil {
 			if err != io.EOF || i == 0 {
@@ -184,6 +215,11 @@ func scanExponent(r io.ByteScanner, binExpOk bool) (exp int64, base int, err err
 			err = nil
 			break // i > 0
 		}
+		if ch == '_' && sepOk {
+			invalSep = invalSep || prev != '0'
+			prev = '_'
+			continue
+		}
 		if ch < '0' || '9' < ch {
 			if i == 0 {
 				r.UnreadByte()
@@ -192,10 +228,15 @@ func scanExponent(r io.ByteScanner, binExpOk bool) (exp int64, base int, err err
 			}
 			break // i > 0
 		}
+		prev = '0'
 		digits = append(digits, ch)
 	}
-	// i > 0 => we have at least one digit
+	// i > 0 => we have at least one digit or underscore
 
+	if invalSep || prev == '_' {
+		err = errInvalSep
+		return
+	}
 	exp, err = strconv.ParseInt(string(digits), 10, 64)
 	return
 }
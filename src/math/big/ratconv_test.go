@@ -64,6 +64,25 @@ var setStringTests2 = []StringTest{
 	{"0x10/0x20", "1/2", true},
 	{"0b1000/3", "8/3", true},
 	{in: "4/3x"},
+	{"0x1.8", "3/2", true},
+	{"-0b1.01", "-5/4", true},
+	{"0o.4e1", "5", true},
+	{"0x.1e2", "241/2048", true}, // e is a hexadecimal digit
+	{"1_000.5", "2001/2", true},
+	{"1e1_0", "10000000000", true},
+	{"0x_18/0b_1_1", "8", true},
+	{in: "1_.5"},
+	{in: "1e1_"},
+	{in: "0x1.8_"},
+	{"0x1p4", "16", true},
+	{"0x1.8p-3", "3/16", true},
+	{"-0b1.1p1_0", "-1536", true},
+	{"0o7p-1", "7/2", true},
+	{"0x.8p1", "1", true},
+	{"0x0p100", "0", true},
+	{in: "1p4"},
+	{in: "0x1p"},
+	{in: "0x1/2p1"},
 	// TODO(gri) add more tests
 }
 
@@ -83,6 +102,8 @@ func TestRatSetString(t *testing.T) {
 			}
 		} else if x != nil {
 			t.Errorf("#%d SetString(%q) got %p want nil", i, test.in, x)
+		} else if test.ok {
+			t.Errorf("#%d SetString(%q) failed", i, test.in)
 		}
 	}
 }
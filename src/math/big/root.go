@@ -0,0 +1,134 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Float.Cbrt and Float.RootN.
+
+package big
+
+// Roots of degree at most rootNewtonMax are computed from the integer
+// root of the scaled mantissa, as for Sqrt; higher degrees, for which that
+// mantissa gets too long, as e**(log(x)/n).
+var rootNewtonMax uint = 8 // measured with BenchmarkFloatRootN
+
+// Cbrt sets z to the rounded cube root of x, and returns z. It is
+// shorthand for z.RootN(x, 3).
+func (z *Float) Cbrt(x *Float) *Float {
+	return z.RootN(x, 3)
+}
+
+// RootN sets z to the rounded n-th root of x, and returns z.
+// If z's precision is 0, it is changed to x's precision before the
+// operation. The result is the exact root rounded according to z's
+// precision and rounding mode, and its accuracy is reported as for Add.
+// For odd n, the root of a negative x is -RootN(-x, n).
+//
+// RootN(±0, n) = ±0 and RootN(±Inf, n) = ±Inf. RootN panics with ErrNaN
+// if n == 0, or if x < 0 and n is even; the value of z is undefined in
+// that case.
+func (z *Float) RootN(x *Float, n uint) *Float {
+	if debugFloat {
+		x.validate()
+	}
+
+	if z.prec == 0 {
+		z.prec = x.prec
+	}
+
+	if n == 0 || x.neg && x.form != zero && n&1 == 0 {
+		// value of z is undefined but make sure it's valid
+		z.acc = Exact
+		z.form = zero
+		z.neg = false
+		if n == 0 {
+			panic(ErrNaN{"zeroth root"})
+		}
+		panic(ErrNaN{"even root of negative operand"})
+	}
+
+	if x.form != finite || n == 1 {
+		// ±0, ±Inf, or the first root
+		return z.Set(x)
+	}
+
+	if n <= rootNewtonMax {
+		z.neg = x.neg
+		z.uroot(x, n)
+		return z
+	}
+
+	// |x| = m × 2**e with odd m
+	neg := x.neg
+	m, e := x.oddExp()
+	m.neg = false
+	if e%int64(n) == 0 && (m.Cmp(intOne) == 0 || uint(m.BitLen()) > n) {
+		// the root is m**(1/n) × 2**(e/n), which is a dyadic rational
+		// only for m = k**n with an odd k, so m = 1 or m >= 3**n
+		k := new(Int).Root(m, n)
+		if new(Int).Exp(k, NewInt(int64(n)), nil).Cmp(m) == 0 {
+			k.neg = neg
+			return z.setScaled(k, e/int64(n))
+		}
+	}
+	// The root is irrational.
+
+	ax := new(Float).Abs(x)
+	nf := new(Float).SetUint64(uint64(n))
+
+	// t = log|x| / n; results close to 1 round like 1 ± |t|
+	t := new(Float).SetPrec(64).Log(ax)
+	if t.Quo(t, nf); int64(t.exp) < -int64(z.prec)-2 {
+		return z.setExpTiny(t.neg, neg)
+	}
+	tbits := uint(0) // |t| < 2**tbits
+	if t.exp > 0 {
+		tbits = uint(t.exp)
+	}
+
+	f, fe, tz := logReduce(ax)
+	nn := new(Int).SetUint64(uint64(n))
+	return z.setBracketed(func(w uint) (lo, hi *Int, exp int64) {
+		// t lies between l/n and h/n, rounded down and up, within
+		// 2**-w of it, relative to the root
+		l, h, lexp := logBracket(f, fe, tz, w+tbits)
+		l.Div(l, nn)
+		h.Add(h, nn).Sub(h, intOne).Div(h, nn)
+		return expBetween(scaledFloat(l, lexp), scaledFloat(h, lexp), w, neg)
+	})
+}
+
+// z = |x|**(1/n) with z's sign, for x != 0 with a non-empty mantissa and
+// valid exponent, and n > 1. As for usqrt, the mantissa of x, as an integer
+// m with x = m × 2**e, is shifted so that its integer n-th root has at
+// least two bits more than z's precision and n divides e; the root of x is
+// then the integer root scaled by 2**(e/n), with a non-zero sticky bit if
+// the root is inexact or bits of m were shifted out.
+func (z *Float) uroot(x *Float, n uint) {
+	e := int64(x.exp) - int64(len(x.mant))*_W
+	t := int64(n)*(int64(z.prec)+2) - int64(len(x.mant))*_W
+	if d := (e - t) % int64(n); d != 0 {
+		if d < 0 {
+			d += int64(n)
+		}
+		t += d
+	}
+
+	var m nat
+	var sbit uint
+	if t >= 0 {
+		m = nat(nil).shl(x.mant, uint(t))
+	} else {
+		m = nat(nil).shr(x.mant, uint(-t))
+		if x.mant.trailingZeroBits() < uint(-t) {
+			sbit = 1
+		}
+	}
+
+	z.mant = z.mant.root(m, n)
+	if nat(nil).expNN(z.mant, nat(nil).setWord(Word(n)), nil).cmp(m) != 0 {
+		sbit = 1
+	}
+
+	ex := (e - t) / int64(n)
+	z.setExpAndRound(ex+int64(len(z.mant))*_W-fnorm(z.mant), sbit)
+}
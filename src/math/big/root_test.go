@@ -0,0 +1,190 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// TestFloatCbrt64 checks Cbrt at precision 53 against math.Cbrt, which is
+// not always correctly rounded.
+func TestFloatCbrt64(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 1000; i++ {
+		f := math.Float64frombits(r.Uint64())
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			continue
+		}
+		if i < 100 {
+			f = float64(i - 50)
+		}
+		want := math.Cbrt(f)
+		z := new(Float).SetPrec(53).Cbrt(new(Float).SetFloat64(f))
+		if got, _ := z.Float64(); got != want && math.Abs(got-want) > math.Abs(want)*0x1p-52 {
+			t.Errorf("Cbrt(%g) = %g; want %g", f, got, want)
+		}
+	}
+}
+
+// TestFloatRootN checks the rounding of RootN in all modes, as
+// TestFloatSqrt does for Sqrt.
+func TestFloatRootN(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, n := range []uint{2, 3, 4, 5, 8, 9, 10, 17, 64} {
+		for _, prec := range []uint{1, 2, 10, 53, 64, 100, 500} {
+			for i := 0; i < 10; i++ {
+				x := new(Float).SetPrec(uint(r.Intn(500) + 1))
+				x.SetInt(new(Int).Rand(r, new(Int).Lsh(intOne, x.Prec())))
+				if x.Sign() == 0 {
+					continue
+				}
+				exp := 200
+				if i%2 == 1 {
+					exp = 1e6
+				}
+				x.SetMantExp(x, r.Intn(2*exp)-exp)
+				if i < 4 && prec <= 64 {
+					// powers of short values: the roots are exact, or ties at low precisions
+					m := new(Float).SetPrec(prec + 1).SetInt64(int64(2*i + 1))
+					m.SetMantExp(m, -int(m.MinPrec())+r.Intn(200)-100)
+					x = exactPow(m, n)
+				}
+				if n&1 != 0 && r.Intn(2) == 0 {
+					x.Neg(x)
+				}
+				for _, mode := range []RoundingMode{ToNearestEven, ToNearestAway, ToZero, AwayFromZero, ToNegativeInf, ToPositiveInf} {
+					z := new(Float).SetPrec(prec).SetMode(mode).RootN(x, n)
+					checkRootN(t, x, n, z, mode)
+				}
+			}
+		}
+	}
+}
+
+// exactPow returns x**n, which is exact at n times x's precision.
+func exactPow(x *Float, n uint) *Float {
+	p := new(Float).Copy(x)
+	for i := uint(1); i < n; i++ {
+		p = exactMul(p, x)
+	}
+	return p
+}
+
+func checkRootN(t *testing.T, x *Float, n uint, z *Float, mode RoundingMode) {
+	prec := z.Prec()
+	desc := fmt.Sprintf("RootN(%s, %d) at precision %d in mode %s = %s (%s)", x.Text('p', 0), n, prec, mode, z.Text('p', 0), z.Acc())
+	if z.Prec() != prec || z.Mode() != mode {
+		t.Errorf("%s: precision %d, mode %s", desc, z.Prec(), z.Mode())
+	}
+	cmp := exactPow(z, n).Cmp(x)
+	if want := Accuracy(cmp); z.Acc() != want {
+		t.Errorf("%s: acc = %s; want %s", desc, z.Acc(), want)
+	}
+	if cmp == 0 {
+		return
+	}
+	// ulp is the distance of |z| to its successor at precision prec
+	ulp := new(Float).SetMantExp(NewFloat(1), z.MantExp(nil)-int(prec))
+	switch mode {
+	case ToZero, ToNegativeInf, AwayFromZero, ToPositiveInf:
+		// z**n < x < (z + ulp)**n, or (z - ulp)**n < x < z**n
+		next := new(Float).SetPrec(prec + 1)
+		if cmp < 0 {
+			next.Add(z, ulp)
+		} else {
+			next.Sub(z, ulp)
+		}
+		if exactPow(next, n).Cmp(x) != -cmp {
+			t.Errorf("%s: not the closest neighbor of the root", desc)
+		}
+		down := mode == ToNegativeInf || mode == ToZero && x.Sign() > 0 || mode == AwayFromZero && x.Sign() < 0
+		if down != (cmp < 0) {
+			t.Errorf("%s: rounded in the wrong direction", desc)
+		}
+	default:
+		// (z - ulp/2)**n <= x <= (z + ulp/2)**n
+		half := new(Float).SetMantExp(ulp, -1)
+		lo := new(Float).SetPrec(prec+2).Sub(z, half)
+		hi := new(Float).SetPrec(prec+2).Add(z, half)
+		if exactPow(lo, n).Cmp(x) > 0 || exactPow(hi, n).Cmp(x) < 0 {
+			t.Errorf("%s: more than half an ulp from the root", desc)
+		}
+	}
+}
+
+func TestFloatRootNSpecial(t *testing.T) {
+	for _, test := range []struct {
+		x    string
+		n    uint
+		want string
+		acc  Accuracy
+	}{
+		{"0", 3, "0", Exact},
+		{"-0", 3, "-0", Exact},
+		{"-0", 4, "-0", Exact},
+		{"+Inf", 5, "+Inf", Exact},
+		{"-Inf", 5, "-Inf", Exact},
+		{"1.5", 1, "1.5", Exact},
+		{"27", 3, "3", Exact},
+		{"-0.125", 3, "-0.5", Exact},
+		{"2", 3, "1.25992105", Below},
+		{"1e-1000", 10, "9.999999999e-101", Below},
+		{"0x1p1000", 100, "1024", Exact},
+		{"-0x1p-1010", 101, "-0.0009765625", Exact},
+		{"-0x1.8p-1010", 101, "-0.0009804907959", Below},
+		{"1594323", 13, "3", Exact},
+		{"1594324", 13, "3.000000144", Below},
+		// results close to 1
+		{"1.0000001", 1 << 31, "1", Below},
+		{"0.9999999", 1 << 31, "1", Above},
+		{"0x1.0000000000000000000000001p0", 1000, "1", Below},
+	} {
+		x := makeFloat(test.x)
+		z := new(Float).SetPrec(32).RootN(x, test.n)
+		if got := z.Text('g', 10); got != test.want || z.Acc() != test.acc {
+			t.Errorf("RootN(%s, %d) = %s (%s); want %s (%s)", test.x, test.n, got, z.Acc(), test.want, test.acc)
+		}
+		// z may be x
+		x.SetPrec(32).RootN(x, test.n)
+		if got := x.Text('g', 10); got != test.want {
+			t.Errorf("aliased RootN(%s, %d) = %s; want %s", test.x, test.n, got, test.want)
+		}
+	}
+
+	if z := new(Float).Cbrt(NewFloat(2)); z.Prec() != 53 {
+		t.Errorf("Cbrt(2) with precision 0: got precision %d; want 53", z.Prec())
+	}
+
+	for _, test := range []struct {
+		x string
+		n uint
+	}{{"1", 0}, {"0", 0}, {"-1", 2}, {"-Inf", 4}, {"-1e-1000", 100}} {
+		func() {
+			defer func() {
+				if _, ok := recover().(ErrNaN); !ok {
+					t.Errorf("RootN(%s, %d) did not panic with ErrNaN", test.x, test.n)
+				}
+			}()
+			new(Float).RootN(makeFloat(test.x), test.n)
+		}()
+	}
+}
+
+func BenchmarkFloatRootN(b *testing.B) {
+	for _, prec := range []uint{64, 1000, 10000} {
+		for _, n := range []uint{3, 8, 9, 16, 100} {
+			x := new(Float).SetPrec(prec).SetInt64(2)
+			z := new(Float).SetPrec(prec)
+			b.Run(fmt.Sprintf("%v/%v", prec, n), func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					z.RootN(x, n)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,132 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the arithmetic of Ints with an int64 operand,
+// which is computed on the words of the Int without converting the
+// operand to a temporary Int.
+
+package big
+
+// AddInt64 sets z to the sum x+y and returns z, like z.Add(x, y) for an
+// Int y, without allocating a temporary Int for y.
+func (z *Int) AddInt64(x *Int, y int64) *Int {
+	return z.addSmall(x, abs64(y), y < 0)
+}
+
+// SubInt64 sets z to the difference x-y and returns z, like z.Sub(x, y)
+// for an Int y, without allocating a temporary Int for y.
+func (z *Int) SubInt64(x *Int, y int64) *Int {
+	return z.addSmall(x, abs64(y), y > 0)
+}
+
+// MulInt64 sets z to the product x*y and returns z, like z.Mul(x, y) for
+// an Int y, without allocating a temporary Int for y.
+func (z *Int) MulInt64(x *Int, y int64) *Int {
+	u := abs64(y)
+	if z.zcap|x.zcap != 0 || varTimeDisabled() || uint64(Word(u)) != u {
+		return z.Mul(x, smallInt(u, y < 0))
+	}
+	z.abs = z.abs.mulAddWW(x.abs, Word(u), 0)
+	z.neg = len(z.abs) > 0 && x.neg != (y < 0) // 0 has no sign
+	return z
+}
+
+// QuoInt64 sets z to the quotient x/y for y != 0 and returns z, like
+// z.Quo(x, y) for an Int y, without allocating a temporary Int for y.
+// If y == 0, a division-by-zero run-time panic occurs. QuoInt64
+// implements truncated division (like Go).
+func (z *Int) QuoInt64(x *Int, y int64) *Int {
+	u := abs64(y)
+	if z.zcap|x.zcap != 0 || varTimeDisabled() || uint64(Word(u)) != u {
+		return z.Quo(x, smallInt(u, y < 0))
+	}
+	z.abs, _ = z.abs.divW(x.abs, Word(u))
+	z.neg = len(z.abs) > 0 && x.neg != (y < 0) // 0 has no sign
+	return z
+}
+
+// addSmall sets z to x+y and returns z, for the value y with absolute
+// value u, which is negative if neg is set.
+func (z *Int) addSmall(x *Int, u uint64, neg bool) *Int {
+	if z.zcap|x.zcap != 0 || varTimeDisabled() || uint64(Word(u)) != u {
+		return z.Add(x, smallInt(u, neg))
+	}
+	y := Word(u)
+	switch {
+	case y == 0:
+		return z.Set(x)
+	case x.neg == neg:
+		// x + y == x + y
+		// (-x) + (-y) == -(x + y)
+		z.abs = z.abs.addW(x.abs, y)
+		z.neg = neg
+	case len(x.abs) > 1 || len(x.abs) == 1 && x.abs[0] >= y:
+		// |x| >= |y|: x + (-y) == x - y, (-x) + y == -(x - y)
+		z.abs = z.abs.subW(x.abs, y)
+		z.neg = len(z.abs) > 0 && x.neg // 0 has no sign
+	default:
+		// |x| < |y|: x + (-y) == -(y - x), (-x) + y == y - x
+		var xw Word
+		if len(x.abs) > 0 {
+			xw = x.abs[0]
+		}
+		z.abs = z.abs.setWord(y - xw)
+		z.neg = neg
+	}
+	return z
+}
+
+// abs64 returns the absolute value of x as a uint64.
+func abs64(x int64) uint64 {
+	if x < 0 {
+		return -uint64(x)
+	}
+	return uint64(x)
+}
+
+// smallInt returns a new Int with the absolute value u, which is negative
+// if neg is set.
+func smallInt(u uint64, neg bool) *Int {
+	return &Int{abs: nat(nil).setUint64(u), neg: neg && u != 0}
+}
+
+// addW returns z = x + y for a single Word y.
+func (z nat) addW(x nat, y Word) nat {
+	m := len(x)
+	if m == 0 {
+		return z.setWord(y)
+	}
+	z = z.make(m + 1)
+	// addVW takes a carry of 0 or 1, so y is added to x[0] here.
+	z0 := x[0] + y
+	var c Word
+	if z0 < y {
+		c = 1
+	}
+	z[0] = z0
+	z[m] = addVW(z[1:m], x[1:], c)
+	return z.norm()
+}
+
+// subW returns z = x - y for a single Word y <= x.
+func (z nat) subW(x nat, y Word) nat {
+	m := len(x)
+	if m == 0 {
+		if y != 0 {
+			panic("underflow")
+		}
+		return z[:0]
+	}
+	z = z.make(m)
+	z0 := x[0] - y
+	var b Word
+	if x[0] < y {
+		b = 1
+	}
+	z[0] = z0
+	if subVW(z[1:m], x[1:], b) != 0 {
+		panic("underflow")
+	}
+	return z.norm()
+}
@@ -0,0 +1,79 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"math"
+	"testing"
+)
+
+func TestScalarArith(t *testing.T) {
+	xs := []string{"0", "1", "-1", "2", "-7", "0xffffffff", "-0x100000000", "0xffffffffffffffff",
+		"-0xffffffffffffffff", "0x10000000000000000", "-0x10000000000000000", "0x1ffffffffffffffff",
+		"0x7fffffffffffffff", "-0x8000000000000000", "0x123456789abcdef0123456789abcdef"}
+	ys := []int64{0, 1, -1, 2, -3, 7, math.MaxInt32, math.MinInt32, 1 << 32, -1<<32 - 1,
+		math.MaxInt64, math.MinInt64, math.MinInt64 + 1, -0x123456789}
+	for _, sx := range xs {
+		for _, y := range ys {
+			x, _ := new(Int).SetString(sx, 0)
+			yi := NewInt(y)
+			for _, test := range []struct {
+				name string
+				got  func(z, x *Int, y int64) *Int
+				want func(z, x, y *Int) *Int
+			}{
+				{"AddInt64", (*Int).AddInt64, (*Int).Add},
+				{"SubInt64", (*Int).SubInt64, (*Int).Sub},
+				{"MulInt64", (*Int).MulInt64, (*Int).Mul},
+				{"QuoInt64", (*Int).QuoInt64, (*Int).Quo},
+			} {
+				if test.name == "QuoInt64" && y == 0 {
+					continue
+				}
+				want := test.want(new(Int), x, yi)
+				if got := test.got(new(Int), x, y); got.Cmp(want) != 0 || !isNormalized(got) {
+					t.Errorf("%s(%s, %d) = %s; want %s", test.name, x, y, got, want)
+				}
+				// aliased operands
+				if got := test.got(new(Int).Set(x), x, y); got.Cmp(want) != 0 {
+					t.Errorf("%s(z = %s, %d) = %s; want %s", test.name, x, y, got, want)
+				}
+				z := new(Int).Set(x)
+				if got := test.got(z, z, y); got.Cmp(want) != 0 || !isNormalized(got) {
+					t.Errorf("aliased %s(%s, %d) = %s; want %s", test.name, x, y, got, want)
+				}
+				// marked operands
+				xc := new(Int).Set(x).SetConstantTime(256)
+				if got := test.got(new(Int), xc, y); got.Cmp(want) != 0 || got.zcap == 0 {
+					t.Errorf("marked %s(%s, %d) = %s (width %d); want %s", test.name, x, y, got, got.zcap, want)
+				}
+			}
+		}
+	}
+}
+
+func TestQuoInt64Zero(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("QuoInt64(1, 0) did not panic")
+		}
+	}()
+	new(Int).QuoInt64(intOne, 0)
+}
+
+func TestScalarArithAllocs(t *testing.T) {
+	z := new(Int).Lsh(intOne, 200)
+	step := func() {
+		z.AddInt64(z, 12345)
+		z.SubInt64(z, -3)
+		z.MulInt64(z, -7)
+		z.QuoInt64(z, -7)
+		z.SubInt64(z, 12348)
+	}
+	step()
+	if allocs := testing.AllocsPerRun(100, step); allocs != 0 {
+		t.Errorf("got %v allocations per step; want 0", allocs)
+	}
+}
@@ -0,0 +1,157 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Scratch, the temporary storage of the
+// arithmetic operations that accept one.
+
+package big
+
+// A Scratch holds the temporary storage of the Int operations that
+// accept one: MulScratch, ExpScratch, DivScratch, and ModScratch. They
+// compute their intermediate values in storage taken from the Scratch,
+// and give the storage they don't keep for the result back to it, so
+// that a loop repeating them on values of similar size, such as a
+// Miller-Rabin test or a modular ladder, stops allocating once the
+// Scratch has grown large enough. The other operations take their
+// temporaries from a pool shared by all goroutines, which the garbage
+// collector may empty at any time.
+//
+// The zero value for a Scratch is ready to use, and a nil *Scratch
+// selects the shared pool. A Scratch must not be used by several
+// goroutines simultaneously. Operations on values marked with
+// SetConstantTime don't use the Scratch, so that it never holds secret
+// values.
+//
+// The results take the storage of the temporaries, and the earlier
+// storage of the receiver z goes to the Scratch in turn, to be
+// overwritten by later operations. Thus a caller that set z's storage
+// with SetBits must not use that storage while the Scratch is in use.
+// With the shared pool, the results are copied instead.
+type Scratch struct {
+	free []*nat
+}
+
+// getNat is like the package-level getNat, but takes the nat from s,
+// or from the shared pool if s is nil.
+func (s *Scratch) getNat(n int) *nat {
+	if s == nil {
+		return getNat(n)
+	}
+	var z *nat
+	if i := len(s.free) - 1; i >= 0 {
+		z = s.free[i]
+		s.free = s.free[:i]
+	} else {
+		z = new(nat)
+	}
+	*z = z.make(n)
+	return z
+}
+
+// putNat returns x, which holds the storage of a nat that is no longer
+// used, to s, or to the shared pool if s is nil.
+func (s *Scratch) putNat(x *nat) {
+	if s == nil {
+		putNat(x)
+		return
+	}
+	s.free = append(s.free, x)
+}
+
+// keep sets *z to the result *t of an operation, and gives the earlier
+// storage of *z to s in t. If s is nil, *t is copied instead, so that the
+// shared pool never holds storage that the caller may own.
+func (s *Scratch) keep(z, t *nat) {
+	if s == nil {
+		*z = z.set(*t)
+		return
+	}
+	*z, *t = *t, *z
+}
+
+// MulScratch is like Mul, but takes its temporary storage from s; see
+// Scratch.
+func (z *Int) MulScratch(x, y *Int, s *Scratch) *Int {
+	if z.zcap|x.zcap|y.zcap != 0 || varTimeDisabled() {
+		return z.Mul(x, y)
+	}
+	// The product is computed in storage from s, so that z may alias x
+	// or y, and z's storage goes back to s (see keep).
+	t := s.getNat(0)
+	*t = (*t).mul(x.abs, y.abs)
+	s.keep(&z.abs, t)
+	z.neg = len(z.abs) > 0 && x.neg != y.neg // 0 has no sign
+	s.putNat(t)
+	return z
+}
+
+// ExpScratch is like Exp, but takes its temporary storage from s; see
+// Scratch.
+func (z *Int) ExpScratch(x, y, m *Int, s *Scratch) *Int {
+	if m != nil && len(m.abs) > 0 && (y.neg || z.zcap|x.zcap|y.zcap|m.zcap != 0 || varTimeDisabled()) {
+		return z.Exp(x, y, m)
+	}
+	var yWords nat
+	if !y.neg {
+		yWords = y.abs
+	}
+	var mWords nat
+	if m != nil {
+		mWords = m.abs // m.abs may be nil for m == 0
+	}
+	neg := len(x.abs) > 0 && x.neg && len(yWords) > 0 && yWords[0]&1 == 1
+	t := s.getNat(0)
+	*t = (*t).expNNScratch(x.abs, yWords, mWords, s)
+	if neg && len(*t) > 0 && len(mWords) > 0 {
+		// make modulus result positive
+		*t = (*t).sub(mWords, *t) // z == x**y mod |m| && 0 <= z < |m|
+		neg = false
+	}
+	s.keep(&z.abs, t)
+	z.neg = neg && len(z.abs) > 0 // 0 has no sign
+	s.putNat(t)
+	return z
+}
+
+// DivScratch is like Div, but takes its temporary storage from s; see
+// Scratch.
+func (z *Int) DivScratch(x, y *Int, s *Scratch) *Int {
+	if z.zcap|x.zcap|y.zcap != 0 || varTimeDisabled() {
+		return z.Div(x, y)
+	}
+	qp, rp := s.getNat(0), s.getNat(0)
+	*qp, *rp = (*qp).div(*rp, x.abs, y.abs)
+	r_neg, y_neg := len(*rp) > 0 && x.neg, y.neg // z may be an alias for y
+	s.keep(&z.abs, qp)
+	z.neg = len(z.abs) > 0 && x.neg != y_neg // 0 has no sign
+	s.putNat(qp)
+	s.putNat(rp)
+	if r_neg {
+		if y_neg {
+			z.Add(z, intOne)
+		} else {
+			z.Sub(z, intOne)
+		}
+	}
+	return z
+}
+
+// ModScratch is like Mod, but takes its temporary storage from s; see
+// Scratch.
+func (z *Int) ModScratch(x, y *Int, s *Scratch) *Int {
+	if z.zcap|x.zcap|y.zcap != 0 || varTimeDisabled() {
+		return z.Mod(x, y)
+	}
+	qp, rp := s.getNat(0), s.getNat(0)
+	*qp, *rp = (*qp).div(*rp, x.abs, y.abs)
+	if len(*rp) > 0 && x.neg {
+		*rp = (*rp).sub(y.abs, *rp) // |y| - |x|%|y|
+	}
+	// y's storage, if z aliases y, goes back to s only now.
+	s.keep(&z.abs, rp)
+	z.neg = false
+	s.putNat(qp)
+	s.putNat(rp)
+	return z
+}
@@ -0,0 +1,131 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"internal/race"
+	"math/rand"
+	"testing"
+)
+
+func TestScratch(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	var s Scratch
+	for i := 0; i < 200; i++ {
+		x := new(Int).Rand(r, new(Int).Lsh(intOne, uint(r.Intn(1500)+1)))
+		y := new(Int).Rand(r, new(Int).Lsh(intOne, uint(r.Intn(1500)+1)))
+		m := new(Int).Rand(r, new(Int).Lsh(intOne, uint(r.Intn(1200)+2)))
+		m.Add(m, NewInt(2))
+		e := new(Int).Rand(r, new(Int).Lsh(intOne, uint(r.Intn(300)+1)))
+		if i&1 != 0 {
+			x.Neg(x)
+		}
+		if i&2 != 0 {
+			y.Neg(y)
+		}
+		if i&4 != 0 {
+			m.SetBit(m, 0, 1) // odd modulus
+		}
+		if y.Sign() == 0 {
+			y.SetInt64(7)
+		}
+
+		for _, test := range []struct {
+			name string
+			got  func(z, x, y *Int) *Int
+			want func(z, x, y *Int) *Int
+		}{
+			{"MulScratch", func(z, x, y *Int) *Int { return z.MulScratch(x, y, &s) }, (*Int).Mul},
+			{"DivScratch", func(z, x, y *Int) *Int { return z.DivScratch(x, y, &s) }, (*Int).Div},
+			{"ModScratch", func(z, x, y *Int) *Int { return z.ModScratch(x, y, &s) }, (*Int).Mod},
+			{"ExpScratch", func(z, x, y *Int) *Int { return z.ExpScratch(x, e, y, &s) },
+				func(z, x, y *Int) *Int { return z.Exp(x, e, y) }},
+		} {
+			yy := y
+			if test.name == "ExpScratch" {
+				yy = m
+			}
+			want := test.want(new(Int), x, yy)
+			if got := test.got(new(Int), x, yy); got.Cmp(want) != 0 {
+				t.Errorf("%s(%s, %s) = %s; want %s", test.name, x, yy, got, want)
+			}
+			// aliased operands
+			z := new(Int).Set(x)
+			if got := test.got(z, z, yy); got.Cmp(want) != 0 {
+				t.Errorf("%s(z = %s, %s) = %s; want %s", test.name, x, yy, got, want)
+			}
+			z = new(Int).Set(yy)
+			if got := test.got(z, x, z); got.Cmp(test.want(new(Int), x, yy)) != 0 {
+				t.Errorf("%s(%s, z = %s) = %s; want %s", test.name, x, yy, got, want)
+			}
+		}
+	}
+}
+
+func TestScratchAllocs(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping in short mode")
+	}
+	if race.Enabled {
+		t.Skip("skipping in race mode: the pools drop items")
+	}
+	// a Miller-Rabin round for a 1024-bit modulus
+	r := rand.New(rand.NewSource(1))
+	n := new(Int).Rand(r, new(Int).Lsh(intOne, 1024))
+	n.SetBit(n, 1023, 1).SetBit(n, 0, 1)
+	d := new(Int).Rsh(n, 1)
+	a := new(Int).Rand(r, n)
+	z := new(Int)
+	var s Scratch
+	round := func() {
+		z.ExpScratch(a, d, n, &s)
+		for i := 0; i < 10; i++ {
+			z.MulScratch(z, z, &s)
+			z.ModScratch(z, n, &s)
+		}
+		z.DivScratch(z, a, &s)
+	}
+	round() // grow s
+	if allocs := testing.AllocsPerRun(10, round); allocs != 0 {
+		t.Errorf("got %v allocations per round; want 0", allocs)
+	}
+}
+
+func TestScratchCallerStorage(t *testing.T) {
+	x := new(Int).Lsh(intOne, 1000)
+	y := NewInt(12345)
+	testCallerStorage(t, "MulScratch with the shared pool", func(z *Int) {
+		z.MulScratch(x, y, nil)
+	})
+	testCallerStorage(t, "ModScratch with the shared pool", func(z *Int) {
+		z.ModScratch(x, y, nil)
+	})
+}
+
+func BenchmarkScratch(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	n := new(Int).Rand(r, new(Int).Lsh(intOne, 1024))
+	n.SetBit(n, 1023, 1).SetBit(n, 0, 1)
+	d := new(Int).Rsh(n, 1)
+	a := new(Int).Rand(r, n)
+	z := new(Int)
+	b.Run("Scratch", func(b *testing.B) {
+		var s Scratch
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			z.ExpScratch(a, d, n, &s)
+			z.MulScratch(z, z, &s)
+			z.ModScratch(z, n, &s)
+		}
+	})
+	b.Run("NoScratch", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			z.Exp(a, d, n)
+			z.Mul(z, z)
+			z.Mod(z, n)
+		}
+	})
+}
@@ -0,0 +1,77 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Float.Sqrt.
+
+package big
+
+// Sqrt sets z to the rounded square root of x, and returns z.
+// If z's precision is 0, it is changed to x's precision before the
+// operation. The result is the exact square root rounded according to
+// z's precision and rounding mode, and its accuracy is reported as for
+// Add.
+//
+// Sqrt(±0) = ±0 and Sqrt(+Inf) = +Inf. Sqrt panics with ErrNaN if x < 0;
+// the value of z is undefined in that case.
+func (z *Float) Sqrt(x *Float) *Float {
+	if debugFloat {
+		x.validate()
+	}
+
+	if z.prec == 0 {
+		z.prec = x.prec
+	}
+
+	if x.neg && x.form != zero {
+		// value of z is undefined but make sure it's valid
+		z.acc = Exact
+		z.form = zero
+		z.neg = false
+		panic(ErrNaN{"square root of negative operand"})
+	}
+
+	z.neg = x.neg
+	if x.form != finite {
+		// ±0, +Inf
+		z.acc = Exact
+		z.form = x.form
+		return z
+	}
+
+	z.neg = false
+	z.usqrt(x.mant, int64(x.exp)-int64(len(x.mant))*_W)
+	return z
+}
+
+// z = √(m × 2**e), for m > 0; for Sqrt, m is the mantissa of x as an
+// integer, with x = m × 2**e. m is shifted so that its integer square
+// root has at least two bits more than z's precision, for the rounding bit
+// and the sticky bit, and e stays even. The result is then the integer
+// square root scaled by 2**(e/2), with a non-zero sticky bit if the square
+// root is inexact or bits of m were shifted out.
+func (z *Float) usqrt(m nat, e int64) {
+	t := 2*(int64(z.prec)+2) - int64(m.bitLen())
+	if (e-t)&1 != 0 {
+		t++
+	}
+
+	var sbit uint
+	if t >= 0 {
+		m = nat(nil).shl(m, uint(t))
+	} else {
+		if m.trailingZeroBits() < uint(-t) {
+			sbit = 1
+		}
+		m = nat(nil).shr(m, uint(-t))
+	}
+
+	var r nat
+	z.mant, r = z.mant.sqrtRem(nil, m)
+	if len(r) > 0 {
+		sbit = 1
+	}
+
+	ex := (e - t) / 2
+	z.setExpAndRound(ex+int64(len(z.mant))*_W-fnorm(z.mant), sbit)
+}
@@ -0,0 +1,156 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// TestFloatSqrt64 checks that Sqrt at precision 53 agrees with math.Sqrt,
+// which is correctly rounded.
+func TestFloatSqrt64(t *testing.T) {
+	r := rand.New(rand.NewSource(0))
+	for i := 0; i < 10000; i++ {
+		f := math.Float64frombits(r.Uint64() &^ (1 << 63))
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			continue
+		}
+		if i < 100 {
+			f = float64(i)
+		}
+		want := math.Sqrt(f)
+		z := new(Float).SetPrec(53).Sqrt(new(Float).SetFloat64(f))
+		if got, _ := z.Float64(); got != want {
+			t.Errorf("Sqrt(%g) = %g; want %g", f, got, want)
+		}
+	}
+}
+
+// TestFloatSqrt checks the rounding of Sqrt in all modes: the exact root
+// of x lies between the result and its neighbor in the direction of the
+// reported accuracy, and within half an ulp for the rounding modes to
+// nearest.
+func TestFloatSqrt(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, prec := range []uint{1, 2, 10, 53, 64, 100, 1000} {
+		for i := 0; i < 50; i++ {
+			x := new(Float).SetPrec(uint(r.Intn(2000) + 1))
+			x.SetInt(new(Int).Rand(r, new(Int).Lsh(intOne, x.Prec())))
+			x.SetMantExp(x, r.Intn(400)-200)
+			if x.Sign() == 0 {
+				continue
+			}
+			if i < 8 {
+				// squares of short values: the roots are exact, or ties at low precisions
+				m := new(Float).SetPrec(prec + 1).SetInt64(int64(2*i + 1))
+				m.SetMantExp(m, -int(m.MinPrec()))
+				x = exactMul(m, m)
+			}
+			for _, mode := range []RoundingMode{ToNearestEven, ToNearestAway, ToZero, AwayFromZero, ToNegativeInf, ToPositiveInf} {
+				z := new(Float).SetPrec(prec).SetMode(mode).Sqrt(x)
+				checkSqrt(t, x, z, mode)
+			}
+		}
+	}
+}
+
+// exactMul returns x*y, which is exact at the sum of their precisions.
+func exactMul(x, y *Float) *Float {
+	return new(Float).SetPrec(x.Prec()+y.Prec()).Mul(x, y)
+}
+
+func checkSqrt(t *testing.T, x, z *Float, mode RoundingMode) {
+	prec := z.Prec()
+	desc := fmt.Sprintf("Sqrt(%s) at precision %d in mode %s = %s (%s)", x.Text('p', 0), prec, mode, z.Text('p', 0), z.Acc())
+	sq := exactMul(z, z)
+	cmp := sq.Cmp(x)
+	if want := Accuracy(cmp); z.Acc() != want {
+		t.Errorf("%s: acc = %s; want %s", desc, z.Acc(), want)
+	}
+	if cmp == 0 {
+		return
+	}
+	// ulp is the distance of z to its successor at precision prec
+	ulp := new(Float).SetMantExp(NewFloat(1), z.MantExp(nil)-int(prec))
+	switch mode {
+	case ToZero, ToNegativeInf, AwayFromZero, ToPositiveInf:
+		// z² < x < (z + ulp)², or (z - ulp)² < x < z²
+		next := new(Float).SetPrec(prec + 1)
+		if cmp < 0 {
+			next.Add(z, ulp)
+		} else {
+			next.Sub(z, ulp)
+		}
+		if exactMul(next, next).Cmp(x) != -cmp {
+			t.Errorf("%s: not the closest neighbor of the root", desc)
+		}
+		if down := mode == ToZero || mode == ToNegativeInf; down != (cmp < 0) {
+			t.Errorf("%s: rounded in the wrong direction", desc)
+		}
+	default:
+		// (z - ulp/2)² <= x <= (z + ulp/2)²
+		half := new(Float).SetMantExp(ulp, -1)
+		lo := new(Float).SetPrec(prec+2).Sub(z, half)
+		hi := new(Float).SetPrec(prec+2).Add(z, half)
+		if exactMul(lo, lo).Cmp(x) > 0 || exactMul(hi, hi).Cmp(x) < 0 {
+			t.Errorf("%s: more than half an ulp from the root", desc)
+		}
+	}
+}
+
+func TestFloatSqrtSpecial(t *testing.T) {
+	for _, test := range []struct {
+		x, want string
+	}{
+		{"0", "0"},
+		{"-0", "-0"},
+		{"+Inf", "+Inf"},
+		{"4", "2"},
+		{"0.25", "0.5"},
+		{"2", "1.414213562"},
+		{"1e-1000", "1e-500"},
+	} {
+		x := makeFloat(test.x)
+		z := new(Float).SetPrec(32).Sqrt(x)
+		if got := z.Text('g', 10); got != test.want {
+			t.Errorf("Sqrt(%s) = %s; want %s", test.x, got, test.want)
+		}
+		// z may be x
+		x.SetPrec(32).Sqrt(x)
+		if got := x.Text('g', 10); got != test.want {
+			t.Errorf("aliased Sqrt(%s) = %s; want %s", test.x, got, test.want)
+		}
+	}
+	if z := new(Float).Sqrt(NewFloat(2)); z.Prec() != 53 {
+		t.Errorf("Sqrt(2) with precision 0: got precision %d; want 53", z.Prec())
+	}
+
+	for _, x := range []string{"-1", "-Inf", "-1e-1000"} {
+		func() {
+			defer func() {
+				if _, ok := recover().(ErrNaN); !ok {
+					t.Errorf("Sqrt(%s) did not panic with ErrNaN", x)
+				}
+			}()
+			new(Float).Sqrt(makeFloat(x))
+		}()
+	}
+}
+
+func BenchmarkFloatSqrt(b *testing.B) {
+	for _, prec := range []uint{64, 128, 256, 1000, 10000, 100000} {
+		x := new(Float).SetPrec(prec).SetInt64(2)
+		z := new(Float).SetPrec(prec)
+		b.Run(fmt.Sprintf("%v", prec), func(b *testing.B) {
+			b.ReportAllocs()
+			for n := 0; n < b.N; n++ {
+				z.Sqrt(x)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements Sum and Product, which combine many operands
+// by balanced tree reduction.
+
+package big
+
+// The halves of Sums and Products of at least parallelSumWords Words
+// are computed concurrently, within the limit set by SetParallelism.
+var parallelSumWords = 1 << 14
+
+// Sum sets dst to the sum of xs and returns dst. The sum of no operands
+// is 0. Large sums are split in a balanced tree, whose top levels are
+// added concurrently, and the partial sums are accumulated in storage
+// that is reused across calls. dst may be one of xs.
+func Sum(dst *Int, xs ...*Int) *Int {
+	words, ct := operandWords(dst, xs)
+	if ct {
+		var z Int
+		for _, x := range xs {
+			z.Add(&z, x)
+		}
+		return dst.Set(&z)
+	}
+	t := getInt()
+	t.sum(xs, words)
+	dst.abs = dst.abs.set(t.abs) // don't pool storage the caller may own
+	dst.neg = t.neg
+	putInt(t)
+	return dst
+}
+
+// Product sets dst to the product of xs and returns dst. The product of
+// no operands is 1. The operands are multiplied pairwise in a balanced
+// tree, so that the factors of the large products are of similar
+// length, and the top levels of the tree are computed concurrently for
+// large products. dst may be one of xs.
+func Product(dst *Int, xs ...*Int) *Int {
+	words, ct := operandWords(dst, xs)
+	if ct {
+		z := NewInt(1)
+		for _, x := range xs {
+			z.Mul(z, x)
+		}
+		return dst.Set(z)
+	}
+	t := getInt()
+	t.product(xs, words)
+	dst.abs = dst.abs.set(t.abs) // don't pool storage the caller may own
+	dst.neg = t.neg
+	putInt(t)
+	return dst
+}
+
+// operandWords returns the total length of xs in Words, and reports
+// whether dst or any of xs is marked with SetConstantTime or
+// variable-time operations are disabled, in which case Sum and Product
+// fall back to sequential Adds and Muls.
+func operandWords(dst *Int, xs []*Int) (words int, ct bool) {
+	zcap := dst.zcap
+	for _, x := range xs {
+		words += len(x.abs)
+		zcap |= x.zcap
+	}
+	return words, zcap != 0 || varTimeDisabled()
+}
+
+// sum sets z to the sum of xs and returns z; z must not be one of xs.
+// words estimates the total length of xs; the halves of xs are assumed
+// to have half of it each. The running sum is hardly longer than its
+// longest operand, so sums too short to be split among goroutines are
+// accumulated sequentially.
+func (z *Int) sum(xs []*Int, words int) *Int {
+	if words < parallelSumWords || len(xs) < 4 {
+		z.SetInt64(0)
+		for _, x := range xs {
+			z.Add(z, x)
+		}
+		return z
+	}
+	m := len(xs) / 2
+	t := getInt()
+	parallelDo(func() { z.sum(xs[:m], words/2) }, func() { t.sum(xs[m:], words/2) })
+	z.Add(z, t)
+	putInt(t)
+	return z
+}
+
+// product sets z to the product of xs and returns z; z must not be one
+// of xs. words is estimated as for sum.
+func (z *Int) product(xs []*Int, words int) *Int {
+	switch len(xs) {
+	case 0:
+		return z.SetInt64(1)
+	case 1:
+		return z.Set(xs[0])
+	case 2:
+		return z.Mul(xs[0], xs[1])
+	}
+	// The halves are computed in temporaries, so that z's storage can
+	// hold their product.
+	m := len(xs) / 2
+	s, t := getInt(), getInt()
+	if words >= parallelSumWords {
+		parallelDo(func() { s.product(xs[:m], words/2) }, func() { t.product(xs[m:], words/2) })
+	} else {
+		s.product(xs[:m], words/2)
+		t.product(xs[m:], words/2)
+	}
+	z.Mul(s, t)
+	putInt(s)
+	putInt(t)
+	return z
+}
@@ -0,0 +1,118 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func sumOperands(r *rand.Rand, n, maxBits int) []*Int {
+	xs := make([]*Int, n)
+	for i := range xs {
+		xs[i] = new(Int).Rand(r, new(Int).Lsh(intOne, uint(r.Intn(maxBits)+1)))
+		xs[i].Add(xs[i], intOne) // products must not vanish
+		if r.Intn(3) == 0 {
+			xs[i].Neg(xs[i])
+		}
+	}
+	return xs
+}
+
+func TestSumProduct(t *testing.T) {
+	defer func(n int) { parallelSumWords = n }(parallelSumWords)
+	r := rand.New(rand.NewSource(0))
+	for _, parallel := range []bool{false, true} {
+		parallelSumWords = 1 << 30
+		if parallel {
+			parallelSumWords = 16
+		}
+		for _, n := range []int{0, 1, 2, 3, 7, 8, 9, 17, 100, 257} {
+			xs := sumOperands(r, n, 300)
+			sum, prod := new(Int), NewInt(1)
+			for _, x := range xs {
+				sum.Add(sum, x)
+				prod.Mul(prod, x)
+			}
+			if got := Sum(new(Int), xs...); got.Cmp(sum) != 0 {
+				t.Errorf("parallel=%v: Sum of %d operands = %s; want %s", parallel, n, got, sum)
+			}
+			if got := Product(new(Int), xs...); got.Cmp(prod) != 0 {
+				t.Errorf("parallel=%v: Product of %d operands = %s; want %s", parallel, n, got, prod)
+			}
+			if n == 0 {
+				continue
+			}
+			// dst aliases an operand
+			ys := append([]*Int(nil), xs...)
+			ys[n/2] = new(Int).Set(xs[n/2])
+			if got := Sum(ys[n/2], ys...); got.Cmp(sum) != 0 {
+				t.Errorf("parallel=%v: aliased Sum of %d operands = %s; want %s", parallel, n, got, sum)
+			}
+			ys[n/2] = new(Int).Set(xs[n/2])
+			if got := Product(ys[n/2], ys...); got.Cmp(prod) != 0 {
+				t.Errorf("parallel=%v: aliased Product of %d operands = %s; want %s", parallel, n, got, prod)
+			}
+		}
+	}
+}
+
+func TestSumProductConstantTime(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	xs := sumOperands(r, 20, 100)
+	want := Sum(new(Int), xs...)
+	wantProd := Product(new(Int), xs...)
+	xs[3] = new(Int).Set(xs[3]).SetConstantTime(128)
+	if got := Sum(new(Int), xs...); got.Cmp(want) != 0 {
+		t.Errorf("Sum = %s; want %s", got, want)
+	}
+	if got := Product(new(Int), xs...); got.Cmp(wantProd) != 0 {
+		t.Errorf("Product = %s; want %s", got, wantProd)
+	}
+}
+
+func BenchmarkSum(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	xs := sumOperands(r, 1e5, 256)
+	z := new(Int)
+	b.Run("Sum", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Sum(z, xs...)
+		}
+	})
+	b.Run("Add", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			z.SetInt64(0)
+			for _, x := range xs {
+				z.Add(z, x)
+			}
+		}
+	})
+}
+
+func BenchmarkProduct(b *testing.B) {
+	r := rand.New(rand.NewSource(1))
+	xs := sumOperands(r, 1e4, 64)
+	z := new(Int)
+	b.Run("Product", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			Product(z, xs...)
+		}
+	})
+	b.Run("Mul", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			z.SetInt64(1)
+			for _, x := range xs {
+				z.Mul(z, x)
+			}
+		}
+	})
+}
+
+func TestSumProductCallerStorage(t *testing.T) {
+	xs := []*Int{new(Int).Lsh(intOne, 1000), NewInt(12345), NewInt(-3)}
+	testCallerStorage(t, "Sum", func(z *Int) { Sum(z, xs...) })
+	testCallerStorage(t, "Product", func(z *Int) { Product(z, xs...) })
+}
@@ -0,0 +1,93 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements a statistical test for timing leaks,
+// for checking the constant-time operations of this package.
+
+package big
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// TimingCheck runs a statistical test for timing leaks in f and returns
+// the resulting t statistic.
+//
+// TimingCheck calls f samples times with secret inputs of size bytes,
+// where each input is chosen at random to be either a fixed all-zero
+// value or uniformly random bytes, and measures the duration of each
+// call. It then applies Welch's t-test to the durations of the two
+// classes of inputs, after discarding the slowest tenth of all
+// measurements to suppress noise from interrupts and scheduling.
+// This is the method of Reparaz, Balasch, and Verbauwhede, "Dude, is
+// my code constant time?" (https://eprint.iacr.org/2016/1123.pdf).
+//
+// A result with an absolute value above about 4.5 indicates with high
+// confidence that the running time of f depends on its input; smaller
+// values mean that no leak was detected with the given number of samples,
+// not that none exists. Leaks are more reliably detected with many samples
+// (say, 10⁵ or more) on an otherwise idle machine. The function f should
+// do enough work per call to be measurable with the system clock, and must
+// not retain secret after it returns, since the buffer is reused.
+func TimingCheck(f func(secret []byte), size, samples int) float64 {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	secret := make([]byte, size)
+	random := make([]bool, samples)
+	times := make([]float64, samples)
+
+	// warm up caches and branch predictors
+	for i := 0; i < 10; i++ {
+		f(secret)
+	}
+
+	for i := range times {
+		random[i] = rnd.Intn(2) == 1
+		for j := range secret {
+			secret[j] = 0
+			if random[i] {
+				secret[j] = byte(rnd.Intn(256))
+			}
+		}
+		start := time.Now()
+		f(secret)
+		times[i] = float64(time.Since(start))
+	}
+
+	// discard outliers above the 90th percentile
+	sorted := append([]float64(nil), times...)
+	sort.Float64s(sorted)
+	cutoff := math.Inf(+1)
+	if len(sorted) > 0 {
+		cutoff = sorted[len(sorted)*9/10]
+	}
+
+	// Welch's t-test, with means and variances computed by Welford's method
+	var n, mean, m2 [2]float64
+	for i, t := range times {
+		if t > cutoff {
+			continue
+		}
+		c := 0
+		if random[i] {
+			c = 1
+		}
+		n[c]++
+		d := t - mean[c]
+		mean[c] += d / n[c]
+		m2[c] += d * (t - mean[c])
+	}
+	if n[0] < 2 || n[1] < 2 {
+		return 0
+	}
+	v0 := m2[0] / (n[0] - 1)
+	v1 := m2[1] / (n[1] - 1)
+	s := math.Sqrt(v0/n[0] + v1/n[1])
+	if s == 0 {
+		return 0
+	}
+	return (mean[0] - mean[1]) / s
+}
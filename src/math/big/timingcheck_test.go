@@ -0,0 +1,139 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"flag"
+	"math"
+	"testing"
+)
+
+var timingcheck = flag.Bool("timingcheck", false, "run timing leak tests of constant-time operations")
+
+// leakSink keeps the leaky test function from being optimized away.
+var leakSink int
+
+func TestTimingCheckLeak(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping timing test in short mode")
+	}
+	// the work done depends strongly on the secret
+	leaky := func(secret []byte) {
+		n := 0
+		for i := 0; i < int(secret[0])*100; i++ {
+			n += i
+		}
+		leakSink = n
+	}
+	if tt := TimingCheck(leaky, 1, 20000); math.Abs(tt) < 4.5 {
+		t.Errorf("leak not detected: t = %.2f", tt)
+	}
+}
+
+func TestTimingCheckDegenerate(t *testing.T) {
+	f := func([]byte) {}
+	if tt := TimingCheck(f, 8, 0); tt != 0 {
+		t.Errorf("got t = %v for no samples; want 0", tt)
+	}
+	if tt := TimingCheck(f, 8, 1); tt != 0 {
+		t.Errorf("got t = %v for one sample; want 0", tt)
+	}
+}
+
+// The tests below measure the constant-time operations of this package.
+// They are sensitive to machine load and are only run with -timingcheck.
+
+func checkTiming(t *testing.T, name string, f func(secret []byte), size int) {
+	if tt := TimingCheck(f, size, 100000); math.Abs(tt) > 4.5 {
+		t.Errorf("%s: possible timing leak: t = %.2f", name, tt)
+	}
+}
+
+func TestTimingCExpNN(t *testing.T) {
+	if !*timingcheck {
+		t.Skip("skipping timing test (use -timingcheck to enable)")
+	}
+	m := natFromString("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff61")
+	x := natFromString("0x1234567890abcdef1234567890abcdef")
+	checkTiming(t, "cexpNN", func(secret []byte) {
+		y := nat(nil).csetBytes(secret)
+		nat(nil).cexpNN(x, y, m, len(m))
+	}, 32)
+}
+
+func TestTimingCExpNNEven(t *testing.T) {
+	if !*timingcheck {
+		t.Skip("skipping timing test (use -timingcheck to enable)")
+	}
+	m := natFromString("0xfffffffffffffffffffffffffffffffe")
+	x := natFromString("0x1234567890abcdef1234567890abcdef")
+	checkTiming(t, "cexpNN (even modulus)", func(secret []byte) {
+		y := nat(nil).csetBytes(secret)
+		nat(nil).cexpNN(x, y, m, len(m))
+	}, 8)
+}
+
+func TestTimingCExpNNModulus(t *testing.T) {
+	if !*timingcheck {
+		t.Skip("skipping timing test (use -timingcheck to enable)")
+	}
+	// the secret modulus is 1 or a random value of either parity
+	x := natFromString("0x1234567890abcdef1234567890abcdef")
+	y := natFromString("0xfedcba0987654321")
+	checkTiming(t, "cexpNN (secret modulus)", func(secret []byte) {
+		s := nat(nil).csetBytes(secret)
+		m := nat(nil).cadd(s, natOne, len(s)+1)
+		nat(nil).cexpNN(x, y, m, len(m))
+	}, 16)
+}
+
+func TestTimingCmod(t *testing.T) {
+	if !*timingcheck {
+		t.Skip("skipping timing test (use -timingcheck to enable)")
+	}
+	m := natFromString("0xffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffff61")
+	checkTiming(t, "cmod", func(secret []byte) {
+		x := nat(nil).csetBytes(secret)
+		nat(nil).cmod(x, m)
+	}, 64)
+}
+
+func TestTimingCmodModulus(t *testing.T) {
+	if !*timingcheck {
+		t.Skip("skipping timing test (use -timingcheck to enable)")
+	}
+	// the secret modulus is 1 or a random value
+	x := natFromString("0x1234567890abcdef1234567890abcdef1234567890abcdef1234567890abcdef")
+	checkTiming(t, "cmod (secret modulus)", func(secret []byte) {
+		s := nat(nil).csetBytes(secret)
+		m := nat(nil).cadd(s, natOne, len(s)+1)
+		nat(nil).cmod(x, m)
+	}, 16)
+}
+
+func TestTimingFillBytesCT(t *testing.T) {
+	if !*timingcheck {
+		t.Skip("skipping timing test (use -timingcheck to enable)")
+	}
+	// the normalized value has no words for a zero secret
+	buf := make([]byte, 64)
+	checkTiming(t, "FillBytesCT", func(secret []byte) {
+		x := &Int{abs: nat(nil).csetBytes(secret).norm()}
+		x.FillBytesCT(buf)
+	}, 64)
+}
+
+func TestTimingCondSelect(t *testing.T) {
+	if !*timingcheck {
+		t.Skip("skipping timing test (use -timingcheck to enable)")
+	}
+	// x and y have the same word length, see CondSelect
+	x := new(Int).Sub(new(Int).Lsh(intOne, 512), intOne)
+	y := new(Int).Lsh(intOne, 511)
+	z := new(Int)
+	checkTiming(t, "CondSelect", func(secret []byte) {
+		z.CondSelect(x, y, uint(secret[0]&1))
+	}, 1)
+}
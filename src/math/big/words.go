@@ -0,0 +1,90 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// This file implements the import and export of Ints as arrays of
+// words of any size and order, independent of the size of a Word.
+
+package big
+
+// An Order specifies the order of the words of a number in a buffer, or
+// of the bytes of a word, for ImportWords and ExportWords.
+type Order int8
+
+const (
+	LeastSignificantFirst Order = -1 // least significant word or byte first
+	MostSignificantFirst  Order = 1  // most significant word or byte first
+)
+
+// ImportWords sets z to the unsigned integer stored in buf as words of
+// size bytes each, in the word order words and the byte order bytes,
+// and returns z. For example, with size 8 and orders LeastSignificantFirst,
+// buf holds the value of x.Bits() on a little-endian 64-bit platform.
+// ImportWords panics if size <= 0, if len(buf) is not a multiple of size,
+// or if an order is invalid. Like GMP's mpz_import, ImportWords doesn't
+// depend on the size and byte order of a Word.
+func (z *Int) ImportWords(buf []byte, size int, words, bytes Order) *Int {
+	pos := wordPos(len(buf), size, words, bytes)
+	z.abs = z.abs.make((len(buf) + _S - 1) / _S)
+	z.abs.clear()
+	for k := range buf {
+		z.abs[k/_S] |= Word(buf[pos(k)]) << (8 * uint(k%_S))
+	}
+	z.abs = z.abs.norm()
+	z.neg = false
+	return z
+}
+
+// ExportWords appends the absolute value of x to buf as the fewest
+// words of size bytes each that hold it, in the word order words and
+// the byte order bytes, and returns the extended buffer. It is the
+// inverse of ImportWords; no words are appended if x is 0. ExportWords
+// panics if size <= 0 or if an order is invalid.
+func (x *Int) ExportWords(buf []byte, size int, words, bytes Order) []byte {
+	if size <= 0 {
+		panic("math/big: invalid word size")
+	}
+	n := (x.abs.bitLen() + 7) / 8
+	n = (n + size - 1) / size * size
+	m := len(buf)
+	if m+n > cap(buf) {
+		b := make([]byte, m, 2*cap(buf)+n)
+		copy(b, buf)
+		buf = b
+	}
+	buf = buf[:m+n]
+	out := buf[m:]
+	pos := wordPos(n, size, words, bytes)
+	for k := 0; k < n; k++ {
+		var b byte
+		if i := k / _S; i < len(x.abs) {
+			b = byte(x.abs[i] >> (8 * uint(k%_S)))
+		}
+		out[pos(k)] = b
+	}
+	return buf
+}
+
+// wordPos returns a function mapping the index k of a byte in order of
+// significance to its position in a buffer of n bytes of words of size
+// bytes, in the given orders.
+func wordPos(n, size int, words, bytes Order) func(k int) int {
+	if size <= 0 || n%size != 0 {
+		panic("math/big: invalid word size")
+	}
+	if words != LeastSignificantFirst && words != MostSignificantFirst ||
+		bytes != LeastSignificantFirst && bytes != MostSignificantFirst {
+		panic("math/big: invalid word or byte order")
+	}
+	count := n / size
+	return func(k int) int {
+		i, j := k/size, k%size
+		if words == MostSignificantFirst {
+			i = count - 1 - i
+		}
+		if bytes == MostSignificantFirst {
+			j = size - 1 - j
+		}
+		return i*size + j
+	}
+}
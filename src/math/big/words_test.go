@@ -0,0 +1,89 @@
+// Copyright 2017 The Go Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package big
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+var wordsTests = []struct {
+	x            string
+	size         int
+	words, bytes Order
+	buf          string
+}{
+	{"0", 4, MostSignificantFirst, MostSignificantFirst, ""},
+	{"0x1", 1, MostSignificantFirst, MostSignificantFirst, "01"},
+	{"0x1", 3, MostSignificantFirst, MostSignificantFirst, "000001"},
+	{"0x1", 3, LeastSignificantFirst, LeastSignificantFirst, "010000"},
+	{"0x0102030405", 2, MostSignificantFirst, MostSignificantFirst, "000102030405"},
+	{"0x0102030405", 2, MostSignificantFirst, LeastSignificantFirst, "010003020504"},
+	{"0x0102030405", 2, LeastSignificantFirst, MostSignificantFirst, "040502030001"},
+	{"0x0102030405", 2, LeastSignificantFirst, LeastSignificantFirst, "050403020100"},
+	{"-0x1122334455667788", 4, MostSignificantFirst, LeastSignificantFirst, "4433221188776655"},
+	{"0x1122334455667788", 8, LeastSignificantFirst, LeastSignificantFirst, "8877665544332211"},
+}
+
+func TestImportExportWords(t *testing.T) {
+	for _, test := range wordsTests {
+		x, _ := new(Int).SetString(test.x, 0)
+		buf, _ := hex.DecodeString(test.buf)
+		if got := x.ExportWords(nil, test.size, test.words, test.bytes); !bytes.Equal(got, buf) {
+			t.Errorf("ExportWords(%s, %d, %d, %d) = %x; want %s", test.x, test.size, test.words, test.bytes, got, test.buf)
+		}
+		if got := x.ExportWords([]byte("abc"), test.size, test.words, test.bytes); string(got[:3]) != "abc" || !bytes.Equal(got[3:], buf) {
+			t.Errorf("ExportWords(%s, %d, %d, %d) after a prefix = %x; want %s", test.x, test.size, test.words, test.bytes, got, test.buf)
+		}
+		want := new(Int).Abs(x)
+		if got := NewInt(-7).ImportWords(buf, test.size, test.words, test.bytes); got.Cmp(want) != 0 || !isNormalized(got) {
+			t.Errorf("ImportWords(%s, %d, %d, %d) = %s; want %s", test.buf, test.size, test.words, test.bytes, got, want)
+		}
+	}
+
+	r := rand.New(rand.NewSource(0))
+	orders := []Order{LeastSignificantFirst, MostSignificantFirst}
+	for i := 0; i < 100; i++ {
+		x := new(Int).Rand(r, new(Int).Lsh(intOne, uint(r.Intn(500))))
+		size := r.Intn(20) + 1
+		words, bytes := orders[r.Intn(2)], orders[r.Intn(2)]
+		buf := x.ExportWords(nil, size, words, bytes)
+		if len(buf)%size != 0 || len(buf) >= len(x.Bytes())+size {
+			t.Errorf("ExportWords(%s, %d, %d, %d) returned %d bytes", x, size, words, bytes, len(buf))
+		}
+		if got := new(Int).ImportWords(buf, size, words, bytes); got.Cmp(x) != 0 {
+			t.Errorf("ImportWords(ExportWords(%s, %d, %d, %d)) = %s", x, size, words, bytes, got)
+		}
+		// leading zero words don't matter
+		buf = append(make([]byte, size), buf...)
+		if words == LeastSignificantFirst {
+			buf = append(buf[size:], buf[:size]...)
+		}
+		if got := new(Int).ImportWords(buf, size, words, bytes); got.Cmp(x) != 0 {
+			t.Errorf("ImportWords of %s with a zero word = %s", x, got)
+		}
+	}
+}
+
+func TestImportExportWordsPanics(t *testing.T) {
+	for _, f := range []func(){
+		func() { new(Int).ImportWords(make([]byte, 5), 2, MostSignificantFirst, MostSignificantFirst) },
+		func() { new(Int).ImportWords(nil, 0, MostSignificantFirst, MostSignificantFirst) },
+		func() { new(Int).ImportWords(nil, 1, 0, MostSignificantFirst) },
+		func() { NewInt(1).ExportWords(nil, 0, MostSignificantFirst, MostSignificantFirst) },
+		func() { NewInt(1).ExportWords(nil, 1, MostSignificantFirst, 2) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("invalid arguments did not panic")
+				}
+			}()
+			f()
+		}()
+	}
+}